@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"httes/core/report"
+	"httes/store"
+)
+
+func compareCmd(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	tol := toleranceFlags(fs)
+	asJSON := fs.Bool("json", false, "print the comparison as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli compare [flags] BASE CURRENT")
+		return exitError
+	}
+
+	base, err := resolveRun(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	current, err := resolveRun(fs.Arg(1))
+	if err != nil {
+		return fail(err)
+	}
+	c, err := compareRuns(base, current, *tol)
+	if err != nil {
+		return fail(err)
+	}
+
+	if *asJSON {
+		b, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return fail(err)
+		}
+		fmt.Println(string(b))
+	} else {
+		fmt.Printf("Base: %s (%d), current: %s (%d)\n", base.Name, base.ID, current.Name, current.ID)
+		printComparison(c)
+	}
+	if c.Regressed {
+		return exitRegression
+	}
+	return exitOK
+}
+
+func baselineCmd(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli baseline ID")
+		return exitError
+	}
+	run, err := resolveRun(args[0])
+	if err != nil {
+		return fail(err)
+	}
+	if err := store.SetBaseline(run.ID); err != nil {
+		return fail(err)
+	}
+	fmt.Printf("Run %s (%d) is the baseline now\n", run.Name, run.ID)
+	return exitOK
+}
+
+func runsCmd() int {
+	runs := store.ListTestRuns()
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartTime.Before(runs[j].StartTime) })
+	for _, r := range runs {
+		mark := ""
+		if r.Baseline {
+			mark = " [baseline]"
+		}
+		fmt.Printf("%5d  %s  %-8s %s%s\n", r.ID, r.StartTime.Format("2006-01-02 15:04:05"), r.Status, r.Name, mark)
+	}
+	return exitOK
+}
+
+// resolveRun возвращает сохранённый запуск по ID, базовый запуск ("baseline") или последний запуск ("latest").
+func resolveRun(ref string) (store.TestRun, error) {
+	switch ref {
+	case "baseline":
+		if r, ok := store.GetBaseline(); ok {
+			return r, nil
+		}
+		return store.TestRun{}, fmt.Errorf("baseline run is not set")
+	case "latest":
+		runs := store.ListTestRuns()
+		if len(runs) == 0 {
+			return store.TestRun{}, fmt.Errorf("there are no stored runs")
+		}
+		latest := runs[0]
+		for _, r := range runs[1:] {
+			if r.StartTime.After(latest.StartTime) {
+				latest = r
+			}
+		}
+		return latest, nil
+	}
+
+	id, err := strconv.Atoi(ref)
+	if err != nil {
+		return store.TestRun{}, fmt.Errorf("invalid run reference %q: expected ID, \"baseline\" or \"latest\"", ref)
+	}
+	r, ok := store.GetTestRunByID(id)
+	if !ok {
+		return store.TestRun{}, fmt.Errorf("test run %d not found", id)
+	}
+	return r, nil
+}
+
+// compareRuns сравнивает сохранённые результаты двух запусков.
+func compareRuns(base, current store.TestRun, tol report.Tolerances) (report.Comparison, error) {
+	bs, err := runSummary(base)
+	if err != nil {
+		return report.Comparison{}, err
+	}
+	cs, err := runSummary(current)
+	if err != nil {
+		return report.Comparison{}, err
+	}
+	return report.Compare(bs, cs, tol), nil
+}
+
+func runSummary(r store.TestRun) (report.RunSummary, error) {
+	var s report.RunSummary
+	if len(r.Result) == 0 {
+		return s, fmt.Errorf("test run %d has no stored result", r.ID)
+	}
+	if err := json.Unmarshal(r.Result, &s); err != nil {
+		return s, fmt.Errorf("test run %d: invalid result: %v", r.ID, err)
+	}
+	return s, nil
+}
+
+func printComparison(c report.Comparison) {
+	lines, _ := c.Lines()
+	for _, l := range lines {
+		fmt.Println(l)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"httes/core/report"
+	"httes/store"
+)
+
+func reportCmd(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	out := fs.String("o", "report.html", "output HTML file")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli report [-o FILE] RUN")
+		return exitError
+	}
+
+	run, err := resolveRun(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	s, err := runSummary(run)
+	if err != nil {
+		return fail(err)
+	}
+	if err := writeHTMLReport(*out, run, s); err != nil {
+		return fail(err)
+	}
+	fmt.Printf("Report of %s (%d) is written to %s\n", run.Name, run.ID, *out)
+	return exitOK
+}
+
+// writeHTMLReport записывает HTML-отчёт запуска в файл path.
+func writeHTMLReport(path string, run store.TestRun, s report.RunSummary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteHTMLReport(f, run.Name, s); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
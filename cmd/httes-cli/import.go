@@ -0,0 +1,112 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"httes/importer"
+)
+
+// listFlag — флаг, который можно указать несколько раз или через запятую.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
+
+// multiFlag — флаг, который можно указать несколько раз. Значение не разбивается по запятым.
+type multiFlag []string
+
+func (m *multiFlag) String() string {
+	return strings.Join(*m, " ")
+}
+
+func (m *multiFlag) Set(v string) error {
+	*m = append(*m, v)
+	return nil
+}
+
+func importCmd(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "input format, detected from the file by default")
+	name := fs.String("name", "", "scenario name")
+	out := fs.String("o", "", "write a JSON config to this file instead of stdout")
+	toStore := fs.Bool("store", false, "save the scenarios to the scenario list instead of writing a config")
+	var opts importer.Options
+	fs.Var((*listFlag)(&opts.Include), "include", "import only requests to these hosts (glob, e.g. *.example.com or api.example.com/v1/*)")
+	fs.Var((*listFlag)(&opts.Exclude), "exclude", "skip requests to these hosts (glob)")
+	fs.BoolVar(&opts.KeepStatic, "keep-static", false, "import requests of scripts, styles, images and fonts")
+	fs.Var((*listFlag)(&opts.Tags), "tag", "import only openapi operations with these tags")
+	fs.Var((*listFlag)(&opts.Operations), "operation", "import only openapi operations with these operation ids")
+	env := fs.String("env", "", "postman environment file with variable values")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli import [flags] FILE")
+		return exitError
+	}
+	opts.Name = *name
+	if *env != "" {
+		b, err := os.ReadFile(*env)
+		if err != nil {
+			return fail(err)
+		}
+		opts.Environment = b
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	res, err := importer.Import(*format, fs.Arg(0), data, opts)
+	if err != nil {
+		return fail(err)
+	}
+	return writeResult(res, fs.Arg(0), *out, *toStore)
+}
+
+// writeResult сохраняет импортированные сценарии в хранилище или записывает конфигурацию в файл out
+// (в stdout, если out пуст). Предупреждения и найденные извлечения переменных выводятся в stderr.
+func writeResult(res *importer.Result, source, out string, toStore bool) int {
+	for _, w := range res.Warnings {
+		fmt.Fprintln(os.Stderr, "warning:", w)
+	}
+	for _, c := range res.Captures {
+		fmt.Fprintln(os.Stderr, "capture:", c)
+	}
+
+	if toStore {
+		saved, err := res.Save(source)
+		if err != nil {
+			return fail(err)
+		}
+		for _, s := range saved {
+			fmt.Printf("Scenario %s (%d) is saved\n", s.Name, s.ID)
+		}
+		return exitOK
+	}
+
+	cfg, err := res.Config()
+	if err != nil {
+		return fail(err)
+	}
+	if out == "" {
+		fmt.Println(string(cfg))
+		return exitOK
+	}
+	if err := os.WriteFile(out, append(cfg, '\n'), 0o644); err != nil {
+		return fail(err)
+	}
+	fmt.Fprintln(os.Stderr, "Config is written to", out)
+	return exitOK
+}
@@ -0,0 +1,86 @@
+// Команда httes-cli запускает нагрузочные тесты без графического интерфейса
+// и сравнивает сохранённые запуски с базовым.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"httes/config"
+	"httes/core/report"
+	"httes/store"
+)
+
+// Коды завершения команды
+const (
+	exitOK         = 0
+	exitRegression = 1 // Регрессия относительно базового запуска или невыполненные пороговые значения
+	exitError      = 2
+)
+
+const usage = `Usage:
+  httes-cli run [flags] CONFIG          run a test from a JSON or YAML config and save it to history
+  httes-cli compare [flags] BASE CURRENT  compare two stored runs (ID, "baseline" or "latest")
+  httes-cli baseline ID                 mark a stored run as the baseline
+  httes-cli report [-o FILE] RUN        write an HTML report of a stored run
+  httes-cli runs                        list stored runs
+  httes-cli import [flags] FILE         convert a HAR file, Postman collection, OpenAPI 3 spec or cURL commands to a config or stored scenarios
+  httes-cli record [flags]              record requests through a local HTTP(S) proxy into a config or a stored scenario
+  httes-cli mock [flags] [ROUTES]       serve mock routes from a JSON or YAML file for dry runs and benchmarks
+  httes-cli schema                      print the JSON Schema of the config format
+
+Run "httes-cli COMMAND -h" for command flags.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitError)
+	}
+
+	if err := store.Init(); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to open store:", err)
+		os.Exit(exitError)
+	}
+
+	var code int
+	switch os.Args[1] {
+	case "run":
+		code = runCmd(os.Args[2:])
+	case "compare":
+		code = compareCmd(os.Args[2:])
+	case "baseline":
+		code = baselineCmd(os.Args[2:])
+	case "report":
+		code = reportCmd(os.Args[2:])
+	case "runs":
+		code = runsCmd()
+	case "import":
+		code = importCmd(os.Args[2:])
+	case "record":
+		code = recordCmd(os.Args[2:])
+	case "mock":
+		code = mockCmd(os.Args[2:])
+	case "schema":
+		os.Stdout.Write(config.Schema)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		code = exitError
+	}
+	os.Exit(code)
+}
+
+// toleranceFlags добавляет флаги допустимых отклонений при сравнении запусков.
+func toleranceFlags(fs *flag.FlagSet) *report.Tolerances {
+	tol := report.DefaultTolerances()
+	fs.Float64Var(&tol.Latency, "latency-tolerance", tol.Latency, "allowed increase of durations and percentiles, %")
+	fs.Float64Var(&tol.Throughput, "throughput-tolerance", tol.Throughput, "allowed decrease of iterations per second, %")
+	fs.Float64Var(&tol.ErrorRate, "error-tolerance", tol.ErrorRate, "allowed increase of error rate, percentage points")
+	return &tol
+}
+
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	return exitError
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"httes/mock"
+)
+
+func mockCmd(args []string) int {
+	fs := flag.NewFlagSet("mock", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "address of the mock server")
+	fs.Parse(args)
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli mock [flags] [ROUTES]")
+		return exitError
+	}
+
+	srv, err := newMockServer(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	listen, err := srv.Start(*addr)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "Mock server is listening on http://%s, it also works as a proxy. Press Ctrl+C to stop\n", listen)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+	srv.Close()
+	fmt.Fprintf(os.Stderr, "Requests served: %d\n", srv.Requests())
+	return exitOK
+}
+
+// newMockServer создаёт mock-сервер с маршрутами из файла path или с маршрутами по умолчанию, если path пуст.
+func newMockServer(path string) (*mock.Server, error) {
+	var cfg mock.Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err = mock.ParseConfig(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return mock.New(cfg)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"httes/importer"
+	"httes/recorder"
+	"httes/store"
+)
+
+func recordCmd(args []string) int {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	addr := fs.String("addr", "127.0.0.1:8888", "address of the recording proxy")
+	caDir := fs.String("ca-dir", "", "directory of the CA certificate for HTTPS interception, the store directory by default")
+	name := fs.String("name", "Recording", "scenario name")
+	out := fs.String("o", "", "write a JSON config to this file instead of stdout")
+	toStore := fs.Bool("store", false, "save the scenario to the scenario list instead of writing a config")
+	harPath := fs.String("har", "", "also write the raw recording to this HAR file")
+	insecure := fs.Bool("insecure", false, "do not verify certificates of the recorded servers")
+	var opts importer.Options
+	fs.Var((*listFlag)(&opts.Include), "include", "record only requests to these hosts (glob, e.g. *.example.com or api.example.com/v1/*)")
+	fs.Var((*listFlag)(&opts.Exclude), "exclude", "skip requests to these hosts (glob)")
+	fs.BoolVar(&opts.KeepStatic, "keep-static", false, "keep requests of scripts, styles, images and fonts")
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli record [flags]")
+		return exitError
+	}
+	opts.Name = *name
+
+	if *caDir == "" {
+		path, err := store.DefaultPath()
+		if err != nil {
+			return fail(err)
+		}
+		*caDir = filepath.Dir(path)
+	}
+	ca, err := recorder.LoadOrCreateCA(*caDir)
+	if err != nil {
+		return fail(err)
+	}
+	rec := recorder.New(ca)
+	if *insecure {
+		rec.Transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	rec.OnRecord = func(count int) {
+		fmt.Fprintf(os.Stderr, "\rRecorded requests: %d", count)
+	}
+	listen, err := rec.Start(*addr)
+	if err != nil {
+		return fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "Recording proxy is listening on %s\n", listen)
+	fmt.Fprintf(os.Stderr, "Trust the CA certificate %s to record HTTPS. Press Ctrl+C to stop\n", ca.CertPath)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+	signal.Stop(stop)
+	rec.Close()
+	fmt.Fprintln(os.Stderr)
+
+	if *harPath != "" {
+		har, err := rec.HAR()
+		if err != nil {
+			return fail(err)
+		}
+		if err := os.WriteFile(*harPath, har, 0o644); err != nil {
+			return fail(err)
+		}
+	}
+	res, err := rec.Result(opts)
+	if err != nil {
+		return fail(err)
+	}
+	source := "recording.har"
+	if *harPath != "" {
+		source = *harPath
+	}
+	return writeResult(res, source, *out, *toStore)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"httes/config"
+	"httes/core"
+	"httes/core/proxy"
+	"httes/core/report"
+	"httes/core/types"
+	"httes/store"
+)
+
+func runCmd(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	name := fs.String("name", "", "name of the test run in history")
+	samples := fs.Bool("samples", false, "store raw request samples with the result, a random sample of 10000 requests for longer runs")
+	export := fs.String("export", "", "write every request result to a file (.jsonl or .csv)")
+	html := fs.String("html", "", "write an HTML report of the run to a file")
+	metrics := fs.String("metrics-addr", "", "serve Prometheus metrics of the running test at this address, e.g. :9090")
+	compare := fs.Bool("compare", false, "compare the run with the baseline and fail on regression")
+	setBaseline := fs.Bool("set-baseline", false, "mark the run as the baseline if it passes")
+	dryRun := fs.Bool("dry-run", false, "send requests to a local mock server instead of the real services, the run is not saved to history")
+	mockRoutes := fs.String("mock", "", "routes of the mock server for -dry-run (JSON or YAML file), implies -dry-run")
+	var opts config.LoadOptions
+	fs.Var((*listFlag)(&opts.Overlays), "overlay", "config file merged over the config, e.g. prod.json (repeatable)")
+	fs.StringVar(&opts.EnvFile, "env-file", "", "file with environment variables for ${NAME} references, .env next to the config by default")
+	fs.Var((*multiFlag)(&opts.Set), "set", "override a config value: path=value, e.g. env.HOST=example.com or steps.0.timeout=10 (repeatable)")
+	tol := toleranceFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: httes-cli run [flags] CONFIG")
+		return exitError
+	}
+
+	cfg, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return fail(err)
+	}
+	opts.BaseDir = filepath.Dir(fs.Arg(0))
+	configType := config.DetectConfigType(fs.Arg(0), cfg)
+	reader, err := config.NewConfigReaderWithOptions(cfg, configType, opts)
+	if err != nil {
+		return fail(err)
+	}
+	// В историю сохраняется собранная конфигурация, чтобы запуск можно было повторить без включаемых файлов,
+	// оверлеев и -set. Переменные окружения остаются ссылками ${NAME}: секреты не сохраняются в истории
+	// и при повторном запуске подставляются из окружения заново.
+	historyOpts := opts
+	historyOpts.KeepEnvRefs = true
+	resolved, err := config.Resolve(cfg, configType, historyOpts)
+	if err != nil {
+		return fail(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		return fail(err)
+	}
+	if *export != "" {
+		h.ResultExport = types.ResultExport{Path: *export}
+	}
+	if *metrics != "" {
+		h.MetricsAddr = *metrics
+	}
+	if *dryRun || *mockRoutes != "" {
+		srv, err := newMockServer(*mockRoutes)
+		if err != nil {
+			return fail(err)
+		}
+		addr, err := srv.Start("127.0.0.1:0")
+		if err != nil {
+			return fail(err)
+		}
+		defer srv.Close()
+		// Mock-сервер работает как прокси для всех шагов, а история запусков остаётся в памяти
+		h.Proxy = proxy.Proxy{Strategy: proxy.ProxyTypeSingle, Addr: &url.URL{Scheme: "http", Host: addr.String()}}
+		store.Close()
+		fmt.Printf("Dry run: requests are answered by the mock server at %s\n", addr)
+	}
+
+	for _, o := range h.ReportOutputs {
+		if o == report.OutputTypeGui {
+			return fail(fmt.Errorf("output %q is available only in the graphical interface", o))
+		}
+	}
+	run, err := store.AddTestRun(store.TestRun{
+		Name:   *name,
+		Status: store.RunStatusRunning,
+		Settings: store.RunSettings{
+			RequestCount: h.IterationCount,
+			Duration:     h.TestDuration,
+			LoadType:     h.LoadType,
+		},
+		Config: resolved,
+	})
+	if err != nil {
+		return fail(fmt.Errorf("failed to save test run: %v", err))
+	}
+
+	// ID запуска в истории помечает метрики, отправляемые во внешние системы
+	h.RunID = fmt.Sprint(run.ID)
+
+	rs, err := report.NewReportServices(h.ReportOutputs, report.Options{
+		TotalRequests: h.IterationCount,
+		Destination:   h.ReportDestination,
+		Thresholds:    h.Thresholds,
+		MetricsAddr:   h.MetricsAddr,
+		ResultExport:  h.ResultExport,
+		Push:          h.Push,
+		RunID:         h.RunID,
+	})
+	if err != nil {
+		run.Status = store.RunStatusFailed
+		store.UpdateTestRun(run)
+		return fail(err)
+	}
+	var result *report.Result
+	if rp, ok := rs.(report.ResultProvider); ok {
+		result = rp.Result()
+	}
+	if result == nil {
+		run.Status = store.RunStatusFailed
+		store.UpdateTestRun(run)
+		return fail(fmt.Errorf("outputs %v do not provide a test result", h.ReportOutputs))
+	}
+	result.KeepSamples = *samples
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	e, err := core.NewEngine(ctx, h, rs)
+	if err == nil {
+		err = e.Init()
+	}
+	if err != nil {
+		run.Status = store.RunStatusFailed
+		store.UpdateTestRun(run)
+		return fail(err)
+	}
+
+	go rs.Start(e.GetResultChan())
+	e.Start()
+
+	summary := result.Summary(h.Thresholds)
+	run.Status = store.RunStatusSuccess
+	if ctx.Err() != nil {
+		run.Status = store.RunStatusStopped
+	} else if !summary.Passed {
+		run.Status = store.RunStatusFailed
+	}
+	if run.Result, err = json.Marshal(summary); err != nil {
+		return fail(fmt.Errorf("failed to save test result: %v", err))
+	}
+	if err := store.UpdateTestRun(run); err != nil {
+		return fail(fmt.Errorf("failed to save test run: %v", err))
+	}
+	if *dryRun || *mockRoutes != "" {
+		fmt.Printf("\nDry run finished, status %s\n", run.Status)
+	} else {
+		fmt.Printf("\nSaved as %s (%d), status %s\n", run.Name, run.ID, run.Status)
+	}
+	if *html != "" {
+		if err := writeHTMLReport(*html, run, summary); err != nil {
+			return fail(err)
+		}
+		fmt.Println("HTML report is written to", *html)
+	}
+
+	code := exitOK
+	if run.Status != store.RunStatusSuccess {
+		code = exitRegression
+	}
+
+	if *compare {
+		base, ok := store.GetBaseline()
+		if !ok {
+			fmt.Println("Baseline run is not set, comparison skipped")
+		} else {
+			c, err := compareRuns(base, run, *tol)
+			if err != nil {
+				return fail(err)
+			}
+			fmt.Printf("\nCompared with baseline %s (%d):\n", base.Name, base.ID)
+			printComparison(c)
+			if c.Regressed {
+				code = exitRegression
+			}
+		}
+	}
+
+	if *setBaseline && code == exitOK {
+		if err := store.SetBaseline(run.ID); err != nil {
+			return fail(err)
+		}
+		fmt.Println("Run is marked as the baseline")
+	}
+	return code
+}
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"strings"
 
 	"httes/core/types"
 )
@@ -30,10 +33,17 @@ type ConfigReader interface {
 // - reader: объект, реализующий интерфейс ConfigReader.
 // - err: ошибка, если тип конфигурации не поддерживается или произошла ошибка инициализации.
 func NewConfigReader(config []byte, configType string) (reader ConfigReader, err error) {
+	return newConfigReader(config, configType, LoadOptions{})
+}
+
+func newConfigReader(config []byte, configType string, opts LoadOptions) (reader ConfigReader, err error) {
 	// Проверяем, поддерживается ли указанный тип конфигурации.
 	if val, ok := AvailableConfigReader[configType]; ok {
 		// Создаем новый объект указанного типа с использованием рефлексии.
 		reader = reflect.New(reflect.TypeOf(val).Elem()).Interface().(ConfigReader)
+		if l, ok := reader.(layeredReader); ok {
+			l.setLoadOptions(opts)
+		}
 
 		// Инициализируем объект переданными данными конфигурации.
 		err = reader.Init(config)
@@ -43,3 +53,19 @@ func NewConfigReader(config []byte, configType string) (reader ConfigReader, err
 	}
 	return
 }
+
+// DetectConfigType определяет тип конфигурации по расширению файла path: .json читается JsonReader,
+// .yml и .yaml — YamlReader. Если расширения нет, например для конфигурации из истории запусков,
+// тип определяется по содержимому: корректный JSON читается JsonReader, остальное — YamlReader.
+func DetectConfigType(path string, config []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigTypeJson
+	case ".yml", ".yaml":
+		return ConfigTypeYaml
+	}
+	if json.Valid(config) {
+		return ConfigTypeJson
+	}
+	return ConfigTypeYaml
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectConfigType(t *testing.T) {
+	tests := []struct {
+		path     string
+		config   string
+		expected string
+	}{
+		{"config.json", `{"request_count": 1}`, ConfigTypeJson},
+		// Некорректный JSON в файле .json читается JsonReader, который сообщает об ошибке
+		{"config.json", "request_count: 1", ConfigTypeJson},
+		{"config.JSON", "", ConfigTypeJson},
+		{"config.yaml", `{"request_count": 1}`, ConfigTypeYaml},
+		{"config.yml", "request_count: 1", ConfigTypeYaml},
+		{"", `{"request_count": 1}`, ConfigTypeJson},
+		{"", "request_count: 1", ConfigTypeYaml},
+		{"config", `{"request_count": 1}`, ConfigTypeJson},
+	}
+	for _, test := range tests {
+		if got := DetectConfigType(test.path, []byte(test.config)); got != test.expected {
+			t.Errorf("%q %q: expected %s, got %s", test.path, test.config, test.expected, got)
+		}
+	}
+}
+
+func TestIncorrectJsonConfig(t *testing.T) {
+	path := filepath.Join("config_testdata", "config_incorrect.json")
+	data := readFile(t, path)
+	_, err := NewConfigReader(data, DetectConfigType(path, data))
+	if err == nil || err.Error() != "provided json is invalid" {
+		t.Errorf("expected invalid json error, got %v", err)
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -57,22 +57,121 @@ type multipartFormData struct {
 // Поля:
 // - Exp: строка регулярного выражения.
 // - No: номер совпадения, которое нужно извлечь.
+// - All: извлечь все совпадения в массив.
+// - NamedGroups: извлечь именованные группы в одноимённые переменные.
 type RegexCaptureConf struct {
-	Exp *string `json:"exp"`
-	No  int     `json:"matchNo"`
+	Exp         *string `json:"exp"`
+	No          int     `json:"matchNo"`
+	All         bool    `json:"all"`
+	NamedGroups bool    `json:"namedGroups"`
+}
+
+// Структура CssSelectorConf описывает захват данных из HTML по CSS-селектору.
+// Поля:
+// - Selector: CSS-селектор.
+// - Attr: имя атрибута; если не указано, извлекается текст элемента.
+// - No: номер совпадения, которое нужно извлечь.
+type CssSelectorConf struct {
+	Selector *string `json:"selector"`
+	Attr     string  `json:"attr"`
+	No       int     `json:"matchNo"`
 }
 
 // Структура capturePath описывает, как захватывать данные из ответа.
 // Поля:
 // - JsonPath, XPath, RegExp: способы извлечения данных (JSONPath, XPath или регулярное выражение).
-// - From: источник данных (например, тело ответа или заголовок).
+// - HtmlXPath, CssSelector: извлечение из HTML по XPath (допускает невалидную разметку) или CSS-селектору.
+// - From: источник данных (тело ответа, заголовок, cookie, статус-код, длительность или итоговый URL).
 // - HeaderKey: ключ заголовка, если данные берутся из заголовков.
+// - Cookie: имя cookie, если данные берутся из cookie.
+// - Default: значение переменной, если извлечение не удалось.
 type capturePath struct {
-	JsonPath  *string           `json:"jsonPath"`
-	XPath     *string           `json:"xPath"`
-	RegExp    *RegexCaptureConf `json:"regExp"`
-	From      string            `json:"from"`
-	HeaderKey *string           `json:"headerKey"`
+	JsonPath    *string           `json:"jsonPath"`
+	XPath       *string           `json:"xPath"`
+	HtmlXPath   *string           `json:"htmlXPath"`
+	CssSelector *CssSelectorConf  `json:"cssSelector"`
+	RegExp      *RegexCaptureConf `json:"regExp"`
+	From        string            `json:"from"`
+	HeaderKey   *string           `json:"headerKey"`
+	Cookie      *string           `json:"cookieName"`
+	Default     interface{}       `json:"default"`
+}
+
+// Структура cookieConf описывает настройки cookie jar итерации.
+type cookieConf struct {
+	Enabled bool           `json:"enabled"`
+	Preset  []presetCookie `json:"preset"`
+}
+
+// Структура presetCookie описывает cookie, добавляемую в jar в начале каждой итерации.
+type presetCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Domain   string `json:"domain"`
+	Path     string `json:"path"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"http_only"`
+}
+
+// toCookieConf преобразует настройки cookie в types.CookieConf.
+// Наличие предустановленных cookie включает cookie jar.
+func (c cookieConf) toCookieConf() types.CookieConf {
+	conf := types.CookieConf{Enabled: c.Enabled || len(c.Preset) > 0}
+	for _, p := range c.Preset {
+		conf.Preset = append(conf.Preset, &http.Cookie{
+			Name:     p.Name,
+			Value:    p.Value,
+			Domain:   p.Domain,
+			Path:     p.Path,
+			Secure:   p.Secure,
+			HttpOnly: p.HttpOnly,
+		})
+	}
+	return conf
+}
+
+// Структура resultExport описывает выгрузку результата каждого запроса в файл.
+type resultExport struct {
+	Path   string `json:"path"`
+	Format string `json:"format"`
+}
+
+// Структура pushTarget описывает отправку результатов запросов во внешнюю систему.
+type pushTarget struct {
+	Type          string            `json:"type"`
+	URL           string            `json:"url"`
+	Headers       map[string]string `json:"headers"`
+	Prefix        string            `json:"prefix"`
+	Tags          map[string]string `json:"tags"`
+	BatchSize     int               `json:"batch_size"`
+	FlushInterval int               `json:"flush_interval"`
+}
+
+// Тип outputList описывает типы вывода отчётов: одну строку или список строк.
+type outputList []string
+
+// Метод UnmarshalJSON для outputList.
+// Принимает как "stdout", так и ["gui", "junit"].
+func (o *outputList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*o = outputList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("output should be a string or a list of strings")
+	}
+	*o = outputList(list)
+	return nil
+}
+
+// Структура threshold описывает пороговое значение метрики теста.
+type threshold struct {
+	Metric string   `json:"metric"`
+	StepID uint16   `json:"step_id"`
+	Min    *float64 `json:"min"`
+	Max    *float64 `json:"max"`
 }
 
 // Структура step описывает один шаг сценария.
@@ -93,6 +192,13 @@ type step struct {
 	CertPath         string                 `json:"cert_path"`
 	CertKeyPath      string                 `json:"cert_key_path"`
 	CaptureEnv       map[string]capturePath `json:"captureEnv"`
+	Condition        string                 `json:"condition"`
+	ForEach          string                 `json:"for_each"`
+	ForEachAs        string                 `json:"for_each_as"`
+	Until            string                 `json:"until"`
+	MaxRepeat        int                    `json:"max_repeat"`
+	OnFailure        string                 `json:"on_failure"`
+	ExpectedStatus   []string               `json:"expected_status"`
 }
 
 // Метод UnmarshalJSON для структуры step.
@@ -115,6 +221,31 @@ func (s *step) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// Структура scenarioConf описывает именованный сценарий смеси с весом.
+type scenarioConf struct {
+	Name   string                 `json:"name"`
+	Weight int                    `json:"weight"`
+	Steps  []step                 `json:"steps"`
+	Envs   map[string]interface{} `json:"env"`
+}
+
+// Метод UnmarshalJSON для scenarioConf.
+// Устанавливает вес сценария по умолчанию.
+func (s *scenarioConf) UnmarshalJSON(data []byte) error {
+	type scenarioConfAlias scenarioConf
+	defaultFields := &scenarioConfAlias{
+		Weight: 1, // Вес по умолчанию: сценарии смеси выполняются равномерно.
+	}
+
+	err := json.Unmarshal(data, defaultFields)
+	if err != nil {
+		return err
+	}
+
+	*s = scenarioConf(*defaultFields)
+	return nil
+}
+
 // Структура JsonReader описывает читатель конфигураций в формате JSON.
 // Включает поля для параметров нагрузки, шагов сценария, прокси, окружения и других.
 type JsonReader struct {
@@ -124,10 +255,23 @@ type JsonReader struct {
 	Duration     int                    `json:"duration"`
 	TimeRunCount timeRunCount           `json:"manual_load"`
 	Steps        []step                 `json:"steps"`
-	Output       string                 `json:"output"`
+	Scenarios    []scenarioConf         `json:"scenarios"`
+	Output       outputList             `json:"output"`
+	OutputPath   string                 `json:"output_path"`
 	Proxy        string                 `json:"proxy"`
 	Envs         map[string]interface{} `json:"env"`
+	Cookies      cookieConf             `json:"cookies"`
+	Thresholds   []threshold            `json:"thresholds"`
+	ResultExport resultExport           `json:"result_export"`
+	MetricsAddr  string                 `json:"metrics_addr"`
+	Push         []pushTarget           `json:"push"`
 	Debug        bool                   `json:"debug"`
+
+	opts LoadOptions // Параметры сборки конфигурации: include, оверлеи, переопределения и переменные окружения
+}
+
+func (j *JsonReader) setLoadOptions(opts LoadOptions) {
+	j.opts = opts
 }
 
 // Метод UnmarshalJSON для JsonReader.
@@ -135,9 +279,9 @@ type JsonReader struct {
 func (j *JsonReader) UnmarshalJSON(data []byte) error {
 	type jsonReaderAlias JsonReader
 	defaultFields := &jsonReaderAlias{
-		LoadType: types.DefaultLoadType,   // Тип нагрузки по умолчанию.
-		Duration: types.DefaultDuration,   // Длительность по умолчанию.
-		Output:   types.DefaultOutputType, // Тип вывода по умолчанию.
+		LoadType: types.DefaultLoadType,               // Тип нагрузки по умолчанию.
+		Duration: types.DefaultDuration,               // Длительность по умолчанию.
+		Output:   outputList{types.DefaultOutputType}, // Тип вывода по умолчанию.
 	}
 
 	// Десериализуем JSON с настройкой полей по умолчанию.
@@ -152,7 +296,7 @@ func (j *JsonReader) UnmarshalJSON(data []byte) error {
 }
 
 // Метод Init для JsonReader.
-// Проверяет, что переданный JSON валиден, и десериализует его в структуру.
+// Проверяет, что переданный JSON валиден, собирает конфигурацию с учётом LoadOptions и десериализует её в структуру.
 func (j *JsonReader) Init(jsonByte []byte) (err error) {
 	// Проверяем валидность JSON.
 	if !json.Valid(jsonByte) {
@@ -160,25 +304,47 @@ func (j *JsonReader) Init(jsonByte []byte) (err error) {
 		return
 	}
 
+	// Собираем конфигурацию из включаемых файлов, оверлеев и переопределений, подставляем переменные окружения.
+	// Каждый файл и итоговая конфигурация проверяются по схеме конфигурации.
+	opts := j.opts
+	if jsonByte, err = opts.resolve(jsonByte, jsonLines(jsonByte)); err != nil {
+		return
+	}
+
 	// Десериализуем JSON в объект JsonReader.
 	err = json.Unmarshal(jsonByte, &j)
+	j.opts = opts
 	return
 }
 
 func (j *JsonReader) CreateHammer() (h types.Heart, err error) {
 	// Создание сценария на основе шагов и переменных окружения.
-	s := types.Scenario{
-		Envs: j.Envs, // Переменные окружения для сценария.
+	s, err := createScenario(j.Steps, j.Envs)
+	if err != nil {
+		return
 	}
-	var si types.ScenarioStep
-	for _, step := range j.Steps {
-		// Преобразование каждого шага в тип ScenarioStep.
-		si, err = stepToScenarioStep(step)
+	s.Cookies = j.Cookies.toCookieConf()
+
+	// Создание смеси сценариев. Глобальные переменные окружения доступны во всех сценариях.
+	var scenarios []types.Scenario
+	for _, sc := range j.Scenarios {
+		envs := make(map[string]interface{}, len(j.Envs)+len(sc.Envs))
+		for k, v := range j.Envs {
+			envs[k] = v
+		}
+		for k, v := range sc.Envs {
+			envs[k] = v
+		}
+
+		var ms types.Scenario
+		ms, err = createScenario(sc.Steps, envs)
 		if err != nil {
 			return
 		}
-		// Добавление шага в сценарий.
-		s.Steps = append(s.Steps, si)
+		ms.Name = sc.Name
+		ms.Weight = sc.Weight
+		ms.Cookies = j.Cookies.toCookieConf()
+		scenarios = append(scenarios, ms)
 	}
 
 	// Создание конфигурации прокси, если она указана.
@@ -221,13 +387,40 @@ func (j *JsonReader) CreateHammer() (h types.Heart, err error) {
 		TestDuration:      j.Duration,
 		TimeRunCountMap:   types.TimeRunCount(j.TimeRunCount),
 		Scenario:          s,
+		Scenarios:         scenarios,
 		Proxy:             p,
-		ReportDestination: j.Output,
+		ReportOutputs:     j.Output,
+		ReportDestination: j.OutputPath,
+		ResultExport:      types.ResultExport(j.ResultExport),
+		MetricsAddr:       j.MetricsAddr,
 		Debug:             j.Debug,
 	}
+	for _, t := range j.Thresholds {
+		h.Thresholds = append(h.Thresholds, types.Threshold(t))
+	}
+	for _, p := range j.Push {
+		h.Push = append(h.Push, types.PushTarget(p))
+	}
 	return
 }
 
+// createScenario создаёт сценарий из шагов конфигурации и переменных окружения.
+func createScenario(steps []step, envs map[string]interface{}) (types.Scenario, error) {
+	s := types.Scenario{
+		Envs: envs, // Переменные окружения для сценария.
+	}
+	for _, step := range steps {
+		// Преобразование каждого шага в тип ScenarioStep.
+		si, err := stepToScenarioStep(step)
+		if err != nil {
+			return s, err
+		}
+		// Добавление шага в сценарий.
+		s.Steps = append(s.Steps, si)
+	}
+	return s, nil
+}
+
 func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 	var payload string
 	var err error
@@ -267,17 +460,30 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 	var capturedEnvs []types.EnvCaptureConf
 	for name, path := range s.CaptureEnv {
 		capConf := types.EnvCaptureConf{
-			JsonPath: path.JsonPath,
-			Xpath:    path.XPath,
-			Name:     name,
-			From:     types.SourceType(path.From),
-			Key:      path.HeaderKey,
+			JsonPath:  path.JsonPath,
+			Xpath:     path.XPath,
+			HtmlXpath: path.HtmlXPath,
+			Name:      name,
+			From:      types.SourceType(path.From),
+			Key:       path.HeaderKey,
+			Cookie:    path.Cookie,
+			Default:   path.Default,
+		}
+
+		if path.CssSelector != nil {
+			capConf.CssSelector = &types.CssSelectorConf{
+				Selector: path.CssSelector.Selector,
+				Attr:     path.CssSelector.Attr,
+				No:       path.CssSelector.No,
+			}
 		}
 
 		if path.RegExp != nil {
 			capConf.RegExp = &types.RegexCaptureConf{
-				Exp: path.RegExp.Exp,
-				No:  path.RegExp.No,
+				Exp:         path.RegExp.Exp,
+				No:          path.RegExp.No,
+				All:         path.RegExp.All,
+				NamedGroups: path.RegExp.NamedGroups,
 			}
 		}
 
@@ -297,6 +503,20 @@ func stepToScenarioStep(s step) (types.ScenarioStep, error) {
 		Sleep:         strings.ReplaceAll(s.Sleep, " ", ""),
 		Custom:        s.Others,
 		EnvsToCapture: capturedEnvs,
+		Flow: types.StepFlow{
+			Condition: s.Condition,
+			ForEach:   s.ForEach,
+			ForEachAs: s.ForEachAs,
+			Until:     s.Until,
+			MaxRepeat: s.MaxRepeat,
+			OnFailure: s.OnFailure,
+		},
+		ExpectedStatus: s.ExpectedStatus,
+	}
+
+	// Имя переменной для элемента forEach по умолчанию.
+	if item.Flow.ForEach != "" && item.Flow.ForEachAs == "" {
+		item.Flow.ForEachAs = types.DefaultForEachAs
 	}
 
 	// Настройка TLS-сертификатов.
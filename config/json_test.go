@@ -0,0 +1,194 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"httes/core/types"
+)
+
+// createHeart читает конфигурацию из config_testdata и создаёт по ней Heart.
+func createHeart(t *testing.T, name string) types.Heart {
+	t.Helper()
+	path := filepath.Join("config_testdata", name)
+	data := readFile(t, path)
+	reader, err := NewConfigReader(data, DetectConfigType(path, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// Сценарии смеси получают глобальные переменные окружения и свои веса, вес по умолчанию равен 1.
+func TestScenarioMixConfig(t *testing.T) {
+	h := createHeart(t, "config_scenario_mix.json")
+	if len(h.Scenario.Steps) != 0 || len(h.Scenarios) != 3 {
+		t.Fatalf("expected 3 scenarios without steps, got %d steps and %d scenarios", len(h.Scenario.Steps), len(h.Scenarios))
+	}
+	expected := []struct {
+		name   string
+		weight int
+		steps  int
+	}{{"browse", 70, 1}, {"search", 25, 1}, {"checkout", 5, 2}}
+	for i, s := range h.Scenarios {
+		if s.Name != expected[i].name || s.Weight != expected[i].weight || len(s.Steps) != expected[i].steps {
+			t.Errorf("scenario %d: expected %+v, got %s with weight %d and %d steps", i, expected[i], s.Name, s.Weight, len(s.Steps))
+		}
+		if s.Envs["HOST"] != "http://localhost:8084" {
+			t.Errorf("scenario %s: global env HOST is not set: %v", s.Name, s.Envs)
+		}
+	}
+	if h.Scenarios[1].Envs["QUERY"] != "phone" || h.Scenarios[0].Envs["QUERY"] != nil {
+		t.Errorf("scenario env QUERY: %v, %v", h.Scenarios[0].Envs, h.Scenarios[1].Envs)
+	}
+
+	data := `{"scenarios": [{"name": "a", "steps": [{"id": 1, "url": "http://localhost"}]}]}`
+	reader, err := NewConfigReader([]byte(data), ConfigTypeJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, err = reader.CreateHammer(); err != nil || h.Scenarios[0].Weight != 1 {
+		t.Errorf("expected default weight 1, got %v %v", h.Scenarios, err)
+	}
+}
+
+// Конфигурация задаёт либо шаги одного сценария, либо смесь сценариев.
+func TestStepsAndScenariosConfig(t *testing.T) {
+	step := `{"id": 1, "url": "http://localhost"}`
+	data := `{"steps": [` + step + `], "scenarios": [{"name": "a", "steps": [` + step + `]}]}`
+	_, err := NewConfigReader([]byte(data), ConfigTypeJson)
+	if err == nil || !strings.Contains(err.Error(), "fields steps and scenarios cannot be used together") {
+		t.Errorf("expected steps and scenarios error, got %v", err)
+	}
+
+	// Пустой список шагов тоже считается заданным
+	data = `{"steps": [], "scenarios": [{"name": "a", "steps": [` + step + `]}]}`
+	if _, err := NewConfigReader([]byte(data), ConfigTypeJson); err == nil {
+		t.Error("expected steps and scenarios error for empty steps")
+	}
+}
+
+// Цели отправки результатов читаются со всеми параметрами и проходят проверку конфигурации.
+func TestPushConfig(t *testing.T) {
+	h := createHeart(t, "config_push.json")
+	expected := []types.PushTarget{
+		{
+			Type:          types.PushInfluxDB,
+			URL:           "http://localhost:8086/api/v2/write?org=httes&bucket=load&precision=ns",
+			Headers:       map[string]string{"Authorization": "Token secret"},
+			Tags:          map[string]string{"env": "staging"},
+			BatchSize:     200,
+			FlushInterval: 500,
+		},
+		{Type: types.PushDogStatsD, URL: "udp://localhost:8125", Prefix: "load"},
+		{Type: types.PushOTLP, URL: "http://localhost:4318/v1/metrics"},
+	}
+	if !reflect.DeepEqual(h.Push, expected) {
+		t.Errorf("expected push targets %+v, got %+v", expected, h.Push)
+	}
+	if err := h.Validate(); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+
+	// Тип и адрес проверяются схемой, схема адреса — при проверке конфигурации
+	for _, tt := range []struct {
+		push string
+		err  string
+	}{
+		{`{"type": "graphite", "url": "udp://localhost:2003"}`, "/push/0/type: value \"graphite\" is not one of"},
+		{`{"type": "otlp"}`, "/push/0: missing required field url"},
+		{`{"type": "otlp", "url": "http://localhost:4318", "batch_size": -1}`, "/push/0/batch_size: value -1 is less than 0"},
+	} {
+		data := `{"push": [` + tt.push + `], "steps": [{"id": 1, "url": "http://localhost"}]}`
+		if _, err := NewConfigReader([]byte(data), ConfigTypeJson); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: expected error %q, got %v", tt.push, tt.err, err)
+		}
+	}
+	data := `{"push": [{"type": "statsd", "url": "http://localhost:8125"}], "steps": [{"id": 1, "url": "http://localhost"}]}`
+	reader, err := NewConfigReader([]byte(data), ConfigTypeJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err = reader.CreateHammer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Validate(); err == nil || err.Error() != "statsd push url should use one of the schemes [udp]: http://localhost:8125" {
+		t.Errorf("unexpected statsd url error %v", err)
+	}
+}
+
+// Вывод задаётся одной строкой или списком, по умолчанию используется stdout.
+func TestOutputConfig(t *testing.T) {
+	h := createHeart(t, "config_multi_output.json")
+	if !reflect.DeepEqual(h.ReportOutputs, []string{"stdout", "junit"}) || h.ReportDestination != "junit.xml" {
+		t.Errorf("unexpected outputs %v with destination %q", h.ReportOutputs, h.ReportDestination)
+	}
+
+	steps := `"steps": [{"id": 1, "url": "http://localhost"}]`
+	tests := []struct {
+		output   string
+		expected []string
+		err      string
+	}{
+		{output: `"output": "junit",`, expected: []string{"junit"}},
+		{output: `"output": [],`, expected: []string{}},
+		{output: "", expected: []string{types.DefaultOutputType}},
+		{output: `"output": 1,`, err: "/output: expected string or array, got integer"},
+		{output: `"output": ["stdout", 1],`, err: "/output/1: expected string, got integer"},
+	}
+	for _, tt := range tests {
+		reader, err := NewConfigReader([]byte("{"+tt.output+steps+"}"), ConfigTypeJson)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: expected error %q, got %v", tt.output, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.output, err)
+		}
+		h, err := reader.CreateHammer()
+		if err != nil || !reflect.DeepEqual(h.ReportOutputs, tt.expected) {
+			t.Errorf("%s: expected outputs %v, got %v %v", tt.output, tt.expected, h.ReportOutputs, err)
+		}
+	}
+}
+
+// Устаревшее поле request_count читается, но iteration_count имеет приоритет.
+func TestIterationCountOverRequestCount(t *testing.T) {
+	h := createHeart(t, "config_iteration_count_over_req_count.json")
+	if h.IterationCount != 333 || h.LoadType != "waved" || h.TestDuration != 21 {
+		t.Errorf("expected 333 iterations in 21 s waved, got %d in %d s %s", h.IterationCount, h.TestDuration, h.LoadType)
+	}
+	if len(h.Scenario.Steps) != 2 || h.Proxy.Addr.String() != "http://proxy_host:80" {
+		t.Errorf("unexpected steps %d and proxy %v", len(h.Scenario.Steps), h.Proxy.Addr)
+	}
+
+	data := `{"request_count": 222, "steps": [{"id": 1, "url": "http://a.test"}]}`
+	reader, err := NewConfigReader([]byte(data), ConfigTypeJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, err = reader.CreateHammer(); err != nil || h.IterationCount != 222 {
+		t.Errorf("expected 222 iterations from request_count, got %d %v", h.IterationCount, err)
+	}
+}
+
+// Ручная нагрузка заменяет количество итераций и длительность суммой своих интервалов.
+func TestManualLoadOverride(t *testing.T) {
+	h := createHeart(t, "config_manual_load_override.json")
+	if h.IterationCount != 35 || h.TestDuration != 18 {
+		t.Errorf("expected 35 iterations in 18 s, got %d in %d s", h.IterationCount, h.TestDuration)
+	}
+	expected := types.TimeRunCount{{Duration: 5, Count: 5}, {Duration: 6, Count: 10}, {Duration: 7, Count: 20}}
+	if !reflect.DeepEqual(h.TimeRunCountMap, expected) {
+		t.Errorf("expected manual load %v, got %v", expected, h.TimeRunCountMap)
+	}
+}
@@ -0,0 +1,418 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ключ конфигурации со списком включаемых файлов
+const includeKey = "include"
+
+// Ссылка на переменную окружения: ${NAME} или ${NAME:-значение по умолчанию}. $${ записывает ${ без подстановки
+var envRefRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_]\w*)(?::-([^}]*))?\}`)
+
+// LoadOptions — параметры сборки конфигурации из нескольких файлов.
+// Конфигурация собирается в таком порядке: включаемые файлы (include), сам файл, оверлеи,
+// переопределения Set, затем во всех строках подставляются переменные окружения ${NAME}.
+type LoadOptions struct {
+	BaseDir  string   // Каталог файла конфигурации, относительно него ищутся include и .env. По умолчанию текущий каталог
+	Overlays []string // Файлы, которые по порядку накладываются на конфигурацию
+	EnvFile  string   // Файл с переменными окружения. По умолчанию .env в BaseDir, если он есть
+	Set      []string // Переопределения вида path=value, например env.HOST=example.com или steps.0.timeout=10
+
+	// Resolve возвращает конфигурацию со ссылками ${NAME} вместо значений переменных окружения.
+	// Подстановка всё равно выполняется для проверки, но значения, например секреты из .env,
+	// не попадают в результат и подставляются заново при каждой сборке этой конфигурации.
+	KeepEnvRefs bool
+}
+
+// layeredReader — читатель конфигурации, который учитывает LoadOptions.
+type layeredReader interface {
+	setLoadOptions(LoadOptions)
+}
+
+// NewConfigReaderWithOptions создаёт читатель конфигурации, который собирает её с учётом opts.
+func NewConfigReaderWithOptions(config []byte, configType string, opts LoadOptions) (ConfigReader, error) {
+	return newConfigReader(config, configType, opts)
+}
+
+// Resolve собирает конфигурацию типа configType (ConfigTypeJson или ConfigTypeYaml) с учётом opts
+// так же, как читатели конфигурации, и возвращает итоговый JSON, проверенный по схеме.
+func Resolve(config []byte, configType string, opts LoadOptions) ([]byte, error) {
+	if configType == ConfigTypeJson {
+		return opts.resolve(config, jsonLines(config))
+	}
+	data, c, err := yamlToJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	return opts.resolve(data, c.lines())
+}
+
+// resolve собирает итоговую конфигурацию в JSON из основного файла data (JSON) и opts.
+// lines — строки значений основного файла для сообщений об ошибках схемы.
+func (o LoadOptions) resolve(data []byte, lines map[string]int) ([]byte, error) {
+	doc, err := o.load(data, "", o.BaseDir, nil, lines)
+	if err != nil {
+		return nil, err
+	}
+	layered := len(o.Overlays) > 0 || len(o.Set) > 0 || hasIncludes(data)
+	for _, path := range o.Overlays {
+		overlay, err := o.loadFile(path, nil)
+		if err != nil {
+			return nil, err
+		}
+		doc = merge(doc, overlay)
+	}
+	for _, s := range o.Set {
+		if err := setPath(doc, s); err != nil {
+			return nil, err
+		}
+	}
+
+	var unexpanded []byte
+	if o.KeepEnvRefs {
+		if unexpanded, err = json.Marshal(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	lookup, err := o.envLookup()
+	if err != nil {
+		return nil, err
+	}
+	missing := map[string]bool{}
+	expanded := expandEnv(doc, lookup, missing)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for n := range missing {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("environment variables are not defined: %s", strings.Join(names, ", "))
+	}
+
+	// Итоговая конфигурация проверяется полностью, включая обязательные поля.
+	// Строки основного файла применимы, только если на него ничего не накладывалось.
+	if layered {
+		lines = nil
+	}
+	if err := validateSchema(expanded, false, "", lines); err != nil {
+		return nil, err
+	}
+	if unexpanded != nil {
+		return unexpanded, nil
+	}
+	return json.Marshal(expanded)
+}
+
+// load разбирает конфигурацию name и накладывает её на включаемые файлы. Пути include ищутся в каталоге dir.
+// stack — цепочка включающих файлов для обнаружения циклов, lines — строки значений файла.
+func (o LoadOptions) load(data []byte, name, dir string, stack []string, lines map[string]int) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // Числа сохраняются без потери точности
+	if err := dec.Decode(&doc); err != nil {
+		if name != "" {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return nil, err
+	}
+	if err := validateSchema(doc, true, name, lines); err != nil {
+		return nil, err
+	}
+
+	var includes []string
+	switch v := doc[includeKey].(type) {
+	case nil:
+	case string:
+		includes = []string{v}
+	case []interface{}:
+		for _, i := range v {
+			s, ok := i.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a file path or a list of file paths", includeKey)
+			}
+			includes = append(includes, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a file path or a list of file paths", includeKey)
+	}
+	delete(doc, includeKey)
+
+	var base map[string]interface{}
+	for _, inc := range includes {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(dir, inc)
+		}
+		part, err := o.loadFile(inc, stack)
+		if err != nil {
+			return nil, err
+		}
+		base = merge(base, part)
+	}
+	return merge(base, doc), nil
+}
+
+// loadFile читает файл конфигурации в формате JSON или YAML вместе с его включаемыми файлами.
+func (o LoadOptions) loadFile(path string, stack []string) (map[string]interface{}, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines map[string]int
+	if DetectConfigType(path, data) == ConfigTypeYaml {
+		var c *yamlConverter
+		if data, c, err = yamlToJSON(data); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		lines = c.lines()
+	} else {
+		lines = jsonLines(data)
+	}
+	return o.load(data, path, filepath.Dir(path), append(stack, abs), lines)
+}
+
+// hasIncludes сообщает, что конфигурация data содержит ключ include.
+func hasIncludes(data []byte) bool {
+	var top map[string]json.RawMessage
+	if json.Unmarshal(data, &top) != nil {
+		return false
+	}
+	_, ok := top[includeKey]
+	return ok
+}
+
+// merge накладывает over на base. Словари объединяются рекурсивно, null в over удаляет ключ,
+// списки объединяются по правилам mergeList.
+func merge(base, over map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		return over
+	}
+	res := make(map[string]interface{}, len(base)+len(over))
+	for k, v := range base {
+		res[k] = v
+	}
+	for k, v := range over {
+		switch ov := v.(type) {
+		case nil:
+			delete(res, k)
+			continue
+		case map[string]interface{}:
+			if bm, ok := res[k].(map[string]interface{}); ok {
+				res[k] = merge(bm, ov)
+				continue
+			}
+		case []interface{}:
+			if bl, ok := res[k].([]interface{}); ok {
+				res[k] = mergeList(bl, ov)
+				continue
+			}
+		}
+		res[k] = v
+	}
+	return res
+}
+
+// mergeList объединяет списки объектов с полем id (шаги) или name (сценарии): элемент over объединяется
+// с элементом base с тем же значением поля, новые элементы добавляются в конец. Остальные списки заменяются.
+func mergeList(base, over []interface{}) []interface{} {
+	for _, key := range []string{"id", "name"} {
+		if !keyedList(base, key) || !keyedList(over, key) {
+			continue
+		}
+		res := append([]interface{}{}, base...)
+		for _, o := range over {
+			om := o.(map[string]interface{})
+			found := false
+			for i, b := range res {
+				bm := b.(map[string]interface{})
+				if fmt.Sprint(bm[key]) == fmt.Sprint(om[key]) {
+					res[i] = merge(bm, om)
+					found = true
+					break
+				}
+			}
+			if !found {
+				res = append(res, om)
+			}
+		}
+		return res
+	}
+	return over
+}
+
+// keyedList сообщает, что все элементы списка — объекты с полем key.
+func keyedList(list []interface{}, key string) bool {
+	for _, v := range list {
+		m, ok := v.(map[string]interface{})
+		if !ok || m[key] == nil {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// setPath применяет переопределение path=value. Путь состоит из ключей и индексов списков через точку.
+// Значение разбирается как JSON (числа, true/false, объекты), иначе записывается строкой.
+func setPath(doc map[string]interface{}, expr string) error {
+	path, raw, ok := strings.Cut(expr, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("invalid override %q, expected path=value", expr)
+	}
+	var value interface{} = raw
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var parsed interface{}
+	if err := dec.Decode(&parsed); err == nil && !dec.More() {
+		value = parsed
+	}
+
+	keys := strings.Split(path, ".")
+	var cur interface{} = doc
+	for i, k := range keys {
+		last := i == len(keys)-1
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			if last {
+				c[k] = value
+				return nil
+			}
+			next, ok := c[k]
+			if !ok || next == nil {
+				next = map[string]interface{}{}
+				c[k] = next
+			}
+			cur = next
+		case []interface{}:
+			n, err := strconv.Atoi(k)
+			if err != nil || n < 0 || n >= len(c) {
+				return fmt.Errorf("override %s: list %s has no element %s", path, strings.Join(keys[:i], "."), k)
+			}
+			if last {
+				c[n] = value
+				return nil
+			}
+			cur = c[n]
+		default:
+			return fmt.Errorf("override %s: %s is not an object or a list", path, strings.Join(keys[:i], "."))
+		}
+	}
+	return nil
+}
+
+// envLookup возвращает функцию поиска переменной окружения. Переменные процесса имеют приоритет над файлом .env.
+func (o LoadOptions) envLookup() (func(string) (string, bool), error) {
+	path := o.EnvFile
+	if path == "" {
+		path = filepath.Join(o.BaseDir, ".env")
+		if _, err := os.Stat(path); err != nil {
+			path = ""
+		}
+	}
+	fileVars := map[string]string{}
+	if path != "" {
+		var err error
+		if fileVars, err = readDotEnv(path); err != nil {
+			return nil, err
+		}
+	}
+	return func(name string) (string, bool) {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true
+		}
+		v, ok := fileVars[name]
+		return v, ok
+	}, nil
+}
+
+// expandEnv подставляет переменные окружения во все строки значения v.
+// Имена переменных без значения и без значения по умолчанию записываются в missing.
+func expandEnv(v interface{}, lookup func(string) (string, bool), missing map[string]bool) interface{} {
+	switch t := v.(type) {
+	case string:
+		return envRefRegexp.ReplaceAllStringFunc(t, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+			m := envRefRegexp.FindStringSubmatch(ref)
+			hasDefault := strings.Contains(ref, ":-")
+			if val, ok := lookup(m[1]); ok && (val != "" || !hasDefault) {
+				return val
+			}
+			if hasDefault {
+				return m[2]
+			}
+			missing[m[1]] = true
+			return ref
+		})
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = expandEnv(val, lookup, missing)
+		}
+	case []interface{}:
+		for i, val := range t {
+			t[i] = expandEnv(val, lookup, missing)
+		}
+	}
+	return v
+}
+
+// readDotEnv читает файл .env: строки NAME=value, комментарии #, необязательный префикс export
+// и значения в одинарных (без экранирования) или двойных кавычках.
+func readDotEnv(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, n)
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[name] = value
+	}
+	return vars, scanner.Err()
+}
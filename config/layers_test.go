@@ -0,0 +1,59 @@
+package config
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func layeredOptions(keepEnvRefs bool) LoadOptions {
+	dir := filepath.Join("config_testdata", "layered")
+	return LoadOptions{
+		BaseDir:     dir,
+		Overlays:    []string{filepath.Join(dir, "prod.json")},
+		EnvFile:     filepath.Join(dir, "stage.env"),
+		Set:         []string{"steps.0.timeout=3"},
+		KeepEnvRefs: keepEnvRefs,
+	}
+}
+
+// Конфигурация для истории запусков собрана из всех слоёв, но значения переменных окружения в неё не попадают.
+// При повторной сборке с тем же окружением она даёт ту же конфигурацию, что и исходные файлы.
+func TestResolveKeepEnvRefs(t *testing.T) {
+	path := filepath.Join("config_testdata", "layered", "base.json")
+	data := readFile(t, path)
+	configType := DetectConfigType(path, data)
+
+	kept, err := Resolve(data, configType, layeredOptions(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"${API_USER}", "${API_PASSWORD}", "${API_HOST:-http://localhost:8080}/api", `"timeout":3`, `"iteration_count":1000`} {
+		if !strings.Contains(string(kept), s) {
+			t.Errorf("resolved config does not contain %s:\n%s", s, kept)
+		}
+	}
+	if strings.Contains(string(kept), "p@ss word") || strings.Contains(string(kept), "include") {
+		t.Errorf("resolved config contains a secret or includes:\n%s", kept)
+	}
+
+	expected, err := Resolve(data, configType, layeredOptions(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rerun, err := Resolve(kept, DetectConfigType("", kept), LoadOptions{EnvFile: layeredOptions(false).EnvFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var a, b map[string]interface{}
+	json.Unmarshal(expected, &a)
+	json.Unmarshal(rerun, &b)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("rerun config differs:\n%s\nexpected:\n%s", rerun, expected)
+	}
+	if !strings.Contains(string(expected), "p@ss word") {
+		t.Errorf("expanded config does not contain the password:\n%s", expected)
+	}
+}
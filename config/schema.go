@@ -0,0 +1,420 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schema — JSON Schema формата конфигурации. Описывает и JSON, и YAML конфигурации.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Максимальное количество ошибок схемы в одном сообщении
+const maxSchemaErrors = 20
+
+// Разобранная схема конфигурации
+var configSchema = mustParseSchema(Schema)
+
+// schemaNode — узел JSON Schema. Поддерживается подмножество ключевых слов, которое использует schema.json.
+type schemaNode struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*schemaNode `json:"properties"`
+	PatternProperties    map[string]*schemaNode `json:"patternProperties"`
+	AdditionalProperties *schemaAdditional      `json:"additionalProperties"`
+	Required             []string               `json:"required"`
+	Items                *schemaNode            `json:"items"`
+	Enum                 []interface{}          `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	Pattern              string                 `json:"pattern"`
+	Not                  *schemaNode            `json:"not"`
+	Defs                 map[string]*schemaNode `json:"$defs"`
+
+	pattern  *regexp.Regexp
+	patterns map[string]*regexp.Regexp // Скомпилированные ключи PatternProperties
+}
+
+// schemaTypes — значение type: одна строка или список.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var list []string
+	err := json.Unmarshal(data, &list)
+	*t = list
+	return err
+}
+
+// schemaAdditional — значение additionalProperties: false или схема значений.
+type schemaAdditional struct {
+	forbidden bool
+	schema    *schemaNode
+}
+
+func (a *schemaAdditional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.forbidden = !allowed
+		return nil
+	}
+	return json.Unmarshal(data, &a.schema)
+}
+
+// schemaError — нарушение схемы в значении с указателем JSON pointer.
+type schemaError struct {
+	pointer string
+	msg     string
+}
+
+func mustParseSchema(data []byte) *schemaNode {
+	var root schemaNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		panic(fmt.Sprintf("config schema is invalid: %v", err))
+	}
+	var compile func(n *schemaNode)
+	compile = func(n *schemaNode) {
+		if n == nil {
+			return
+		}
+		if n.Pattern != "" {
+			n.pattern = regexp.MustCompile(n.Pattern)
+		}
+		for _, p := range n.Properties {
+			compile(p)
+		}
+		for expr, p := range n.PatternProperties {
+			if n.patterns == nil {
+				n.patterns = map[string]*regexp.Regexp{}
+			}
+			n.patterns[expr] = regexp.MustCompile(expr)
+			compile(p)
+		}
+		for _, d := range n.Defs {
+			compile(d)
+		}
+		if n.AdditionalProperties != nil {
+			compile(n.AdditionalProperties.schema)
+		}
+		compile(n.Items)
+		compile(n.Not)
+	}
+	compile(&root)
+	return &root
+}
+
+// validateSchema проверяет конфигурацию по схеме. В частичном режиме (partial) не проверяются
+// обязательные поля: так проверяются включаемые файлы и оверлеи, которые дополняют друг друга.
+// lines сопоставляет указатели JSON pointer со строками файла name и может быть пустым.
+func validateSchema(doc interface{}, partial bool, name string, lines map[string]int) error {
+	var errs []schemaError
+	configSchema.validate(doc, "", partial, &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return lines[errs[i].pointer] < lines[errs[j].pointer]
+	})
+
+	msgs := make([]string, 0, len(errs))
+	for i, e := range errs {
+		if i == maxSchemaErrors {
+			msgs = append(msgs, fmt.Sprintf("... and %d more errors", len(errs)-i))
+			break
+		}
+		pointer := e.pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+		msg := pointer + ": " + e.msg
+		if line, ok := lines[e.pointer]; ok {
+			msg = fmt.Sprintf("line %d: %s", line, msg)
+		}
+		if name != "" {
+			msg = name + ": " + msg
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Errorf("config does not match the schema:\n%s", strings.Join(msgs, "\n"))
+}
+
+func (n *schemaNode) validate(v interface{}, pointer string, partial bool, errs *[]schemaError) {
+	if n.Ref != "" {
+		n = configSchema.resolveRef(n.Ref)
+	}
+	if v == nil {
+		return // null равнозначен отсутствию значения, а в оверлеях удаляет ключ
+	}
+	add := func(format string, args ...interface{}) {
+		*errs = append(*errs, schemaError{pointer: pointer, msg: fmt.Sprintf(format, args...)})
+	}
+
+	if len(n.Type) > 0 && !n.Type.match(v) {
+		add("expected %s, got %s", strings.Join(n.Type, " or "), jsonKind(v))
+		return
+	}
+	if len(n.Enum) > 0 && !enumContains(n.Enum, v) {
+		values := make([]string, 0, len(n.Enum))
+		for _, e := range n.Enum {
+			b, _ := json.Marshal(e)
+			values = append(values, string(b))
+		}
+		add("value %s is not one of %s", jsonText(v), strings.Join(values, ", "))
+	}
+
+	// Обязательные поля в частичном режиме не проверяются, поэтому и not с ними проверяется только в итоговой конфигурации
+	if n.Not != nil && !partial && n.Not.matches(v) {
+		add("%s", n.Not.notMessage())
+	}
+
+	switch t := v.(type) {
+	case string:
+		if n.pattern != nil && !n.pattern.MatchString(t) {
+			add("value %q does not match %s", t, n.Pattern)
+		}
+	case json.Number:
+		f, _ := t.Float64()
+		if n.Minimum != nil && f < *n.Minimum {
+			add("value %s is less than %v", t, *n.Minimum)
+		}
+		if n.Maximum != nil && f > *n.Maximum {
+			add("value %s is greater than %v", t, *n.Maximum)
+		}
+	case map[string]interface{}:
+		if !partial {
+			for _, r := range n.Required {
+				if t[r] == nil {
+					add("missing required field %s", r)
+				}
+			}
+		}
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := pointer + "/" + escapePointer(k)
+			if p, ok := n.Properties[k]; ok {
+				p.validate(t[k], child, partial, errs)
+				continue
+			}
+			if p := n.matchPattern(k); p != nil {
+				p.validate(t[k], child, partial, errs)
+				continue
+			}
+			switch {
+			case n.AdditionalProperties == nil:
+			case n.AdditionalProperties.forbidden:
+				msg := "unknown field " + k
+				if s := suggestField(k, n.Properties); s != "" {
+					msg += fmt.Sprintf(", did you mean %s?", s)
+				}
+				*errs = append(*errs, schemaError{pointer: child, msg: msg})
+			default:
+				n.AdditionalProperties.schema.validate(t[k], child, partial, errs)
+			}
+		}
+	case []interface{}:
+		if n.Items != nil {
+			for i, item := range t {
+				n.Items.validate(item, pointer+"/"+strconv.Itoa(i), partial, errs)
+			}
+		}
+	}
+}
+
+// matches сообщает, что значение соответствует схеме.
+func (n *schemaNode) matches(v interface{}) bool {
+	var errs []schemaError
+	n.validate(v, "", false, &errs)
+	return len(errs) == 0
+}
+
+// notMessage описывает нарушение ключевого слова not со схемой n. Схема not со списком required
+// запрещает задавать перечисленные поля вместе.
+func (n *schemaNode) notMessage() string {
+	if len(n.Required) > 1 {
+		return fmt.Sprintf("fields %s and %s cannot be used together",
+			strings.Join(n.Required[:len(n.Required)-1], ", "), n.Required[len(n.Required)-1])
+	}
+	return "value matches a schema it should not match"
+}
+
+// matchPattern возвращает схему из PatternProperties, ключ которой совпадает с name.
+func (n *schemaNode) matchPattern(name string) *schemaNode {
+	for expr, re := range n.patterns {
+		if re.MatchString(name) {
+			return n.PatternProperties[expr]
+		}
+	}
+	return nil
+}
+
+// resolveRef возвращает схему по локальной ссылке вида #/$defs/name.
+func (n *schemaNode) resolveRef(ref string) *schemaNode {
+	def, ok := n.Defs[strings.TrimPrefix(ref, "#/$defs/")]
+	if !ok {
+		panic("config schema: unknown $ref " + ref)
+	}
+	if def.Ref != "" {
+		return n.resolveRef(def.Ref)
+	}
+	return def
+}
+
+// match сообщает, что значение имеет один из типов схемы.
+func (t schemaTypes) match(v interface{}) bool {
+	kind := jsonKind(v)
+	for _, typ := range t {
+		switch {
+		case typ == kind:
+			return true
+		case typ == "number" && kind == "integer":
+			return true
+		}
+	}
+	return false
+}
+
+// jsonKind возвращает тип значения в терминах JSON Schema. Целые числа имеют тип integer.
+func jsonKind(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if f, err := t.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func enumContains(enum []interface{}, v interface{}) bool {
+	for _, e := range enum {
+		if n, ok := v.(json.Number); ok {
+			if f, ok := e.(float64); ok && n.String() == strconv.FormatFloat(f, 'f', -1, 64) {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonText(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// suggestField возвращает известное поле, которое отличается от name не более чем на две правки.
+func suggestField(name string, properties map[string]*schemaNode) string {
+	best, bestDist := "", 3
+	for p := range properties {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(p)); d < bestDist || d == bestDist && p < best {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+// editDistance — расстояние Левенштейна между строками.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// escapePointer экранирует ключ для JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// jsonLines сопоставляет указатели JSON pointer всех значений JSON со строками, на которых они записаны.
+// Для полей объекта используется строка ключа.
+func jsonLines(data []byte) map[string]int {
+	lines := map[string]int{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	line, offset := 1, 0
+	current := func() int {
+		end := int(dec.InputOffset())
+		line += bytes.Count(data[offset:end], []byte{'\n'})
+		offset = end
+		return line
+	}
+
+	var walk func(pointer string) error
+	walk = func(pointer string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := lines[pointer]; !ok {
+			lines[pointer] = current()
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := pointer + "/" + escapePointer(key.(string))
+				lines[child] = current()
+				if err := walk(child); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(pointer + "/" + strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	walk("")
+	return lines
+}
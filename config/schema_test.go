@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"httes/core/types"
+)
+
+// Ошибки схемы указывают строку и путь к полю, а для опечаток в именах полей предлагают похожее поле.
+func TestSchemaErrors(t *testing.T) {
+	const step = `{"id": 1, "url": "http://a.test"}`
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			name: "unknown key",
+			data: `{"iteration_cout": 10, "steps": [` + step + `]}`,
+			err:  "line 1: /iteration_cout: unknown field iteration_cout, did you mean iteration_count?",
+		},
+		{
+			name: "unknown step key",
+			data: "{\n  \"steps\": [\n    {\"id\": 1, \"url\": \"http://a.test\", \"methd\": \"GET\"}\n  ]\n}",
+			err:  "line 3: /steps/0/methd: unknown field methd, did you mean method?",
+		},
+		{
+			name: "wrong type",
+			data: `{"duration": "10", "steps": [` + step + `]}`,
+			err:  "line 1: /duration: expected integer, got string",
+		},
+		{
+			name: "missing required step field",
+			data: `{"steps": [{"url": "http://a.test"}]}`,
+			err:  "line 1: /steps/0: missing required field id",
+		},
+		{
+			name: "missing required manual load field",
+			data: `{"manual_load": [{"duration": 5}], "steps": [` + step + `]}`,
+			err:  "line 1: /manual_load/0: missing required field count",
+		},
+		{
+			name: "pattern",
+			data: `{"load_type": "spiky", "steps": [` + step + `]}`,
+			err:  `line 1: /load_type: value "spiky" does not match (?i)^(linear|incremental|waved)$`,
+		},
+		{
+			name: "minimum",
+			data: `{"steps": [{"id": 1, "url": "http://a.test", "timeout": -1}]}`,
+			err:  "line 1: /steps/0/timeout: value -1 is less than 0",
+		},
+		{
+			name: "several errors in line order",
+			data: "{\n  \"duration\": \"10\",\n  \"steps\": [{\"id\": 1, \"url\": \"http://a.test\", \"timeout\": -1}]\n}",
+			err:  "line 2: /duration: expected integer, got string\nline 3: /steps/0/timeout: value -1 is less than 0",
+		},
+	}
+	for _, tt := range tests {
+		_, err := NewConfigReader([]byte(tt.data), ConfigTypeJson)
+		if expected := "config does not match the schema:\n" + tt.err; err == nil || err.Error() != expected {
+			t.Errorf("%s: expected error\n%s\ngot\n%v", tt.name, expected, err)
+		}
+	}
+}
+
+// Ошибки проверки шага, которые не выражаются схемой, оборачиваются в StepValidationError с номером шага и полем.
+func TestStepValidationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  string
+		id    uint16
+		field string
+		err   string
+	}{
+		{name: "method", step: `{"id": 2, "url": "http://a.test", "method": "FETCH"}`, id: 2, field: "method", err: "неподдерживаемый метод запроса: FETCH"},
+		{name: "header env", step: `{"id": 2, "url": "http://a.test", "headers": {"X-Token": "{{TOKEN}}"}}`, id: 2, field: "headers.X-Token", err: "{{TOKEN}} is not defined to use by global and captured environments"},
+		{name: "duplicate id", step: `{"id": 1, "url": "http://a.test"}`, id: 1, field: "id", err: "duplicate step id: 1"},
+		{name: "capture", step: `{"id": 2, "url": "http://a.test", "captureEnv": {"TOKEN": {"from": "body"}}}`, id: 2, field: "captureEnv.TOKEN",
+			err: "TOKEN, необходимо указать один из jsonPath, regExp, xPath, htmlXPath или cssSelector для извлечения из тела"},
+	}
+	for _, tt := range tests {
+		data := `{"steps": [{"id": 1, "url": "http://a.test"}, ` + tt.step + `]}`
+		reader, err := NewConfigReader([]byte(data), ConfigTypeJson)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		h, err := reader.CreateHammer()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		err = h.Validate()
+		var stepErr types.StepValidationError
+		if !errors.As(err, &stepErr) {
+			t.Errorf("%s: expected a step validation error, got %v", tt.name, err)
+			continue
+		}
+		if stepErr.StepID != tt.id || stepErr.Field != tt.field || errors.Unwrap(stepErr).Error() != tt.err {
+			t.Errorf("%s: expected step %d %s error %q, got %v", tt.name, tt.id, tt.field, tt.err, err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Тип конфигурации YAML.
+const ConfigTypeYaml = "yamlReader"
+
+// Функция init вызывается при загрузке пакета.
+// Регистрирует реализацию YamlReader в карте AvailableConfigReader.
+func init() {
+	AvailableConfigReader[ConfigTypeYaml] = &YamlReader{}
+}
+
+// Структура YamlReader описывает читатель конфигураций в формате YAML.
+// Схема конфигурации совпадает с JsonReader. Поддерживаются комментарии, якоря (&name),
+// ссылки на них (*name) и слияние словарей (<<: *name), например для общих заголовков шагов.
+// Якоря удобно объявлять в полях верхнего уровня с префиксом x-, которые не читаются.
+type YamlReader struct {
+	JsonReader
+}
+
+// Метод Init для YamlReader.
+// Преобразует YAML в JSON и собирает и десериализует его так же, как JsonReader.
+// Ошибки содержат номер строки исходного файла.
+func (y *YamlReader) Init(yamlByte []byte) error {
+	jsonByte, c, err := yamlToJSON(yamlByte)
+	if err != nil {
+		return err
+	}
+	opts := y.opts
+	if jsonByte, err = opts.resolve(jsonByte, c.lines()); err != nil {
+		return err
+	}
+	err = json.Unmarshal(jsonByte, &y.JsonReader)
+	y.opts = opts
+	if err != nil {
+		return c.locate(err)
+	}
+	return nil
+}
+
+// yamlToJSON преобразует YAML в JSON. Конвертер хранит строки значений для поиска места ошибки.
+func yamlToJSON(yamlByte []byte) ([]byte, *yamlConverter, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlByte, &doc); err != nil {
+		return nil, nil, fmt.Errorf("provided yaml is invalid: %v", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil, fmt.Errorf("provided yaml is empty")
+	}
+
+	c := &yamlConverter{}
+	if err := c.convert(doc.Content[0], nil, "", 0); err != nil {
+		return nil, nil, err
+	}
+	return c.buf.Bytes(), c, nil
+}
+
+// yamlValue — значение YAML с путём по ключам словарей, используется для поиска строки ошибки.
+type yamlValue struct {
+	path    []string
+	pointer string // JSON pointer значения, включая индексы списков
+	kind    string // Тип значения в терминах JSON: string, number, bool, array, object или null
+	line    int
+}
+
+// yamlConverter преобразует дерево YAML в JSON и запоминает строки всех значений.
+type yamlConverter struct {
+	buf    bytes.Buffer
+	values []yamlValue
+}
+
+// convert записывает узел n в JSON. line — строка ключа словаря, значением которого является узел, или 0.
+func (c *yamlConverter) convert(n *yaml.Node, path []string, pointer string, line int) error {
+	if n.Kind == yaml.AliasNode {
+		return c.convert(n.Alias, path, pointer, line)
+	}
+	if line == 0 {
+		line = n.Line
+	}
+	v := yamlValue{path: path, pointer: pointer, line: line}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		v.kind = "object"
+		c.values = append(c.values, v)
+
+		keys, values, keyLines, err := mappingPairs(n)
+		if err != nil {
+			return err
+		}
+		c.buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			b, _ := json.Marshal(k)
+			c.buf.Write(b)
+			c.buf.WriteByte(':')
+			child := pointer + "/" + escapePointer(k)
+			if err := c.convert(values[i], append(path[:len(path):len(path)], k), child, keyLines[i]); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte('}')
+
+	case yaml.SequenceNode:
+		v.kind = "array"
+		c.values = append(c.values, v)
+
+		c.buf.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				c.buf.WriteByte(',')
+			}
+			if err := c.convert(item, path, pointer+"/"+strconv.Itoa(i), 0); err != nil {
+				return err
+			}
+		}
+		c.buf.WriteByte(']')
+
+	case yaml.ScalarNode:
+		var s interface{}
+		if err := n.Decode(&s); err != nil {
+			return fmt.Errorf("line %d: %v", n.Line, err)
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return fmt.Errorf("line %d: unsupported value %q", n.Line, n.Value)
+		}
+		switch b[0] {
+		case '"':
+			v.kind = "string"
+		case 't', 'f':
+			v.kind = "bool"
+		case 'n':
+			v.kind = "null"
+		default:
+			v.kind = "number"
+		}
+		c.values = append(c.values, v)
+		c.buf.Write(b)
+
+	default:
+		return fmt.Errorf("line %d: unsupported yaml node", n.Line)
+	}
+	return nil
+}
+
+// mappingPairs возвращает ключи, значения и строки ключей словаря с учётом слияния (<<).
+// Явно заданные ключи имеют приоритет над ключами из слияния.
+func mappingPairs(n *yaml.Node) (keys []string, values []*yaml.Node, keyLines []int, err error) {
+	index := map[string]int{}
+	lines := map[string]int{}
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, val := n.Content[i], n.Content[i+1]
+		if k.Tag == "!!merge" {
+			merges = append(merges, val)
+			continue
+		}
+		if line, ok := lines[k.Value]; ok {
+			return nil, nil, nil, fmt.Errorf("line %d: mapping key %q already defined at line %d", k.Line, k.Value, line)
+		}
+		lines[k.Value] = k.Line
+		index[k.Value] = len(keys)
+		keys = append(keys, k.Value)
+		values = append(values, val)
+		keyLines = append(keyLines, k.Line)
+	}
+
+	for _, m := range merges {
+		if m.Kind == yaml.AliasNode {
+			m = m.Alias
+		}
+		sources := []*yaml.Node{m}
+		if m.Kind == yaml.SequenceNode {
+			sources = m.Content
+		}
+		for _, src := range sources {
+			if src.Kind == yaml.AliasNode {
+				src = src.Alias
+			}
+			if src.Kind != yaml.MappingNode {
+				return nil, nil, nil, fmt.Errorf("line %d: only mappings can be merged", src.Line)
+			}
+			mk, mv, ml, err := mappingPairs(src)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			for i, k := range mk {
+				if _, ok := index[k]; ok {
+					continue
+				}
+				index[k] = len(keys)
+				keys = append(keys, k)
+				values = append(values, mv[i])
+				keyLines = append(keyLines, ml[i])
+			}
+		}
+	}
+	return
+}
+
+// locate добавляет к ошибке несоответствия типа номер строки значения в YAML.
+// Значение ищется по пути поля и типу: сначала по полному пути, затем по имени поля.
+func (c *yamlConverter) locate(err error) error {
+	var te *json.UnmarshalTypeError
+	if !errors.As(err, &te) || te.Field == "" {
+		return err
+	}
+	kind := te.Value
+	if strings.HasPrefix(kind, "number") {
+		kind = "number"
+	}
+	field := strings.Split(te.Field, ".")
+	msg := fmt.Sprintf("field %s: cannot use %s as %s", te.Field, te.Value, te.Type)
+
+	for _, full := range []bool{true, false} {
+		for _, v := range c.values {
+			if v.kind != kind || len(v.path) < len(field) {
+				continue
+			}
+			if full && len(v.path) != len(field) {
+				continue
+			}
+			if equalPath(v.path[len(v.path)-len(field):], field) {
+				return fmt.Errorf("line %d: %s", v.line, msg)
+			}
+		}
+	}
+	return errors.New(msg)
+}
+
+// lines сопоставляет указатели JSON pointer значений со строками YAML.
+func (c *yamlConverter) lines() map[string]int {
+	lines := make(map[string]int, len(c.values))
+	for _, v := range c.values {
+		lines[v.pointer] = v.line
+	}
+	return lines
+}
+
+func equalPath(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
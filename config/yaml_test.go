@@ -0,0 +1,80 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"httes/core/types"
+)
+
+// Конфигурация YAML с якорями, слиянием словарей и полями x- читается так же, как JSON.
+func TestYamlConfig(t *testing.T) {
+	h := createHeart(t, "config.yaml")
+	if h.IterationCount != 100 || h.LoadType != types.LoadTypeWaved || h.TestDuration != 10 {
+		t.Errorf("unexpected load: %d iterations, %s, %ds", h.IterationCount, h.LoadType, h.TestDuration)
+	}
+	if !reflect.DeepEqual(h.ReportOutputs, []string{"stdout", "junit"}) {
+		t.Errorf("unexpected outputs %v", h.ReportOutputs)
+	}
+	if h.Proxy.Addr == nil || h.Proxy.Addr.String() != "http://127.0.0.1:3128" {
+		t.Errorf("unexpected proxy %v", h.Proxy.Addr)
+	}
+	if !reflect.DeepEqual(h.Scenario.Envs, map[string]interface{}{"target": "https://app.example.com"}) {
+		t.Errorf("unexpected envs %v", h.Scenario.Envs)
+	}
+	if len(h.Scenario.Steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(h.Scenario.Steps))
+	}
+
+	login, profile := h.Scenario.Steps[0], h.Scenario.Steps[1]
+	headers := map[string]string{"Content-Type": "application/json", "Accept": "application/json"}
+	if login.ID != 1 || login.Name != "Login" || login.Method != "POST" || login.URL != "{{target}}/api/login" || login.Timeout != 3 {
+		t.Errorf("unexpected login step %+v", login)
+	}
+	if !reflect.DeepEqual(login.Headers, headers) {
+		t.Errorf("login: unexpected headers %v", login.Headers)
+	}
+	if login.Payload != `{"user": "test", "password": "secret"}`+"\n" {
+		t.Errorf("login: unexpected payload %q", login.Payload)
+	}
+	if c := login.EnvsToCapture; len(c) != 1 || c[0].Name != "TOKEN" || c[0].From != types.Body || c[0].JsonPath == nil || *c[0].JsonPath != "token" {
+		t.Errorf("login: unexpected captures %+v", c)
+	}
+
+	headers["Authorization"] = "Bearer {{TOKEN}}"
+	if profile.ID != 2 || profile.Method != "GET" || profile.Sleep != "300-500" || !reflect.DeepEqual(profile.Headers, headers) {
+		t.Errorf("unexpected profile step %+v", profile)
+	}
+}
+
+// Неизвестные поля отклоняются с номером строки и подсказкой, кроме полей x- верхнего уровня.
+func TestYamlUnknownFields(t *testing.T) {
+	step := "steps:\n  - id: 1\n    url: http://localhost\n"
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{
+			config: step + "    captureEnv:\n      TOKEN: {from: body, json_path: token}\n",
+			err:    "line 5: /steps/0/captureEnv/TOKEN/json_path: unknown field json_path, did you mean jsonPath?",
+		},
+		{config: step + "    timeot: 3\n", err: "line 4: /steps/0/timeot: unknown field timeot, did you mean timeout?"},
+		{config: "iteraton_count: 3\n" + step, err: "line 1: /iteraton_count: unknown field iteraton_count, did you mean iteration_count?"},
+		{config: step + "    x-retry: 3\n", err: "line 4: /steps/0/x-retry: unknown field x-retry"},
+		{config: "x-headers: {Accept: '*/*'}\n" + step},
+	}
+	for _, tt := range tests {
+		_, err := NewConfigReader([]byte(tt.config), DetectConfigType(filepath.Join("config_testdata", "config.yaml"), nil))
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.config, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasSuffix(err.Error(), "\n"+tt.err) {
+			t.Errorf("%q: expected error %q, got %v", tt.config, tt.err, err)
+		}
+	}
+}
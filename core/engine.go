@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"math/rand"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"httes/core/proxy"
@@ -22,11 +24,15 @@ const (
 type engine struct {
 	heart types.Heart // настройки теста
 
-	proxyService    proxy.ProxyService        // сервис для работы с прокси
-	scenarioService *scenario.ScenarioService // сервис для выполнения сценариев
-	reportService   report.ReportService      // сервис для генерации отчетов
+	proxyService     proxy.ProxyService          // сервис для работы с прокси
+	scenarioServices []*scenario.ScenarioService // сервисы для выполнения сценариев, по одному на сценарий смеси
+	scenarioWeights  []int                       // накопленные веса сценариев для выбора по весу
+	reportService    report.ReportService        // сервис для генерации отчетов
 
 	tickCounter int            // счетчик тиков
+	iterationID atomic.Int64   // счетчик итераций для нумерации результатов
+	inFlight    atomic.Int64   // количество выполняющихся итераций
+	targetRate  atomic.Uint64  // math.Float64bits целевой интенсивности итераций в секунду
 	reqCountArr []int          // массив количества запросов на каждый тик
 	wg          sync.WaitGroup // группа ожидания для синхронизации горутин
 
@@ -51,16 +57,25 @@ func NewEngine(ctx context.Context, h types.Heart, rs report.ReportService) (e *
 		return
 	}
 
-	// Инициализация сервиса сценариев
-	ss := scenario.NewScenarioService()
+	// Инициализация сервисов сценариев, по одному на каждый сценарий смеси
+	scenarios := h.AllScenarios()
+	services := make([]*scenario.ScenarioService, 0, len(scenarios))
+	weights := make([]int, 0, len(scenarios))
+	total := 0
+	for _, s := range scenarios {
+		services = append(services, scenario.NewScenarioService())
+		total += s.Weight
+		weights = append(weights, total)
+	}
 
 	// Создание экземпляра движка
 	e = &engine{
-		heart:           h,
-		ctx:             ctx,
-		proxyService:    ps,
-		scenarioService: ss,
-		reportService:   rs,
+		heart:            h,
+		ctx:              ctx,
+		proxyService:     ps,
+		scenarioServices: services,
+		scenarioWeights:  weights,
+		reportService:    rs,
 	}
 
 	return
@@ -74,10 +89,12 @@ func (e *engine) Init() (err error) {
 		return
 	}
 
-	// Инициализация сервиса сценариев
-	if err = e.scenarioService.Init(e.ctx, e.heart.Scenario, e.proxyService.GetAll(), e.heart.Debug); err != nil {
-		fmt.Println("ScenarioService Init failed:", err)
-		return
+	// Инициализация сервисов сценариев
+	for i, s := range e.heart.AllScenarios() {
+		if err = e.scenarioServices[i].Init(e.ctx, s, e.proxyService.GetAll(), e.heart.Debug); err != nil {
+			fmt.Println("ScenarioService Init failed:", err)
+			return
+		}
 	}
 
 	// Инициализация сервиса отчетов
@@ -85,6 +102,9 @@ func (e *engine) Init() (err error) {
 		fmt.Println("ReportService Init failed:", err)
 		return
 	}
+	if lr, ok := e.reportService.(report.LoadStatsReceiver); ok {
+		lr.SetLoadStats(e)
+	}
 
 	// Инициализация канала результатов
 	e.resultChan = make(chan *types.ScenarioResult, e.heart.IterationCount*2) // Увеличим буфер
@@ -122,6 +142,7 @@ func (e *engine) Start() {
 			}
 			mutex.Lock()
 			reqCount := e.reqCountArr[e.tickCounter]
+			e.targetRate.Store(math.Float64bits(float64(reqCount) * 1000 / tickerInterval))
 			if reqCount > 0 {
 				e.wg.Add(reqCount)
 				go e.runWorkers(e.tickCounter)
@@ -132,6 +153,16 @@ func (e *engine) Start() {
 	}
 }
 
+// InFlight возвращает количество выполняющихся итераций.
+func (e *engine) InFlight() int64 {
+	return e.inFlight.Load()
+}
+
+// TargetRate возвращает текущую целевую интенсивность итераций в секунду.
+func (e *engine) TargetRate() float64 {
+	return math.Float64frombits(e.targetRate.Load())
+}
+
 // runWorkers запускает воркеров для выполнения запросов на текущем тике.
 func (e *engine) runWorkers(c int) {
 	for i := 1; i <= e.reqCountArr[c]; i++ {
@@ -152,10 +183,14 @@ func (e *engine) runWorker(scenarioStartTime time.Time) {
 	default:
 	}
 
+	e.inFlight.Add(1)
+	defer e.inFlight.Add(-1)
+
 	var res *types.ScenarioResult
 	var err *types.RequestError
 
 	p := e.proxyService.GetProxy()
+	ss := e.pickScenarioService()
 	retryCount := 3
 	for i := 1; i <= retryCount; i++ {
 		select {
@@ -163,7 +198,7 @@ func (e *engine) runWorker(scenarioStartTime time.Time) {
 			return
 		default:
 		}
-		res, err = e.scenarioService.Do(p, scenarioStartTime)
+		res, err = ss.Do(p, scenarioStartTime)
 		if err != nil {
 			fmt.Println("scenarioService.Do returned error:", err)
 			if err.Type == types.ErrorProxy {
@@ -185,6 +220,7 @@ func (e *engine) runWorker(scenarioStartTime time.Time) {
 		return
 	}
 
+	res.IterationID = e.iterationID.Add(1)
 	res.Others = make(map[string]interface{})
 	res.Others["heartOthers"] = e.heart.Others
 	res.Others["proxyCountry"] = e.proxyService.GetProxyCountry(p)
@@ -219,10 +255,31 @@ func (e *engine) stop() {
 	}
 	fmt.Println("Cleaning up...")
 	e.proxyService.Done()
-	e.scenarioService.Done()
+	for _, ss := range e.scenarioServices {
+		ss.Done()
+	}
 	fmt.Println("Engine stopped, test completed")
 }
 
+// pickScenarioService выбирает сервис сценария для итерации с вероятностью, пропорциональной весу сценария.
+func (e *engine) pickScenarioService() *scenario.ScenarioService {
+	if len(e.scenarioServices) == 1 {
+		return e.scenarioServices[0]
+	}
+	return e.scenarioServices[pickWeighted(e.scenarioWeights, rand.Intn(e.scenarioWeights[len(e.scenarioWeights)-1]))]
+}
+
+// pickWeighted возвращает индекс сценария для случайного числа n из [0, сумма весов)
+// по накопленным весам cumulative. Сценарии с нулевым весом не выбираются.
+func pickWeighted(cumulative []int, n int) int {
+	for i, w := range cumulative {
+		if n < w {
+			return i
+		}
+	}
+	return len(cumulative) - 1
+}
+
 // GetResultChan возвращает канал результатов
 func (e *engine) GetResultChan() chan *types.ScenarioResult {
 	if e.resultChan == nil {
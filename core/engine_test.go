@@ -0,0 +1,357 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"httes/config"
+	"httes/core/proxy"
+	"httes/core/report"
+	"httes/core/scenario"
+	"httes/core/types"
+	"httes/mock"
+)
+
+// Маршруты mock-сервера для сценария входа и просмотра заказа
+var shopRoutes = []mock.Route{
+	{
+		Method:  "POST",
+		Path:    "/login",
+		Headers: map[string]string{"X-Session": "sess-{{.Counter}}"},
+		Body:    `{"token":"{{uuid}}","user":"{{.JSON.user}}"}`,
+		Latency: mock.Latency{Distribution: mock.LatencyUniform, Min: 1, Max: 5},
+	},
+	{
+		Path:    "/orders/{id}",
+		Body:    `{"id":"{{.Params.id}}","auth":"{{.Header.Get "Authorization"}}"}`,
+		Chunked: mock.Chunked{Count: 2, Interval: 1},
+	},
+}
+
+// Сценарий входа и просмотра заказа. BASE заменяется адресом сервиса
+const shopConfig = `{
+  "iteration_count": 20,
+  "load_type": "linear",
+  "duration": 1,
+  "steps": [
+    {
+      "id": 1,
+      "url": "BASE/login",
+      "method": "POST",
+      "payload": "{\"user\":\"demo\"}",
+      "captureEnv": {
+        "TOKEN": {"from": "body", "jsonPath": "token"},
+        "SESSION": {"from": "header", "headerKey": "X-Session"}
+      }
+    },
+    {
+      "id": 2,
+      "url": "BASE/orders/{{SESSION}}",
+      "headers": {"Authorization": "Bearer {{TOKEN}}"},
+      "captureEnv": {
+        "ORDER": {"from": "body", "jsonPath": "id"},
+        "AUTH": {"from": "body", "jsonPath": "auth"}
+      }
+    }
+  ]
+}`
+
+// collectingReport — сервис отчётов, который сохраняет результаты итераций для проверки.
+type collectingReport struct {
+	mu      sync.Mutex
+	results []*types.ScenarioResult
+	done    chan struct{}
+}
+
+func (r *collectingReport) DoneChan() <-chan struct{} { return r.done }
+
+func (r *collectingReport) Init(debug bool) error {
+	r.done = make(chan struct{})
+	return nil
+}
+
+func (r *collectingReport) Start(input chan *types.ScenarioResult) {
+	for res := range input {
+		r.mu.Lock()
+		r.results = append(r.results, res)
+		r.mu.Unlock()
+	}
+	close(r.done)
+}
+
+func (r *collectingReport) Stop() {}
+
+func startMock(tb testing.TB, routes []mock.Route) *mock.Server {
+	tb.Helper()
+	srv, err := mock.New(mock.Config{Routes: routes})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := srv.Start("127.0.0.1:0"); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func shopHeart(tb testing.TB, base string) types.Heart {
+	tb.Helper()
+	reader, err := config.NewConfigReader([]byte(strings.ReplaceAll(shopConfig, "BASE", base)), config.ConfigTypeJson)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return h
+}
+
+func TestEngineCapturesAgainstMock(t *testing.T) {
+	srv := startMock(t, shopRoutes)
+	mockURL, _ := url.Parse(srv.URL())
+
+	tests := []struct {
+		name  string
+		base  string
+		proxy *url.URL
+	}{
+		{name: "direct", base: srv.URL()},
+		// Пробный запуск: адреса шагов не меняются, запросы по HTTP и HTTPS обслуживает mock-сервер как прокси
+		{name: "dry run https", base: "https://shop.example.test", proxy: mockURL},
+		{name: "dry run http", base: "http://shop.example.test", proxy: mockURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := shopHeart(t, tt.base)
+			h.Proxy = proxy.Proxy{Strategy: proxy.ProxyTypeSingle, Addr: tt.proxy}
+
+			rs := &collectingReport{}
+			e, err := NewEngine(context.Background(), h, rs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Init(); err != nil {
+				t.Fatal(err)
+			}
+			go rs.Start(e.GetResultChan())
+			e.Start()
+
+			if len(rs.results) != h.IterationCount {
+				t.Fatalf("expected %d iterations, got %d", h.IterationCount, len(rs.results))
+			}
+			sessions := map[interface{}]bool{}
+			for _, res := range rs.results {
+				if len(res.StepResults) != 2 {
+					t.Fatalf("iteration %d: expected 2 steps, got %d", res.IterationID, len(res.StepResults))
+				}
+				login, order := res.StepResults[0], res.StepResults[1]
+				for _, st := range res.StepResults {
+					if st.IsFailed() || len(st.FailedCaptures) > 0 {
+						t.Fatalf("iteration %d step %d failed: %v %v", res.IterationID, st.StepID, st.Err, st.FailedCaptures)
+					}
+				}
+				// Значения из ответа первого шага передаются во второй и возвращаются mock-сервером
+				if order.ExtractedEnvs["ORDER"] != login.ExtractedEnvs["SESSION"] {
+					t.Errorf("iteration %d: order %v, session %v", res.IterationID, order.ExtractedEnvs["ORDER"], login.ExtractedEnvs["SESSION"])
+				}
+				if order.ExtractedEnvs["AUTH"] != fmt.Sprintf("Bearer %v", login.ExtractedEnvs["TOKEN"]) {
+					t.Errorf("iteration %d: auth %v, token %v", res.IterationID, order.ExtractedEnvs["AUTH"], login.ExtractedEnvs["TOKEN"])
+				}
+				sessions[login.ExtractedEnvs["SESSION"]] = true
+			}
+			if len(sessions) != h.IterationCount {
+				t.Errorf("expected a separate session for every iteration, got %d", len(sessions))
+			}
+		})
+	}
+}
+
+// Метрики Prometheus после короткого прогона: выводу prometheus результаты передаются вместе с остальными выводами.
+func TestEngineMetricsScrape(t *testing.T) {
+	srv := startMock(t, shopRoutes)
+	h := shopHeart(t, srv.URL())
+
+	rs := &collectingReport{}
+	metrics := report.NewMetricsExporter("127.0.0.1:0")
+	e, err := NewEngine(context.Background(), h, report.NewMultiReportService(rs, metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Init(); err != nil {
+		t.Fatal(err)
+	}
+	go e.reportService.Start(e.GetResultChan())
+	e.Start()
+	<-e.reportService.DoneChan()
+
+	scrape := httptest.NewServer(metrics)
+	defer scrape.Close()
+	resp, err := http.Get(scrape.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	n := h.IterationCount
+	expected := []string{
+		"# TYPE httes_requests_total counter",
+		fmt.Sprintf(`httes_requests_total{step="1",step_name="",status="200",error_type=""} %d`, n),
+		fmt.Sprintf(`httes_requests_total{step="2",step_name="",status="200",error_type=""} %d`, n),
+		"# TYPE httes_iterations_total counter",
+		fmt.Sprintf(`httes_iterations_total{scenario="",result="success"} %d`, n),
+		"# TYPE httes_request_duration_seconds histogram",
+		fmt.Sprintf(`httes_request_duration_seconds_bucket{step="1",phase="total",le="+Inf"} %d`, n),
+		fmt.Sprintf(`httes_request_duration_seconds_count{step="2",phase="total"} %d`, n),
+		fmt.Sprintf(`httes_request_duration_seconds_count{step="1",phase="server_processing"} %d`, n),
+		"httes_iterations_in_flight 0",
+		"# TYPE httes_target_iterations_per_second gauge",
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+	// Целевая интенсивность передаётся движком через report.LoadStats
+	if strings.Contains(string(body), "httes_target_iterations_per_second 0\n") {
+		t.Errorf("target rate is not set:\n%s", body)
+	}
+	// Неуспешных итераций не было, поэтому серия с result="failed" отсутствует
+	if strings.Contains(string(body), `result="failed"`) {
+		t.Errorf("unexpected failed iterations:\n%s", body)
+	}
+	if len(rs.results) != n {
+		t.Errorf("other outputs should get all results: expected %d, got %d", n, len(rs.results))
+	}
+}
+
+// Смесь сценариев из конфигурации: веса по умолчанию равны 1, каждый сценарий выбирается
+// на доле случайных чисел, равной его доле в сумме весов.
+func TestEngineScenarioMixWeights(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "config", "config_testdata", "config_scenario_mix.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		config  string
+		names   []string
+		weights []int
+	}{
+		{name: "fixture", config: string(data), names: []string{"browse", "search", "checkout"}, weights: []int{70, 25, 5}},
+		{name: "default weight", config: strings.Replace(string(data), `"weight": 5,`, "", 1), names: []string{"browse", "search", "checkout"}, weights: []int{70, 25, 1}},
+		{name: "zero weight", config: strings.Replace(string(data), `"weight": 25,`, `"weight": 0,`, 1), names: []string{"browse", "search", "checkout"}, weights: []int{70, 0, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := config.NewConfigReader([]byte(tt.config), config.ConfigTypeJson)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h, err := reader.CreateHammer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err := NewEngine(context.Background(), h, &collectingReport{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scenarios := h.AllScenarios()
+			total := 0
+			for i, s := range scenarios {
+				if s.Name != tt.names[i] || s.Weight != tt.weights[i] {
+					t.Errorf("scenario %d: expected %s with weight %d, got %s with weight %d", i, tt.names[i], tt.weights[i], s.Name, s.Weight)
+				}
+				total += tt.weights[i]
+			}
+			if w := e.scenarioWeights; w[len(w)-1] != total {
+				t.Fatalf("expected total weight %d, got cumulative weights %v", total, w)
+			}
+
+			// Каждое случайное число из [0, total) выбирает ровно один сценарий
+			picks := make([]int, len(scenarios))
+			for n := 0; n < total; n++ {
+				picks[pickWeighted(e.scenarioWeights, n)]++
+			}
+			if !reflect.DeepEqual(picks, tt.weights) {
+				t.Errorf("expected picks %v, got %v", tt.weights, picks)
+			}
+		})
+	}
+}
+
+// Сервис сценария выбирается из смеси случайно с вероятностью, пропорциональной весу.
+func TestEnginePickScenarioService(t *testing.T) {
+	e := &engine{
+		scenarioServices: []*scenario.ScenarioService{scenario.NewScenarioService(), scenario.NewScenarioService()},
+		scenarioWeights:  []int{3, 4},
+	}
+	const n = 10000
+	first := 0
+	for i := 0; i < n; i++ {
+		if e.pickScenarioService() == e.scenarioServices[0] {
+			first++
+		}
+	}
+	// Ожидается 7500, стандартное отклонение — около 43
+	if first < 7200 || first > 7800 {
+		t.Errorf("expected about %d picks of the first scenario, got %d", n*3/4, first)
+	}
+}
+
+// BenchmarkScenarioService измеряет собственные накладные расходы httes: итерации сценария из двух шагов
+// с извлечением переменных против mock-сервера без задержек. Mock-сервер работает в том же процессе
+// и делит с клиентом те же ядра, количество которых задаётся флагом -cpu:
+//
+//	go test -run '^$' -bench ScenarioService -cpu 1,2,4 ./core/
+func BenchmarkScenarioService(b *testing.B) {
+	routes := make([]mock.Route, len(shopRoutes))
+	copy(routes, shopRoutes)
+	routes[0].Latency = mock.Latency{}
+	routes[1].Chunked = mock.Chunked{}
+	srv := startMock(b, routes)
+
+	h := shopHeart(b, srv.URL())
+	ss := scenario.NewScenarioService()
+	if err := ss.Init(context.Background(), h.Scenario, []*url.URL{nil}, false); err != nil {
+		b.Fatal(err)
+	}
+	defer ss.Done()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			res, err := ss.Do(nil, time.Now())
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			for _, st := range res.StepResults {
+				if st.IsFailed() {
+					b.Errorf("step %d failed: %v", st.StepID, st.Err)
+					return
+				}
+			}
+		}
+	})
+	rps := float64(srv.Requests()) / b.Elapsed().Seconds()
+	b.ReportMetric(rps, "rps")
+	b.ReportMetric(rps/float64(runtime.GOMAXPROCS(0)), "rps/core")
+}
@@ -28,8 +28,23 @@ func aggregate(r *Result, scr *types.ScenarioResult) {
 	if r.ProgressPoints == nil {
 		r.ProgressPoints = make(map[int]float32)
 	}
+	if r.ScenarioResults == nil {
+		r.ScenarioResults = make(map[string]*ScenarioResultSummary)
+	}
+	if r.TimeSeries == nil {
+		r.TimeSeries = make(map[int]*TimePoint)
+	}
 
 	for _, sr := range scr.StepResults {
+		// Пропущенные по условию шаги не участвуют в статистике запросов
+		if sr.Skipped {
+			if _, ok := r.StepResults[sr.StepID]; !ok {
+				r.StepResults[sr.StepID] = newStepResultSummary(sr.StepName)
+			}
+			r.StepResults[sr.StepID].SkippedCount++
+			continue
+		}
+
 		// Подсчёт параметров (ключей в Custom)
 		paramCount := len(sr.Custom)
 		r.TotalParamCount += paramCount
@@ -37,12 +52,7 @@ func aggregate(r *Result, scr *types.ScenarioResult) {
 
 		// Инициализация StepResults, если ещё не создана
 		if _, ok := r.StepResults[sr.StepID]; !ok {
-			r.StepResults[sr.StepID] = &ScenarioStepResultSummary{
-				Name:           sr.StepName,
-				Durations:      make(map[string]float32),
-				StatusCodeDist: make(map[int]int),
-				ErrorDist:      map[string]int{},
-			}
+			r.StepResults[sr.StepID] = newStepResultSummary(sr.StepName)
 		}
 
 		// Обновление статистики шага
@@ -81,6 +91,18 @@ func aggregate(r *Result, scr *types.ScenarioResult) {
 		}
 		count := stepResult.SuccessCount + stepResult.FailedCount
 		stepResult.Durations["duration"] = (stepResult.Durations["duration"]*float32(count-1) + totalStepDuration) / float32(count)
+		stepResult.durations.add(totalStepDuration)
+
+		if r.KeepSamples {
+			r.samples.add(RequestSample{
+				Time:         sr.RequestTime,
+				ScenarioName: scr.ScenarioName,
+				StepID:       sr.StepID,
+				StatusCode:   sr.StatusCode,
+				Duration:     totalStepDuration,
+				Error:        sr.Err.Reason,
+			})
+		}
 
 		// Обновление общих длительностей
 		for k, v := range sr.Custom {
@@ -131,6 +153,70 @@ func aggregate(r *Result, scr *types.ScenarioResult) {
 	if r.SuccessCount+r.FailedCount > 0 {
 		r.AvgDuration = (r.AvgDuration*float32(r.SuccessCount+r.FailedCount-1) + totalDuration) / float32(r.SuccessCount+r.FailedCount)
 	}
+
+	r.durations.add(totalDuration)
+	aggregateTimeSeries(r, scr, isSuccess, totalDuration)
+
+	// Обновление статистики сценария смеси
+	if scr.ScenarioName != "" {
+		aggregateScenario(r, scr, isSuccess, totalDuration)
+	}
+}
+
+// aggregateTimeSeries обновляет статистику секунды теста, в которую началась итерация.
+func aggregateTimeSeries(r *Result, scr *types.ScenarioResult, isSuccess bool, totalDuration float32) {
+	if r.StartTime.IsZero() {
+		r.StartTime = scr.StartTime
+	}
+
+	// Итерации могут завершаться не в порядке запуска, более ранние относятся к первой секунде
+	second := 0
+	if scr.StartTime.After(r.StartTime) {
+		second = int(scr.StartTime.Sub(r.StartTime) / time.Second)
+	}
+
+	tp, ok := r.TimeSeries[second]
+	if !ok {
+		tp = &TimePoint{Second: second}
+		r.TimeSeries[second] = tp
+	}
+	if isSuccess {
+		tp.SuccessCount++
+	} else {
+		tp.FailedCount++
+	}
+	count := tp.SuccessCount + tp.FailedCount
+	tp.AvgDuration = (tp.AvgDuration*float32(count-1) + totalDuration) / float32(count)
+}
+
+// aggregateScenario обновляет статистику именованного сценария, к которому относится итерация.
+func aggregateScenario(r *Result, scr *types.ScenarioResult, isSuccess bool, totalDuration float32) {
+	sc, ok := r.ScenarioResults[scr.ScenarioName]
+	if !ok {
+		sc = &ScenarioResultSummary{Name: scr.ScenarioName}
+		r.ScenarioResults[scr.ScenarioName] = sc
+	}
+
+	if isSuccess {
+		sc.SuccessCount++
+	} else {
+		sc.FailedCount++
+	}
+	count := sc.SuccessCount + sc.FailedCount
+	sc.AvgDuration = (sc.AvgDuration*float32(count-1) + totalDuration) / float32(count)
+
+	for _, sr := range scr.StepResults {
+		found := false
+		for _, id := range sc.StepIDs {
+			if id == sr.StepID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sc.StepIDs = append(sc.StepIDs, sr.StepID)
+		}
+	}
 }
 
 type Result struct {
@@ -138,14 +224,47 @@ type Result struct {
 	FailedCount     int
 	AvgDuration     float32
 	StepResults     map[uint16]*ScenarioStepResultSummary
-	TotalParamCount int                // Общее количество ключей в Custom
-	TotalRequests   int                // Общее количество запросов
-	ProgressPoints  map[int]float32    // Средняя длительность на точках прогресса (ключ: SuccessCount, значение: AvgDuration)
-	Durations       map[string]float32 // Средние длительности по всем шагам
-	StatusCodeDist  map[int]int        // Распределение статус-кодов по всем шагам
+	TotalParamCount int                               // Общее количество ключей в Custom
+	TotalRequests   int                               // Общее количество запросов
+	ProgressPoints  map[int]float32                   // Средняя длительность на точках прогресса (ключ: SuccessCount, значение: AvgDuration)
+	Durations       map[string]float32                // Средние длительности по всем шагам
+	StatusCodeDist  map[int]int                       // Распределение статус-кодов по всем шагам
+	ScenarioResults map[string]*ScenarioResultSummary // Статистика по сценариям смеси (ключ: имя сценария)
+	StartTime       time.Time                         // Время начала первой итерации
+	TimeSeries      map[int]*TimePoint                // Статистика по секундам от начала теста (ключ: номер секунды)
+	KeepSamples     bool                              // Сохранять сырые замеры запросов, не более maxSamples
+	durations       reservoir                         // Выборка длительностей итераций для процентилей
+	samples         sampleReservoir                   // Выборка сырых замеров запросов, если включён KeepSamples
 	mu              sync.Mutex
 }
 
+// TimePoint содержит статистику итераций, начатых в течение одной секунды теста.
+type TimePoint struct {
+	Second       int     `json:"second"`
+	SuccessCount int64   `json:"success_count"`
+	FailedCount  int64   `json:"fail_count"`
+	AvgDuration  float32 `json:"avg_duration"`
+}
+
+// RequestSample — сырой замер одного запроса.
+type RequestSample struct {
+	Time         time.Time `json:"time"`
+	ScenarioName string    `json:"scenario_name,omitempty"`
+	StepID       uint16    `json:"step_id"`
+	StatusCode   int       `json:"status_code"`
+	Duration     float32   `json:"duration"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// ScenarioResultSummary содержит статистику итераций одного сценария смеси.
+type ScenarioResultSummary struct {
+	Name         string   `json:"name"`
+	SuccessCount int64    `json:"success_count"`
+	FailedCount  int64    `json:"fail_count"`
+	AvgDuration  float32  `json:"avg_duration"`
+	StepIDs      []uint16 `json:"step_ids"` // ID шагов сценария, для которых есть результаты
+}
+
 func (r *Result) successPercentage() int {
 	if r.SuccessCount+r.FailedCount == 0 {
 		return 0
@@ -168,6 +287,17 @@ type ScenarioStepResultSummary struct {
 	Durations      map[string]float32 `json:"durations"`
 	SuccessCount   int64              `json:"success_count"`
 	FailedCount    int64              `json:"fail_count"`
+	SkippedCount   int64              `json:"skipped_count"`
+	durations      reservoir          // Выборка длительностей шага для процентилей
+}
+
+func newStepResultSummary(name string) *ScenarioStepResultSummary {
+	return &ScenarioStepResultSummary{
+		Name:           name,
+		Durations:      make(map[string]float32),
+		StatusCodeDist: make(map[int]int),
+		ErrorDist:      map[string]int{},
+	}
 }
 
 // func (s *ScenarioStepResultSummary) successPercentage() int {
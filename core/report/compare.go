@@ -0,0 +1,242 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Допустимые отклонения по умолчанию при сравнении запусков
+const (
+	DefaultLatencyTolerance    = 10.0 // Рост длительности, %
+	DefaultThroughputTolerance = 10.0 // Снижение пропускной способности, %
+	DefaultErrorRateTolerance  = 1.0  // Рост доли ошибок, процентные пункты
+)
+
+// Tolerances задаёт допустимые отклонения текущего запуска от базового.
+// Отклонения сверх допустимых считаются регрессией.
+type Tolerances struct {
+	Latency    float64 `json:"latency"`    // Допустимый рост длительностей и процентилей, %
+	Throughput float64 `json:"throughput"` // Допустимое снижение итераций в секунду, %
+	ErrorRate  float64 `json:"error_rate"` // Допустимый рост доли ошибок и неуспешных статус-кодов, процентные пункты
+}
+
+// DefaultTolerances возвращает допустимые отклонения по умолчанию.
+func DefaultTolerances() Tolerances {
+	return Tolerances{
+		Latency:    DefaultLatencyTolerance,
+		Throughput: DefaultThroughputTolerance,
+		ErrorRate:  DefaultErrorRateTolerance,
+	}
+}
+
+// MetricDelta — изменение метрики текущего запуска относительно базового.
+type MetricDelta struct {
+	Name       string  `json:"name"`
+	StepID     uint16  `json:"step_id,omitempty"` // 0 — метрика всего теста
+	Base       float64 `json:"base"`
+	Current    float64 `json:"current"`
+	Delta      float64 `json:"delta"`    // Изменение в % или процентных пунктах (для Absolute)
+	Absolute   bool    `json:"absolute"` // Delta — разность значений в процентных пунктах, а не относительное изменение
+	Regression bool    `json:"regression"`
+}
+
+// String возвращает описание изменения, например "p95: 0.1200 -> 0.1500 (+25.0%) REGRESSION".
+func (d MetricDelta) String() string {
+	unit := "%"
+	if d.Absolute {
+		unit = "pp"
+	}
+	s := fmt.Sprintf("%-16s %10.4f -> %10.4f (%+.1f%s)", d.Name, d.Base, d.Current, d.Delta, unit)
+	if d.Regression {
+		s += " REGRESSION"
+	}
+	return s
+}
+
+// StepComparison содержит изменения метрик одного шага.
+type StepComparison struct {
+	StepID  uint16        `json:"step_id"`
+	Name    string        `json:"name"`
+	Metrics []MetricDelta `json:"metrics"`
+}
+
+// Comparison — результат сравнения текущего запуска с базовым.
+type Comparison struct {
+	Tolerances  Tolerances       `json:"tolerances"`
+	Metrics     []MetricDelta    `json:"metrics"`
+	Steps       []StepComparison `json:"steps"`
+	StatusCodes []MetricDelta    `json:"status_codes"` // Доли статус-кодов в процентах от всех запросов
+	Regressed   bool             `json:"regressed"`
+}
+
+// Regressions возвращает все изменения метрик, превышающие допустимые отклонения.
+func (c Comparison) Regressions() []MetricDelta {
+	var res []MetricDelta
+	for _, m := range c.Metrics {
+		if m.Regression {
+			res = append(res, m)
+		}
+	}
+	for _, st := range c.Steps {
+		for _, m := range st.Metrics {
+			if m.Regression {
+				res = append(res, m)
+			}
+		}
+	}
+	for _, m := range c.StatusCodes {
+		if m.Regression {
+			res = append(res, m)
+		}
+	}
+	return res
+}
+
+// Lines возвращает построчное текстовое представление сравнения и признак регрессии для каждой строки.
+func (c Comparison) Lines() (lines []string, regressions []bool) {
+	add := func(s string, r bool) {
+		lines = append(lines, s)
+		regressions = append(regressions, r)
+	}
+
+	add("Test:", false)
+	for _, m := range c.Metrics {
+		add("  "+m.String(), m.Regression)
+	}
+	for _, st := range c.Steps {
+		add(fmt.Sprintf("Step (%d) %s:", st.StepID, st.Name), false)
+		for _, m := range st.Metrics {
+			add("  "+m.String(), m.Regression)
+		}
+	}
+	if len(c.StatusCodes) > 0 {
+		add("Status codes (share of requests):", false)
+		for _, m := range c.StatusCodes {
+			add("  "+m.String(), m.Regression)
+		}
+	}
+	if c.Regressed {
+		add(fmt.Sprintf("Result: REGRESSION (%d metric(s) beyond tolerances)", len(c.Regressions())), true)
+	} else {
+		add("Result: OK", false)
+	}
+	return
+}
+
+// Compare сравнивает текущий запуск с базовым: процентили и средние длительности, пропускную способность,
+// доли ошибок по тесту и шагам и распределение статус-кодов. Шаги, которых нет в одном из запусков, не сравниваются.
+func Compare(base, current RunSummary, tol Tolerances) Comparison {
+	c := Comparison{Tolerances: tol}
+
+	c.Metrics = append(c.Metrics, latencyDeltas(0, float64(base.AvgDuration), float64(current.AvgDuration), base.Percentiles, current.Percentiles, tol)...)
+	c.Metrics = append(c.Metrics, relativeDelta("rps", 0, float64(base.Rps), float64(current.Rps), tol.Throughput, true))
+	c.Metrics = append(c.Metrics, absoluteDelta("fail_rate", 0,
+		failRate(int64(base.SuccessCount), int64(base.FailedCount)),
+		failRate(int64(current.SuccessCount), int64(current.FailedCount)), tol.ErrorRate))
+
+	ids := make([]int, 0, len(current.Steps))
+	for id := range current.Steps {
+		if _, ok := base.Steps[id]; ok {
+			ids = append(ids, int(id))
+		}
+	}
+	sort.Ints(ids)
+	for _, i := range ids {
+		id := uint16(i)
+		b, cur := base.Steps[id], current.Steps[id]
+		sc := StepComparison{StepID: id, Name: cur.Name}
+		sc.Metrics = append(sc.Metrics, latencyDeltas(id, float64(b.Durations["duration"]), float64(cur.Durations["duration"]), b.Percentiles, cur.Percentiles, tol)...)
+		sc.Metrics = append(sc.Metrics, absoluteDelta("fail_rate", id,
+			failRate(b.SuccessCount, b.FailedCount), failRate(cur.SuccessCount, cur.FailedCount), tol.ErrorRate))
+		c.Steps = append(c.Steps, sc)
+	}
+
+	c.StatusCodes = statusCodeDeltas(base, current, tol)
+
+	c.Regressed = len(c.Regressions()) > 0
+	return c
+}
+
+// latencyDeltas сравнивает среднюю длительность и процентили.
+func latencyDeltas(stepID uint16, baseAvg, curAvg float64, basePct, curPct map[string]float32, tol Tolerances) []MetricDelta {
+	res := []MetricDelta{relativeDelta("avg_duration", stepID, baseAvg, curAvg, tol.Latency, false)}
+	for _, k := range []string{"p50", "p90", "p95", "p99"} {
+		res = append(res, relativeDelta(k, stepID, float64(basePct[k]), float64(curPct[k]), tol.Latency, false))
+	}
+	return res
+}
+
+// statusCodeDeltas сравнивает доли статус-кодов. Регрессией считается рост доли кодов ошибок (>= 400).
+func statusCodeDeltas(base, current RunSummary, tol Tolerances) []MetricDelta {
+	baseTotal, curTotal := 0, 0
+	codes := map[int]struct{}{}
+	for c, n := range base.StatusCodeDist {
+		baseTotal += n
+		codes[c] = struct{}{}
+	}
+	for c, n := range current.StatusCodeDist {
+		curTotal += n
+		codes[c] = struct{}{}
+	}
+
+	keys := make([]int, 0, len(codes))
+	for c := range codes {
+		keys = append(keys, c)
+	}
+	sort.Ints(keys)
+
+	res := make([]MetricDelta, 0, len(keys))
+	for _, c := range keys {
+		d := MetricDelta{
+			Name:     fmt.Sprintf("status %d", c),
+			Base:     share(base.StatusCodeDist[c], baseTotal),
+			Current:  share(current.StatusCodeDist[c], curTotal),
+			Absolute: true,
+		}
+		d.Delta = d.Current - d.Base
+		d.Regression = (c >= 400 || c == 0) && d.Delta > tol.ErrorRate
+		res = append(res, d)
+	}
+	return res
+}
+
+// relativeDelta вычисляет относительное изменение метрики в процентах.
+// Для метрик, у которых лучше большее значение (higherIsBetter), регрессией считается снижение сверх tolerance,
+// для остальных — рост сверх tolerance.
+func relativeDelta(name string, stepID uint16, base, cur, tolerance float64, higherIsBetter bool) MetricDelta {
+	d := MetricDelta{Name: name, StepID: stepID, Base: base, Current: cur}
+	switch {
+	case base != 0:
+		d.Delta = (cur - base) / math.Abs(base) * 100
+	case cur != 0:
+		d.Delta = 100
+	}
+	if higherIsBetter {
+		d.Regression = d.Delta < -tolerance
+	} else {
+		d.Regression = d.Delta > tolerance
+	}
+	return d
+}
+
+// absoluteDelta вычисляет разность значений в процентных пунктах. Регрессией считается рост сверх tolerance.
+func absoluteDelta(name string, stepID uint16, base, cur, tolerance float64) MetricDelta {
+	d := MetricDelta{Name: name, StepID: stepID, Base: base, Current: cur, Delta: cur - base, Absolute: true}
+	d.Regression = d.Delta > tolerance
+	return d
+}
+
+func failRate(success, failed int64) float64 {
+	if success+failed == 0 {
+		return 0
+	}
+	return float64(failed) * 100 / float64(success+failed)
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
@@ -0,0 +1,171 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func compareSummaries() (base, current RunSummary) {
+	step := func(name string, duration, p95 float32, success, failed int64) *StepSummary {
+		return &StepSummary{
+			ScenarioStepResultSummary: ScenarioStepResultSummary{
+				Name:         name,
+				Durations:    map[string]float32{"duration": duration},
+				SuccessCount: success,
+				FailedCount:  failed,
+			},
+			Percentiles: map[string]float32{"p50": duration, "p90": p95, "p95": p95, "p99": p95},
+		}
+	}
+	base = RunSummary{
+		SuccessCount:   990,
+		FailedCount:    10,
+		AvgDuration:    0.1,
+		Rps:            100,
+		Percentiles:    map[string]float32{"p50": 0.08, "p90": 0.15, "p95": 0.2, "p99": 0.3},
+		StatusCodeDist: map[int]int{200: 990, 500: 10},
+		Steps: map[uint16]*StepSummary{
+			1: step("login", 0.05, 0.1, 500, 0),
+			2: step("search", 0.2, 0.3, 490, 10),
+			3: step("legacy", 0.1, 0.1, 100, 0),
+		},
+	}
+	current = RunSummary{
+		SuccessCount:   970,
+		FailedCount:    30,
+		AvgDuration:    0.105,
+		Rps:            85,
+		Percentiles:    map[string]float32{"p50": 0.08, "p90": 0.15, "p95": 0.25, "p99": 0.3},
+		StatusCodeDist: map[int]int{200: 970, 500: 25, 0: 5},
+		Steps: map[uint16]*StepSummary{
+			1: step("login", 0.05, 0.1, 500, 0),
+			2: step("search", 0.21, 0.36, 485, 15),
+			4: step("checkout", 0.3, 0.4, 100, 0),
+		},
+	}
+	return
+}
+
+// Сравниваются метрики теста, общие для обоих запусков шаги и доли статус-кодов.
+// Шаги, которых нет в одном из запусков, не сравниваются.
+func TestCompare(t *testing.T) {
+	base, current := compareSummaries()
+	c := Compare(base, current, DefaultTolerances())
+
+	type delta struct {
+		base, current, delta float64
+		regression           bool
+	}
+	check := func(scope string, metrics []MetricDelta, expected map[string]delta) {
+		t.Helper()
+		if len(metrics) != len(expected) {
+			t.Errorf("%s: expected %d metrics, got %v", scope, len(expected), metrics)
+		}
+		for _, m := range metrics {
+			e, ok := expected[m.Name]
+			if !ok {
+				t.Errorf("%s: unexpected metric %s", scope, m)
+				continue
+			}
+			near := func(a, b float64) bool { return math.Abs(a-b) < 1e-3 }
+			if !near(m.Base, e.base) || !near(m.Current, e.current) || !near(m.Delta, e.delta) || m.Regression != e.regression {
+				t.Errorf("%s: expected %s %+v, got %s", scope, m.Name, e, m)
+			}
+		}
+	}
+
+	check("test", c.Metrics, map[string]delta{
+		"avg_duration": {0.1, 0.105, 5, false},
+		"p50":          {0.08, 0.08, 0, false},
+		"p90":          {0.15, 0.15, 0, false},
+		"p95":          {0.2, 0.25, 25, true},
+		"p99":          {0.3, 0.3, 0, false},
+		"rps":          {100, 85, -15, true},
+		"fail_rate":    {1, 3, 2, true},
+	})
+
+	if len(c.Steps) != 2 || c.Steps[0].StepID != 1 || c.Steps[1].StepID != 2 || c.Steps[1].Name != "search" {
+		t.Fatalf("expected steps 1 and 2, got %+v", c.Steps)
+	}
+	check("step 1", c.Steps[0].Metrics, map[string]delta{
+		"avg_duration": {0.05, 0.05, 0, false},
+		"p50":          {0.05, 0.05, 0, false},
+		"p90":          {0.1, 0.1, 0, false},
+		"p95":          {0.1, 0.1, 0, false},
+		"p99":          {0.1, 0.1, 0, false},
+		"fail_rate":    {0, 0, 0, false},
+	})
+	check("step 2", c.Steps[1].Metrics, map[string]delta{
+		"avg_duration": {0.2, 0.21, 5, false},
+		"p50":          {0.2, 0.21, 5, false},
+		"p90":          {0.3, 0.36, 20, true},
+		"p95":          {0.3, 0.36, 20, true},
+		"p99":          {0.3, 0.36, 20, true},
+		"fail_rate":    {2, 3, 1, false},
+	})
+	for _, st := range c.Steps {
+		for _, m := range st.Metrics {
+			if m.StepID != st.StepID {
+				t.Errorf("step %d: metric %s has step id %d", st.StepID, m.Name, m.StepID)
+			}
+		}
+	}
+
+	// Статус 0 — запросы без ответа, его рост тоже считается ростом ошибок
+	check("status codes", c.StatusCodes, map[string]delta{
+		"status 0":   {0, 0.5, 0.5, false},
+		"status 200": {99, 97, -2, false},
+		"status 500": {1, 2.5, 1.5, true},
+	})
+	if names := []string{c.StatusCodes[0].Name, c.StatusCodes[1].Name, c.StatusCodes[2].Name}; strings.Join(names, ",") != "status 0,status 200,status 500" {
+		t.Errorf("status codes are not sorted: %v", names)
+	}
+
+	if !c.Regressed || len(c.Regressions()) != 7 {
+		t.Errorf("expected 7 regressions, got %v", c.Regressions())
+	}
+	lines, regressions := c.Lines()
+	if last := lines[len(lines)-1]; last != "Result: REGRESSION (7 metric(s) beyond tolerances)" || !regressions[len(regressions)-1] {
+		t.Errorf("unexpected result line %q", last)
+	}
+	for i, l := range lines {
+		if strings.HasPrefix(l, "Step (3)") || strings.HasPrefix(l, "Step (4)") {
+			t.Errorf("line %d: step missing in one of the runs is compared: %s", i, l)
+		}
+	}
+}
+
+// Регрессия определяется по допустимым отклонениям: изменение, равное допустимому, регрессией не считается.
+func TestCompareTolerances(t *testing.T) {
+	base, current := compareSummaries()
+	tests := []struct {
+		tol         Tolerances
+		regressions []string
+	}{
+		{tol: DefaultTolerances(), regressions: []string{"p95", "rps", "fail_rate", "2/p90", "2/p95", "2/p99", "status 500"}},
+		{tol: Tolerances{Latency: 25, Throughput: 15, ErrorRate: 2}, regressions: nil},
+		{tol: Tolerances{Latency: 20, Throughput: 20, ErrorRate: 1.5}, regressions: []string{"p95", "fail_rate"}},
+		{tol: Tolerances{Latency: 30, Throughput: 10, ErrorRate: 5}, regressions: []string{"rps"}},
+	}
+	for _, tt := range tests {
+		c := Compare(base, current, tt.tol)
+		var names []string
+		for _, m := range c.Regressions() {
+			if m.StepID != 0 {
+				names = append(names, fmt.Sprintf("%d/%s", m.StepID, m.Name))
+			} else {
+				names = append(names, m.Name)
+			}
+		}
+		if strings.Join(names, ",") != strings.Join(tt.regressions, ",") || c.Regressed != (len(tt.regressions) > 0) {
+			t.Errorf("tolerances %+v: expected regressions %v, got %v (regressed %t)", tt.tol, tt.regressions, names, c.Regressed)
+		}
+	}
+
+	// Запуск без изменений не считается регрессией даже с нулевыми допустимыми отклонениями
+	if c := Compare(base, base, Tolerances{}); c.Regressed {
+		t.Errorf("unexpected regressions comparing a run with itself: %v", c.Regressions())
+	}
+}
@@ -15,6 +15,7 @@ type verboseRequest struct {
 	Method  string            `json:"method"`  // HTTP-метод (GET, POST и т.д.)
 	Headers map[string]string `json:"headers"` // Заголовки запроса
 	Body    interface{}       `json:"body"`    // Тело запроса
+	Curl    string            `json:"curl"`    // Команда cURL, повторяющая запрос
 }
 
 // verboseResponse представляет подробную информацию об HTTP-ответе.
@@ -33,6 +34,8 @@ type verboseHttpRequestInfo struct {
 	Envs           map[string]interface{} `json:"envs"`           // Используемые переменные окружения
 	FailedCaptures map[string]string      `json:"failedCaptures"` // Переменные окружения, которые не удалось захватить
 	Error          string                 `json:"error"`          // Ошибка, если шаг не выполнен
+	Skipped        bool                   `json:"skipped"`        // Шаг пропущен по условию
+	Iteration      int                    `json:"iteration"`      // Номер выполнения шага в цикле
 }
 
 // Преобразует результат шага сценария (ScenarioStepResult) в структуру verboseHttpRequestInfo.
@@ -42,30 +45,50 @@ func ScenarioStepResultToVerboseHttpRequestInfo(sr *types.ScenarioStepResult) ve
 
 	verboseInfo.StepId = sr.StepID     // Устанавливаем ID шага
 	verboseInfo.StepName = sr.StepName // Устанавливаем имя шага
+	verboseInfo.Iteration = sr.Iteration
 
-	if sr.Err.Type == types.ErrorInvalidRequest {
-		// Если запрос не удалось подготовить, записываем ошибку
+	if sr.Skipped {
+		// Шаг пропущен по условию, запрос не выполнялся
+		verboseInfo.Skipped = true
+		return verboseInfo
+	}
+
+	if sr.Err.Type == types.ErrorInvalidRequest || sr.DebugInfo == nil {
+		// Если запрос не удалось подготовить или он не выполнялся из-за ошибки условия, записываем ошибку
 		verboseInfo.Error = sr.Err.Error()
 		return verboseInfo
 	}
 
 	// Декодируем заголовки и тело запроса
-	requestHeaders, requestBody, _ := decode(sr.DebugInfo["requestHeaders"].(http.Header),
-		sr.DebugInfo["requestBody"].([]byte))
+	sentHeaders, _ := sr.DebugInfo["requestHeaders"].(http.Header)
+	sentBody, _ := sr.DebugInfo["requestBody"].([]byte)
+	url, _ := sr.DebugInfo["url"].(string)
+	method, _ := sr.DebugInfo["method"].(string)
+	requestHeaders, requestBody, _ := decode(sentHeaders, sentBody)
 	verboseInfo.Request = verboseRequest{
-		Url:     sr.DebugInfo["url"].(string),
-		Method:  sr.DebugInfo["method"].(string),
+		Url:     url,
+		Method:  method,
 		Headers: requestHeaders,
 		Body:    requestBody,
 	}
 
+	// Команда cURL собирается из фактического запроса, поэтому содержит подставленные значения переменных
+	sentStep := types.ScenarioStep{
+		Method:  verboseInfo.Request.Method,
+		URL:     verboseInfo.Request.Url,
+		Headers: requestHeaders,
+		Payload: string(sentBody),
+	}
+	verboseInfo.Request.Curl = sentStep.Curl()
+
 	if sr.Err.Type != "" {
 		// Если произошла ошибка, записываем её
 		verboseInfo.Error = sr.Err.Error()
 	} else {
 		// Декодируем заголовки и тело ответа
-		responseHeaders, responseBody, _ := decode(sr.DebugInfo["responseHeaders"].(http.Header),
-			sr.DebugInfo["responseBody"].([]byte))
+		receivedHeaders, _ := sr.DebugInfo["responseHeaders"].(http.Header)
+		receivedBody, _ := sr.DebugInfo["responseBody"].([]byte)
+		responseHeaders, responseBody, _ := decode(receivedHeaders, receivedBody)
 		verboseInfo.Response = verboseResponse{
 			StatusCode: sr.StatusCode,
 			Headers:    responseHeaders,
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"httes/core/types"
+)
+
+// Отладочный вывод stdout печатает каждый шаг итерации, включая шаги без отладочной информации:
+// пропущенные по условию и не выполненные из-за ошибки вычисления условия.
+func TestStdoutDebugConditionFailed(t *testing.T) {
+	var out bytes.Buffer
+	r := NewStdoutReportService(&out, 1)
+	if err := r.Init(true); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := &types.ScenarioStepResult{
+		StepID:      1,
+		StepName:    "login",
+		RequestTime: time.Now(),
+		StatusCode:  200,
+		DebugInfo: map[string]interface{}{
+			"url":             "http://shop.example.test/login",
+			"method":          "POST",
+			"requestHeaders":  http.Header{"Content-Type": {"application/json"}},
+			"requestBody":     []byte(`{"user":"demo"}`),
+			"responseHeaders": http.Header{"Content-Type": {"application/json"}},
+			"responseBody":    []byte(`{"token":"t0k3n"}`),
+		},
+	}
+	conditionFailed := &types.ScenarioStepResult{
+		StepID:      2,
+		StepName:    "poll",
+		RequestTime: time.Now(),
+		Err:         types.RequestError{Type: types.ErrorCondition, Reason: "until: TOKEN is not defined"},
+	}
+	skipped := &types.ScenarioStepResult{StepID: 3, StepName: "logout", RequestTime: time.Now(), Skipped: true}
+
+	input := make(chan *types.ScenarioResult, 1)
+	input <- &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{sent, conditionFailed, skipped}}
+	close(input)
+	r.Start(input)
+
+	var infos []verboseHttpRequestInfo
+	dec := json.NewDecoder(strings.NewReader(out.String()[:strings.Index(out.String(), "RESULT")]))
+	for dec.More() {
+		var info verboseHttpRequestInfo
+		if err := dec.Decode(&info); err != nil {
+			t.Fatalf("%v:\n%s", err, out.String())
+		}
+		infos = append(infos, info)
+	}
+	if len(infos) != 3 {
+		t.Fatalf("expected 3 debug records, got %d:\n%s", len(infos), out.String())
+	}
+	if req := infos[0].Request; req.Url != "http://shop.example.test/login" || req.Method != "POST" || !strings.Contains(req.Curl, "-X POST") ||
+		!strings.Contains(req.Curl, `--data-binary '{"user":"demo"}'`) {
+		t.Errorf("unexpected request of a sent step: %+v", req)
+	}
+	if body, _ := infos[0].Response.Body.(map[string]interface{}); body["token"] != "t0k3n" {
+		t.Errorf("unexpected response of a sent step: %+v", infos[0].Response)
+	}
+	if info := infos[1]; info.Error != types.ErrorCondition+": until: TOKEN is not defined" || !isVerboseInfoRequestEmpty(info.Request) {
+		t.Errorf("unexpected condition failed step: %+v", info)
+	}
+	if !infos[2].Skipped {
+		t.Errorf("step 3 should be skipped: %+v", infos[2])
+	}
+}
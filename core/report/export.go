@@ -0,0 +1,212 @@
+package report
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"httes/core/types"
+)
+
+const (
+	OutputTypeExport = "export"
+
+	// Файл выгрузки результатов запросов по умолчанию
+	DefaultExportDestination = "results.jsonl"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypeExport] = func(opts Options) ReportService {
+		return NewResultExporter(opts.ResultExport)
+	}
+}
+
+// Длительности запроса, выгружаемые в отдельные столбцы CSV
+var exportDurationKeys = []string{
+	"dnsDuration", "connDuration", "tlsDuration", "reqDuration", "serverProcessDuration", "resDuration",
+}
+
+// RequestRecord — запись сырого результата одного запроса в файле выгрузки.
+type RequestRecord struct {
+	Time          time.Time          `json:"time"`
+	IterationID   int64              `json:"iteration_id"`
+	ScenarioName  string             `json:"scenario_name,omitempty"`
+	StepID        uint16             `json:"step_id"`
+	StepName      string             `json:"step_name"`
+	RequestID     string             `json:"request_id"`
+	Loop          int                `json:"loop"` // Номер повторения шага в цикле
+	Skipped       bool               `json:"skipped"`
+	StatusCode    int                `json:"status_code"`
+	Duration      float64            `json:"duration"` // Секунды
+	Durations     map[string]float64 `json:"durations"`
+	ContentLength int64              `json:"content_length"`
+	ErrorType     string             `json:"error_type,omitempty"`
+	ErrorReason   string             `json:"error_reason,omitempty"`
+	Proxy         string             `json:"proxy,omitempty"`
+}
+
+// newRequestRecords создаёт записи выгрузки для всех шагов итерации.
+func newRequestRecords(scr *types.ScenarioResult) []RequestRecord {
+	proxy := ""
+	if scr.ProxyAddr != nil {
+		proxy = scr.ProxyAddr.String()
+	}
+	res := make([]RequestRecord, 0, len(scr.StepResults))
+	for _, sr := range scr.StepResults {
+		rec := RequestRecord{
+			Time:          sr.RequestTime,
+			IterationID:   scr.IterationID,
+			ScenarioName:  scr.ScenarioName,
+			StepID:        sr.StepID,
+			StepName:      sr.StepName,
+			RequestID:     sr.RequestID.String(),
+			Loop:          sr.Iteration,
+			Skipped:       sr.Skipped,
+			StatusCode:    sr.StatusCode,
+			Duration:      sr.Duration.Seconds(),
+			Durations:     make(map[string]float64, len(sr.Custom)),
+			ContentLength: sr.ContentLength,
+			ErrorType:     sr.Err.Type,
+			ErrorReason:   sr.Err.Reason,
+			Proxy:         proxy,
+		}
+		for k, v := range sr.Custom {
+			if d, ok := v.(time.Duration); ok {
+				rec.Durations[k] = d.Seconds()
+			}
+		}
+		res = append(res, rec)
+	}
+	return res
+}
+
+// ResultExporter — сервис отчётов, записывающий результат каждого запроса в файл JSON Lines или CSV.
+// Результаты он получает из общего потока результатов, как и остальные выводы.
+type ResultExporter struct {
+	export   types.ResultExport
+	file     *os.File
+	buf      *bufio.Writer
+	csv      *csv.Writer
+	err      error
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewResultExporter создаёт сервис выгрузки результатов запросов в файл e.Path.
+// Если путь пуст, результаты записываются в DefaultExportDestination.
+func NewResultExporter(e types.ResultExport) *ResultExporter {
+	if e.Path == "" {
+		e.Path = DefaultExportDestination
+	}
+	return &ResultExporter{
+		export:   e,
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Init создаёт файл выгрузки.
+func (x *ResultExporter) Init(debug bool) error {
+	format := x.export.FileFormat()
+	if format != types.ExportFormatJSONL && format != types.ExportFormatCSV {
+		return fmt.Errorf("unsupported result export format: %s", format)
+	}
+	f, err := os.Create(x.export.Path)
+	if err != nil {
+		return err
+	}
+	x.file = f
+	x.buf = bufio.NewWriterSize(f, 64*1024)
+	if format == types.ExportFormatCSV {
+		x.csv = csv.NewWriter(x.buf)
+		x.err = x.csv.Write(append([]string{
+			"time", "iteration_id", "scenario_name", "step_id", "step_name", "request_id", "loop", "skipped",
+			"status_code", "duration", "content_length", "error_type", "error_reason", "proxy",
+		}, exportDurationKeys...))
+	}
+	return nil
+}
+
+// Start записывает результаты итераций до закрытия input и закрывает файл.
+func (x *ResultExporter) Start(input chan *types.ScenarioResult) {
+	defer x.Stop()
+	if input == nil || x.file == nil {
+		return
+	}
+	enc := json.NewEncoder(x.buf)
+	for scr := range input {
+		if x.err != nil {
+			continue
+		}
+		for _, rec := range newRequestRecords(scr) {
+			if x.csv != nil {
+				x.err = x.csv.Write(rec.csvRow())
+			} else {
+				x.err = enc.Encode(rec)
+			}
+			if x.err != nil {
+				break
+			}
+		}
+	}
+	if x.csv != nil && x.err == nil {
+		x.csv.Flush()
+		x.err = x.csv.Error()
+	}
+	if err := x.buf.Flush(); err != nil && x.err == nil {
+		x.err = err
+	}
+	if err := x.file.Close(); err != nil && x.err == nil {
+		x.err = err
+	}
+	if x.err != nil {
+		fmt.Println("Warning: failed to export results:", x.err)
+		return
+	}
+	fmt.Println("Request results are written to", x.export.Path)
+}
+
+// Err возвращает ошибку записи файла. Результат действителен после закрытия DoneChan.
+func (x *ResultExporter) Err() error {
+	return x.err
+}
+
+func (x *ResultExporter) DoneChan() <-chan struct{} {
+	return x.doneChan
+}
+
+func (x *ResultExporter) Stop() {
+	x.stopOnce.Do(func() { close(x.doneChan) })
+}
+
+// csvRow возвращает запись в виде строки CSV. Длительности, не входящие в exportDurationKeys, не выгружаются.
+func (rec RequestRecord) csvRow() []string {
+	row := []string{
+		rec.Time.Format(time.RFC3339Nano),
+		strconv.FormatInt(rec.IterationID, 10),
+		rec.ScenarioName,
+		strconv.Itoa(int(rec.StepID)),
+		rec.StepName,
+		rec.RequestID,
+		strconv.Itoa(rec.Loop),
+		strconv.FormatBool(rec.Skipped),
+		strconv.Itoa(rec.StatusCode),
+		strconv.FormatFloat(rec.Duration, 'f', -1, 64),
+		strconv.FormatInt(rec.ContentLength, 10),
+		rec.ErrorType,
+		rec.ErrorReason,
+		rec.Proxy,
+	}
+	for _, k := range exportDurationKeys {
+		if d, ok := rec.Durations[k]; ok {
+			row = append(row, strconv.FormatFloat(d, 'f', -1, 64))
+		} else {
+			row = append(row, "")
+		}
+	}
+	return row
+}
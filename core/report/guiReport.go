@@ -17,8 +17,8 @@ import (
 const OutputTypeGui = "gui"
 
 func init() {
-	AvailableOutputServices[OutputTypeGui] = func(resultGrid *widget.TextGrid, progressBar *widget.ProgressBar, progressText *widget.Label, totalRequests int) ReportService {
-		return NewGuiReportService(resultGrid, progressBar, progressText, totalRequests)
+	AvailableOutputServices[OutputTypeGui] = func(opts Options) ReportService {
+		return NewGuiReportService(opts.ResultGrid, opts.ProgressBar, opts.ProgressText, opts.TotalRequests)
 	}
 }
 
@@ -142,6 +142,11 @@ func (r *guiReport) Start(input chan *types.ScenarioResult) {
 	}
 }
 
+// Result возвращает агрегированный результат теста
+func (r *guiReport) Result() *Result {
+	return r.result
+}
+
 func (r *guiReport) DoneChan() <-chan struct{} {
 	return r.doneChan
 }
@@ -251,6 +256,12 @@ func (r *guiReport) printDetails() {
 		bGui.WriteString(fmt.Sprintf("  %-20s:%.4fs\n", v.name, v.duration))
 	}
 
+	percentiles := r.result.durations.percentiles()
+	bGui.WriteString("\nDurations (Percentiles):\n")
+	for _, k := range []string{"p50", "p90", "p95", "p99"} {
+		bGui.WriteString(fmt.Sprintf("  %-20s:%.4fs\n", k, percentiles[k]))
+	}
+
 	if len(r.result.StatusCodeDist) > 0 {
 		bGui.WriteString("\nStatus Code (Message) :Count\n")
 		keys := make([]int, 0, len(r.result.StatusCodeDist))
@@ -264,6 +275,41 @@ func (r *guiReport) printDetails() {
 		}
 	}
 
+	var skipped int64
+	for _, st := range r.result.StepResults {
+		skipped += st.SkippedCount
+	}
+	if skipped > 0 {
+		bGui.WriteString(fmt.Sprintf("\nSkipped Steps:    %d\n", skipped))
+	}
+
+	if len(r.result.ScenarioResults) > 0 {
+		bGui.WriteString("\nScenarios:\n")
+		names := make([]string, 0, len(r.result.ScenarioResults))
+		for n := range r.result.ScenarioResults {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		total := int64(r.result.SuccessCount + r.result.FailedCount)
+		for _, n := range names {
+			sc := r.result.ScenarioResults[n]
+			count := sc.SuccessCount + sc.FailedCount
+			share := 0
+			if total > 0 {
+				share = int(count * 100 / total)
+			}
+			bGui.WriteString(fmt.Sprintf("  %-20s:%d (%d%%), failed %d, avg %.4fs\n", n, count, share, sc.FailedCount, sc.AvgDuration))
+			sort.Slice(sc.StepIDs, func(i, j int) bool { return sc.StepIDs[i] < sc.StepIDs[j] })
+			for _, id := range sc.StepIDs {
+				st, ok := r.result.StepResults[id]
+				if !ok {
+					continue
+				}
+				bGui.WriteString(fmt.Sprintf("    (%d) %-15s:ok %d, failed %d, avg %.4fs\n", id, st.Name, st.SuccessCount, st.FailedCount, st.Durations["duration"]))
+			}
+		}
+	}
+
 	avgParamCount := float32(0)
 	if r.result.TotalRequests > 0 {
 		avgParamCount = float32(r.result.TotalParamCount) / float32(r.result.TotalRequests)
@@ -296,8 +342,16 @@ func (r *guiReport) printInDebugMode(input chan *types.ScenarioResult) {
 			fmt.Printf("Debug: StepResult: StepID=%d, StatusCode=%d, Duration=%v, Err=%+v\n",
 				sr.StepID, sr.StatusCode, sr.Duration, sr.Err)
 			verboseInfo := ScenarioStepResultToVerboseHttpRequestInfo(sr)
-			b.WriteString(fmt.Sprintf("\n\nSTEP (%d) %s\n", verboseInfo.StepId, verboseInfo.StepName))
+			if verboseInfo.Iteration > 0 {
+				b.WriteString(fmt.Sprintf("\n\nSTEP (%d) %s #%d\n", verboseInfo.StepId, verboseInfo.StepName, verboseInfo.Iteration+1))
+			} else {
+				b.WriteString(fmt.Sprintf("\n\nSTEP (%d) %s\n", verboseInfo.StepId, verboseInfo.StepName))
+			}
 			b.WriteString("------------------------------------\n")
+			if verboseInfo.Skipped {
+				b.WriteString("⏭️ Skipped: condition is false\n")
+				continue
+			}
 			b.WriteString("- Environment Variables\n")
 			for eKey, eVal := range verboseInfo.Envs {
 				switch eVal.(type) {
@@ -332,6 +386,7 @@ func (r *guiReport) printInDebugMode(input chan *types.ScenarioResult) {
 			} else {
 				b.WriteString(fmt.Sprintf("%v\n", verboseInfo.Request.Body))
 			}
+			b.WriteString(fmt.Sprintf("  cURL:\n    %s\n", strings.ReplaceAll(verboseInfo.Request.Curl, "\n", "\n    ")))
 
 			if verboseInfo.Error != "" {
 				if len(verboseInfo.FailedCaptures) > 0 {
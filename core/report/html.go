@@ -0,0 +1,290 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+// Размер графиков временного ряда в HTML-отчёте
+const (
+	htmlChartWidth  = 900
+	htmlChartHeight = 260
+)
+
+// htmlReport — данные шаблона HTML-отчёта.
+type htmlReport struct {
+	Title       string
+	Generated   time.Time
+	Summary     RunSummary
+	FailRate    float64
+	Percentiles []htmlPercentiles
+	Steps       []htmlStep
+	StatusCodes []htmlCount
+	Errors      []htmlCount
+	Scenarios   []*ScenarioResultSummary
+	Charts      []htmlChart
+}
+
+type htmlPercentiles struct {
+	Name                     string
+	Avg, P50, P90, P95, P99  float32
+	Success, Failed, Skipped int64
+	Durations                map[string]float32
+}
+
+type htmlStep struct {
+	ID uint16
+	htmlPercentiles
+}
+
+type htmlCount struct {
+	Name  string
+	Count int
+	Share float64
+}
+
+type htmlChart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// WriteHTMLReport записывает итоговый результат теста в самодостаточный HTML-файл:
+// сводку, таблицы шагов и процентилей, распределения статус-кодов и ошибок и графики временного ряда.
+func WriteHTMLReport(w io.Writer, title string, s RunSummary) error {
+	r := htmlReport{
+		Title:     title,
+		Generated: time.Now(),
+		Summary:   s,
+		FailRate:  failRate(int64(s.SuccessCount), int64(s.FailedCount)),
+		Percentiles: []htmlPercentiles{{
+			Name: "Test", Avg: s.AvgDuration,
+			P50: s.Percentiles["p50"], P90: s.Percentiles["p90"], P95: s.Percentiles["p95"], P99: s.Percentiles["p99"],
+			Success: int64(s.SuccessCount), Failed: int64(s.FailedCount),
+		}},
+	}
+
+	ids := make([]int, 0, len(s.Steps))
+	for id := range s.Steps {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	errors := map[string]int{}
+	for _, id := range ids {
+		st := s.Steps[uint16(id)]
+		p := htmlPercentiles{
+			Name: st.Name, Avg: st.Durations["duration"],
+			P50: st.Percentiles["p50"], P90: st.Percentiles["p90"], P95: st.Percentiles["p95"], P99: st.Percentiles["p99"],
+			Success: st.SuccessCount, Failed: st.FailedCount, Skipped: st.SkippedCount,
+			Durations: st.Durations,
+		}
+		r.Steps = append(r.Steps, htmlStep{ID: uint16(id), htmlPercentiles: p})
+		r.Percentiles = append(r.Percentiles, p)
+		for e, n := range st.ErrorDist {
+			errors[e] += n
+		}
+	}
+
+	codes := make([]int, 0, len(s.StatusCodeDist))
+	total := 0
+	for c, n := range s.StatusCodeDist {
+		codes = append(codes, c)
+		total += n
+	}
+	sort.Ints(codes)
+	for _, c := range codes {
+		r.StatusCodes = append(r.StatusCodes, htmlCount{
+			Name: fmt.Sprintf("%d %s", c, http.StatusText(c)), Count: s.StatusCodeDist[c], Share: share(s.StatusCodeDist[c], total),
+		})
+	}
+
+	totalErrors := 0
+	for _, n := range errors {
+		totalErrors += n
+	}
+	for e, n := range errors {
+		r.Errors = append(r.Errors, htmlCount{Name: e, Count: n, Share: share(n, totalErrors)})
+	}
+	// Ошибки с одинаковым количеством упорядочиваются по имени, чтобы отчёт не зависел от порядка обхода карты
+	sort.Slice(r.Errors, func(i, j int) bool {
+		if r.Errors[i].Count != r.Errors[j].Count {
+			return r.Errors[i].Count > r.Errors[j].Count
+		}
+		return r.Errors[i].Name < r.Errors[j].Name
+	})
+
+	names := make([]string, 0, len(s.Scenarios))
+	for n := range s.Scenarios {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		r.Scenarios = append(r.Scenarios, s.Scenarios[n])
+	}
+
+	charts, err := timeSeriesCharts(s.TimeSeries)
+	if err != nil {
+		return err
+	}
+	r.Charts = charts
+
+	return htmlTemplate.Execute(w, r)
+}
+
+// timeSeriesCharts рисует графики итераций, средней длительности и ошибок по секундам теста в формате SVG.
+func timeSeriesCharts(series []TimePoint) ([]htmlChart, error) {
+	if len(series) < 2 {
+		return nil, nil
+	}
+	x := make([]float64, len(series))
+	rps := make([]float64, len(series))
+	latency := make([]float64, len(series))
+	errors := make([]float64, len(series))
+	for i, tp := range series {
+		x[i] = float64(tp.Second + 1)
+		rps[i] = float64(tp.SuccessCount + tp.FailedCount)
+		latency[i] = float64(tp.AvgDuration) * 1000
+		errors[i] = float64(tp.FailedCount)
+	}
+
+	var res []htmlChart
+	for _, c := range []struct {
+		title string
+		y     []float64
+		color drawing.Color
+	}{
+		{"Iterations per second", rps, drawing.Color{R: 33, G: 150, B: 243, A: 255}},
+		{"Average duration, ms", latency, drawing.Color{R: 76, G: 175, B: 80, A: 255}},
+		{"Errors per second", errors, drawing.Color{R: 244, G: 67, B: 54, A: 255}},
+	} {
+		graph := chart.Chart{
+			Width:  htmlChartWidth,
+			Height: htmlChartHeight,
+			XAxis:  chart.XAxis{Name: "Second"},
+			YAxis:  chart.YAxis{Name: c.title},
+			Series: []chart.Series{chart.ContinuousSeries{
+				XValues: x,
+				YValues: c.y,
+				Style:   chart.Style{StrokeColor: c.color, StrokeWidth: 2},
+			}},
+		}
+		// Для постоянного ряда диапазон задаётся явно, иначе оси строятся по нулевому диапазону
+		if v, ok := constantValue(c.y); ok {
+			graph.YAxis.Range = &chart.ContinuousRange{Min: 0, Max: 1}
+			if v > 0 {
+				graph.YAxis.Range = &chart.ContinuousRange{Min: 0, Max: v * 2}
+			}
+		}
+		var buf bytes.Buffer
+		if err := graph.Render(chart.SVG, &buf); err != nil {
+			return nil, fmt.Errorf("failed to render chart %s: %v", c.title, err)
+		}
+		res = append(res, htmlChart{Title: c.title, SVG: template.HTML(buf.String())})
+	}
+	return res, nil
+}
+
+// constantValue возвращает значение ряда, если все его значения одинаковы.
+func constantValue(values []float64) (float64, bool) {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return 0, false
+		}
+	}
+	return values[0], true
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sec": func(v float32) string { return fmt.Sprintf("%.4f", v) },
+	"pct": func(v float64) string { return fmt.Sprintf("%.2f%%", v) },
+	"durationKeys": func() []string {
+		return []string{"dnsDuration", "connDuration", "tlsDuration", "reqDuration", "serverProcessDuration", "resDuration"}
+	},
+	"durationName": func(k string) string { return keyToStr[k].name },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, Arial, sans-serif; margin: 24px; color: #222; }
+h1 { margin-bottom: 4px; }
+h2 { margin-top: 32px; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+.meta { color: #777; }
+table { border-collapse: collapse; margin-top: 8px; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: right; }
+th { background: #f4f4f4; }
+td.name, th.name { text-align: left; }
+.passed { color: #2e7d32; font-weight: bold; }
+.failed { color: #c62828; font-weight: bold; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 10px 16px; min-width: 140px; }
+.card .value { font-size: 22px; font-weight: bold; }
+.card .label { color: #777; font-size: 13px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Started {{.Summary.StartTime.Format "2006-01-02 15:04:05"}}, generated {{.Generated.Format "2006-01-02 15:04:05"}}</div>
+
+<h2>Summary</h2>
+<div class="cards">
+  <div class="card"><div class="value">{{.Summary.SuccessCount}} / {{.Summary.FailedCount}}</div><div class="label">successful / failed iterations</div></div>
+  <div class="card"><div class="value">{{pct .FailRate}}</div><div class="label">failure rate</div></div>
+  <div class="card"><div class="value">{{.Summary.TotalRequests}}</div><div class="label">requests</div></div>
+  <div class="card"><div class="value">{{.Summary.Duration}}s</div><div class="label">duration</div></div>
+  <div class="card"><div class="value">{{printf "%.2f" .Summary.Rps}}</div><div class="label">iterations per second</div></div>
+  <div class="card"><div class="value">{{sec .Summary.AvgDuration}}s</div><div class="label">average duration</div></div>
+  {{if .Summary.Thresholds}}<div class="card"><div class="value {{if .Summary.Passed}}passed{{else}}failed{{end}}">{{if .Summary.Passed}}PASSED{{else}}FAILED{{end}}</div><div class="label">thresholds</div></div>{{end}}
+</div>
+
+<h2>Latency percentiles, s</h2>
+<table>
+<tr><th class="name">Name</th><th>OK</th><th>Failed</th><th>Skipped</th><th>Avg</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th></tr>
+{{range .Percentiles}}<tr><td class="name">{{.Name}}</td><td>{{.Success}}</td><td>{{.Failed}}</td><td>{{.Skipped}}</td><td>{{sec .Avg}}</td><td>{{sec .P50}}</td><td>{{sec .P90}}</td><td>{{sec .P95}}</td><td>{{sec .P99}}</td></tr>
+{{end}}</table>
+
+{{if .Steps}}<h2>Step durations, s</h2>
+<table>
+<tr><th>ID</th><th class="name">Step</th>{{range durationKeys}}<th>{{durationName .}}</th>{{end}}<th>Total</th></tr>
+{{range .Steps}}{{$d := .Durations}}<tr><td>{{.ID}}</td><td class="name">{{.Name}}</td>{{range durationKeys}}<td>{{sec (index $d .)}}</td>{{end}}<td>{{sec .Avg}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Scenarios}}<h2>Scenarios</h2>
+<table>
+<tr><th class="name">Scenario</th><th>OK</th><th>Failed</th><th>Avg, s</th></tr>
+{{range .Scenarios}}<tr><td class="name">{{.Name}}</td><td>{{.SuccessCount}}</td><td>{{.FailedCount}}</td><td>{{sec .AvgDuration}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .StatusCodes}}<h2>Status codes</h2>
+<table>
+<tr><th class="name">Status</th><th>Count</th><th>Share</th></tr>
+{{range .StatusCodes}}<tr><td class="name">{{.Name}}</td><td>{{.Count}}</td><td>{{pct .Share}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Errors}}<h2>Errors</h2>
+<table>
+<tr><th class="name">Reason</th><th>Count</th><th>Share</th></tr>
+{{range .Errors}}<tr><td class="name">{{.Name}}</td><td>{{.Count}}</td><td>{{pct .Share}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Summary.Thresholds}}<h2>Thresholds</h2>
+<table>
+<tr><th class="name">Threshold</th></tr>
+{{range .Summary.Thresholds}}<tr><td class="name {{if .Passed}}passed{{else}}failed{{end}}">{{.}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Charts}}<h2>Time series</h2>
+{{range .Charts}}<h3>{{.Title}}</h3>
+{{.SVG}}
+{{end}}{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,131 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// tableRows возвращает текст ячеек строк таблицы, следующей за заголовком раздела section.
+func tableRows(t *testing.T, doc *html.Node, section string) [][]string {
+	t.Helper()
+	rows, err := htmlquery.QueryAll(doc, "//h2[text()='"+section+"']/following-sibling::table[1]//tr[td]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res [][]string
+	for _, row := range rows {
+		var cells []string
+		for _, td := range htmlquery.Find(row, "./td") {
+			cells = append(cells, htmlquery.InnerText(td))
+		}
+		res = append(res, cells)
+	}
+	return res
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	step := func(name string, success, failed int64, errors map[string]int) *StepSummary {
+		return &StepSummary{
+			ScenarioStepResultSummary: ScenarioStepResultSummary{
+				Name:         name,
+				Durations:    map[string]float32{"duration": 0.12, "dnsDuration": 0.001, "connDuration": 0.002},
+				SuccessCount: success,
+				FailedCount:  failed,
+				ErrorDist:    errors,
+			},
+			Percentiles: map[string]float32{"p50": 0.1, "p90": 0.2, "p95": 0.25, "p99": 0.3},
+		}
+	}
+	s := RunSummary{
+		StartTime:      time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		Duration:       3,
+		SuccessCount:   95,
+		FailedCount:    5,
+		TotalRequests:  200,
+		AvgDuration:    0.24,
+		Rps:            33.3,
+		Percentiles:    map[string]float32{"p50": 0.2, "p90": 0.4, "p95": 0.5, "p99": 0.6},
+		StatusCodeDist: map[int]int{500: 4, 200: 195, 0: 1},
+		Steps: map[uint16]*StepSummary{
+			2: step("search <q>", 95, 5, map[string]int{"connection: refused": 1, "timeout": 2, "conditionError: until": 2}),
+			1: step("login", 100, 0, map[string]int{}),
+		},
+		TimeSeries: []TimePoint{
+			{Second: 0, SuccessCount: 30, FailedCount: 1, AvgDuration: 0.2},
+			{Second: 1, SuccessCount: 35, FailedCount: 4, AvgDuration: 0.3},
+			{Second: 2, SuccessCount: 30, AvgDuration: 0.2},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, "Shop & checkout", s); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := htmlquery.Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if title := htmlquery.InnerText(htmlquery.FindOne(doc, "//h1")); title != "Shop & checkout" {
+		t.Errorf("unexpected title %q", title)
+	}
+	// Имена экранируются шаблоном
+	if strings.Contains(buf.String(), "search <q>") {
+		t.Error("step name is not escaped")
+	}
+
+	check := func(section string, expected [][]string) {
+		t.Helper()
+		rows := tableRows(t, doc, section)
+		if len(rows) != len(expected) {
+			t.Fatalf("%s: expected %d rows, got %q", section, len(expected), rows)
+		}
+		for i := range rows {
+			if strings.Join(rows[i], "|") != strings.Join(expected[i], "|") {
+				t.Errorf("%s, row %d: expected %q, got %q", section, i, expected[i], rows[i])
+			}
+		}
+	}
+	check("Latency percentiles, s", [][]string{
+		{"Test", "95", "5", "0", "0.2400", "0.2000", "0.4000", "0.5000", "0.6000"},
+		{"login", "100", "0", "0", "0.1200", "0.1000", "0.2000", "0.2500", "0.3000"},
+		{"search <q>", "95", "5", "0", "0.1200", "0.1000", "0.2000", "0.2500", "0.3000"},
+	})
+	check("Step durations, s", [][]string{
+		{"1", "login", "0.0010", "0.0020", "0.0000", "0.0000", "0.0000", "0.0000", "0.1200"},
+		{"2", "search <q>", "0.0010", "0.0020", "0.0000", "0.0000", "0.0000", "0.0000", "0.1200"},
+	})
+	check("Status codes", [][]string{
+		{"0 ", "1", "0.50%"},
+		{"200 OK", "195", "97.50%"},
+		{"500 Internal Server Error", "4", "2.00%"},
+	})
+	// Ошибки упорядочены по количеству, а с одинаковым количеством — по имени
+	check("Errors", [][]string{
+		{"conditionError: until", "2", "40.00%"},
+		{"timeout", "2", "40.00%"},
+		{"connection: refused", "1", "20.00%"},
+	})
+
+	charts := htmlquery.Find(doc, "//h2[text()='Time series']/following-sibling::h3")
+	svgs := htmlquery.Find(doc, "//svg")
+	if len(charts) != 3 || len(svgs) != 3 {
+		t.Fatalf("expected 3 charts, got %d titles and %d svg", len(charts), len(svgs))
+	}
+	for i, title := range []string{"Iterations per second", "Average duration, ms", "Errors per second"} {
+		if got := htmlquery.InnerText(charts[i]); got != title {
+			t.Errorf("chart %d: expected %q, got %q", i, title, got)
+		}
+	}
+	// Без порогов и сценариев соответствующие разделы не выводятся
+	for _, section := range []string{"Thresholds", "Scenarios"} {
+		if htmlquery.FindOne(doc, "//h2[text()='"+section+"']") != nil {
+			t.Errorf("unexpected section %s", section)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"httes/core/types"
+)
+
+const (
+	OutputTypeJUnit = "junit"
+
+	// Файл отчёта JUnit по умолчанию
+	DefaultJUnitDestination = "junit.xml"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypeJUnit] = func(opts Options) ReportService {
+		return NewJUnitReportService(opts.Destination, opts.Thresholds)
+	}
+}
+
+// junitReport агрегирует результаты теста и по завершении записывает их в файл JUnit XML.
+// Каждый шаг сценария и каждое пороговое значение становятся отдельным тестом.
+type junitReport struct {
+	path       string
+	thresholds []types.Threshold
+	doneChan   chan struct{}
+	result     *Result
+	stopOnce   sync.Once
+}
+
+// NewJUnitReportService создаёт сервис отчётов JUnit XML, записывающий отчёт в path.
+// Если path пуст, отчёт записывается в DefaultJUnitDestination.
+func NewJUnitReportService(path string, thresholds []types.Threshold) ReportService {
+	if path == "" {
+		path = DefaultJUnitDestination
+	}
+	return &junitReport{
+		path:       path,
+		thresholds: thresholds,
+		doneChan:   make(chan struct{}),
+		result: &Result{
+			StepResults: make(map[uint16]*ScenarioStepResultSummary),
+		},
+	}
+}
+
+func (r *junitReport) Init(debug bool) error {
+	return nil
+}
+
+func (r *junitReport) Start(input chan *types.ScenarioResult) {
+	defer r.Stop()
+	if input == nil {
+		return
+	}
+	for scr := range input {
+		aggregate(r.result, scr)
+	}
+
+	f, err := os.Create(r.path)
+	if err != nil {
+		fmt.Println("Failed to write JUnit report:", err)
+		return
+	}
+	defer f.Close()
+	if err := WriteJUnit(f, r.result.Summary(r.thresholds)); err != nil {
+		fmt.Println("Failed to write JUnit report:", err)
+		return
+	}
+	fmt.Println("JUnit report is written to", r.path)
+}
+
+// Result возвращает агрегированный результат теста
+func (r *junitReport) Result() *Result {
+	return r.result
+}
+
+func (r *junitReport) DoneChan() <-chan struct{} {
+	return r.doneChan
+}
+
+func (r *junitReport) Stop() {
+	r.stopOnce.Do(func() { close(r.doneChan) })
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit записывает итоговый результат теста в формате JUnit XML.
+// Шаг считается упавшим, если хотя бы один его запрос завершился ошибкой; текст ошибки содержит
+// распределение ошибок и статус-кодов. Пороговое значение падает, если оно не выполнено.
+func WriteJUnit(w io.Writer, s RunSummary) error {
+	doc := junitTestSuites{Name: "httes", Time: float64(s.Duration)}
+	timestamp := ""
+	if !s.StartTime.IsZero() {
+		timestamp = s.StartTime.Format("2006-01-02T15:04:05")
+	}
+
+	steps := junitTestSuite{Name: "steps", Timestamp: timestamp, Time: float64(s.Duration)}
+	ids := make([]int, 0, len(s.Steps))
+	for id := range s.Steps {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		st := s.Steps[uint16(id)]
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("(%d) %s", id, st.Name),
+			ClassName: "httes.steps",
+			Time:      junitTime(st.Durations["duration"]),
+			SystemOut: fmt.Sprintf("ok %d, failed %d, skipped %d, avg %.4fs, p50 %.4fs, p90 %.4fs, p95 %.4fs, p99 %.4fs",
+				st.SuccessCount, st.FailedCount, st.SkippedCount, st.Durations["duration"],
+				st.Percentiles["p50"], st.Percentiles["p90"], st.Percentiles["p95"], st.Percentiles["p99"]),
+		}
+		switch {
+		case st.FailedCount > 0:
+			tc.Failure = &junitFailure{
+				Message: fmt.Sprintf("%d of %d requests failed", st.FailedCount, st.SuccessCount+st.FailedCount),
+				Type:    "RequestError",
+				Text:    stepFailureText(&st.ScenarioStepResultSummary),
+			}
+			steps.Failures++
+		case st.SuccessCount == 0 && st.SkippedCount > 0:
+			tc.Skipped = &struct{}{}
+			steps.Skipped++
+		}
+		steps.Cases = append(steps.Cases, tc)
+	}
+	steps.Tests = len(steps.Cases)
+	doc.Suites = append(doc.Suites, steps)
+
+	if len(s.Thresholds) > 0 {
+		thresholds := junitTestSuite{Name: "thresholds", Timestamp: timestamp}
+		for _, t := range s.Thresholds {
+			th := types.Threshold{Metric: t.Metric, StepID: t.StepID, Min: t.Min, Max: t.Max}
+			tc := junitTestCase{Name: th.String(), ClassName: "httes.thresholds"}
+			if !t.Passed {
+				tc.Failure = &junitFailure{
+					Message: fmt.Sprintf("%s: actual value %.4g", th, t.Value),
+					Type:    "ThresholdViolation",
+				}
+				thresholds.Failures++
+			}
+			thresholds.Cases = append(thresholds.Cases, tc)
+		}
+		thresholds.Tests = len(thresholds.Cases)
+		doc.Suites = append(doc.Suites, thresholds)
+	}
+
+	for _, ts := range doc.Suites {
+		doc.Tests += ts.Tests
+		doc.Failures += ts.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitTime округляет длительность в секундах до 0.1 мс, чтобы в отчёт не попадали погрешности float32.
+func junitTime(v float32) float64 {
+	return math.Round(float64(v)*1e4) / 1e4
+}
+
+// stepFailureText возвращает распределение ошибок и статус-кодов шага.
+func stepFailureText(st *ScenarioStepResultSummary) string {
+	var b strings.Builder
+	if len(st.ErrorDist) > 0 {
+		b.WriteString("Errors:\n")
+		reasons := make([]string, 0, len(st.ErrorDist))
+		for e := range st.ErrorDist {
+			reasons = append(reasons, e)
+		}
+		sort.Strings(reasons)
+		for _, e := range reasons {
+			fmt.Fprintf(&b, "  %s: %d\n", e, st.ErrorDist[e])
+		}
+	}
+	if len(st.StatusCodeDist) > 0 {
+		b.WriteString("Status codes:\n")
+		codes := make([]int, 0, len(st.StatusCodeDist))
+		for c := range st.StatusCodeDist {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		for _, c := range codes {
+			fmt.Fprintf(&b, "  %d: %d\n", c, st.StatusCodeDist[c])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"httes/config"
+	"httes/core/types"
+)
+
+const expectedJUnit = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="httes" tests="5" failures="2" time="1">
+  <testsuite name="steps" tests="3" failures="1" skipped="1" time="1" timestamp="2026-03-01T10:00:00">
+    <testcase name="(1) Index &lt;home&gt;" classname="httes.steps" time="0.1">
+      <system-out>ok 4, failed 0, skipped 0, avg 0.1000s, p50 0.1000s, p90 0.1000s, p95 0.1000s, p99 0.1000s</system-out>
+    </testcase>
+    <testcase name="(2) Checkout" classname="httes.steps" time="0.2">
+      <failure message="1 of 4 requests failed" type="RequestError"><![CDATA[Errors:
+  unexpected status 500: 1
+Status codes:
+  200: 3
+  500: 1
+]]></failure>
+      <system-out>ok 3, failed 1, skipped 0, avg 0.2000s, p50 0.2000s, p90 0.2000s, p95 0.2000s, p99 0.2000s</system-out>
+    </testcase>
+    <testcase name="(3) Logout" classname="httes.steps" time="0">
+      <skipped></skipped>
+      <system-out>ok 0, failed 0, skipped 4, avg 0.0000s, p50 0.0000s, p90 0.0000s, p95 0.0000s, p99 0.0000s</system-out>
+    </testcase>
+  </testsuite>
+  <testsuite name="thresholds" tests="2" failures="1" skipped="0" time="0" timestamp="2026-03-01T10:00:00">
+    <testcase name="p95 &lt;= 0.5" classname="httes.thresholds" time="0"></testcase>
+    <testcase name="fail_rate &lt;= 1" classname="httes.thresholds" time="0">
+      <failure message="fail_rate &lt;= 1: actual value 25" type="ThresholdViolation"></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+
+// Отчёт JUnit по конфигурации config_junit.json: шаги с ошибками и пропущенные шаги,
+// а также невыполненные пороговые значения становятся упавшими и пропущенными тестами.
+func TestJUnitReport(t *testing.T) {
+	path := filepath.Join("..", "..", "config", "config_testdata", "config_junit.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := config.NewConfigReader(data, config.DetectConfigType(path, data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.ReportOutputs) != 1 || h.ReportOutputs[0] != OutputTypeJUnit || h.ReportDestination != DefaultJUnitDestination || len(h.Thresholds) != 2 {
+		t.Fatalf("unexpected junit config: outputs %v, destination %q, thresholds %v", h.ReportOutputs, h.ReportDestination, h.Thresholds)
+	}
+
+	dest := filepath.Join(t.TempDir(), "junit.xml")
+	r := AvailableOutputServices[OutputTypeJUnit](Options{Destination: dest, Thresholds: h.Thresholds})
+	if err := r.Init(false); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	input := make(chan *types.ScenarioResult, 4)
+	for i := 0; i < 4; i++ {
+		checkout := &types.ScenarioStepResult{StepID: 2, StepName: "Checkout", StatusCode: 200, Duration: 200 * time.Millisecond}
+		if i == 3 {
+			checkout.StatusCode = 500
+			checkout.Err = types.RequestError{Type: types.ErrorStatus, Reason: "unexpected status 500"}
+		}
+		input <- &types.ScenarioResult{
+			StartTime: start.Add(time.Duration(i) * 100 * time.Millisecond),
+			StepResults: []*types.ScenarioStepResult{
+				{StepID: 1, StepName: "Index <home>", StatusCode: 200, Duration: 100 * time.Millisecond},
+				checkout,
+				{StepID: 3, StepName: "Logout", Skipped: true},
+			},
+		}
+	}
+	close(input)
+	r.Start(input)
+
+	out, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != expectedJUnit {
+		t.Errorf("unexpected report:\n%s\nexpected:\n%s", out, expectedJUnit)
+	}
+}
@@ -0,0 +1,225 @@
+package report
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"httes/core/types"
+	"httes/core/util"
+)
+
+// Размер буфера результатов каждого вывода, если входной канал не буферизован
+const multiReportBufferSize = 1024
+
+// multiReport передаёт каждый результат итерации нескольким сервисам отчётов так, чтобы медленный вывод
+// не задерживал остальные. Выводы, которые агрегируют результаты (stdout, gui, junit, export), получают каждый
+// результат: результаты, которые вывод ещё не успел обработать, накапливаются в его неограниченной очереди.
+// Выводам метрик (LossyReceiver) результаты передаются через ограниченный буфер: если вывод не успевает
+// их обрабатывать и его буфер заполнен, результат для этого вывода отбрасывается.
+type multiReport struct {
+	names    []string
+	services []ReportService
+	dropped  []atomic.Int64 // Количество отброшенных результатов для каждого сервиса метрик
+	doneChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewMultiReportService создаёт сервис отчётов, который передаёт результаты всем services.
+// DoneChan закрывается, когда все сервисы завершили работу.
+func NewMultiReportService(services ...ReportService) ReportService {
+	return newMultiReport(nil, services)
+}
+
+// newMultiReport создаёт сервис отчётов для services. Имена names используются в предупреждениях
+// об отброшенных результатах, без них сервисы называются по типу.
+func newMultiReport(names []string, services []ReportService) *multiReport {
+	return &multiReport{
+		names:    names,
+		services: services,
+		dropped:  make([]atomic.Int64, len(services)),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// NewReportServices создаёт сервис отчётов для одного или нескольких типов вывода.
+// Для одного типа возвращается сам сервис, для нескольких — сервис, передающий результаты каждому из них.
+// Выводы prometheus, export и push добавляются автоматически, если в opts заданы их параметры.
+func NewReportServices(outputs []string, opts Options) (ReportService, error) {
+	if len(outputs) == 0 {
+		outputs = []string{OutputTypeStdout}
+	}
+	enabled := []struct {
+		output string
+		on     bool
+	}{
+		{OutputTypePrometheus, opts.MetricsAddr != ""},
+		{OutputTypeExport, opts.ResultExport.Enabled()},
+		{OutputTypePush, len(opts.Push) > 0},
+	}
+	for _, e := range enabled {
+		if e.on && !util.StringInSlice(e.output, outputs) {
+			outputs = append(outputs[:len(outputs):len(outputs)], e.output)
+		}
+	}
+	services := make([]ReportService, 0, len(outputs))
+	seen := make(map[string]bool, len(outputs))
+	for _, o := range outputs {
+		if seen[o] {
+			return nil, fmt.Errorf("duplicate output type: %s", o)
+		}
+		seen[o] = true
+		if o == OutputTypePush && len(opts.Push) == 0 {
+			return nil, fmt.Errorf("output %s requires at least one push target", o)
+		}
+		rs, err := NewReportService(o, opts)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, rs)
+	}
+	if len(services) == 1 {
+		return services[0], nil
+	}
+	return newMultiReport(outputs, services), nil
+}
+
+func (r *multiReport) Init(debug bool) error {
+	for _, s := range r.services {
+		if err := s.Init(debug); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *multiReport) Start(input chan *types.ScenarioResult) {
+	defer r.Stop()
+	if input == nil {
+		for _, s := range r.services {
+			s.Start(nil)
+		}
+		return
+	}
+
+	size := cap(input)
+	if size == 0 {
+		size = multiReportBufferSize
+	}
+	outputs := make([]chan *types.ScenarioResult, len(r.services))
+	lossy := make([]bool, len(r.services))
+	for i, s := range r.services {
+		out := make(chan *types.ScenarioResult, size)
+		go s.Start(out)
+		outputs[i] = out
+		if lr, ok := s.(LossyReceiver); ok && lr.Lossy() {
+			lossy[i] = true
+		} else {
+			outputs[i] = unboundedQueue(out)
+		}
+	}
+
+	for scr := range input {
+		for i, out := range outputs {
+			if !lossy[i] {
+				out <- scr
+				continue
+			}
+			select {
+			case out <- scr:
+			default:
+				r.dropped[i].Add(1)
+			}
+		}
+	}
+	for _, out := range outputs {
+		close(out)
+	}
+	for i, s := range r.services {
+		<-s.DoneChan()
+		if n := r.dropped[i].Load(); n > 0 {
+			fmt.Printf("Warning: %d results were not passed to output %s because its buffer was full\n", n, r.name(i))
+		}
+	}
+}
+
+// unboundedQueue возвращает канал, результаты из которого передаются в out в том же порядке.
+// Запись в возвращаемый канал не ждёт, пока out освободится: ожидающие результаты накапливаются в очереди.
+// После закрытия возвращаемого канала оставшиеся результаты передаются в out, и out закрывается.
+func unboundedQueue(out chan *types.ScenarioResult) chan *types.ScenarioResult {
+	in := make(chan *types.ScenarioResult)
+	go func() {
+		defer close(out)
+		var pending []*types.ScenarioResult
+		for in != nil || len(pending) > 0 {
+			// Пока очередь пуста, отправка в out отключена nil-каналом
+			var send chan *types.ScenarioResult
+			var next *types.ScenarioResult
+			if len(pending) > 0 {
+				send, next = out, pending[0]
+			}
+			select {
+			case scr, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				pending = append(pending, scr)
+			case send <- next:
+				pending[0] = nil
+				pending = pending[1:]
+			}
+		}
+	}()
+	return in
+}
+
+// Lossy сообщает, что все выводы допускают потерю результатов.
+func (r *multiReport) Lossy() bool {
+	for _, s := range r.services {
+		if lr, ok := s.(LossyReceiver); !ok || !lr.Lossy() {
+			return false
+		}
+	}
+	return true
+}
+
+// name возвращает имя i-го сервиса для сообщений.
+func (r *multiReport) name(i int) string {
+	if i < len(r.names) {
+		return r.names[i]
+	}
+	return fmt.Sprintf("%T", r.services[i])
+}
+
+// SetLoadStats передаёт состояние нагрузки движка сервисам, которым оно нужно.
+func (r *multiReport) SetLoadStats(stats LoadStats) {
+	for _, s := range r.services {
+		if lr, ok := s.(LoadStatsReceiver); ok {
+			lr.SetLoadStats(stats)
+		}
+	}
+}
+
+// Result возвращает агрегированный результат первого сервиса, который его предоставляет.
+func (r *multiReport) Result() *Result {
+	for _, s := range r.services {
+		if rp, ok := s.(ResultProvider); ok {
+			return rp.Result()
+		}
+	}
+	return nil
+}
+
+func (r *multiReport) DoneChan() <-chan struct{} {
+	return r.doneChan
+}
+
+func (r *multiReport) Stop() {
+	r.stopOnce.Do(func() {
+		for _, s := range r.services {
+			s.Stop()
+		}
+		close(r.doneChan)
+	})
+}
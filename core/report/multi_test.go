@@ -0,0 +1,219 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"httes/core/types"
+)
+
+// countingReport — сервис отчётов, который только считает полученные результаты.
+// Если задан block, результаты начинают читаться после его закрытия.
+type countingReport struct {
+	mu       sync.Mutex
+	count    int
+	block    chan struct{}
+	doneChan chan struct{}
+}
+
+func newCountingReport() *countingReport {
+	return &countingReport{doneChan: make(chan struct{})}
+}
+
+func (r *countingReport) Init(debug bool) error { return nil }
+
+func (r *countingReport) Start(input chan *types.ScenarioResult) {
+	defer close(r.doneChan)
+	if r.block != nil {
+		<-r.block
+	}
+	for range input {
+		r.mu.Lock()
+		r.count++
+		r.mu.Unlock()
+	}
+}
+
+func (r *countingReport) DoneChan() <-chan struct{} { return r.doneChan }
+
+func (r *countingReport) Stop() {}
+
+// Медленный вывод метрик не задерживает передачу результатов остальным: после заполнения его буфера
+// результаты для него отбрасываются, а остальные выводы получают все результаты.
+func TestMultiReportSlowOutput(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	sink.block = make(chan struct{})
+	fast := newCountingReport()
+	slow := NewMetricsPusher(types.PushTarget{Type: types.PushInfluxDB, URL: srv.URL, BatchSize: 1}, "r1")
+	r := newMultiReport([]string{"fast", "push"}, []ReportService{fast, slow})
+	if err := r.Init(false); err != nil {
+		t.Fatal(err)
+	}
+
+	const size = 16
+	input := make(chan *types.ScenarioResult, size)
+	go r.Start(input)
+
+	// Отправка первого пакета блокируется приёмником, следующие результаты заполняют буфер вывода push
+	input <- pushResult(1, 200, "")
+	sink.wait(t, 1)
+	const total = 1 + size + 10
+	sent := make(chan struct{})
+	go func() {
+		for i := 1; i < total; i++ {
+			input <- pushResult(1, 200, "")
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fan-out is stalled by the slow output")
+	}
+
+	// Дожидается, пока буфер медленного вывода будет заполнен, а результаты сверх него отброшены
+	deadline := time.Now().Add(2 * time.Second)
+	for r.dropped[1].Load() < 10 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(sink.block)
+	close(input)
+	<-r.DoneChan()
+
+	if n := r.dropped[1].Load(); n != 10 {
+		t.Errorf("slow output: expected 10 dropped results, got %d", n)
+	}
+	if n := len(sink.bodies); n != total-10 {
+		t.Errorf("slow output: expected %d batches, got %d", total-10, n)
+	}
+	if fast.count != total || r.dropped[0].Load() != 0 {
+		t.Errorf("fast output: expected %d results, got %d and %d dropped", total, fast.count, r.dropped[0].Load())
+	}
+	if err := slow.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+// Медленный агрегирующий вывод получает все результаты и не задерживает передачу остальным:
+// необработанные результаты накапливаются в его очереди, а не отбрасываются.
+func TestMultiReportSlowAggregatingOutput(t *testing.T) {
+	fast := newCountingReport()
+	slow := newCountingReport()
+	slow.block = make(chan struct{})
+	r := newMultiReport([]string{"fast", "slow"}, []ReportService{fast, slow})
+	if err := r.Init(false); err != nil {
+		t.Fatal(err)
+	}
+	if r.Lossy() {
+		t.Error("outputs without LossyReceiver should not drop results")
+	}
+
+	const size = 16
+	input := make(chan *types.ScenarioResult, size)
+	go r.Start(input)
+
+	// Результатов во много раз больше, чем вмещает буфер вывода
+	const total = size * 100
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < total; i++ {
+			input <- pushResult(1, 200, "")
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fan-out is stalled by the slow output")
+	}
+	close(input)
+
+	// Быстрый вывод обрабатывает результаты, пока медленный заблокирован
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		fast.mu.Lock()
+		n := fast.count
+		fast.mu.Unlock()
+		if n == total {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("fast output got %d of %d results while the slow output is blocked", n, total)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(slow.block)
+	<-r.DoneChan()
+
+	for i, rs := range []*countingReport{fast, slow} {
+		if rs.count != total || r.dropped[i].Load() != 0 {
+			t.Errorf("%s output: expected %d results, got %d and %d dropped", r.names[i], total, rs.count, r.dropped[i].Load())
+		}
+	}
+}
+
+// Выводы export и push включаются параметрами и получают результаты вместе с выводом из списка.
+func TestNewReportServicesEnabledOutputs(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	rs, err := NewReportServices([]string{OutputTypeJUnit}, Options{
+		Destination:  filepath.Join(t.TempDir(), "junit.xml"),
+		ResultExport: types.ResultExport{Path: path},
+		Push:         []types.PushTarget{{Type: types.PushInfluxDB, URL: srv.URL}},
+		RunID:        "42",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, ok := rs.(*multiReport)
+	if !ok {
+		t.Fatalf("expected several outputs, got %T", rs)
+	}
+	if got := strings.Join(r.names, ","); got != "junit,export,push" {
+		t.Errorf("unexpected outputs %s", got)
+	}
+	if err := rs.Init(false); err != nil {
+		t.Fatal(err)
+	}
+
+	input := make(chan *types.ScenarioResult, 2)
+	go rs.Start(input)
+	input <- pushResult(1, 200, "")
+	input <- pushResult(2, 0, types.ErrorConn)
+	close(input)
+	<-rs.DoneChan()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []RequestRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec RequestRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 2 || records[1].StepID != 2 || records[1].ErrorType != types.ErrorConn {
+		t.Errorf("unexpected exported records: %+v", records)
+	}
+	if len(sink.bodies) != 1 || strings.Count(sink.bodies[0], "run_id=42,") != 2 {
+		t.Errorf("unexpected pushed metrics: %q", sink.bodies)
+	}
+	if res := rs.(ResultProvider).Result(); res.SuccessCount+res.FailedCount != 2 {
+		t.Errorf("junit output should get all results")
+	}
+
+	if _, err := NewReportServices([]string{OutputTypePush}, Options{}); err == nil {
+		t.Error("push output without targets should fail")
+	}
+}
@@ -0,0 +1,89 @@
+package report
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Максимальное количество значений, хранимых для расчёта процентилей.
+// При большем количестве значений используется случайная выборка (reservoir sampling).
+const maxReservoirSize = 10000
+
+// Максимальное количество сырых замеров запросов, сохраняемых с результатом теста (Result.KeepSamples).
+// При большем количестве запросов сохраняется случайная выборка.
+const maxSamples = 10000
+
+// Процентили, рассчитываемые для длительностей
+var percentileKeys = map[string]float64{
+	"p50": 50,
+	"p90": 90,
+	"p95": 95,
+	"p99": 99,
+}
+
+// reservoir хранит равномерную случайную выборку значений ограниченного размера.
+type reservoir struct {
+	values []float32
+	count  int64
+}
+
+func (rv *reservoir) add(v float32) {
+	rv.count++
+	if len(rv.values) < maxReservoirSize {
+		rv.values = append(rv.values, v)
+		return
+	}
+	if j := rand.Int63n(rv.count); j < maxReservoirSize {
+		rv.values[j] = v
+	}
+}
+
+// percentiles возвращает значения процентилей из percentileKeys методом ближайшего ранга.
+func (rv *reservoir) percentiles() map[string]float32 {
+	res := make(map[string]float32, len(percentileKeys))
+	if rv == nil || len(rv.values) == 0 {
+		for k := range percentileKeys {
+			res[k] = 0
+		}
+		return res
+	}
+
+	sorted := make([]float32, len(rv.values))
+	copy(sorted, rv.values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	for k, p := range percentileKeys {
+		idx := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		res[k] = sorted[idx]
+	}
+	return res
+}
+
+// sampleReservoir хранит равномерную случайную выборку замеров запросов ограниченного размера.
+type sampleReservoir struct {
+	values []RequestSample
+	count  int64
+}
+
+func (rv *sampleReservoir) add(s RequestSample) {
+	rv.count++
+	if len(rv.values) < maxSamples {
+		rv.values = append(rv.values, s)
+		return
+	}
+	if j := rand.Int63n(rv.count); j < maxSamples {
+		rv.values[j] = s
+	}
+}
+
+// sorted возвращает копию выборки, упорядоченную по времени запроса.
+func (rv *sampleReservoir) sorted() []RequestSample {
+	res := make([]RequestSample, len(rv.values))
+	copy(res, rv.values)
+	sort.Slice(res, func(i, j int) bool { return res[i].Time.Before(res[j].Time) })
+	return res
+}
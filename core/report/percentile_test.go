@@ -0,0 +1,47 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleReservoirIsCapped(t *testing.T) {
+	var rv sampleReservoir
+	start := time.Now()
+	total := maxSamples * 3
+	for i := 0; i < total; i++ {
+		rv.add(RequestSample{Time: start.Add(time.Duration(i) * time.Millisecond), StepID: uint16(i % 2)})
+	}
+	if len(rv.values) != maxSamples || rv.count != int64(total) {
+		t.Fatalf("expected %d of %d samples, got %d of %d", maxSamples, total, len(rv.values), rv.count)
+	}
+
+	sorted := rv.sorted()
+	late := 0
+	for i, s := range sorted {
+		if i > 0 && s.Time.Before(sorted[i-1].Time) {
+			t.Fatalf("samples are not sorted by time at %d", i)
+		}
+		if s.Time.Sub(start) >= time.Duration(maxSamples)*time.Millisecond {
+			late++
+		}
+	}
+	// Выборка равномерна: около двух третей замеров приходится на запросы после заполнения выборки
+	if late < maxSamples/2 || late > maxSamples*5/6 {
+		t.Errorf("sample is not uniform: %d of %d samples are from the last two thirds", late, maxSamples)
+	}
+}
+
+func TestSummarySamples(t *testing.T) {
+	r := &Result{KeepSamples: true}
+	r.samples.add(RequestSample{StepID: 1})
+	s := r.Summary(nil)
+	if len(s.Samples) != 1 || s.SampleCount != 1 {
+		t.Errorf("expected 1 sample, got %d of %d", len(s.Samples), s.SampleCount)
+	}
+
+	r.KeepSamples = false
+	if s := r.Summary(nil); s.Samples != nil || s.SampleCount != 0 {
+		t.Errorf("samples should not be stored, got %d", len(s.Samples))
+	}
+}
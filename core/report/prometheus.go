@@ -0,0 +1,297 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"httes/core/types"
+)
+
+const (
+	OutputTypePrometheus = "prometheus"
+
+	// Адрес HTTP-сервера метрик по умолчанию
+	DefaultMetricsAddr = ":9090"
+)
+
+func init() {
+	AvailableOutputServices[OutputTypePrometheus] = func(opts Options) ReportService {
+		return NewMetricsExporter(opts.MetricsAddr)
+	}
+}
+
+// Границы корзин гистограмм длительностей в секундах
+var metricsBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Фазы запроса, для которых строятся гистограммы, и соответствующие ключи ScenarioStepResult.Custom
+var metricsPhases = []struct{ name, key string }{
+	{"dns", "dnsDuration"},
+	{"conn", "connDuration"},
+	{"tls", "tlsDuration"},
+	{"request_write", "reqDuration"},
+	{"server_processing", "serverProcessDuration"},
+	{"response_read", "resDuration"},
+}
+
+type requestKey struct {
+	stepID    uint16
+	stepName  string
+	status    int
+	errorType string
+}
+
+type phaseKey struct {
+	stepID uint16
+	phase  string
+}
+
+type iterationKey struct {
+	scenario string
+	success  bool
+}
+
+// histogram — накопительная гистограмма в формате Prometheus.
+type histogram struct {
+	counts []int64 // Количество значений в каждой корзине metricsBuckets
+	count  int64
+	sum    float64
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range metricsBuckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// MetricsExporter — сервис отчётов, публикующий метрики выполняющегося теста в формате Prometheus по HTTP.
+// Результаты итераций он получает из общего потока результатов, как и остальные выводы,
+// а число выполняющихся итераций и целевую интенсивность — от движка через SetLoadStats.
+type MetricsExporter struct {
+	addr     string
+	server   *http.Server
+	listener net.Listener
+	doneChan chan struct{}
+	stopOnce sync.Once
+
+	mu         sync.Mutex
+	requests   map[requestKey]int64
+	phases     map[phaseKey]*histogram
+	iterations map[iterationKey]int64
+	load       LoadStats
+}
+
+// NewMetricsExporter создаёт сервис метрик Prometheus по адресу addr, например ":9090".
+// Если addr пуст, используется DefaultMetricsAddr. Метрики доступны по пути /metrics после Init.
+func NewMetricsExporter(addr string) *MetricsExporter {
+	if addr == "" {
+		addr = DefaultMetricsAddr
+	}
+	return &MetricsExporter{
+		addr:       addr,
+		doneChan:   make(chan struct{}),
+		requests:   map[requestKey]int64{},
+		phases:     map[phaseKey]*histogram{},
+		iterations: map[iterationKey]int64{},
+	}
+}
+
+// Init начинает принимать запросы метрик.
+func (m *MetricsExporter) Init(debug bool) error {
+	l, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("metrics listener: %v", err)
+	}
+	m.listener = l
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go m.server.Serve(l)
+	fmt.Println("Metrics are available at http://" + m.Addr() + "/metrics")
+	return nil
+}
+
+// Addr возвращает фактический адрес, на котором доступны метрики.
+func (m *MetricsExporter) Addr() string {
+	if m.listener == nil {
+		return m.addr
+	}
+	return m.listener.Addr().String()
+}
+
+// ServeHTTP отвечает на запрос метрик текущими значениями.
+func (m *MetricsExporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteMetrics(w)
+}
+
+// SetLoadStats задаёт источник значений httes_iterations_in_flight и httes_target_iterations_per_second.
+func (m *MetricsExporter) SetLoadStats(stats LoadStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.load = stats
+}
+
+// Start учитывает результаты итераций в метриках до закрытия input.
+func (m *MetricsExporter) Start(input chan *types.ScenarioResult) {
+	defer close(m.doneChan)
+	for scr := range input {
+		m.observe(scr)
+	}
+}
+
+func (m *MetricsExporter) DoneChan() <-chan struct{} {
+	return m.doneChan
+}
+
+// Stop останавливает HTTP-сервер метрик.
+func (m *MetricsExporter) Stop() {
+	m.stopOnce.Do(func() {
+		if m.server == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		m.server.Shutdown(ctx)
+	})
+}
+
+// Lossy сообщает, что при перегрузке часть результатов может не учитываться в метриках.
+func (m *MetricsExporter) Lossy() bool {
+	return true
+}
+
+func (m *MetricsExporter) observe(scr *types.ScenarioResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	success := true
+	for _, sr := range scr.StepResults {
+		if sr.Skipped {
+			continue
+		}
+		if sr.Err.Type != "" {
+			success = false
+		}
+		m.requests[requestKey{stepID: sr.StepID, stepName: sr.StepName, status: sr.StatusCode, errorType: sr.Err.Type}]++
+
+		m.phaseHistogram(sr.StepID, "total").observe(sr.Duration.Seconds())
+		for _, p := range metricsPhases {
+			if d, ok := sr.Custom[p.key].(time.Duration); ok {
+				m.phaseHistogram(sr.StepID, p.name).observe(d.Seconds())
+			}
+		}
+	}
+	m.iterations[iterationKey{scenario: scr.ScenarioName, success: success}]++
+}
+
+func (m *MetricsExporter) phaseHistogram(stepID uint16, phase string) *histogram {
+	k := phaseKey{stepID: stepID, phase: phase}
+	h, ok := m.phases[k]
+	if !ok {
+		h = &histogram{counts: make([]int64, len(metricsBuckets))}
+		m.phases[k] = h
+	}
+	return h
+}
+
+// WriteMetrics записывает текущие значения метрик в текстовом формате Prometheus.
+func (m *MetricsExporter) WriteMetrics(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP httes_requests_total Requests by step, status code and error type.")
+	fmt.Fprintln(w, "# TYPE httes_requests_total counter")
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		a, b := reqKeys[i], reqKeys[j]
+		if a.stepID != b.stepID {
+			return a.stepID < b.stepID
+		}
+		if a.status != b.status {
+			return a.status < b.status
+		}
+		return a.errorType < b.errorType
+	})
+	for _, k := range reqKeys {
+		fmt.Fprintf(w, "httes_requests_total{step=\"%d\",step_name=\"%s\",status=\"%d\",error_type=\"%s\"} %d\n",
+			k.stepID, escapeLabel(k.stepName), k.status, escapeLabel(k.errorType), m.requests[k])
+	}
+
+	fmt.Fprintln(w, "# HELP httes_iterations_total Finished iterations by scenario and result.")
+	fmt.Fprintln(w, "# TYPE httes_iterations_total counter")
+	itKeys := make([]iterationKey, 0, len(m.iterations))
+	for k := range m.iterations {
+		itKeys = append(itKeys, k)
+	}
+	sort.Slice(itKeys, func(i, j int) bool {
+		if itKeys[i].scenario != itKeys[j].scenario {
+			return itKeys[i].scenario < itKeys[j].scenario
+		}
+		return itKeys[i].success
+	})
+	for _, k := range itKeys {
+		result := "failed"
+		if k.success {
+			result = "success"
+		}
+		fmt.Fprintf(w, "httes_iterations_total{scenario=\"%s\",result=\"%s\"} %d\n", escapeLabel(k.scenario), result, m.iterations[k])
+	}
+
+	fmt.Fprintln(w, "# HELP httes_request_duration_seconds Request duration by step and phase.")
+	fmt.Fprintln(w, "# TYPE httes_request_duration_seconds histogram")
+	phKeys := make([]phaseKey, 0, len(m.phases))
+	for k := range m.phases {
+		phKeys = append(phKeys, k)
+	}
+	sort.Slice(phKeys, func(i, j int) bool {
+		if phKeys[i].stepID != phKeys[j].stepID {
+			return phKeys[i].stepID < phKeys[j].stepID
+		}
+		return phKeys[i].phase < phKeys[j].phase
+	})
+	for _, k := range phKeys {
+		h := m.phases[k]
+		labels := fmt.Sprintf("step=\"%d\",phase=\"%s\"", k.stepID, k.phase)
+		for i, b := range metricsBuckets {
+			fmt.Fprintf(w, "httes_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(b, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(w, "httes_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(w, "httes_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "httes_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	var inFlight int64
+	var targetRate float64
+	if m.load != nil {
+		inFlight, targetRate = m.load.InFlight(), m.load.TargetRate()
+	}
+	fmt.Fprintln(w, "# HELP httes_iterations_in_flight Iterations being executed.")
+	fmt.Fprintln(w, "# TYPE httes_iterations_in_flight gauge")
+	fmt.Fprintf(w, "httes_iterations_in_flight %d\n", inFlight)
+
+	fmt.Fprintln(w, "# HELP httes_target_iterations_per_second Current target rate of the load profile.")
+	fmt.Fprintln(w, "# TYPE httes_target_iterations_per_second gauge")
+	fmt.Fprintf(w, "httes_target_iterations_per_second %s\n", strconv.FormatFloat(targetRate, 'g', -1, 64))
+}
+
+// Экранирование значений меток по правилам текстового формата Prometheus
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
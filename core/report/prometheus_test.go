@@ -0,0 +1,57 @@
+package report
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"httes/core/types"
+)
+
+type fixedLoad struct{}
+
+func (fixedLoad) InFlight() int64     { return 3 }
+func (fixedLoad) TargetRate() float64 { return 12.5 }
+
+func TestMetricsExporterWriteMetrics(t *testing.T) {
+	m := NewMetricsExporter("127.0.0.1:0")
+	m.SetLoadStats(fixedLoad{})
+
+	input := make(chan *types.ScenarioResult, 3)
+	input <- &types.ScenarioResult{ScenarioName: "shop", StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: `say "hi"`, StatusCode: 200, Duration: 20 * time.Millisecond,
+			Custom: map[string]interface{}{"dnsDuration": 2 * time.Millisecond}},
+		{StepID: 2, Skipped: true},
+	}}
+	input <- &types.ScenarioResult{ScenarioName: "shop", StepResults: []*types.ScenarioStepResult{
+		{StepID: 1, StepName: `say "hi"`, Duration: 3 * time.Second, Err: types.RequestError{Type: types.ErrorConn}},
+	}}
+	close(input)
+	m.Start(input)
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		`httes_requests_total{step="1",step_name="say \"hi\"",status="0",error_type="connectionError"} 1`,
+		`httes_requests_total{step="1",step_name="say \"hi\"",status="200",error_type=""} 1`,
+		`httes_iterations_total{scenario="shop",result="success"} 1`,
+		`httes_iterations_total{scenario="shop",result="failed"} 1`,
+		`httes_request_duration_seconds_bucket{step="1",phase="total",le="0.025"} 1`,
+		`httes_request_duration_seconds_bucket{step="1",phase="total",le="2.5"} 1`,
+		`httes_request_duration_seconds_bucket{step="1",phase="total",le="5"} 2`,
+		`httes_request_duration_seconds_sum{step="1",phase="total"} 3.02`,
+		`httes_request_duration_seconds_count{step="1",phase="dns"} 1`,
+		`httes_iterations_in_flight 3`,
+		`httes_target_iterations_per_second 12.5`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics do not contain %q:\n%s", line, body)
+		}
+	}
+	// Пропущенные шаги не учитываются
+	if strings.Contains(body, `step="2"`) {
+		t.Errorf("skipped step is counted:\n%s", body)
+	}
+}
@@ -0,0 +1,559 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"httes/core/types"
+
+	"github.com/google/uuid"
+)
+
+const OutputTypePush = "push"
+
+func init() {
+	AvailableOutputServices[OutputTypePush] = func(opts Options) ReportService {
+		return NewPushReportService(opts.Push, opts.RunID)
+	}
+}
+
+// Максимальный размер UDP-пакета с метриками, чтобы пакет помещался в MTU без фрагментации
+const maxUDPPacket = 1432
+
+// pushPoint — результат одного запроса для отправки во внешнюю систему.
+type pushPoint struct {
+	time          time.Time
+	tags          [][2]string // Пары ключ-значение, отсортированные по ключу
+	duration      float64     // Секунды
+	phases        map[string]float64
+	contentLength int64
+	failed        bool
+}
+
+// tagKey возвращает строковое представление тегов для группировки точек.
+func (p pushPoint) tagKey() string {
+	var b strings.Builder
+	for _, t := range p.tags {
+		b.WriteString(t[0])
+		b.WriteByte('=')
+		b.WriteString(t[1])
+		b.WriteByte(',')
+	}
+	return b.String()
+}
+
+// MetricsPusher — сервис отчётов, отправляющий результаты запросов во внешнюю систему мониторинга пакетами.
+// Результаты он получает из общего потока результатов, как и остальные выводы.
+type MetricsPusher struct {
+	target   types.PushTarget
+	tags     map[string]string
+	doneChan chan struct{}
+	stopOnce sync.Once
+
+	encode    func([]pushPoint) [][]byte
+	transport pushTransport
+
+	errMu   sync.Mutex
+	sendErr error // Последняя ошибка отправки
+	failed  int64 // Количество неотправленных сообщений
+}
+
+// NewMetricsPusher создаёт сервис отправки результатов в target. Все метрики помечаются тегом run_id.
+func NewMetricsPusher(target types.PushTarget, runID string) *MetricsPusher {
+	if target.BatchSize <= 0 {
+		target.BatchSize = types.DefaultPushBatchSize
+	}
+	if target.FlushInterval <= 0 {
+		target.FlushInterval = types.DefaultPushFlushInterval
+	}
+	if target.Prefix == "" {
+		target.Prefix = "httes"
+	}
+
+	p := &MetricsPusher{
+		target:   target,
+		tags:     map[string]string{"run_id": runID},
+		doneChan: make(chan struct{}),
+	}
+	for k, v := range target.Tags {
+		p.tags[k] = v
+	}
+	return p
+}
+
+// Init выбирает формат и подключается к приёмнику метрик.
+func (p *MetricsPusher) Init(debug bool) error {
+	u, err := url.Parse(p.target.URL)
+	if err != nil {
+		return err
+	}
+	contentType := "text/plain; charset=utf-8"
+	switch p.target.Type {
+	case types.PushInfluxDB:
+		p.encode = p.encodeInflux
+	case types.PushStatsD, types.PushDogStatsD:
+		p.encode = p.encodeStatsD
+	case types.PushOTLP:
+		p.encode = p.encodeOTLP
+		contentType = "application/json"
+	default:
+		return fmt.Errorf("unsupported push type: %s", p.target.Type)
+	}
+
+	if u.Scheme == "udp" {
+		conn, err := net.Dial("udp", u.Host)
+		if err != nil {
+			return fmt.Errorf("%s push: %v", p.target.Type, err)
+		}
+		p.transport = &udpTransport{conn: conn}
+	} else {
+		p.transport = &httpTransport{
+			url:         p.target.URL,
+			headers:     p.target.Headers,
+			contentType: contentType,
+			client:      &http.Client{Timeout: 5 * time.Second},
+		}
+	}
+	return nil
+}
+
+// Start отправляет результаты итераций пакетами по BatchSize или раз в FlushInterval до закрытия input,
+// затем отправляет оставшиеся результаты и закрывает соединение.
+func (p *MetricsPusher) Start(input chan *types.ScenarioResult) {
+	defer p.Stop()
+	if input == nil || p.transport == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Duration(p.target.FlushInterval) * time.Millisecond)
+	defer ticker.Stop()
+
+	batch := make([]pushPoint, 0, p.target.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		for _, msg := range p.encode(batch) {
+			if err := p.transport.send(msg); err != nil {
+				p.errMu.Lock()
+				p.sendErr = err
+				p.failed++
+				p.errMu.Unlock()
+			}
+		}
+		batch = batch[:0]
+	}
+
+loop:
+	for {
+		select {
+		case scr, ok := <-input:
+			if !ok {
+				flush()
+				break loop
+			}
+			batch = append(batch, p.points(scr)...)
+			if len(batch) >= p.target.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+	p.transport.close()
+	if err := p.Err(); err != nil {
+		fmt.Println("Warning: failed to push metrics:", err)
+	}
+}
+
+// Err возвращает последнюю ошибку отправки, если часть сообщений не была доставлена.
+func (p *MetricsPusher) Err() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+	if p.sendErr != nil {
+		return fmt.Errorf("%s push: %d message(s) not sent, last error: %v", p.target.Type, p.failed, p.sendErr)
+	}
+	return nil
+}
+
+func (p *MetricsPusher) DoneChan() <-chan struct{} {
+	return p.doneChan
+}
+
+func (p *MetricsPusher) Stop() {
+	p.stopOnce.Do(func() { close(p.doneChan) })
+}
+
+// Lossy сообщает, что при перегрузке часть результатов может не отправляться: итоговые результаты
+// теста собирают другие выводы.
+func (p *MetricsPusher) Lossy() bool {
+	return true
+}
+
+// NewPushReportService создаёт сервис отправки результатов во все targets. У каждого приёмника свой буфер,
+// поэтому недоступный приёмник не задерживает отправку в остальные. Если runID пуст, создаётся случайный.
+func NewPushReportService(targets []types.PushTarget, runID string) ReportService {
+	if runID == "" {
+		runID = uuid.New().String()[:8]
+	}
+	names := make([]string, 0, len(targets))
+	services := make([]ReportService, 0, len(targets))
+	for _, t := range targets {
+		names = append(names, OutputTypePush+" "+t.Type)
+		services = append(services, NewMetricsPusher(t, runID))
+	}
+	if len(services) == 1 {
+		return services[0]
+	}
+	return newMultiReport(names, services)
+}
+
+// points создаёт точки для всех выполненных шагов итерации.
+func (p *MetricsPusher) points(scr *types.ScenarioResult) []pushPoint {
+	res := make([]pushPoint, 0, len(scr.StepResults))
+	for _, sr := range scr.StepResults {
+		if sr.Skipped {
+			continue
+		}
+		tags := map[string]string{
+			"scenario":   scr.ScenarioName,
+			"step":       strconv.Itoa(int(sr.StepID)),
+			"step_name":  sr.StepName,
+			"status":     strconv.Itoa(sr.StatusCode),
+			"error_type": sr.Err.Type,
+		}
+		if scr.ProxyAddr != nil {
+			tags["proxy"] = scr.ProxyAddr.Host
+		}
+		for k, v := range p.tags {
+			tags[k] = v
+		}
+
+		pt := pushPoint{
+			time:          sr.RequestTime,
+			duration:      sr.Duration.Seconds(),
+			phases:        make(map[string]float64, len(metricsPhases)),
+			contentLength: sr.ContentLength,
+			failed:        sr.Err.Type != "",
+		}
+		if pt.time.IsZero() {
+			// Запрос не был отправлен, например из-за ошибки подключения к прокси
+			pt.time = time.Now()
+		}
+		for k, v := range tags {
+			if v != "" {
+				pt.tags = append(pt.tags, [2]string{k, v})
+			}
+		}
+		sort.Slice(pt.tags, func(i, j int) bool { return pt.tags[i][0] < pt.tags[j][0] })
+		for _, ph := range metricsPhases {
+			if d, ok := sr.Custom[ph.key].(time.Duration); ok {
+				pt.phases[ph.name] = d.Seconds()
+			}
+		}
+		res = append(res, pt)
+	}
+	return res
+}
+
+// Экранирование имён и тегов line protocol InfluxDB
+var (
+	influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagEscaper         = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+)
+
+// encodeInflux кодирует пакет в line protocol InfluxDB: одна строка на запрос, время в наносекундах.
+func (p *MetricsPusher) encodeInflux(batch []pushPoint) [][]byte {
+	lines := make([][]byte, 0, len(batch))
+	measurement := influxMeasurementEscaper.Replace(p.target.Prefix + "_request")
+	for _, pt := range batch {
+		var b bytes.Buffer
+		b.WriteString(measurement)
+		for _, t := range pt.tags {
+			fmt.Fprintf(&b, ",%s=%s", influxTagEscaper.Replace(t[0]), influxTagEscaper.Replace(t[1]))
+		}
+		fmt.Fprintf(&b, " duration=%s", formatFloat(pt.duration))
+		for _, ph := range metricsPhases {
+			if v, ok := pt.phases[ph.name]; ok {
+				fmt.Fprintf(&b, ",%s=%s", ph.name, formatFloat(v))
+			}
+		}
+		fmt.Fprintf(&b, ",content_length=%di,failed=%t %d\n", pt.contentLength, pt.failed, pt.time.UnixNano())
+		lines = append(lines, b.Bytes())
+	}
+	return p.pack(lines)
+}
+
+// encodeStatsD кодирует пакет в формат StatsD: длительности в миллисекундах (|ms) и счётчики запросов и ошибок.
+// Для DogStatsD теги передаются в формате |#key:value, для StatsD ID шага включается в имя метрики.
+func (p *MetricsPusher) encodeStatsD(batch []pushPoint) [][]byte {
+	dog := p.target.Type == types.PushDogStatsD
+	lines := make([][]byte, 0, len(batch)*3)
+	for _, pt := range batch {
+		prefix, suffix := p.target.Prefix+".", ""
+		if dog {
+			tags := make([]string, 0, len(pt.tags))
+			for _, t := range pt.tags {
+				tags = append(tags, statsdTag(t[0])+":"+statsdTag(t[1]))
+			}
+			suffix = "|#" + strings.Join(tags, ",")
+		} else {
+			for _, t := range pt.tags {
+				if t[0] == "step" {
+					prefix += "step_" + t[1] + "."
+				}
+			}
+		}
+
+		metric := func(name, value, kind string) {
+			lines = append(lines, []byte(prefix+name+":"+value+"|"+kind+suffix+"\n"))
+		}
+		metric("request.duration", formatFloat(pt.duration*1000), "ms")
+		for _, ph := range metricsPhases {
+			if v, ok := pt.phases[ph.name]; ok {
+				metric("request."+ph.name, formatFloat(v*1000), "ms")
+			}
+		}
+		metric("requests", "1", "c")
+		if pt.failed {
+			metric("errors", "1", "c")
+		}
+	}
+	return p.pack(lines)
+}
+
+// statsdTag заменяет символы, недопустимые в тегах DogStatsD.
+func statsdTag(s string) string {
+	return strings.NewReplacer("|", "_", ",", "_", "#", "_", ":", "_", "\n", "_").Replace(s)
+}
+
+// encodeOTLP кодирует пакет в запрос OTLP/HTTP JSON (ExportMetricsServiceRequest).
+// Для каждого набора тегов передаются дельта-счётчик запросов и ошибок и гистограмма длительности.
+func (p *MetricsPusher) encodeOTLP(batch []pushPoint) [][]byte {
+	type group struct {
+		attrs    []otlpKeyValue
+		requests int64
+		errors   int64
+		hist     histogram
+	}
+	groups := map[string]*group{}
+	var keys []string
+	start, end := batch[0].time, batch[0].time
+	for _, pt := range batch {
+		k := pt.tagKey()
+		g, ok := groups[k]
+		if !ok {
+			g = &group{hist: histogram{counts: make([]int64, len(metricsBuckets))}}
+			for _, t := range pt.tags {
+				g.attrs = append(g.attrs, otlpKeyValue{Key: t[0], Value: otlpValue{StringValue: t[1]}})
+			}
+			groups[k] = g
+			keys = append(keys, k)
+		}
+		g.requests++
+		if pt.failed {
+			g.errors++
+		}
+		g.hist.observe(pt.duration)
+		if pt.time.Before(start) {
+			start = pt.time
+		}
+		if pt.time.After(end) {
+			end = pt.time
+		}
+	}
+	sort.Strings(keys)
+
+	startNano, endNano := strconv.FormatInt(start.UnixNano(), 10), strconv.FormatInt(end.UnixNano(), 10)
+	requests := otlpSum{Temporality: 1, Monotonic: true}
+	errors := otlpSum{Temporality: 1, Monotonic: true}
+	durations := otlpHistogram{Temporality: 1}
+	for _, k := range keys {
+		g := groups[k]
+		requests.DataPoints = append(requests.DataPoints, otlpNumberPoint{Attributes: g.attrs, Start: startNano, Time: endNano, Value: strconv.FormatInt(g.requests, 10)})
+		errors.DataPoints = append(errors.DataPoints, otlpNumberPoint{Attributes: g.attrs, Start: startNano, Time: endNano, Value: strconv.FormatInt(g.errors, 10)})
+
+		// В OTLP количество указывается для каждой корзины отдельно, а не накопительно
+		buckets := make([]string, 0, len(metricsBuckets)+1)
+		prev := int64(0)
+		for _, c := range g.hist.counts {
+			buckets = append(buckets, strconv.FormatInt(c-prev, 10))
+			prev = c
+		}
+		buckets = append(buckets, strconv.FormatInt(g.hist.count-prev, 10))
+		durations.DataPoints = append(durations.DataPoints, otlpHistogramPoint{
+			Attributes: g.attrs, Start: startNano, Time: endNano,
+			Count: strconv.FormatInt(g.hist.count, 10), Sum: g.hist.sum,
+			BucketCounts: buckets, ExplicitBounds: metricsBuckets,
+		})
+	}
+
+	req := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{{Key: "service.name", Value: otlpValue{StringValue: "httes"}}}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope: otlpScope{Name: "httes"},
+			Metrics: []otlpMetric{
+				{Name: p.target.Prefix + ".requests", Unit: "1", Sum: &requests},
+				{Name: p.target.Prefix + ".errors", Unit: "1", Sum: &errors},
+				{Name: p.target.Prefix + ".request.duration", Unit: "s", Histogram: &durations},
+			},
+		}},
+	}}}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil
+	}
+	return [][]byte{b}
+}
+
+// pack объединяет строки в сообщения: для UDP — пакеты не больше maxUDPPacket, для HTTP — одно сообщение.
+func (p *MetricsPusher) pack(lines [][]byte) [][]byte {
+	if _, ok := p.transport.(*udpTransport); !ok {
+		return [][]byte{bytes.Join(lines, nil)}
+	}
+	var res [][]byte
+	var cur []byte
+	for _, l := range lines {
+		if len(cur) > 0 && len(cur)+len(l) > maxUDPPacket {
+			res = append(res, cur)
+			cur = nil
+		}
+		cur = append(cur, l...)
+	}
+	if len(cur) > 0 {
+		res = append(res, cur)
+	}
+	return res
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// pushTransport доставляет закодированные сообщения в приёмник.
+type pushTransport interface {
+	send(msg []byte) error
+	close() error
+}
+
+type udpTransport struct {
+	conn net.Conn
+}
+
+func (t *udpTransport) send(msg []byte) error {
+	_, err := t.conn.Write(msg)
+	return err
+}
+
+func (t *udpTransport) close() error {
+	return t.conn.Close()
+}
+
+type httpTransport struct {
+	url         string
+	headers     map[string]string
+	contentType string
+	client      *http.Client
+}
+
+func (t *httpTransport) send(msg []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(msg))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", t.contentType)
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+// Структуры OTLP/HTTP JSON. Целые 64-битные значения кодируются строками согласно спецификации.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Unit      string         `json:"unit"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints  []otlpNumberPoint `json:"dataPoints"`
+	Temporality int               `json:"aggregationTemporality"` // 1 — DELTA
+	Monotonic   bool              `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints  []otlpHistogramPoint `json:"dataPoints"`
+	Temporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpNumberPoint struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+	Start      string         `json:"startTimeUnixNano"`
+	Time       string         `json:"timeUnixNano"`
+	Value      string         `json:"asInt"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes     []otlpKeyValue `json:"attributes"`
+	Start          string         `json:"startTimeUnixNano"`
+	Time           string         `json:"timeUnixNano"`
+	Count          string         `json:"count"`
+	Sum            float64        `json:"sum"`
+	BucketCounts   []string       `json:"bucketCounts"`
+	ExplicitBounds []float64      `json:"explicitBounds"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
@@ -0,0 +1,346 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"httes/core/types"
+)
+
+// httpSink — HTTP-приёмник метрик, сохраняющий тела запросов.
+type httpSink struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	received chan struct{}
+	status   int
+	block    chan struct{} // Если задан, ответ задерживается до закрытия канала
+}
+
+func startHTTPSink(t *testing.T) (*httpSink, *httptest.Server) {
+	t.Helper()
+	s := &httpSink{received: make(chan struct{}, 1024), status: http.StatusNoContent}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *httpSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.bodies = append(s.bodies, string(body))
+	s.headers = append(s.headers, r.Header.Clone())
+	block := s.block
+	s.mu.Unlock()
+	s.received <- struct{}{}
+	if block != nil {
+		<-block
+	}
+	w.WriteHeader(s.status)
+}
+
+func (s *httpSink) wait(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-s.received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %d requests, got %d", n, i)
+		}
+	}
+}
+
+// udpSink читает пакеты метрик из UDP-сокета.
+func startUDPSink(t *testing.T) (net.PacketConn, string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, "udp://" + conn.LocalAddr().String()
+}
+
+func readPackets(t *testing.T, conn net.PacketConn) []string {
+	t.Helper()
+	var packets []string
+	buf := make([]byte, 64*1024)
+	for {
+		conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return packets
+		}
+		packets = append(packets, string(buf[:n]))
+	}
+}
+
+var pushTime = time.Unix(1700000000, 123)
+
+// pushResult возвращает итерацию из одного шага.
+func pushResult(stepID uint16, status int, errType string) *types.ScenarioResult {
+	return &types.ScenarioResult{
+		ScenarioName: "shop",
+		StepResults: []*types.ScenarioStepResult{{
+			StepID:        stepID,
+			StepName:      "get order",
+			RequestTime:   pushTime,
+			StatusCode:    status,
+			Duration:      20 * time.Millisecond,
+			ContentLength: 42,
+			Err:           types.RequestError{Type: errType},
+			Custom:        map[string]interface{}{"dnsDuration": 2 * time.Millisecond},
+		}},
+	}
+}
+
+// runningPusher — сервис отправки, запущенный на собственном канале результатов.
+type runningPusher struct {
+	*MetricsPusher
+	input chan *types.ScenarioResult
+}
+
+func newPusher(t *testing.T, target types.PushTarget) runningPusher {
+	t.Helper()
+	p := runningPusher{MetricsPusher: NewMetricsPusher(target, "r1"), input: make(chan *types.ScenarioResult, 1024)}
+	if err := p.Init(false); err != nil {
+		t.Fatal(err)
+	}
+	go p.Start(p.input)
+	return p
+}
+
+func (p runningPusher) observe(scr *types.ScenarioResult) {
+	p.input <- scr
+}
+
+// close дожидается отправки всех результатов и возвращает ошибку отправки.
+func (p runningPusher) close() error {
+	close(p.input)
+	<-p.DoneChan()
+	return p.Err()
+}
+
+func TestPushInfluxLineProtocol(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	p := newPusher(t, types.PushTarget{
+		Type:    types.PushInfluxDB,
+		URL:     srv.URL + "/api/v2/write",
+		Headers: map[string]string{"Authorization": "Token secret"},
+		Tags:    map[string]string{"env": "stage 1,a=b"},
+	})
+	p.observe(pushResult(1, 200, ""))
+	p.observe(pushResult(2, 0, types.ErrorConn))
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.bodies) != 1 {
+		t.Fatalf("expected one batch, got %d", len(sink.bodies))
+	}
+	expected := fmt.Sprintf(""+
+		"httes_request,env=stage\\ 1\\,a\\=b,run_id=r1,scenario=shop,status=200,step=1,step_name=get\\ order duration=0.02,dns=0.002,content_length=42i,failed=false %d\n"+
+		"httes_request,env=stage\\ 1\\,a\\=b,error_type=connectionError,run_id=r1,scenario=shop,status=0,step=2,step_name=get\\ order duration=0.02,dns=0.002,content_length=42i,failed=true %d\n",
+		pushTime.UnixNano(), pushTime.UnixNano())
+	if sink.bodies[0] != expected {
+		t.Errorf("unexpected line protocol:\n%s\nexpected:\n%s", sink.bodies[0], expected)
+	}
+	if h := sink.headers[0]; h.Get("Authorization") != "Token secret" || !strings.HasPrefix(h.Get("Content-Type"), "text/plain") {
+		t.Errorf("unexpected headers: %v", h)
+	}
+}
+
+func TestPushStatsD(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      string
+		expected []string
+	}{
+		{"StatsD", types.PushStatsD, []string{
+			"load.step_1.request.duration:20|ms",
+			"load.step_1.request.dns:2|ms",
+			"load.step_1.requests:1|c",
+			"load.step_2.request.duration:20|ms",
+			"load.step_2.request.dns:2|ms",
+			"load.step_2.requests:1|c",
+			"load.step_2.errors:1|c",
+		}},
+		{"DogStatsD", types.PushDogStatsD, []string{
+			"load.request.duration:20|ms|#run_id:r1,scenario:shop,status:200,step:1,step_name:get order",
+			"load.request.dns:2|ms|#run_id:r1,scenario:shop,status:200,step:1,step_name:get order",
+			"load.requests:1|c|#run_id:r1,scenario:shop,status:200,step:1,step_name:get order",
+			"load.request.duration:20|ms|#error_type:connectionError,run_id:r1,scenario:shop,status:0,step:2,step_name:get order",
+			"load.request.dns:2|ms|#error_type:connectionError,run_id:r1,scenario:shop,status:0,step:2,step_name:get order",
+			"load.requests:1|c|#error_type:connectionError,run_id:r1,scenario:shop,status:0,step:2,step_name:get order",
+			"load.errors:1|c|#error_type:connectionError,run_id:r1,scenario:shop,status:0,step:2,step_name:get order",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, addr := startUDPSink(t)
+			p := newPusher(t, types.PushTarget{Type: test.typ, URL: addr, Prefix: "load"})
+			p.observe(pushResult(1, 200, ""))
+			p.observe(pushResult(2, 0, types.ErrorConn))
+			if err := p.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			packets := readPackets(t, conn)
+			if len(packets) != 1 {
+				t.Fatalf("expected one packet, got %d", len(packets))
+			}
+			got := strings.Split(strings.TrimSuffix(packets[0], "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("unexpected metrics:\n%s\nexpected:\n%s", strings.Join(got, "\n"), strings.Join(test.expected, "\n"))
+			}
+		})
+	}
+}
+
+// Строки пакета UDP не разрываются между пакетами, а пакеты помещаются в MTU.
+func TestPushUDPPacketSize(t *testing.T) {
+	conn, addr := startUDPSink(t)
+	p := newPusher(t, types.PushTarget{Type: types.PushInfluxDB, URL: addr})
+	const n = 100
+	for i := 0; i < n; i++ {
+		p.observe(pushResult(uint16(i), 200, ""))
+	}
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	packets := readPackets(t, conn)
+	if len(packets) < 2 {
+		t.Fatalf("expected several packets, got %d", len(packets))
+	}
+	lines := 0
+	for _, pkt := range packets {
+		if len(pkt) > maxUDPPacket {
+			t.Errorf("packet of %d bytes exceeds %d", len(pkt), maxUDPPacket)
+		}
+		if !strings.HasSuffix(pkt, "\n") {
+			t.Errorf("packet ends with a partial line: %q", pkt[len(pkt)-20:])
+		}
+		for _, l := range strings.Split(strings.TrimSuffix(pkt, "\n"), "\n") {
+			if !strings.HasPrefix(l, "httes_request,") {
+				t.Errorf("broken line %q", l)
+			}
+			lines++
+		}
+	}
+	if lines != n {
+		t.Errorf("expected %d lines, got %d", n, lines)
+	}
+}
+
+func TestPushOTLP(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	p := newPusher(t, types.PushTarget{Type: types.PushOTLP, URL: srv.URL + "/v1/metrics"})
+	p.observe(pushResult(1, 200, ""))
+	p.observe(pushResult(1, 200, ""))
+	p.observe(pushResult(2, 0, types.ErrorConn))
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.bodies) != 1 || sink.headers[0].Get("Content-Type") != "application/json" {
+		t.Fatalf("expected one json request, got %d", len(sink.bodies))
+	}
+	var req otlpRequest
+	if err := json.Unmarshal([]byte(sink.bodies[0]), &req); err != nil {
+		t.Fatal(err)
+	}
+	metrics := req.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	if len(metrics) != 3 || metrics[0].Name != "httes.requests" || metrics[1].Name != "httes.errors" || metrics[2].Name != "httes.request.duration" {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	// Точки группируются по набору тегов в порядке их строкового представления:
+	// шаг 2 с ошибкой подключения (error_type=... < run_id=...) и шаг 1 без ошибок
+	requests, errors, durations := metrics[0].Sum.DataPoints, metrics[1].Sum.DataPoints, metrics[2].Histogram.DataPoints
+	if len(requests) != 2 || requests[0].Value != "1" || requests[1].Value != "2" {
+		t.Errorf("unexpected request counters: %+v", requests)
+	}
+	if errors[0].Value != "1" || errors[1].Value != "0" {
+		t.Errorf("unexpected error counters: %+v", errors)
+	}
+	if attrs := requests[0].Attributes; len(attrs) != 6 || attrs[0].Key != "error_type" || attrs[0].Value.StringValue != types.ErrorConn {
+		t.Errorf("unexpected attributes: %+v", attrs)
+	}
+	if !metrics[0].Sum.Monotonic || metrics[0].Sum.Temporality != 1 {
+		t.Errorf("counters should be monotonic deltas")
+	}
+	h := durations[1]
+	if h.Count != "2" || h.Sum != 0.04 || len(h.BucketCounts) != len(metricsBuckets)+1 {
+		t.Fatalf("unexpected histogram: %+v", h)
+	}
+	// 20 мс попадают в корзину (0.01, 0.025]
+	for i, c := range h.BucketCounts {
+		expected := "0"
+		if i == 2 {
+			expected = "2"
+		}
+		if c != expected {
+			t.Errorf("bucket %d: expected %s, got %s", i, expected, c)
+		}
+	}
+	if requests[0].Start != fmt.Sprint(pushTime.UnixNano()) {
+		t.Errorf("unexpected start time %s", requests[0].Start)
+	}
+}
+
+func TestPushBatching(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	p := newPusher(t, types.PushTarget{Type: types.PushInfluxDB, URL: srv.URL, BatchSize: 2, FlushInterval: 60000})
+
+	// Полные пакеты отправляются сразу, не дожидаясь интервала
+	for i := 0; i < 4; i++ {
+		p.observe(pushResult(1, 200, ""))
+	}
+	sink.wait(t, 2)
+	// Неполный пакет отправляется при закрытии
+	p.observe(pushResult(1, 200, ""))
+	if err := p.close(); err != nil {
+		t.Fatal(err)
+	}
+	sink.wait(t, 1)
+	for i, b := range sink.bodies {
+		expected := 2
+		if i == 2 {
+			expected = 1
+		}
+		if n := strings.Count(b, "\n"); n != expected {
+			t.Errorf("batch %d: expected %d lines, got %d", i, expected, n)
+		}
+	}
+}
+
+func TestPushFlushInterval(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	p := newPusher(t, types.PushTarget{Type: types.PushInfluxDB, URL: srv.URL, BatchSize: 100, FlushInterval: 20})
+	defer p.close()
+
+	p.observe(pushResult(1, 200, ""))
+	sink.wait(t, 1)
+}
+
+func TestPushSendErrors(t *testing.T) {
+	sink, srv := startHTTPSink(t)
+	sink.status = http.StatusUnauthorized
+	p := newPusher(t, types.PushTarget{Type: types.PushInfluxDB, URL: srv.URL, BatchSize: 1})
+	p.observe(pushResult(1, 200, ""))
+	p.observe(pushResult(1, 200, ""))
+
+	err := p.close()
+	if err == nil || !strings.Contains(err.Error(), "2 message(s) not sent") || !strings.Contains(err.Error(), "401") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
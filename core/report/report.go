@@ -8,7 +8,21 @@ import (
 	"fyne.io/fyne/v2/widget"
 )
 
-var AvailableOutputServices = make(map[string]func(*widget.TextGrid, *widget.ProgressBar, *widget.Label, int) ReportService)
+var AvailableOutputServices = make(map[string]func(Options) ReportService)
+
+// Options — параметры создания сервиса отчётов. Каждый сервис использует только нужные ему поля.
+type Options struct {
+	ResultGrid    *widget.TextGrid    // Вывод результатов в графическом интерфейсе
+	ProgressBar   *widget.ProgressBar // Прогресс теста в графическом интерфейсе
+	ProgressText  *widget.Label       // Текст прогресса в графическом интерфейсе
+	TotalRequests int                 // Общее количество итераций теста
+	Destination   string              // Файл для записи отчёта
+	Thresholds    []types.Threshold   // Пороговые значения метрик для итогового результата
+	MetricsAddr   string              // Адрес HTTP-сервера метрик Prometheus. Если задан, вывод prometheus включается автоматически
+	ResultExport  types.ResultExport  // Выгрузка результатов запросов в файл. Если задана, вывод export включается автоматически
+	Push          []types.PushTarget  // Внешние системы метрик. Если заданы, вывод push включается автоматически
+	RunID         string              // Идентификатор запуска для тега run_id метрик вывода push
+}
 
 type ReportService interface {
 	DoneChan() <-chan struct{}
@@ -17,10 +31,28 @@ type ReportService interface {
 	Stop()
 }
 
-// NewReportService создаёт новый сервис отчётов с поддержкой прогресс-бара, текста и totalRequests
-func NewReportService(s string, resultGrid *widget.TextGrid, progressBar *widget.ProgressBar, progressText *widget.Label, totalRequests int) (ReportService, error) {
+// LoadStats предоставляет текущее состояние нагрузки движка.
+type LoadStats interface {
+	InFlight() int64     // Количество выполняющихся итераций
+	TargetRate() float64 // Целевая интенсивность итераций в секунду
+}
+
+// LoadStatsReceiver реализуется сервисами отчётов, которым нужно состояние нагрузки движка.
+// Движок передаёт себя в SetLoadStats при инициализации.
+type LoadStatsReceiver interface {
+	SetLoadStats(stats LoadStats)
+}
+
+// LossyReceiver реализуется сервисами отчётов, которым допустимо терять результаты при перегрузке,
+// например выводами метрик во внешние системы. Результаты остальных сервисов не отбрасываются.
+type LossyReceiver interface {
+	Lossy() bool
+}
+
+// NewReportService создаёт сервис отчётов указанного типа.
+func NewReportService(s string, opts Options) (ReportService, error) {
 	if constructor, ok := AvailableOutputServices[s]; ok {
-		return constructor(resultGrid, progressBar, progressText, totalRequests), nil
+		return constructor(opts), nil
 	}
 	return nil, fmt.Errorf("unsupported output type: %s", s)
 }
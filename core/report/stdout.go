@@ -0,0 +1,160 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"httes/core/types"
+)
+
+const OutputTypeStdout = types.DefaultOutputType
+
+func init() {
+	AvailableOutputServices[OutputTypeStdout] = func(opts Options) ReportService {
+		return NewStdoutReportService(os.Stdout, opts.TotalRequests)
+	}
+}
+
+// stdoutReport выводит прогресс и итог теста в текстовом виде без графического интерфейса.
+type stdoutReport struct {
+	out           io.Writer
+	doneChan      chan struct{}
+	result        *Result
+	debug         bool
+	totalRequests int
+	stopOnce      sync.Once
+}
+
+// NewStdoutReportService создаёт сервис отчётов, который пишет прогресс и итог теста в out.
+func NewStdoutReportService(out io.Writer, totalRequests int) ReportService {
+	return &stdoutReport{
+		out:      out,
+		doneChan: make(chan struct{}),
+		result: &Result{
+			StepResults: make(map[uint16]*ScenarioStepResultSummary),
+		},
+		totalRequests: totalRequests,
+	}
+}
+
+func (r *stdoutReport) Init(debug bool) error {
+	r.debug = debug
+	return nil
+}
+
+func (r *stdoutReport) Start(input chan *types.ScenarioResult) {
+	defer r.Stop()
+	if input == nil {
+		return
+	}
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case scr, ok := <-input:
+			if !ok {
+				fmt.Fprint(r.out, "\n")
+				WriteSummary(r.out, r.result.Summary(nil))
+				return
+			}
+			aggregate(r.result, scr)
+			if r.debug {
+				r.printDebug(scr)
+			}
+		case <-ticker.C:
+			r.printProgress()
+		}
+	}
+}
+
+// Result возвращает агрегированный результат теста
+func (r *stdoutReport) Result() *Result {
+	return r.result
+}
+
+func (r *stdoutReport) DoneChan() <-chan struct{} {
+	return r.doneChan
+}
+
+func (r *stdoutReport) Stop() {
+	r.stopOnce.Do(func() { close(r.doneChan) })
+}
+
+// printProgress выводит количество выполненных итераций и среднюю длительность
+func (r *stdoutReport) printProgress() {
+	r.result.mu.Lock()
+	done := r.result.SuccessCount + r.result.FailedCount
+	failed := r.result.FailedCount
+	avg := r.result.AvgDuration
+	r.result.mu.Unlock()
+
+	if r.totalRequests > 0 {
+		fmt.Fprintf(r.out, "\r%d/%d iterations, failed %d, avg %.4fs", done, r.totalRequests, failed, avg)
+	} else {
+		fmt.Fprintf(r.out, "\r%d iterations, failed %d, avg %.4fs", done, failed, avg)
+	}
+}
+
+// printDebug выводит подробную информацию о шагах итерации в формате JSON
+func (r *stdoutReport) printDebug(scr *types.ScenarioResult) {
+	for _, sr := range scr.StepResults {
+		b, err := json.MarshalIndent(ScenarioStepResultToVerboseHttpRequestInfo(sr), "", "  ")
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(r.out, "%s\n", b)
+	}
+}
+
+// WriteSummary выводит итоговый результат теста в текстовом виде.
+func WriteSummary(w io.Writer, s RunSummary) {
+	total := s.SuccessCount + s.FailedCount
+	fmt.Fprintf(w, "RESULT\n")
+	fmt.Fprintf(w, "-------------------------------------\n")
+	fmt.Fprintf(w, "Iterations:       %d (success %d, failed %d)\n", total, s.SuccessCount, s.FailedCount)
+	fmt.Fprintf(w, "Requests:         %d\n", s.TotalRequests)
+	fmt.Fprintf(w, "Duration:         %ds, %.2f iterations/s\n", s.Duration, s.Rps)
+	fmt.Fprintf(w, "Avg Duration:     %.4fs\n", s.AvgDuration)
+	fmt.Fprintf(w, "Percentiles:      p50 %.4fs, p90 %.4fs, p95 %.4fs, p99 %.4fs\n",
+		s.Percentiles["p50"], s.Percentiles["p90"], s.Percentiles["p95"], s.Percentiles["p99"])
+
+	if len(s.StatusCodeDist) > 0 {
+		fmt.Fprintf(w, "\nStatus Code (Message) :Count\n")
+		codes := make([]int, 0, len(s.StatusCodeDist))
+		for c := range s.StatusCodeDist {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		for _, c := range codes {
+			fmt.Fprintf(w, "  %-20s:%d\n", fmt.Sprintf("%d (%s)", c, http.StatusText(c)), s.StatusCodeDist[c])
+		}
+	}
+
+	if len(s.Steps) > 0 {
+		fmt.Fprintf(w, "\nSteps:\n")
+		ids := make([]int, 0, len(s.Steps))
+		for id := range s.Steps {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			st := s.Steps[uint16(id)]
+			fmt.Fprintf(w, "  (%d) %-15s:ok %d, failed %d, skipped %d, avg %.4fs, p95 %.4fs\n",
+				id, st.Name, st.SuccessCount, st.FailedCount, st.SkippedCount, st.Durations["duration"], st.Percentiles["p95"])
+		}
+	}
+
+	if len(s.Thresholds) > 0 {
+		fmt.Fprintf(w, "\nThresholds:\n")
+		for _, t := range s.Thresholds {
+			fmt.Fprintf(w, "  %s\n", t)
+		}
+	}
+}
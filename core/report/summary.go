@@ -0,0 +1,171 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"httes/core/types"
+)
+
+// RunSummary — итоговый результат теста в сериализуемом виде для сохранения и сравнения запусков.
+type RunSummary struct {
+	StartTime      time.Time                         `json:"start_time"`
+	Duration       int                               `json:"duration"` // Длительность теста в секундах по временному ряду
+	SuccessCount   int                               `json:"success_count"`
+	FailedCount    int                               `json:"fail_count"`
+	TotalRequests  int                               `json:"total_requests"`
+	AvgDuration    float32                           `json:"avg_duration"`
+	Rps            float32                           `json:"rps"`
+	Percentiles    map[string]float32                `json:"percentiles"`
+	Durations      map[string]float32                `json:"durations"`
+	StatusCodeDist map[int]int                       `json:"status_code_dist"`
+	Steps          map[uint16]*StepSummary           `json:"steps"`
+	Scenarios      map[string]*ScenarioResultSummary `json:"scenarios,omitempty"`
+	TimeSeries     []TimePoint                       `json:"time_series"`
+	Thresholds     []ThresholdResult                 `json:"thresholds,omitempty"`
+	Passed         bool                              `json:"passed"`                 // Все пороговые значения выполнены
+	Samples        []RequestSample                   `json:"samples,omitempty"`      // Не более maxSamples замеров
+	SampleCount    int64                             `json:"sample_count,omitempty"` // Количество запросов, из которых взята выборка Samples
+}
+
+// StepSummary содержит статистику шага вместе с процентилями длительности.
+type StepSummary struct {
+	ScenarioStepResultSummary
+	Percentiles map[string]float32 `json:"percentiles"`
+}
+
+// ThresholdResult — результат проверки порогового значения метрики.
+type ThresholdResult struct {
+	Metric string   `json:"metric"`
+	StepID uint16   `json:"step_id,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Value  float64  `json:"value"`
+	Passed bool     `json:"passed"`
+}
+
+// String возвращает описание результата проверки, например "p95 <= 0.5: 0.42 (passed)".
+func (t ThresholdResult) String() string {
+	th := types.Threshold{Metric: t.Metric, StepID: t.StepID, Min: t.Min, Max: t.Max}
+	status := "passed"
+	if !t.Passed {
+		status = "failed"
+	}
+	return fmt.Sprintf("%s: %.4g (%s)", th, t.Value, status)
+}
+
+// ResultProvider реализуется сервисами отчётов, которые предоставляют агрегированный результат теста.
+type ResultProvider interface {
+	Result() *Result
+}
+
+// Summary возвращает итоговый результат теста с проверкой пороговых значений.
+func (r *Result) Summary(thresholds []types.Threshold) RunSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := RunSummary{
+		StartTime:      r.StartTime,
+		SuccessCount:   r.SuccessCount,
+		FailedCount:    r.FailedCount,
+		TotalRequests:  r.TotalRequests,
+		AvgDuration:    r.AvgDuration,
+		Percentiles:    r.durations.percentiles(),
+		Durations:      make(map[string]float32, len(r.Durations)),
+		StatusCodeDist: make(map[int]int, len(r.StatusCodeDist)),
+		Steps:          make(map[uint16]*StepSummary, len(r.StepResults)),
+		TimeSeries:     make([]TimePoint, 0, len(r.TimeSeries)),
+		Passed:         true,
+	}
+	for k, v := range r.Durations {
+		s.Durations[k] = v
+	}
+	for k, v := range r.StatusCodeDist {
+		s.StatusCodeDist[k] = v
+	}
+	for id, st := range r.StepResults {
+		s.Steps[id] = &StepSummary{ScenarioStepResultSummary: *st, Percentiles: st.durations.percentiles()}
+	}
+	if len(r.ScenarioResults) > 0 {
+		s.Scenarios = make(map[string]*ScenarioResultSummary, len(r.ScenarioResults))
+		for n, sc := range r.ScenarioResults {
+			c := *sc
+			s.Scenarios[n] = &c
+		}
+	}
+
+	for _, tp := range r.TimeSeries {
+		s.TimeSeries = append(s.TimeSeries, *tp)
+		if tp.Second+1 > s.Duration {
+			s.Duration = tp.Second + 1
+		}
+	}
+	sort.Slice(s.TimeSeries, func(i, j int) bool { return s.TimeSeries[i].Second < s.TimeSeries[j].Second })
+	if s.Duration > 0 {
+		s.Rps = float32(s.SuccessCount+s.FailedCount) / float32(s.Duration)
+	}
+
+	if r.KeepSamples {
+		s.Samples = r.samples.sorted()
+		s.SampleCount = r.samples.count
+	}
+
+	s.Thresholds = EvaluateThresholds(&s, thresholds)
+	for _, t := range s.Thresholds {
+		if !t.Passed {
+			s.Passed = false
+		}
+	}
+	return s
+}
+
+// EvaluateThresholds проверяет пороговые значения метрик по итоговому результату теста.
+func EvaluateThresholds(s *RunSummary, thresholds []types.Threshold) []ThresholdResult {
+	res := make([]ThresholdResult, 0, len(thresholds))
+	for _, t := range thresholds {
+		tr := ThresholdResult{Metric: t.Metric, StepID: t.StepID, Min: t.Min, Max: t.Max}
+		tr.Value = s.metricValue(t.Metric, t.StepID)
+		tr.Passed = (t.Min == nil || tr.Value >= *t.Min) && (t.Max == nil || tr.Value <= *t.Max)
+		res = append(res, tr)
+	}
+	return res
+}
+
+// metricValue возвращает значение метрики теста или шага stepID (если не 0).
+func (s *RunSummary) metricValue(metric string, stepID uint16) float64 {
+	var success, failed int64
+	var avg float32
+	percentiles := s.Percentiles
+	if stepID != 0 {
+		st, ok := s.Steps[stepID]
+		if !ok {
+			return 0
+		}
+		success, failed = st.SuccessCount, st.FailedCount
+		avg = st.Durations["duration"]
+		percentiles = st.Percentiles
+	} else {
+		success, failed = int64(s.SuccessCount), int64(s.FailedCount)
+		avg = s.AvgDuration
+	}
+
+	switch metric {
+	case types.ThresholdAvgDuration:
+		return float64(avg)
+	case types.ThresholdP50, types.ThresholdP90, types.ThresholdP95, types.ThresholdP99:
+		return float64(percentiles[metric])
+	case types.ThresholdFailRate:
+		if success+failed == 0 {
+			return 0
+		}
+		return float64(failed) * 100 / float64(success+failed)
+	case types.ThresholdRps:
+		return float64(s.Rps)
+	case types.ThresholdSuccessCount:
+		return float64(success)
+	case types.ThresholdFailedCount:
+		return float64(failed)
+	}
+	return 0
+}
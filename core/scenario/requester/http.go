@@ -41,6 +41,7 @@ type HttpRequester struct {
 	debug                bool                           // Режим отладки
 	dynamicRgx           *regexp.Regexp                 // Регулярка для динамических переменных
 	envRgx               *regexp.Regexp                 // Регулярка для окружных переменных
+	funcRgx              *regexp.Regexp                 // Регулярка для вызовов шаблонных функций
 }
 
 // Init создаёт клиента для указанного шага сценария. HttpRequester использует один http.Client для всех запросов
@@ -55,6 +56,7 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 	h.debug = debug
 	h.dynamicRgx = regexp.MustCompile(regex.DynamicVariableRegex) // Инициализация регулярки для {{var}}
 	h.envRgx = regexp.MustCompile(regex.EnvironmentVariableRegex) // Инициализация регулярки для ${var}
+	h.funcRgx = regexp.MustCompile(regex.TemplateFunctionRegex)   // Инициализация регулярки для {{func args}}
 
 	// Настройка TLS
 	tlsConfig := h.initTLSConfig()
@@ -88,7 +90,7 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 		h.containsDynamicField["body"] = true
 	}
 
-	if h.envRgx.MatchString(h.packet.Payload) { // Проверка на окружные переменные в теле
+	if h.hasEnvOrFunc(h.packet.Payload) { // Проверка на окружные переменные и функции в теле
 		h.containsEnvVar["body"] = true
 	}
 
@@ -101,7 +103,7 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 		h.containsDynamicField["url"] = true
 	}
 
-	if h.envRgx.MatchString(h.packet.URL) { // Проверка на окружные переменные в URL
+	if h.hasEnvOrFunc(h.packet.URL) { // Проверка на окружные переменные и функции в URL
 		h.containsEnvVar["url"] = true
 	}
 
@@ -119,7 +121,7 @@ func (h *HttpRequester) Init(ctx context.Context, s types.ScenarioStep, proxyAdd
 				}
 				h.containsDynamicField["header"] = true
 			}
-			if h.envRgx.MatchString(k) || h.envRgx.MatchString(v) { // Окружные переменные в заголовках
+			if h.hasEnvOrFunc(k) || h.hasEnvOrFunc(v) { // Окружные переменные и функции в заголовках
 				h.containsEnvVar["header"] = true
 			}
 		}
@@ -149,7 +151,7 @@ func (h *HttpRequester) Done() {
 	h.client.CloseIdleConnections()
 }
 
-func (h *HttpRequester) Send(envs map[string]interface{}) (res *types.ScenarioStepResult) {
+func (h *HttpRequester) Send(envs map[string]interface{}, jar http.CookieJar) (res *types.ScenarioStepResult) {
 	var statusCode int                // Код ответа
 	var contentLength int64           // Длина контента ответа
 	var requestErr types.RequestError // Ошибка запроса
@@ -191,13 +193,21 @@ func (h *HttpRequester) Send(envs map[string]interface{}) (res *types.ScenarioSt
 		httpReq.Body = io.NopCloser(bytes.NewReader(copiedReqBody.Bytes()))
 	}
 
+	// Клиент с cookie jar итерации. Копия разделяет транспорт, а значит и пул соединений, с h.client
+	client := h.client
+	if jar != nil {
+		c := *h.client
+		c.Jar = jar
+		client = &c
+	}
+
 	durations.setReqStart() // Фиксация времени начала запроса
 
 	// Выполнение запроса
-	httpRes, err := h.client.Do(httpReq)
+	httpRes, err := client.Do(httpReq)
 	if err != nil { // Ошибка выполнения запроса
 		requestErr = fetchErrType(err)
-		failedCaptures = h.captureEnvironmentVariables(nil, nil, extractedVars)
+		failedCaptures = h.captureEnvironmentVariables(nil, extractedVars)
 	}
 
 	// Чтение тела ответа для повторного использования соединений
@@ -208,7 +218,6 @@ func (h *HttpRequester) Send(envs map[string]interface{}) (res *types.ScenarioSt
 			if bodyReadErr != nil {
 				requestErr = fetchErrType(bodyReadErr)
 			}
-			failedCaptures = h.captureEnvironmentVariables(httpRes.Header, respBody, extractedVars)
 		}
 
 		if !bodyRead { // Если тело ещё не прочитано
@@ -226,10 +235,28 @@ func (h *HttpRequester) Send(envs map[string]interface{}) (res *types.ScenarioSt
 		respHeaders = httpRes.Header
 		contentLength = httpRes.ContentLength
 		statusCode = httpRes.StatusCode
+		if requestErr.Type == "" && !h.packet.StatusExpected(statusCode) {
+			requestErr = types.RequestError{
+				Type:   types.ErrorStatus,
+				Reason: fmt.Sprintf("unexpected status code %d, expected %s", statusCode, strings.Join(h.packet.ExpectedStatus, ", ")),
+			}
+		}
 	}
 	// Фиксация времени получения ответа после чтения тела
 	durations.setResDur()
 
+	// Извлечение переменных после чтения тела, чтобы была известна длительность запроса
+	if httpRes != nil && bodyRead {
+		failedCaptures = h.captureEnvironmentVariables(&captureSource{
+			header:     httpRes.Header,
+			body:       respBody,
+			cookies:    responseCookies(httpRes, jar),
+			statusCode: httpRes.StatusCode,
+			duration:   durations.totalDuration(),
+			url:        httpRes.Request.URL.String(),
+		}, extractedVars)
+	}
+
 	var ddResTime time.Duration // Время ответа от сервера (если указано)
 	if httpRes != nil && httpRes.Header.Get("x-server-response-time") != "" {
 		resTime, _ := strconv.ParseFloat(httpRes.Header.Get("x-server-response-time"), 64)
@@ -347,7 +374,7 @@ func (h *HttpRequester) prepareReq(envs map[string]interface{}, trace *httptrace
 	if h.containsEnvVar["header"] {
 		for k, v := range httpReq.Header {
 			for i, vv := range v {
-				if h.envRgx.MatchString(vv) {
+				if h.hasEnvOrFunc(vv) {
 					vvv, err := h.ei.InjectEnv(vv, envs)
 					if err != nil {
 						return nil, err
@@ -357,7 +384,7 @@ func (h *HttpRequester) prepareReq(envs map[string]interface{}, trace *httptrace
 			}
 			httpReq.Header.Set(k, strings.Join(v, ","))
 
-			if h.envRgx.MatchString(k) {
+			if h.hasEnvOrFunc(k) {
 				kk, err := h.ei.InjectEnv(k, envs)
 				if err != nil {
 					return nil, err
@@ -387,6 +414,11 @@ func (h *HttpRequester) prepareReq(envs map[string]interface{}, trace *httptrace
 	return httpReq, nil
 }
 
+// hasEnvOrFunc проверяет, содержит ли строка переменные окружения или вызовы шаблонных функций
+func (h *HttpRequester) hasEnvOrFunc(s string) bool {
+	return h.envRgx.MatchString(s) || h.funcRgx.MatchString(s)
+}
+
 // На данный момент точный тип ошибки определить нельзя, нужен более элегантный способ
 func fetchErrType(err error) types.RequestError {
 	var requestErr types.RequestError = types.RequestError{
@@ -558,16 +590,35 @@ func newTrace(duration *duration, proxyAddr *url.URL) *httptrace.ClientTrace {
 	}
 }
 
-func (h *HttpRequester) captureEnvironmentVariables(header http.Header, respBody []byte,
+// responseCookies возвращает cookie, установленные ответом, и cookie из jar для URL запроса
+func responseCookies(res *http.Response, jar http.CookieJar) []*http.Cookie {
+	cookies := res.Cookies()
+	if jar != nil && res.Request != nil {
+		cookies = append(cookies, jar.Cookies(res.Request.URL)...)
+	}
+	return cookies
+}
+
+// captureSource содержит данные ответа, из которых извлекаются переменные
+type captureSource struct {
+	header     http.Header
+	body       []byte
+	cookies    []*http.Cookie
+	statusCode int
+	duration   time.Duration
+	url        string // Итоговый URL после редиректов
+}
+
+func (h *HttpRequester) captureEnvironmentVariables(src *captureSource,
 	extractedVars map[string]interface{}) map[string]string {
 	var err error
 	failedCaptures := make(map[string]string, 0) // Карта для ошибок извлечения
 	var captureError extraction.ExtractionError
 
 	// Если запрос провалился, устанавливаем значения по умолчанию
-	if header == nil && respBody == nil {
+	if src == nil {
 		for _, ce := range h.packet.EnvsToCapture {
-			extractedVars[ce.Name] = ""                // Пустое значение по умолчанию
+			setCaptureDefault(ce, extractedVars)       // Значение по умолчанию
 			failedCaptures[ce.Name] = "request failed" // Причина ошибки
 		}
 		return failedCaptures
@@ -578,17 +629,63 @@ func (h *HttpRequester) captureEnvironmentVariables(header http.Header, respBody
 		var val interface{}
 		switch ce.From {
 		case types.Header: // Извлечение из заголовков
-			val, err = extraction.Extract(header, ce)
+			val, err = extraction.Extract(src.header, ce)
 		case types.Body: // Извлечение из тела ответа
-			val, err = extraction.Extract(respBody, ce)
+			val, err = extraction.Extract(src.body, ce)
+		case types.Cookie: // Извлечение из cookie
+			val, err = extraction.Extract(src.cookies, ce)
+		case types.Status: // Извлечение статус-кода
+			val, err = extraction.Extract(src.statusCode, ce)
+		case types.Duration: // Извлечение длительности запроса в мс
+			val, err = extraction.Extract(float64(src.duration)/float64(time.Millisecond), ce)
+		case types.URL: // Извлечение итогового URL
+			val, err = extraction.Extract(src.url, ce)
 		}
 		if err != nil && errors.As(err, &captureError) { // Если ошибка извлечения
-			extractedVars[ce.Name] = ""                    // Устанавливаем пустое значение
+			setCaptureDefault(ce, extractedVars)           // Устанавливаем значение по умолчанию
 			failedCaptures[ce.Name] = captureError.Error() // Записываем ошибку
 			continue                                       // Продолжаем для остальных переменных
 		}
 		extractedVars[ce.Name] = val // Сохраняем извлечённое значение
+
+		// Именованные группы регулярного выражения сохраняются в одноимённые переменные
+		if ce.RegExp != nil && ce.RegExp.NamedGroups {
+			setGroupVars(val, extractedVars)
+		}
 	}
 
 	return failedCaptures
 }
+
+// setCaptureDefault устанавливает значение по умолчанию для неудавшегося захвата.
+// Если значение по умолчанию не задано, используется пустая строка.
+func setCaptureDefault(ce types.EnvCaptureConf, extractedVars map[string]interface{}) {
+	var def interface{} = ""
+	if ce.Default != nil {
+		def = ce.Default
+	}
+	for _, name := range ce.CapturedNames() {
+		extractedVars[name] = def
+	}
+}
+
+// setGroupVars сохраняет именованные группы в переменные.
+// Для всех совпадений (all) переменная группы содержит массив значений группы.
+func setGroupVars(val interface{}, extractedVars map[string]interface{}) {
+	switch groups := val.(type) {
+	case map[string]string:
+		for k, v := range groups {
+			extractedVars[k] = v
+		}
+	case []map[string]string:
+		values := map[string][]string{}
+		for _, m := range groups {
+			for k, v := range m {
+				values[k] = append(values[k], v)
+			}
+		}
+		for k, v := range values {
+			extractedVars[k] = v
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package requester
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"httes/core/types"
+	"httes/mock"
+)
+
+// startMock запускает mock-сервер с маршрутами routes и останавливает его после теста.
+func startMock(t *testing.T, routes ...mock.Route) *mock.Server {
+	t.Helper()
+	srv, err := mock.New(mock.Config{Routes: routes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func newRequester(t *testing.T, step types.ScenarioStep) *HttpRequester {
+	t.Helper()
+	if step.Method == "" {
+		step.Method = "GET"
+	}
+	if step.Timeout == 0 {
+		step.Timeout = types.DefaultTimeout
+	}
+	h := &HttpRequester{}
+	if err := h.Init(context.Background(), step, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(h.Done)
+	return h
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestHttpRequesterSendCaptures(t *testing.T) {
+	srv := startMock(t, mock.Route{
+		Method:  "POST",
+		Path:    "/users/{id}",
+		Status:  201,
+		Headers: map[string]string{"X-Request-Id": "req-{{.Counter}}"},
+		Body:    `{"id":"{{.Params.id}}","name":"{{.JSON.name}}","auth":"{{.Header.Get "Authorization"}}"}`,
+		Chunked: mock.Chunked{Count: 3, Interval: 5},
+	})
+
+	h := newRequester(t, types.ScenarioStep{
+		ID:      1,
+		Method:  "POST",
+		URL:     srv.URL() + "/users/{{USER}}",
+		Headers: map[string]string{"Authorization": "Bearer {{TOKEN}}", "Content-Type": "application/json"},
+		Payload: `{"name":"{{NAME}}"}`,
+		EnvsToCapture: []types.EnvCaptureConf{
+			{Name: "ID", From: types.Body, JsonPath: strPtr("id")},
+			{Name: "NAME_BACK", From: types.Body, JsonPath: strPtr("name")},
+			{Name: "AUTH", From: types.Body, JsonPath: strPtr("auth")},
+			{Name: "REQUEST_ID", From: types.Header, Key: strPtr("X-Request-Id")},
+			{Name: "STATUS", From: types.Status},
+		},
+	})
+
+	res := h.Send(map[string]interface{}{"USER": "u42", "TOKEN": "t0k3n", "NAME": "Ann"}, nil)
+	if res.Err.Type != "" {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if res.StatusCode != 201 {
+		t.Errorf("status: expected 201, got %d", res.StatusCode)
+	}
+	expected := map[string]interface{}{
+		"ID":         "u42",
+		"NAME_BACK":  "Ann",
+		"AUTH":       "Bearer t0k3n",
+		"REQUEST_ID": "req-1",
+		"STATUS":     201,
+	}
+	for k, v := range expected {
+		if res.ExtractedEnvs[k] != v {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", k, v, v, res.ExtractedEnvs[k], res.ExtractedEnvs[k])
+		}
+	}
+	if len(res.FailedCaptures) > 0 {
+		t.Errorf("unexpected failed captures: %v", res.FailedCaptures)
+	}
+	// Две паузы между тремя частями тела входят в длительность запроса
+	if res.Duration < 10*time.Millisecond {
+		t.Errorf("duration %v does not include the chunked response", res.Duration)
+	}
+}
+
+func TestHttpRequesterSendLatency(t *testing.T) {
+	srv := startMock(t, mock.Route{Path: "/slow", Latency: mock.Latency{Mean: 50}})
+	h := newRequester(t, types.ScenarioStep{ID: 1, URL: srv.URL() + "/slow"})
+
+	res := h.Send(nil, nil)
+	if res.Err.Type != "" {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if res.Duration < 50*time.Millisecond {
+		t.Errorf("duration: expected at least 50ms, got %v", res.Duration)
+	}
+	if d := res.Custom["serverProcessDuration"].(time.Duration); d < 50*time.Millisecond {
+		t.Errorf("server process duration: expected at least 50ms, got %v", d)
+	}
+}
+
+func TestHttpRequesterSendInjectedErrors(t *testing.T) {
+	srv := startMock(t,
+		mock.Route{Path: "/unavailable", Error: mock.ErrorInjection{Rate: 1, Status: 503}},
+		mock.Route{Path: "/reset", Error: mock.ErrorInjection{Rate: 1, Abort: true}},
+		mock.Route{Path: "/missing-header"},
+	)
+
+	res := newRequester(t, types.ScenarioStep{ID: 1, URL: srv.URL() + "/unavailable", ExpectedStatus: []string{"2XX"}}).Send(nil, nil)
+	if res.StatusCode != 503 || res.Err.Type != types.ErrorStatus {
+		t.Errorf("injected status: expected 503 with %s, got %d with %q", types.ErrorStatus, res.StatusCode, res.Err.Type)
+	}
+
+	res = newRequester(t, types.ScenarioStep{ID: 2, URL: srv.URL() + "/reset"}).Send(nil, nil)
+	if res.Err.Type != types.ErrorConn || !res.IsFailed() {
+		t.Errorf("aborted connection: expected %s, got %q (%s)", types.ErrorConn, res.Err.Type, res.Err.Reason)
+	}
+
+	res = newRequester(t, types.ScenarioStep{
+		ID:  3,
+		URL: srv.URL() + "/missing-header",
+		EnvsToCapture: []types.EnvCaptureConf{
+			{Name: "TOKEN", From: types.Header, Key: strPtr("X-Token")},
+			{Name: "FALLBACK", From: types.Header, Key: strPtr("X-Token"), Default: "none"},
+		},
+	}).Send(nil, nil)
+	if _, ok := res.FailedCaptures["TOKEN"]; !ok {
+		t.Errorf("capture of a missing header should fail, got %v", res.ExtractedEnvs)
+	}
+	if res.ExtractedEnvs["FALLBACK"] != "none" {
+		t.Errorf("capture default: expected none, got %v", res.ExtractedEnvs["FALLBACK"])
+	}
+}
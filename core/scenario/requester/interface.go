@@ -2,6 +2,7 @@ package requester
 
 import (
 	"context"
+	"net/http"
 	"net/url"
 
 	"httes/core/types"
@@ -11,7 +12,8 @@ import (
 // // Поле протокола в типах.Шаг сценария определяет, какую реализацию отправителя запроса использовать.
 type Requester interface {
 	Init(ctx context.Context, ss types.ScenarioStep, url *url.URL, debug bool) error
-	Send(envs map[string]interface{}) *types.ScenarioStepResult
+	// jar - cookie jar итерации сценария, nil если cookie jar отключён.
+	Send(envs map[string]interface{}, jar http.CookieJar) *types.ScenarioStepResult
 	Done()
 }
 
@@ -0,0 +1,511 @@
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Выражения условий используются для управления потоком сценария (condition, until).
+// Поддерживаются:
+//   - литералы: числа, строки в одинарных или двойных кавычках, true, false, null
+//   - переменные: status, duration, error и любые переменные окружения (name или {{name}}).
+//     Переменные status, duration и error скрывают переменные окружения с теми же именами
+//   - операторы сравнения: ==, !=, <, <=, >, >=, contains
+//   - логические операторы: &&, ||, ! и скобки
+//
+// Пример: status == 201 && {{token}} != ""
+
+// Validate проверяет синтаксис выражения без его вычисления.
+func Validate(expr string) error {
+	_, err := parse(expr)
+	return err
+}
+
+// Identifiers возвращает имена переменных, используемых в выражении.
+func Identifiers(expr string) ([]string, error) {
+	n, err := parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	n.walk(func(n *node) {
+		if n.kind == nodeIdent {
+			ids = append(ids, n.value.(string))
+		}
+	})
+	return ids, nil
+}
+
+// Evaluate вычисляет выражение с использованием переданных переменных.
+func Evaluate(expr string, vars map[string]interface{}) (bool, error) {
+	n, err := parse(expr)
+	if err != nil {
+		return false, err
+	}
+	v, err := n.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	return truthy(v), nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, value: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, value: ")"})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string literal at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokString, value: string(runes[i+1 : end])})
+			i = end + 1
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			end := i + 2
+			for end+1 < len(runes) && !(runes[end] == '}' && runes[end+1] == '}') {
+				end++
+			}
+			if end+1 >= len(runes) {
+				return nil, fmt.Errorf("unterminated variable at %d", i)
+			}
+			name := strings.TrimSpace(string(runes[i+2 : end]))
+			if name == "" {
+				return nil, fmt.Errorf("empty variable at %d", i)
+			}
+			tokens = append(tokens, token{kind: tokIdent, value: name})
+			i = end + 2
+		case strings.ContainsRune("=!<>&|", r):
+			op := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=&|", runes[i+1]) {
+				op += string(runes[i+1])
+			}
+			switch op {
+			case "==", "!=", "<", "<=", ">", ">=", "&&", "||", "!":
+			default:
+				return nil, fmt.Errorf("unknown operator %s at %d", op, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, value: op})
+			i += len(op)
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := i + 1
+			for end < len(runes) && (unicode.IsDigit(runes[end]) || runes[end] == '.') {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokNumber, value: string(runes[i:end])})
+			i = end
+		case unicode.IsLetter(r) || r == '_':
+			end := i + 1
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			word := string(runes[i:end])
+			if word == "contains" {
+				tokens = append(tokens, token{kind: tokOp, value: word})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, value: word})
+			}
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokEOF}), nil
+}
+
+type nodeKind int
+
+const (
+	nodeLiteral nodeKind = iota
+	nodeIdent
+	nodeNot
+	nodeBinary
+)
+
+type node struct {
+	kind  nodeKind
+	op    string
+	value interface{}
+	left  *node
+	right *node
+}
+
+func (n *node) walk(f func(*node)) {
+	if n == nil {
+		return
+	}
+	f(n)
+	n.left.walk(f)
+	n.right.walk(f)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(expr string) (*node, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", expr, err)
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = fmt.Errorf("unexpected token %q", p.peek().value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %v", expr, err)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().value == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOp && p.peek().value == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &node{kind: nodeBinary, op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	if p.peek().kind == tokOp && p.peek().value == "!" {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &node{kind: nodeNot, left: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if t.kind == tokOp {
+		switch t.value {
+		case "==", "!=", "<", "<=", ">", ">=", "contains":
+			p.next()
+			right, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return &node{kind: nodeBinary, op: t.value, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseOperand() (*node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return n, nil
+	case tokNumber:
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", t.value)
+		}
+		return &node{kind: nodeLiteral, value: f}, nil
+	case tokString:
+		return &node{kind: nodeLiteral, value: t.value}, nil
+	case tokIdent:
+		switch t.value {
+		case "true":
+			return &node{kind: nodeLiteral, value: true}, nil
+		case "false":
+			return &node{kind: nodeLiteral, value: false}, nil
+		case "null", "nil":
+			return &node{kind: nodeLiteral, value: nil}, nil
+		}
+		return &node{kind: nodeIdent, value: t.value}, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected token %q", t.value)
+	}
+}
+
+func (n *node) eval(vars map[string]interface{}) (interface{}, error) {
+	switch n.kind {
+	case nodeLiteral:
+		return n.value, nil
+	case nodeIdent:
+		name := n.value.(string)
+		v, ok := vars[name]
+		if !ok {
+			return nil, fmt.Errorf("%s is not defined", name)
+		}
+		return v, nil
+	case nodeNot:
+		v, err := n.left.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		return !truthy(v), nil
+	}
+
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Короткое замыкание для логических операторов
+	switch n.op {
+	case "&&":
+		if !truthy(left) {
+			return false, nil
+		}
+		right, err := n.right.eval(vars)
+		return truthy(right), err
+	case "||":
+		if truthy(left) {
+			return true, nil
+		}
+		right, err := n.right.eval(vars)
+		return truthy(right), err
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return compare(n.op, left, right)
+}
+
+func compare(op string, left, right interface{}) (bool, error) {
+	if op == "contains" {
+		return contains(left, right), nil
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if lok && rok {
+		switch op {
+		case "==":
+			return lf == rf, nil
+		case "!=":
+			return lf != rf, nil
+		case "<":
+			return lf < rf, nil
+		case "<=":
+			return lf <= rf, nil
+		case ">":
+			return lf > rf, nil
+		case ">=":
+			return lf >= rf, nil
+		}
+	}
+
+	lb, lIsBool := left.(bool)
+	rb, rIsBool := right.(bool)
+	if lIsBool && rIsBool {
+		switch op {
+		case "==":
+			return lb == rb, nil
+		case "!=":
+			return lb != rb, nil
+		}
+		return false, fmt.Errorf("operator %s is not supported for booleans", op)
+	}
+
+	if left == nil || right == nil {
+		switch op {
+		case "==":
+			return isEmpty(left) && isEmpty(right), nil
+		case "!=":
+			return !(isEmpty(left) && isEmpty(right)), nil
+		}
+		return false, nil
+	}
+
+	ls, rs := toString(left), toString(right)
+	switch op {
+	case "==":
+		return ls == rs, nil
+	case "!=":
+		return ls != rs, nil
+	case "<":
+		return ls < rs, nil
+	case "<=":
+		return ls <= rs, nil
+	case ">":
+		return ls > rs, nil
+	case ">=":
+		return ls >= rs, nil
+	}
+	return false, fmt.Errorf("unknown operator %s", op)
+}
+
+func contains(container, item interface{}) bool {
+	if container == nil {
+		return false
+	}
+	rv := reflect.ValueOf(container)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if eq, _ := compare("==", rv.Index(i).Interface(), item); eq {
+				return true
+			}
+		}
+		return false
+	case reflect.Map:
+		// Ключи-строки ищутся напрямую, остальные сравниваются так же, как элементы массива
+		if keyType := rv.Type().Key(); keyType.Kind() == reflect.String {
+			return rv.MapIndex(reflect.ValueOf(toString(item)).Convert(keyType)).IsValid()
+		}
+		for _, k := range rv.MapKeys() {
+			if eq, _ := compare("==", k.Interface(), item); eq {
+				return true
+			}
+		}
+		return false
+	default:
+		return strings.Contains(toString(container), toString(item))
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case int32:
+		return float64(t), true
+	case uint16:
+		return float64(t), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case string:
+		return t != "" && t != "false" && t != "0"
+	}
+	if f, ok := toFloat(v); ok {
+		return f != 0
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return rv.Len() > 0
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package condition
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		expr     string
+		expected []token
+	}{
+		{"Comparison", "status >= 200", []token{{tokIdent, "status"}, {tokOp, ">="}, {tokNumber, "200"}}},
+		{"Variable", "{{ token }} != ''", []token{{tokIdent, "token"}, {tokOp, "!="}, {tokString, ""}}},
+		{"Logical", "!(a && -1.5 < b) || c contains \"x\"", []token{
+			{tokOp, "!"}, {tokLParen, "("}, {tokIdent, "a"}, {tokOp, "&&"}, {tokNumber, "-1.5"}, {tokOp, "<"},
+			{tokIdent, "b"}, {tokRParen, ")"}, {tokOp, "||"}, {tokIdent, "c"}, {tokOp, "contains"}, {tokString, "x"},
+		}},
+		// Многобайтовые символы перед переменной не должны сдвигать её границы
+		{"NonASCIIBeforeVariable", "'привет' == {{имя}}", []token{{tokString, "привет"}, {tokOp, "=="}, {tokIdent, "имя"}}},
+		{"NonASCIIVariables", "{{город}} == 'Москва' && {{日本}} contains '語'", []token{
+			{tokIdent, "город"}, {tokOp, "=="}, {tokString, "Москва"}, {tokOp, "&&"},
+			{tokIdent, "日本"}, {tokOp, "contains"}, {tokString, "語"},
+		}},
+		{"NonASCIIIdent", "статус == 200", []token{{tokIdent, "статус"}, {tokOp, "=="}, {tokNumber, "200"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := tokenize(test.expr)
+			if err != nil {
+				t.Fatalf("tokenize failed: %v", err)
+			}
+			expected := append(test.expected, token{kind: tokEOF})
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		})
+	}
+}
+
+func TestTokenizeInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		err  string
+	}{
+		{"UnterminatedString", "a == 'abc", "unterminated string literal at 5"},
+		{"UnterminatedVariable", "'ё' == {{token", "unterminated variable at 7"},
+		{"UnterminatedVariableSingleBrace", "{{token}", "unterminated variable at 0"},
+		{"EmptyVariable", "{{ }} == 1", "empty variable at 0"},
+		{"UnknownOperator", "a => b", "unknown operator = at 2"},
+		{"UnexpectedCharacter", "a == b; c", "unexpected character ';' at 6"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := tokenize(test.expr)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]interface{}{
+		"status":   201,
+		"duration": 12.5,
+		"error":    "",
+		"token":    "abc",
+		"empty":    "",
+		"count":    "3",
+		"flag":     true,
+		"ids":      []interface{}{1.0, 2.0},
+		"город":    "Москва",
+		"user":     map[string]interface{}{"id": 7.0},
+		"codes":    map[int]string{404: "not found"},
+		"weights":  map[float64]bool{0.5: true},
+	}
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{"status == 201", true},
+		{"status >= 200 && status < 300", true},
+		{"status == 201 && {{token}} != ''", true},
+		{"duration > 20 || error == ''", true},
+		{"!(status == 201)", false},
+		{"count == 3", true},
+		{"count > 10", false},
+		{"flag == true", true},
+		{"flag", true},
+		{"empty", false},
+		{"empty == null", true},
+		{"token contains 'b'", true},
+		{"ids contains 2", true},
+		{"ids contains 3", false},
+		{"user contains 'id'", true},
+		{"user contains 'name'", false},
+		// Ключи, которые не являются строками, сравниваются со значением, а не приводятся к строке
+		{"codes contains 404", true},
+		{"codes contains '404'", true},
+		{"codes contains 500", false},
+		{"codes contains 'x'", false},
+		{"weights contains 0.5", true},
+		{"{{город}} == 'Москва'", true},
+		{"город contains 'ск'", true},
+		{"'мир' == 'мир' && {{город}} != 'Казань'", true},
+		// Короткое замыкание: правая часть с неизвестной переменной не вычисляется
+		{"status == 500 && missing == 1", false},
+		{"status == 201 || missing == 1", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			got, err := Evaluate(test.expr, vars)
+			if err != nil {
+				t.Fatalf("evaluation failed: %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateInvalid(t *testing.T) {
+	vars := map[string]interface{}{"status": 200, "flag": true}
+
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"", "empty expression"},
+		{"status ==", "unexpected end of expression"},
+		{"(status == 200", "missing closing parenthesis"},
+		{"status == 200 200", "unexpected token \"200\""},
+		{"1.2.3 == status", "invalid number 1.2.3"},
+		{"{{токен}} == 'x'", "токен is not defined"},
+		{"status == 200 && missing", "missing is not defined"},
+		{"flag < true", "operator < is not supported for booleans"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := Evaluate(test.expr, vars)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestIdentifiers(t *testing.T) {
+	ids, err := Identifiers("{{город}} == 'x' && (status > 1 || !{{токен}}) && true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"город", "status", "токен"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
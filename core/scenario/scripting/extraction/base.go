@@ -48,6 +48,26 @@ func Extract(source interface{}, ce types.EnvCaptureConf) (val interface{}, err
 		} else {
 			err = fmt.Errorf("http header key not specified")
 		}
+	case types.Cookie: // Если источник — cookie ответа и cookie jar
+		cookies := source.([]*http.Cookie)
+		if ce.Cookie != nil {
+			val, err = extractFromCookies(cookies, *ce.Cookie)
+			if err == nil && ce.RegExp != nil {
+				// Применяем регулярное выражение к значению cookie
+				val, err = extractWithRegex(val, *ce.RegExp)
+			}
+		} else {
+			err = fmt.Errorf("cookie name not specified")
+		}
+	case types.Status: // Если источник — статус-код ответа
+		val = source.(int)
+	case types.Duration: // Если источник — длительность запроса в мс
+		val = source.(float64)
+	case types.URL: // Если источник — итоговый URL после редиректов
+		val = source.(string)
+		if ce.RegExp != nil {
+			val, err = extractWithRegex(val, *ce.RegExp)
+		}
 	case types.Body: // Если источник — тело ответа
 		if ce.JsonPath != nil {
 			val, err = extractFromJson(source, *ce.JsonPath)
@@ -55,6 +75,10 @@ func Extract(source interface{}, ce types.EnvCaptureConf) (val interface{}, err
 			val, err = extractWithRegex(source, *ce.RegExp)
 		} else if ce.Xpath != nil {
 			val, err = extractFromXml(source, *ce.Xpath)
+		} else if ce.HtmlXpath != nil {
+			val, err = extractFromHtml(source, *ce.HtmlXpath)
+		} else if ce.CssSelector != nil {
+			val, err = extractWithCssSelector(source, *ce.CssSelector)
 		}
 	}
 
@@ -72,6 +96,24 @@ func Extract(source interface{}, ce types.EnvCaptureConf) (val interface{}, err
 func extractWithRegex(source interface{}, regexConf types.RegexCaptureConf) (val interface{}, err error) {
 	re := regexExtractor{}
 	re.Init(*regexConf.Exp)
+
+	// Все совпадения и именованные группы извлекаются как строки
+	if regexConf.All || regexConf.NamedGroups {
+		var text string
+		switch s := source.(type) {
+		case []byte:
+			text = string(s)
+		case string:
+			text = s
+		default:
+			return "", fmt.Errorf("Unsupported type for extraction source")
+		}
+		if regexConf.All {
+			return re.extractAll(text, regexConf.NamedGroups)
+		}
+		return re.extractGroups(text, regexConf.No)
+	}
+
 	switch s := source.(type) {
 	case []byte:
 		return re.extractFromByteSlice(s, regexConf.No)
@@ -82,6 +124,16 @@ func extractWithRegex(source interface{}, regexConf types.RegexCaptureConf) (val
 	}
 }
 
+// Извлечение значения cookie по имени. Используется первая найденная cookie.
+func extractFromCookies(cookies []*http.Cookie, name string) (interface{}, error) {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c.Value, nil
+		}
+	}
+	return "", fmt.Errorf("cookie %s not found", name)
+}
+
 // Извлечение из JSON по пути (JsonPath)
 func extractFromJson(source interface{}, jsonPath string) (interface{}, error) {
 	je := jsonExtractor{}
@@ -106,6 +158,32 @@ func extractFromXml(source interface{}, xPath string) (interface{}, error) {
 	}
 }
 
+// Извлечение из HTML по XPath
+func extractFromHtml(source interface{}, xPath string) (interface{}, error) {
+	he := htmlExtractor{}
+	switch s := source.(type) {
+	case []byte:
+		return he.extractWithXPath(s, xPath)
+	case string:
+		return he.extractWithXPath([]byte(s), xPath)
+	default:
+		return "", fmt.Errorf("Unsupported type for extraction source")
+	}
+}
+
+// Извлечение из HTML по CSS-селектору
+func extractWithCssSelector(source interface{}, conf types.CssSelectorConf) (interface{}, error) {
+	he := htmlExtractor{}
+	switch s := source.(type) {
+	case []byte:
+		return he.extractWithSelector(s, conf)
+	case string:
+		return he.extractWithSelector([]byte(s), conf)
+	default:
+		return "", fmt.Errorf("Unsupported type for extraction source")
+	}
+}
+
 // Кастомная ошибка для случаев неудачного извлечения
 type ExtractionError struct {
 	msg        string
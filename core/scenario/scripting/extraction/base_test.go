@@ -0,0 +1,82 @@
+package extraction
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"httes/core/types"
+)
+
+func TestExtract(t *testing.T) {
+	regex := func(exp string, no int, all, namedGroups bool) *types.RegexCaptureConf {
+		return &types.RegexCaptureConf{Exp: strPtr(exp), No: no, All: all, NamedGroups: namedGroups}
+	}
+	const body = `<a href="/a">A</a> user=ann id=1 <a href="/b">B</a> user=bob id=2`
+
+	tests := []struct {
+		name     string
+		source   interface{}
+		conf     types.EnvCaptureConf
+		expected interface{}
+		err      string
+	}{
+		{name: "status", source: 201, conf: types.EnvCaptureConf{From: types.Status}, expected: 201},
+		{name: "duration", source: 12.5, conf: types.EnvCaptureConf{From: types.Duration}, expected: 12.5},
+		{name: "url", source: "http://shop.example.test/orders/7?tab=main", conf: types.EnvCaptureConf{From: types.URL}, expected: "http://shop.example.test/orders/7?tab=main"},
+		{
+			name: "url with regex", source: "http://shop.example.test/orders/7?tab=main",
+			conf: types.EnvCaptureConf{From: types.URL, RegExp: regex(`\d+`, 0, false, false)}, expected: "7",
+		},
+		{
+			name: "url regex no match", source: "http://shop.example.test/",
+			conf: types.EnvCaptureConf{From: types.URL, RegExp: regex(`\d+`, 0, false, false)}, err: "no match for this regex",
+		},
+		{
+			name: "regex match number", source: []byte(body),
+			conf: types.EnvCaptureConf{From: types.Body, RegExp: regex(`id=\d+`, 1, false, false)}, expected: []byte("id=2"),
+		},
+		{
+			name: "regex all", source: []byte(body),
+			conf: types.EnvCaptureConf{From: types.Body, RegExp: regex(`href="[^"]+"`, 0, true, false)}, expected: []string{`href="/a"`, `href="/b"`},
+		},
+		{
+			name: "regex named groups", source: []byte(body),
+			conf:     types.EnvCaptureConf{From: types.Body, RegExp: regex(`user=(?P<USER_NAME>\w+) id=(?P<USER_ID>\d+)`, 1, false, true)},
+			expected: map[string]string{"USER_NAME": "bob", "USER_ID": "2"},
+		},
+		{
+			name: "regex all named groups", source: body,
+			conf: types.EnvCaptureConf{From: types.Body, RegExp: regex(`user=(?P<USER_NAME>\w+) id=(?P<USER_ID>\d+)`, 0, true, true)},
+			expected: []map[string]string{
+				{"USER_NAME": "ann", "USER_ID": "1"},
+				{"USER_NAME": "bob", "USER_ID": "2"},
+			},
+		},
+		{
+			name: "regex all no match", source: []byte(body),
+			conf: types.EnvCaptureConf{From: types.Body, RegExp: regex(`src="[^"]+"`, 0, true, false)}, err: "no match for this regex",
+		},
+		{
+			name: "regex named groups no match", source: []byte(body),
+			conf: types.EnvCaptureConf{From: types.Body, RegExp: regex(`email=(?P<EMAIL>\S+)`, 0, false, true)}, err: "no match for this regex",
+		},
+		{
+			name: "header with regex", source: http.Header{"Location": {"/orders/7"}},
+			conf: types.EnvCaptureConf{From: types.Header, Key: strPtr("Location"), RegExp: regex(`\d+`, 0, false, false)}, expected: "7",
+		},
+		{name: "nil source", source: nil, conf: types.EnvCaptureConf{From: types.Status}, err: "source is nil"},
+	}
+	for _, tt := range tests {
+		val, err := Extract(tt.source, tt.conf)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err || val != "" {
+				t.Errorf("%s: expected error %q, got %v %v", tt.name, tt.err, val, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(val, tt.expected) {
+			t.Errorf("%s: expected %#v, got %#v %v", tt.name, tt.expected, val, err)
+		}
+	}
+}
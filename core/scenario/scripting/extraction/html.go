@@ -0,0 +1,68 @@
+package extraction
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"httes/core/types"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+type htmlExtractor struct {
+}
+
+// extractWithXPath выполняет XPath по HTML-документу. В отличие от xmlExtractor
+// допускает невалидную разметку: незакрытые теги, атрибуты без значений и т.д.
+func (he htmlExtractor) extractWithXPath(source []byte, xPath string) (interface{}, error) {
+	doc, err := htmlquery.Parse(bytes.NewReader(source))
+	if err != nil {
+		return nil, err
+	}
+
+	// returns the first matched element
+	node, err := htmlquery.Query(doc, xPath)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("no match for this xpath")
+	}
+
+	return strings.TrimSpace(htmlquery.InnerText(node)), nil
+}
+
+// extractWithSelector находит элементы по CSS-селектору и возвращает текст или атрибут элемента с номером conf.No.
+func (he htmlExtractor) extractWithSelector(source []byte, conf types.CssSelectorConf) (interface{}, error) {
+	sel, err := cascadia.Compile(*conf.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := html.Parse(bytes.NewReader(source))
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := sel.MatchAll(doc)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no match for this css selector")
+	}
+	if conf.No < 0 || conf.No >= len(nodes) {
+		return nil, fmt.Errorf("css selector matched %d element(s), match %d not found", len(nodes), conf.No)
+	}
+
+	node := nodes[conf.No]
+	if conf.Attr == "" {
+		return strings.TrimSpace(htmlquery.InnerText(node)), nil
+	}
+	for _, a := range node.Attr {
+		if a.Key == conf.Attr {
+			return a.Val, nil
+		}
+	}
+	return nil, fmt.Errorf("attribute %s not found", conf.Attr)
+}
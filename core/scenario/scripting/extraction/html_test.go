@@ -0,0 +1,71 @@
+package extraction
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"httes/core/types"
+)
+
+// Разметка с незакрытыми тегами и атрибутами без значений, которую не разбирает XPath по XML
+const loginPage = `<!DOCTYPE html>
+<html><head><title> Sign in </title></head>
+<body>
+<form action="/login" method="post">
+  <input type="hidden" name="csrf" value="c5rf-t0k3n">
+  <input type="text" name="user" required>
+  <p class="hint">First hint
+  <p class="hint">Second hint
+</form>
+</body></html>`
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestExtractHtml(t *testing.T) {
+	css := func(selector, attr string, no int) types.EnvCaptureConf {
+		return types.EnvCaptureConf{Name: "VAL", From: types.Body, CssSelector: &types.CssSelectorConf{Selector: strPtr(selector), Attr: attr, No: no}}
+	}
+	xpath := func(expr string) types.EnvCaptureConf {
+		return types.EnvCaptureConf{Name: "VAL", From: types.Body, HtmlXpath: strPtr(expr)}
+	}
+
+	tests := []struct {
+		name     string
+		conf     types.EnvCaptureConf
+		expected interface{}
+		err      string
+	}{
+		{name: "css attribute", conf: css("input[name=csrf]", "value", 0), expected: "c5rf-t0k3n"},
+		{name: "css text", conf: css("title", "", 0), expected: "Sign in"},
+		{name: "css match number", conf: css("p.hint", "", 1), expected: "Second hint"},
+		{name: "css attribute without value", conf: css("input[name=user]", "required", 0), expected: ""},
+		{name: "css no match", conf: css("input[name=password]", "value", 0), err: "no match for this css selector"},
+		{name: "css match number out of range", conf: css("p.hint", "", 2), err: "css selector matched 2 element(s), match 2 not found"},
+		{name: "css missing attribute", conf: css("input[name=csrf]", "placeholder", 0), err: "attribute placeholder not found"},
+		{name: "css bad selector", conf: css("input[name=", "value", 0), err: "expected"},
+		{name: "xpath text", conf: xpath("//title"), expected: "Sign in"},
+		{name: "xpath attribute", conf: xpath("//input[@name='csrf']/@value"), expected: "c5rf-t0k3n"},
+		{name: "xpath first match", conf: xpath("//p[@class='hint']"), expected: "First hint"},
+		{name: "xpath no match", conf: xpath("//input[@name='password']"), err: "no match for this xpath"},
+		{name: "xpath bad expression", conf: xpath("//input[@name="), err: "expression"},
+	}
+	for _, tt := range tests {
+		// Тело ответа передаётся как []byte, а значение из других источников — как строка
+		for _, source := range []interface{}{[]byte(loginPage), loginPage} {
+			val, err := Extract(source, tt.conf)
+			if tt.err != "" {
+				var extractionErr ExtractionError
+				if !errors.As(err, &extractionErr) || !strings.Contains(err.Error(), tt.err) || val != "" {
+					t.Errorf("%s: expected extraction error containing %q, got %v %v", tt.name, tt.err, val, err)
+				}
+				continue
+			}
+			if err != nil || val != tt.expected {
+				t.Errorf("%s: expected %q, got %q %v", tt.name, tt.expected, val, err)
+			}
+		}
+	}
+}
@@ -38,3 +38,47 @@ func (ri *regexExtractor) extractFromByteSlice(text []byte, matchNo int) ([]byte
 	}
 	return matches[0], nil
 }
+
+// extractAll возвращает все совпадения. Для namedGroups каждое совпадение — карта групп.
+func (ri *regexExtractor) extractAll(text string, namedGroups bool) (interface{}, error) {
+	if !namedGroups {
+		matches := ri.r.FindAllString(text, -1)
+		if matches == nil {
+			return nil, fmt.Errorf("no match for this regex")
+		}
+		return matches, nil
+	}
+
+	submatches := ri.r.FindAllStringSubmatch(text, -1)
+	if submatches == nil {
+		return nil, fmt.Errorf("no match for this regex")
+	}
+	groups := make([]map[string]string, 0, len(submatches))
+	for _, sm := range submatches {
+		groups = append(groups, ri.groupMap(sm))
+	}
+	return groups, nil
+}
+
+// extractGroups возвращает именованные группы совпадения с номером matchNo.
+func (ri *regexExtractor) extractGroups(text string, matchNo int) (map[string]string, error) {
+	submatches := ri.r.FindAllStringSubmatch(text, -1)
+	if submatches == nil {
+		return nil, fmt.Errorf("no match for this regex")
+	}
+
+	if len(submatches) > matchNo {
+		return ri.groupMap(submatches[matchNo]), nil
+	}
+	return ri.groupMap(submatches[0]), nil
+}
+
+func (ri *regexExtractor) groupMap(submatch []string) map[string]string {
+	groups := make(map[string]string)
+	for i, name := range ri.r.SubexpNames() {
+		if name != "" {
+			groups[name] = submatch[i]
+		}
+	}
+	return groups
+}
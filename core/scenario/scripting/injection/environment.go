@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"httes/core/types/regex"
+	"httes/core/types/templatecall"
 )
 
 type EnvironmentInjector struct {
@@ -15,6 +16,8 @@ type EnvironmentInjector struct {
 	jr  *regexp.Regexp
 	dr  *regexp.Regexp
 	jdr *regexp.Regexp
+	fr  *regexp.Regexp
+	jfr *regexp.Regexp
 }
 
 func (ei *EnvironmentInjector) Init() {
@@ -22,6 +25,8 @@ func (ei *EnvironmentInjector) Init() {
 	ei.jr = regexp.MustCompile(regex.JsonEnvironmentVarRegex)
 	ei.dr = regexp.MustCompile(regex.DynamicVariableRegex)
 	ei.jdr = regexp.MustCompile(regex.JsonDynamicVariableRegex)
+	ei.fr = regexp.MustCompile(regex.TemplateFunctionRegex)
+	ei.jfr = regexp.MustCompile(regex.JsonTemplateFunctionRegex)
 }
 
 func (ei *EnvironmentInjector) getFakeData(key string) (interface{}, error) {
@@ -59,6 +64,9 @@ func (ei *EnvironmentInjector) InjectEnv(text string, envs map[string]interface{
 		truncated = truncateTag(string(s), regex.EnvironmentVariableRegex)
 
 		env, ok := envs[truncated]
+		if !ok && IsTemplateFunc(truncated) {
+			return s // a call without arguments, e.g. {{now}}
+		}
 		if !ok {
 			err = fmt.Errorf("env not found")
 		}
@@ -93,6 +101,9 @@ func (ei *EnvironmentInjector) InjectEnv(text string, envs map[string]interface{
 		truncated = truncateTag(string(s), regex.JsonEnvironmentVarRegex)
 
 		env, ok := envs[truncated]
+		if !ok && IsTemplateFunc(truncated) {
+			return s
+		}
 		if !ok {
 			err = fmt.Errorf("env not found")
 		}
@@ -109,17 +120,53 @@ func (ei *EnvironmentInjector) InjectEnv(text string, envs map[string]interface{
 		return s
 	}
 
+	injectFuncStrFunc := func(s string) string {
+		if isVariableTag(s) {
+			return s
+		}
+		res, err := ei.callTemplateFunc(s, envs)
+		if err != nil {
+			errors = append(errors, err)
+			return s
+		}
+		return stringify(res)
+	}
+	injectFuncToJsonByteFunc := func(s []byte) []byte {
+		if isVariableTag(string(s)) {
+			return s
+		}
+		res, err := ei.callTemplateFunc(string(s), envs)
+		if err == nil {
+			var mRes []byte
+			mRes, err = json.Marshal(res)
+			if err == nil {
+				return mRes
+			}
+		}
+		errors = append(errors, err)
+		return s
+	}
+
 	// json injection
 	bText := []byte(text)
 	if json.Valid(bText) {
-		if ei.jr.Match(bText) {
+		if ei.jr.Match(bText) || ei.fr.Match(bText) {
 			replacedBytes := ei.jr.ReplaceAllFunc(bText, injectToJsonByteFunc)
-			return string(replacedBytes), nil
+			replacedBytes = ei.jfr.ReplaceAllFunc(replacedBytes, injectFuncToJsonByteFunc)
+
+			// vars and calls embedded into a longer json string, e.g. "Bearer {{base64 token}}"
+			replaced := ei.r.ReplaceAllStringFunc(string(replacedBytes), jsonEscaped(injectStrFunc))
+			replaced = ei.fr.ReplaceAllStringFunc(replaced, jsonEscaped(injectFuncStrFunc))
+			if len(errors) == 0 {
+				return replaced, nil
+			}
+			return replaced, unifyErrors(errors)
 		}
 	}
 
 	// string injection
 	replaced := ei.r.ReplaceAllStringFunc(text, injectStrFunc)
+	replaced = ei.fr.ReplaceAllStringFunc(replaced, injectFuncStrFunc)
 	if len(errors) == 0 {
 		return replaced, nil
 	}
@@ -128,6 +175,25 @@ func (ei *EnvironmentInjector) InjectEnv(text string, envs map[string]interface{
 
 }
 
+// isVariableTag reports whether a tag matched by the template function regex is a variable, e.g. {{TOKEN}}.
+// Variables are replaced (or reported as missing) before function calls.
+func isVariableTag(tag string) bool {
+	call, err := templatecall.Parse(tag)
+	return err == nil && len(call.Args) == 0 && !IsTemplateFunc(call.Name)
+}
+
+// jsonEscaped wraps a replacer so that its result can be placed inside a json string literal.
+func jsonEscaped(f func(string) string) func(string) string {
+	return func(s string) string {
+		res := f(s)
+		if res == s {
+			return s
+		}
+		b, _ := json.Marshal(res)
+		return string(b[1 : len(b)-1])
+	}
+}
+
 func (ei *EnvironmentInjector) InjectDynamic(text string) (string, error) {
 	errors := []error{}
 
@@ -3,7 +3,11 @@ package injection
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"httes/core/types/templatecall"
 
 	"github.com/google/uuid"
 )
@@ -108,3 +112,108 @@ func ExampleEnvironmentInjector() {
 		fmt.Println(randInt)
 	}
 }
+
+func TestInjectionTemplateFunctions(t *testing.T) {
+	replacer := EnvironmentInjector{}
+	replacer.Init()
+
+	envs := map[string]interface{}{
+		"token": "secret",
+		"name":  "kenan",
+		"ids":   []float64{1, 2},
+		"upper": "shadowed",
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		expected string
+	}{
+		{"Base64", "{{base64 token}}", "c2VjcmV0"},
+		{"Sha256", "{{sha256 'abc'}}", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"UpperInURL", "https://test.com/{{upper name}}?q={{urlEncode \"a b\"}}", "https://test.com/KENAN?q=a+b"},
+		{"Sprintf", `{{sprintf "%s-%v" name 5}}`, "kenan-5"},
+		{"SprintfNumbers", `{{sprintf "%03d/%.2f/%s" 7 2.5 "8"}}`, "007/2.50/8"},
+		{"JSONNumber", `{"n": "{{json 42}}", "s": "{{json \"42\"}}"}`, `{"n": 42, "s": "42"}`},
+		{"VariableShadowsFunction", "{{upper}}/{{name}}", "shadowed/kenan"},
+		{"NowUnixDate", `{{now "+1d" "2006"}}`, time.Now().Add(24 * time.Hour).Format("2006")},
+		{"JSONWholeValue", `{"ids": "{{json ids}}", "auth": "{{base64 token}}"}`, `{"ids": [1,2], "auth": "c2VjcmV0"}`},
+		{"JSONEmbedded", `{"auth": "Bearer {{base64 token}}", "name": "{{name}}"}`, `{"auth": "Bearer c2VjcmV0", "name": "kenan"}`},
+		{"JSONEscapedArgs", `{"h": "{{sha1 \"abc\"}}"}`, `{"h": "a9993e364706816aba3e25717850c26c9cd0d89d"}`},
+	}
+
+	for _, test := range tests {
+		tf := func(t *testing.T) {
+			got, err := replacer.InjectEnv(test.target, envs)
+			if err != nil {
+				t.Errorf("injection failed %v", err)
+			}
+			if got != test.expected {
+				t.Errorf("injection unsuccessful, expected : %s, got :%s", test.expected, got)
+			}
+		}
+		t.Run(test.name, tf)
+	}
+
+	randInt, err := replacer.InjectEnv("{{randomInt 5 7}}", envs)
+	if err != nil || (randInt != "5" && randInt != "6" && randInt != "7") {
+		t.Errorf("randomInt unsuccessful, got: %s, err: %v", randInt, err)
+	}
+
+	if _, err := replacer.InjectEnv("{{base64 missing}}", envs); err == nil {
+		t.Errorf("expected error for undefined argument")
+	}
+
+	if _, err := replacer.InjectEnv("{{missing}}", envs); err == nil {
+		t.Errorf("expected error for undefined variable")
+	}
+}
+
+func TestInjectionTemplateFunctionsWithoutArgs(t *testing.T) {
+	replacer := EnvironmentInjector{}
+	replacer.Init()
+
+	tests := []struct {
+		name   string
+		target string
+		prefix string
+		suffix string
+	}{
+		{"String", "{{now}}", "", ""},
+		{"URL", "https://test.com/?t={{now}}&n={{name}}", "https://test.com/?t=", "&n=kenan"},
+		{"JSONWholeValue", `{"at": "{{now}}"}`, `{"at": "`, `"}`},
+		{"JSONEmbedded", `{"at": "t={{now}}"}`, `{"at": "t=`, `"}`},
+	}
+
+	for _, test := range tests {
+		tf := func(t *testing.T) {
+			got, err := replacer.InjectEnv(test.target, map[string]interface{}{"name": "kenan"})
+			if err != nil {
+				t.Fatalf("injection failed %v", err)
+			}
+			if !strings.HasPrefix(got, test.prefix) || !strings.HasSuffix(got, test.suffix) {
+				t.Fatalf("injection unsuccessful, got :%s", got)
+			}
+			ts, err := time.Parse(time.RFC3339, strings.TrimSuffix(strings.TrimPrefix(got, test.prefix), test.suffix))
+			if err != nil {
+				t.Fatalf("now returned an invalid time: %v", err)
+			}
+			if d := time.Since(ts); d < -time.Second || d > 2*time.Second {
+				t.Errorf("now is off by %v", d)
+			}
+		}
+		t.Run(test.name, tf)
+	}
+}
+
+// Функции шаблонов регистрируются для всех имён, которые проверка конфигурации считает функциями.
+func TestTemplateFuncNames(t *testing.T) {
+	if len(templateFuncMap) != len(templatecall.FuncNames) {
+		t.Errorf("expected %d template functions, got %d", len(templatecall.FuncNames), len(templateFuncMap))
+	}
+	for _, name := range templatecall.FuncNames {
+		if !IsTemplateFunc(name) {
+			t.Errorf("template function %s is not registered", name)
+		}
+	}
+}
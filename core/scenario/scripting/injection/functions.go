@@ -0,0 +1,285 @@
+package injection
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"httes/core/types/templatecall"
+)
+
+// templateFunc is a built-in function callable from templates as {{name arg1 "arg2"}}.
+type templateFunc func(args []interface{}) (interface{}, error)
+
+// IsTemplateFunc reports whether name is a registered template function.
+func IsTemplateFunc(name string) bool {
+	_, ok := templateFuncMap[name]
+	return ok
+}
+
+// callTemplateFunc resolves arguments of the call against envs and fake data and invokes the function.
+func (ei *EnvironmentInjector) callTemplateFunc(tag string, envs map[string]interface{}) (interface{}, error) {
+	call, err := templatecall.Parse(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	f, ok := templateFuncMap[call.Name]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a valid template function", call.Name)
+	}
+
+	args := make([]interface{}, 0, len(call.Args))
+	for _, a := range call.Args {
+		switch {
+		case a.Literal:
+			args = append(args, a.LiteralValue())
+		case strings.HasPrefix(a.Value, "_"):
+			v, err := ei.getFakeData(a.Value[1:])
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, v)
+		default:
+			v, ok := envs[a.Value]
+			if !ok {
+				return nil, fmt.Errorf("%s could not be found in vars global and extracted from previous steps", a.Value)
+			}
+			args = append(args, v)
+		}
+	}
+
+	res, err := f(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", call.Name, err)
+	}
+	return res, nil
+}
+
+func stringify(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []byte:
+		return string(t)
+	case json.RawMessage:
+		return string(t)
+	case int64, int:
+		return fmt.Sprintf("%d", t)
+	case float64:
+		return fmt.Sprintf("%g", t)
+	case bool:
+		return fmt.Sprintf("%t", t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toInt(v interface{}) (int, error) {
+	switch t := v.(type) {
+	case int:
+		return t, nil
+	case int64:
+		return int(t), nil
+	case float64:
+		return int(t), nil
+	default:
+		return strconv.Atoi(strings.TrimSpace(stringify(v)))
+	}
+}
+
+func argCount(args []interface{}, min, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		if min == max {
+			return fmt.Errorf("expected %d argument(s), got %d", min, len(args))
+		}
+		return fmt.Errorf("unexpected argument count: %d", len(args))
+	}
+	return nil
+}
+
+func stringFunc(f func(string) string) templateFunc {
+	return func(args []interface{}) (interface{}, error) {
+		if err := argCount(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return f(stringify(args[0])), nil
+	}
+}
+
+func hashFunc(h func() hash.Hash) templateFunc {
+	return stringFunc(func(s string) string {
+		hh := h()
+		hh.Write([]byte(s))
+		return hex.EncodeToString(hh.Sum(nil))
+	})
+}
+
+func base64DecodeFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 1, 1); err != nil {
+		return nil, err
+	}
+	s := stringify(args[0])
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		b, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func urlDecodeFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 1, 1); err != nil {
+		return nil, err
+	}
+	return url.QueryUnescape(stringify(args[0]))
+}
+
+func hmacSha256Func(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 2, 2); err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(stringify(args[0])))
+	mac.Write([]byte(stringify(args[1])))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+var timeLayouts = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"RFC1123Z":    time.RFC1123Z,
+	"RFC822":      time.RFC822,
+	"date":        "2006-01-02",
+	"datetime":    "2006-01-02 15:04:05",
+}
+
+// parseOffset parses durations like "+5m", "-1h30m" or "+2d".
+func parseOffset(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(s, "+"), "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid offset %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(s, "+"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %s", s)
+	}
+	return d, nil
+}
+
+// nowFunc returns the current time shifted by an optional offset and formatted by an optional layout.
+// {{now "+5m" "RFC3339"}}, {{now "-1d" "unix"}}, {{now "0s" "2006-01-02"}}
+func nowFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 0, 2); err != nil {
+		return nil, err
+	}
+	t := time.Now()
+	if len(args) > 0 {
+		offset, err := parseOffset(stringify(args[0]))
+		if err != nil {
+			return nil, err
+		}
+		t = t.Add(offset)
+	}
+
+	layout := "RFC3339"
+	if len(args) > 1 {
+		layout = stringify(args[1])
+	}
+	switch layout {
+	case "unix":
+		return t.Unix(), nil
+	case "unixMilli":
+		return t.UnixMilli(), nil
+	}
+	if l, ok := timeLayouts[layout]; ok {
+		layout = l
+	}
+	return t.Format(layout), nil
+}
+
+func randomIntFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 2, 2); err != nil {
+		return nil, err
+	}
+	min, err := toInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	max, err := toInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if min > max {
+		min, max = max, min
+	}
+	return min + rand.Intn(max-min+1), nil
+}
+
+func jsonFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 1, 1); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
+
+func sprintfFunc(args []interface{}) (interface{}, error) {
+	if err := argCount(args, 1, -1); err != nil {
+		return nil, err
+	}
+	return fmt.Sprintf(stringify(args[0]), args[1:]...), nil
+}
+
+func builtinTemplateFuncs() map[string]templateFunc {
+	return map[string]templateFunc{
+		// Encoding
+		"base64":       stringFunc(func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }),
+		"base64Url":    stringFunc(func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }),
+		"base64Decode": base64DecodeFunc,
+		"urlEncode":    stringFunc(url.QueryEscape),
+		"urlDecode":    urlDecodeFunc,
+		"json":         jsonFunc,
+
+		// Hashes
+		"md5":        hashFunc(md5.New),
+		"sha1":       hashFunc(sha1.New),
+		"sha256":     hashFunc(sha256.New),
+		"sha512":     hashFunc(sha512.New),
+		"hmacSha256": hmacSha256Func,
+
+		// Strings
+		"upper":   stringFunc(strings.ToUpper),
+		"lower":   stringFunc(strings.ToLower),
+		"trim":    stringFunc(strings.TrimSpace),
+		"sprintf": sprintfFunc,
+
+		// Time and numbers
+		"now":       nowFunc,
+		"randomInt": randomIntFunc,
+	}
+}
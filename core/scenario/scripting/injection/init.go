@@ -3,6 +3,7 @@ package injection
 import "github.com/ddosify/go-faker/faker"
 
 var dynamicFakeDataMap map[string]interface{}
+var templateFuncMap map[string]templateFunc
 var dataFaker faker.Faker
 
 func init() {
@@ -170,4 +171,13 @@ func init() {
 		"randomFloat":  dataFaker.RandomFloat,
 		"randomString": dataFaker.RandomString,
 	}
+
+	// Functions: {{now}}, {{base64 token}}, {{now "+5m" "RFC3339"}}, {{randomInt 1 100}}, {{sprintf "%03d" 7}}
+	templateFuncMap = builtinTemplateFuncs()
+}
+
+// IsDynamicVariable reports whether name is a dynamic fake data variable used as {{_name}}.
+func IsDynamicVariable(name string) bool {
+	_, ok := dynamicFakeDataMap[name]
+	return ok
 }
@@ -3,7 +3,10 @@ package scenario
 import (
 	"context"
 	"math/rand"
+	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,11 +14,17 @@ import (
 	"time"
 
 	"httes/core/scenario/requester"
+	"httes/core/scenario/scripting/condition"
 	"httes/core/scenario/scripting/injection"
 	"httes/core/types"
 	"httes/core/types/regex"
+
+	"github.com/google/uuid"
 )
 
+// Максимальное количество переходов goto за одну итерацию, защищает от бесконечных циклов
+const maxGotoJumps = 100
+
 // ScenarioService инкапсулирует информацию о прокси, сценариях и отправителях запросов.
 type ScenarioService struct {
 	clients     map[*url.URL][]scenarioItemRequester // Клиенты (по прокси): для каждого прокси — массив запросчиков
@@ -49,11 +58,14 @@ func (s *ScenarioService) Init(ctx context.Context, scenario types.Scenario, pro
 // Do выполняет сценарий для указанного прокси.
 // Возвращает "types.Response", заполненный запросчиком для данного прокси, и добавляет startTime в ответ.
 // Возвращает ошибку только если types.Response.Err.Type равен types.ErrorProxy или types.ErrorIntented.
+// Шаги выполняются с учётом управления потоком (types.StepFlow): пропуск по условию, циклы и переходы.
+// Ошибка вычисления условия записывается в результат шага с типом types.ErrorCondition.
 func (s *ScenarioService) Do(proxy *url.URL, startTime time.Time) (
 	response *types.ScenarioResult, err *types.RequestError) {
 	response = &types.ScenarioResult{StepResults: []*types.ScenarioStepResult{}}
 	response.StartTime = startTime
 	response.ProxyAddr = proxy
+	response.ScenarioName = s.scenario.Name
 
 	requesters, e := s.getOrCreateRequesters(proxy)
 	if e != nil {
@@ -68,27 +80,233 @@ func (s *ScenarioService) Do(proxy *url.URL, startTime time.Time) (
 	// Внедряем динамические переменные заранее для каждой итерации
 	injectDynamicVars(envs)
 
-	for _, sr := range requesters {
-		res := sr.requester.Send(envs)
+	// Cookie jar общий для всех шагов итерации
+	jar, e := s.newCookieJar()
+	if e != nil {
+		return nil, &types.RequestError{Type: types.ErrorUnkown, Reason: e.Error()}
+	}
 
-		if res.Err.Type == types.ErrorProxy || res.Err.Type == types.ErrorIntented {
-			err = &res.Err
-			if res.Err.Type == types.ErrorIntented {
-				return
+	var last *types.ScenarioStepResult // Последний выполненный запрос, используется в условиях
+	jumps := 0                         // Количество переходов goto в текущей итерации
+	for i := 0; i < len(requesters); {
+		sr := requesters[i]
+		next := i + 1
+
+		// Проверка условия выполнения шага. Ошибка вычисления условия считается неудачей шага, а не пропуском
+		var results []*types.ScenarioStepResult
+		var failed, stop bool
+		if sr.flow.Condition != "" {
+			ok, e := condition.Evaluate(sr.flow.Condition, flowVars(envs, last))
+			if e == nil && !ok {
+				response.StepResults = append(response.StepResults, skippedResult(sr))
+				i = next
+				continue
+			}
+			if e != nil {
+				results, failed = []*types.ScenarioStepResult{conditionFailedResult(sr, "condition", e)}, true
 			}
 		}
-		response.StepResults = append(response.StepResults, res)
 
-		// Пауза перед выполнением следующего шага
-		if sr.sleeper != nil && len(s.scenario.Steps) > 1 {
-			sr.sleeper.sleep()
+		if results == nil {
+			results, failed, stop = s.runStep(sr, envs, jar)
+		}
+		response.StepResults = append(response.StepResults, results...)
+		for _, res := range results {
+			if res.Err.Type == types.ErrorProxy || res.Err.Type == types.ErrorIntented {
+				err = &res.Err
+			}
+			if !res.Skipped {
+				last = res
+			}
+		}
+		if stop {
+			return
 		}
 
-		enrichEnvFromPrevStep(envs, res.ExtractedEnvs)
+		// Действие при неудачном выполнении шага
+		if failed {
+			action, target, _ := sr.flow.ParseOnFailure() // Проверено в types.scenario.validate()
+			switch action {
+			case types.FailureActionAbort:
+				return
+			case types.FailureActionGoto:
+				if jumps < maxGotoJumps {
+					jumps++
+					next = indexOfStep(requesters, target)
+				}
+			}
+		}
+		i = next
 	}
 	return
 }
 
+// runStep выполняет шаг с учётом циклов forEach и until.
+// Возвращает результаты всех выполнений, признак неудачи шага и признак немедленной остановки итерации.
+func (s *ScenarioService) runStep(sr scenarioItemRequester, envs map[string]interface{}, jar http.CookieJar) (
+	results []*types.ScenarioStepResult, failed bool, stop bool) {
+	items := []interface{}{nil}
+	if sr.flow.ForEach != "" {
+		items = toItems(envs[sr.flow.ForEach])
+		if len(items) == 0 {
+			return []*types.ScenarioStepResult{skippedResult(sr)}, false, false
+		}
+	}
+
+	maxRepeat := 1
+	if sr.flow.Until != "" {
+		maxRepeat = sr.flow.MaxRepeat
+		if maxRepeat == 0 {
+			maxRepeat = types.DefaultMaxRepeat
+		}
+	}
+
+	for _, item := range items {
+		if sr.flow.ForEach != "" {
+			envs[sr.flow.ForEachAs] = item
+		}
+
+		for r := 0; r < maxRepeat; r++ {
+			res := sr.requester.Send(envs, jar)
+			res.Iteration = len(results)
+			results = append(results, res)
+
+			if res.Err.Type == types.ErrorIntented {
+				return results, true, true
+			}
+
+			enrichEnvFromPrevStep(envs, res.ExtractedEnvs)
+
+			repeat := false
+			if sr.flow.Until != "" {
+				done, e := condition.Evaluate(sr.flow.Until, flowVars(envs, res))
+				switch {
+				case e != nil:
+					// Повторы прекращаются, шаг считается неудачным
+					if res.Err.Type == "" {
+						res.Err = conditionError("until", e)
+					}
+					failed = true
+				case !done && r == maxRepeat-1:
+					failed = true // Условие until не выполнено за отведённое число повторов
+				case !done:
+					repeat = true
+				}
+			}
+
+			// Пауза перед выполнением следующего шага или повтора. В сценарии из одного шага
+			// паузы нужны только между повторами until
+			if sr.sleeper != nil && (len(s.scenario.Steps) > 1 || repeat) {
+				sr.sleeper.sleep()
+			}
+			if !repeat {
+				break
+			}
+		}
+
+		if results[len(results)-1].IsFailed() {
+			failed = true
+		}
+	}
+	return results, failed, false
+}
+
+// newCookieJar создаёт cookie jar для итерации сценария и добавляет в него предустановленные cookie.
+// Возвращает nil, если cookie jar отключён.
+func (s *ScenarioService) newCookieJar() (http.CookieJar, error) {
+	if !s.scenario.Cookies.Enabled {
+		return nil, nil
+	}
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range s.scenario.Cookies.Preset {
+		path := c.Path
+		if path == "" {
+			path = "/"
+		}
+		u := &url.URL{Scheme: "http", Host: strings.TrimPrefix(c.Domain, "."), Path: path}
+		if c.Secure {
+			u.Scheme = "https"
+		}
+		ck := *c
+		jar.SetCookies(u, []*http.Cookie{&ck})
+	}
+	return jar, nil
+}
+
+// flowVars формирует переменные для выражений условий: окружение и данные последнего запроса.
+// Переменные status, duration и error скрывают переменные окружения с теми же именами.
+func flowVars(envs map[string]interface{}, last *types.ScenarioStepResult) map[string]interface{} {
+	vars := make(map[string]interface{}, len(envs)+3)
+	for k, v := range envs {
+		vars[k] = v
+	}
+	vars[types.FlowVarStatus] = 0
+	vars[types.FlowVarDuration] = float64(0)
+	vars[types.FlowVarError] = ""
+	if last != nil {
+		vars[types.FlowVarStatus] = last.StatusCode
+		vars[types.FlowVarDuration] = float64(last.Duration) / float64(time.Millisecond)
+		vars[types.FlowVarError] = last.Err.Type
+	}
+	return vars
+}
+
+// toItems преобразует захваченный массив в срез элементов для forEach.
+func toItems(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{v}
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// skippedResult создаёт результат для шага, пропущенного по условию.
+func skippedResult(sr scenarioItemRequester) *types.ScenarioStepResult {
+	return &types.ScenarioStepResult{
+		StepID:      sr.scenarioItemID,
+		StepName:    sr.name,
+		RequestID:   uuid.New(),
+		RequestTime: time.Now(),
+		Skipped:     true,
+	}
+}
+
+// conditionFailedResult возвращает результат шага, который не выполнялся из-за ошибки вычисления выражения field.
+func conditionFailedResult(sr scenarioItemRequester, field string, err error) *types.ScenarioStepResult {
+	return &types.ScenarioStepResult{
+		StepID:      sr.scenarioItemID,
+		StepName:    sr.name,
+		RequestID:   uuid.New(),
+		RequestTime: time.Now(),
+		Err:         conditionError(field, err),
+	}
+}
+
+// conditionError описывает ошибку вычисления выражения field шага (condition или until).
+func conditionError(field string, err error) types.RequestError {
+	return types.RequestError{Type: types.ErrorCondition, Reason: field + ": " + err.Error()}
+}
+
+// indexOfStep возвращает индекс запросчика шага с указанным ID.
+func indexOfStep(requesters []scenarioItemRequester, id uint16) int {
+	for i, r := range requesters {
+		if r.scenarioItemID == id {
+			return i
+		}
+	}
+	return len(requesters)
+}
+
 // enrichEnvFromPrevStep добавляет переменные из предыдущего шага в текущее окружение.
 func enrichEnvFromPrevStep(m1 map[string]interface{}, m2 map[string]interface{}) {
 	for k, v := range m2 {
@@ -133,6 +351,8 @@ func (s *ScenarioService) createRequesters(proxy *url.URL) (err error) {
 			s.clients[proxy],
 			scenarioItemRequester{
 				scenarioItemID: si.ID,
+				name:           si.Name,
+				flow:           si.Flow,
 				sleeper:        newSleeper(si.Sleep),
 				requester:      r,
 			},
@@ -165,6 +385,8 @@ func injectDynamicVars(envs map[string]interface{}) {
 
 type scenarioItemRequester struct {
 	scenarioItemID uint16
+	name           string
+	flow           types.StepFlow
 	sleeper        Sleeper
 	requester      requester.Requester
 }
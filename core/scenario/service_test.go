@@ -0,0 +1,247 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"httes/config"
+	"httes/core/types"
+	"httes/mock"
+)
+
+// Сценарий с выражениями, которые нельзя вычислить: булевы значения не сравниваются через <
+const flowErrorsConfig = `{
+  "iteration_count": 1,
+  "steps": [
+    {"id": 1, "url": "BASE/flag", "captureEnv": {"FLAG": {"from": "body", "jsonPath": "flag"}}},
+    {"id": 2, "url": "BASE/a", "condition": "FLAG < true"},
+    {"id": 3, "url": "BASE/b", "until": "FLAG < true", "max_repeat": 3},
+    {"id": 4, "url": "BASE/c", "condition": "FLAG == true"}
+  ]
+}`
+
+// startScenario запускает mock-сервер с маршрутами routes и сервис сценария из конфигурации cfg,
+// в которой BASE заменяется адресом mock-сервера.
+func startScenario(t *testing.T, cfg string, routes ...mock.Route) (*ScenarioService, *mock.Server) {
+	t.Helper()
+	srv := startMock(t, routes...)
+	return initScenario(t, readScenario(t, strings.ReplaceAll(cfg, "BASE", srv.URL())), false), srv
+}
+
+// startMock запускает mock-сервер с маршрутами routes и останавливает его после теста.
+func startMock(t *testing.T, routes ...mock.Route) *mock.Server {
+	t.Helper()
+	srv, err := mock.New(mock.Config{Routes: routes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// readScenario создаёт сценарий из JSON-конфигурации cfg.
+func readScenario(t *testing.T, cfg string) types.Scenario {
+	t.Helper()
+	reader, err := config.NewConfigReader([]byte(cfg), config.ConfigTypeJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.Scenario
+}
+
+// readFixture читает конфигурацию name из config/config_testdata.
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "config", "config_testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// initScenario инициализирует сервис сценария s без прокси.
+func initScenario(t *testing.T, s types.Scenario, debug bool) *ScenarioService {
+	t.Helper()
+	ss := NewScenarioService()
+	if err := ss.Init(context.Background(), s, []*url.URL{nil}, debug); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(ss.Done)
+	return ss
+}
+
+func TestScenarioServiceReportsConditionErrors(t *testing.T) {
+	ss, srv := startScenario(t, flowErrorsConfig,
+		mock.Route{Path: "/flag", Body: `{"flag":true}`},
+		mock.Route{Path: "/*", Body: `{"ok":true}`},
+	)
+
+	res, reqErr := ss.Do(nil, time.Now())
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+	if len(res.StepResults) != 4 {
+		t.Fatalf("expected 4 step results, got %d", len(res.StepResults))
+	}
+
+	for _, st := range res.StepResults[1:3] {
+		if st.Skipped || !st.IsFailed() || st.Err.Type != types.ErrorCondition {
+			t.Errorf("step %d: expected a failed step with %s, got skipped=%t %q", st.StepID, types.ErrorCondition, st.Skipped, st.Err.Type)
+		}
+	}
+	if r := res.StepResults[1].Err.Reason; !strings.HasPrefix(r, "condition: ") {
+		t.Errorf("step 2: unexpected reason %q", r)
+	}
+	// Шаг с until выполняется один раз: ошибка выражения прекращает повторы
+	if r := res.StepResults[2].Err.Reason; !strings.HasPrefix(r, "until: ") {
+		t.Errorf("step 3: unexpected reason %q", r)
+	}
+	if st := res.StepResults[3]; st.IsFailed() || st.StatusCode != 200 {
+		t.Errorf("step 4: expected success, got %d %v", st.StatusCode, st.Err)
+	}
+	if n := srv.Requests(); n != 3 {
+		t.Errorf("expected 3 requests (steps 1, 3 and 4), got %d", n)
+	}
+}
+
+// Повторы until разделяются паузой шага и в сценарии из одного шага.
+func TestScenarioServiceUntilSleepSingleStep(t *testing.T) {
+	ss, srv := startScenario(t, `{
+  "iteration_count": 1,
+  "steps": [
+    {"id": 1, "url": "BASE/state", "sleep": "100", "until": "STATE == 'ready'", "max_repeat": 3,
+     "captureEnv": {"STATE": {"from": "body", "jsonPath": "state"}}}
+  ]
+}`, mock.Route{Path: "/state", Body: `{"state":"pending"}`})
+
+	start := time.Now()
+	res, reqErr := ss.Do(nil, time.Now())
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+	if n := srv.Requests(); n != 3 || len(res.StepResults) != 3 {
+		t.Fatalf("expected 3 repeats, got %d requests and %d results", n, len(res.StepResults))
+	}
+	// Две паузы между тремя повторами, после последнего повтора паузы нет
+	if d := time.Since(start); d < 200*time.Millisecond || d >= 300*time.Millisecond {
+		t.Errorf("expected two pauses between repeats, the iteration took %v", d)
+	}
+}
+
+// Cookie, установленная ответом шага, отправляется на следующих шагах итерации вместе с предустановленными,
+// а следующая итерация начинается с новым jar, в котором есть только предустановленные cookie.
+func TestScenarioServiceCookies(t *testing.T) {
+	srv := startMock(t,
+		mock.Route{
+			Method:  "POST",
+			Path:    "/login",
+			Headers: map[string]string{"Set-Cookie": "session_id=sess-{{.Counter}}; Path=/"},
+			Body:    `{"cookie":"{{.Header.Get "Cookie"}}"}`,
+		},
+		mock.Route{Path: "/profile", Body: `{"cookie":"{{.Header.Get "Cookie"}}","session":"{{.Header.Get "X-Session"}}"}`},
+	)
+
+	// Предустановленная cookie задана для домена localhost, поэтому mock-сервер вызывается по этому имени
+	base := strings.Replace(srv.URL(), "127.0.0.1", "localhost", 1)
+	s := readScenario(t, strings.ReplaceAll(readFixture(t, "config_cookies.json"), "http://localhost:8084", base))
+	if c := s.Cookies; !c.Enabled || len(c.Preset) != 1 || c.Preset[0].Name != "locale" || c.Preset[0].Domain != "localhost" {
+		t.Fatalf("unexpected cookies config %+v", c)
+	}
+	ss := initScenario(t, s, true)
+
+	for i := 1; i <= 2; i++ {
+		res, reqErr := ss.Do(nil, time.Now())
+		if reqErr != nil {
+			t.Fatal(reqErr)
+		}
+		if len(res.StepResults) != 2 {
+			t.Fatalf("iteration %d: expected 2 step results, got %d", i, len(res.StepResults))
+		}
+		session := fmt.Sprintf("sess-%d", i)
+		expected := []string{
+			`{"cookie":"locale=ru"}`,
+			fmt.Sprintf(`{"cookie":"locale=ru; session_id=%s","session":"%s"}`, session, session),
+		}
+		for j, st := range res.StepResults {
+			if st.IsFailed() {
+				t.Fatalf("iteration %d, step %d: %v", i, st.StepID, st.Err)
+			}
+			if body, _ := st.DebugInfo["responseBody"].([]byte); string(body) != expected[j] {
+				t.Errorf("iteration %d, step %d: expected %s, got %s", i, st.StepID, expected[j], body)
+			}
+		}
+	}
+}
+
+// Захваты из HTML, статуса, длительности, итогового URL и регулярных выражений с группами доступны
+// на следующем шаге, а неудавшийся захват получает значение по умолчанию.
+func TestScenarioServiceExtendedCaptures(t *testing.T) {
+	srv := startMock(t,
+		mock.Route{Method: "GET", Path: "/login", Status: 302, Headers: map[string]string{"Location": "/login/form"}},
+		mock.Route{
+			Method:  "GET",
+			Path:    "/login/form",
+			Headers: map[string]string{"Content-Type": "text/html"},
+			Body: `<html><head><title>Sign in</title></head><body>
+<form><input type="hidden" name="csrf" value="c5rf-{{.Counter}}"></form>
+<a href="/help">Help</a> <a href="/signup">Sign up</a>
+<p>user=ann id=42</p>
+</body></html>`,
+		},
+		mock.Route{Method: "POST", Path: "/login", Body: "{{.Body}}"},
+	)
+	s := readScenario(t, strings.ReplaceAll(readFixture(t, "config_capture_extended.json"), "http://localhost:8084", srv.URL()))
+	ss := initScenario(t, s, true)
+
+	res, reqErr := ss.Do(nil, time.Now())
+	if reqErr != nil {
+		t.Fatal(reqErr)
+	}
+	if len(res.StepResults) != 2 {
+		t.Fatalf("expected 2 step results, got %d", len(res.StepResults))
+	}
+
+	page := res.StepResults[0]
+	expected := map[string]interface{}{
+		"CSRF":      "c5rf-1",
+		"TITLE":     "Sign in",
+		"STATUS":    200,
+		"FINAL_URL": srv.URL() + "/login/form",
+		"LINKS":     []string{`href="/help"`, `href="/signup"`},
+		"USER":      map[string]string{"USER_NAME": "ann", "USER_ID": "42"},
+		"USER_NAME": "ann",
+		"USER_ID":   "42",
+		"LOCALE":    "en",
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(page.ExtractedEnvs[k], v) {
+			t.Errorf("%s: expected %#v, got %#v", k, v, page.ExtractedEnvs[k])
+		}
+	}
+	if elapsed, ok := page.ExtractedEnvs["ELAPSED"].(float64); !ok || elapsed <= 0 {
+		t.Errorf("ELAPSED: expected a positive duration in ms, got %#v", page.ExtractedEnvs["ELAPSED"])
+	}
+	// Тело страницы не JSON, поэтому для LOCALE используется значение по умолчанию
+	if len(page.FailedCaptures) != 1 || page.FailedCaptures["LOCALE"] == "" {
+		t.Errorf("expected only the LOCALE capture to fail, got %v", page.FailedCaptures)
+	}
+
+	login := res.StepResults[1]
+	if body, _ := login.DebugInfo["responseBody"].([]byte); string(body) != "csrf=c5rf-1&user=ann&id=42&locale=en" {
+		t.Errorf("unexpected payload of the next step %s", body)
+	}
+}
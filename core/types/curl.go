@@ -0,0 +1,75 @@
+package types
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Символы, при которых аргумент команды не нужно заключать в кавычки
+var shellSafeRegexp = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+// Curl возвращает команду cURL, которая выполняет запрос шага.
+// Переменные окружения не подставляются: для команды с подставленными значениями
+// используйте шаг, собранный из фактического запроса, например в режиме отладки.
+// Сертификат клиента не добавляется, так как шаг хранит уже загруженный сертификат, а не пути к файлам.
+func (si *ScenarioStep) Curl() string {
+	cmd := "curl"
+	switch si.Method {
+	case "", http.MethodGet:
+	case http.MethodHead:
+		cmd += " --head"
+	default:
+		cmd += " -X " + si.Method
+	}
+	cmd += " " + shellQuote(si.URL)
+
+	var opts []string
+	keys := make([]string, 0, len(si.Headers))
+	for k := range si.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		opts = append(opts, "-H "+shellQuote(k+": "+si.Headers[k]))
+	}
+	if si.Auth != (Auth{}) {
+		opts = append(opts, "-u "+shellQuote(si.Auth.Username+":"+si.Auth.Password))
+	}
+	if si.Payload != "" {
+		opts = append(opts, "--data-binary "+shellQuote(si.Payload))
+	}
+	if si.Timeout > 0 {
+		opts = append(opts, "--max-time "+strconv.Itoa(si.Timeout))
+	}
+
+	// Параметры HTTP-клиента, отличающиеся от поведения cURL по умолчанию
+	if strings.HasPrefix(strings.ToLower(si.URL), "https://") {
+		opts = append(opts, "-k") // Сертификат сервера не проверяется
+	}
+	if v, _ := si.Custom["disable-redirect"].(bool); !v {
+		opts = append(opts, "-L")
+	}
+	if v, _ := si.Custom["disable-compression"].(bool); !v {
+		opts = append(opts, "--compressed")
+	}
+	if v, _ := si.Custom["h2"].(bool); v {
+		opts = append(opts, "--http2")
+	}
+
+	// Каждый параметр с новой строки, как в «Copy as cURL» браузера
+	for _, o := range opts {
+		cmd += " \\\n  " + o
+	}
+	return cmd
+}
+
+// shellQuote заключает аргумент в одинарные кавычки для командной оболочки, если это нужно.
+func shellQuote(s string) string {
+	if shellSafeRegexp.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
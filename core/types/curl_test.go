@@ -0,0 +1,49 @@
+package types
+
+import "testing"
+
+func TestScenarioStepCurl(t *testing.T) {
+	tests := []struct {
+		name string
+		step ScenarioStep
+		curl string
+	}{
+		{
+			name: "get",
+			step: ScenarioStep{Method: "GET", URL: "http://a.test/items?page=2"},
+			curl: "curl 'http://a.test/items?page=2' \\\n  -L \\\n  --compressed",
+		},
+		{
+			name: "head without redirects and compression",
+			step: ScenarioStep{Method: "HEAD", URL: "http://a.test/", Custom: map[string]interface{}{"disable-redirect": true, "disable-compression": true}},
+			curl: "curl --head http://a.test/",
+		},
+		{
+			name: "post with quoting",
+			step: ScenarioStep{
+				Method:  "POST",
+				URL:     "https://a.test/search?q=a b&lang=en",
+				Headers: map[string]string{"X-Note": "it's", "Accept": "*/*"},
+				Payload: `{"q":"it's"}`,
+				Auth:    Auth{Type: "basic", Username: "ann", Password: "p:ss"},
+				Timeout: 3,
+				Custom:  map[string]interface{}{"h2": true},
+			},
+			curl: "curl -X POST 'https://a.test/search?q=a b&lang=en' \\\n" +
+				"  -H 'Accept: */*' \\\n" +
+				"  -H 'X-Note: it'\\''s' \\\n" +
+				"  -u ann:p:ss \\\n" +
+				"  --data-binary '{\"q\":\"it'\\''s\"}' \\\n" +
+				"  --max-time 3 \\\n" +
+				"  -k \\\n" +
+				"  -L \\\n" +
+				"  --compressed \\\n" +
+				"  --http2",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.step.Curl(); got != tt.curl {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.name, tt.curl, got)
+		}
+	}
+}
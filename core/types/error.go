@@ -13,6 +13,8 @@ const (
 	ErrorParse          = "parseError"
 	ErrorAddr           = "addressError"
 	ErrorInvalidRequest = "invalidRequestError"
+	ErrorStatus         = "statusError"    // Response status is not one of the step's expected statuses
+	ErrorCondition      = "conditionError" // Condition or until expression of the step could not be evaluated
 
 	// Reasons
 	ReasonProxyFailed  = "proxy connection refused"
@@ -50,6 +52,24 @@ func (sc ScenarioValidationError) Unwrap() error {
 	return sc.wrappedErr
 }
 
+// StepValidationError is a validation error of a scenario step. Field is the config field of the step, e.g. method or captureEnv.token.
+type StepValidationError struct { // UnWrappable
+	StepID     uint16
+	Field      string
+	wrappedErr error
+}
+
+func (e StepValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("шаг %d: %v", e.StepID, e.wrappedErr)
+	}
+	return fmt.Sprintf("шаг %d, поле %s: %v", e.StepID, e.Field, e.wrappedErr)
+}
+
+func (e StepValidationError) Unwrap() error {
+	return e.wrappedErr
+}
+
 type EnvironmentNotDefinedError struct { // UnWrappable
 	msg        string
 	wrappedErr error
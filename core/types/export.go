@@ -0,0 +1,53 @@
+package types
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"httes/core/util"
+)
+
+// Форматы файла сырых результатов запросов.
+const (
+	ExportFormatJSONL = "jsonl" // Одна JSON-запись на строку
+	ExportFormatCSV   = "csv"   // CSV с заголовком
+)
+
+// Список поддерживаемых форматов выгрузки.
+var exportFormats = [...]string{ExportFormatJSONL, ExportFormatCSV}
+
+// ResultExport описывает выгрузку результата каждого запроса в файл во время теста.
+type ResultExport struct {
+	// Путь к файлу. Пустой путь отключает выгрузку.
+	Path string
+
+	// Формат файла. Если не задан, определяется по расширению файла, по умолчанию jsonl.
+	Format string
+}
+
+// Enabled возвращает true, если выгрузка включена.
+func (e ResultExport) Enabled() bool {
+	return e.Path != ""
+}
+
+// FileFormat возвращает формат файла с учётом расширения.
+func (e ResultExport) FileFormat() string {
+	if e.Format != "" {
+		return strings.ToLower(e.Format)
+	}
+	if strings.EqualFold(filepath.Ext(e.Path), ".csv") {
+		return ExportFormatCSV
+	}
+	return ExportFormatJSONL
+}
+
+func (e ResultExport) validate() error {
+	if !e.Enabled() {
+		return nil
+	}
+	if !util.StringInSlice(e.FileFormat(), exportFormats[:]) {
+		return fmt.Errorf("unsupported result export format: %s", e.Format)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"httes/core/scenario/scripting/condition"
+)
+
+// Действия при неудачном выполнении шага
+const (
+	// Продолжить выполнение со следующего шага (по умолчанию)
+	FailureActionContinue = "continue"
+	// Прервать текущую итерацию сценария
+	FailureActionAbort = "abort"
+	// Перейти к шагу с указанным ID, например "goto:2"
+	FailureActionGoto = "goto"
+
+	// Имя переменной для текущего элемента forEach по умолчанию
+	DefaultForEachAs = "item"
+	// Ограничение числа повторов until по умолчанию
+	DefaultMaxRepeat = 10
+
+	// Переменные, доступные в выражениях условий помимо переменных окружения.
+	// Они скрывают переменные окружения с теми же именами
+	FlowVarStatus   = "status"   // статус-код последнего выполненного запроса
+	FlowVarDuration = "duration" // длительность последнего запроса в мс
+	FlowVarError    = "error"    // тип ошибки последнего запроса или пустая строка
+)
+
+// StepFlow описывает управление потоком выполнения шага внутри итерации сценария.
+type StepFlow struct {
+	// Выражение, при ложном значении которого шаг пропускается. Например: status == 201
+	Condition string
+
+	// Имя переменной-массива, для каждого элемента которой шаг выполняется повторно.
+	ForEach string
+
+	// Имя переменной, в которую помещается текущий элемент ForEach.
+	ForEachAs string
+
+	// Выражение, до истинности которого шаг повторяется. Например: {{state}} == "ready"
+	Until string
+
+	// Максимальное количество повторов для Until.
+	MaxRepeat int
+
+	// Действие при неудаче шага: "continue", "abort" или "goto:<stepID>".
+	OnFailure string
+}
+
+// IsEmpty возвращает true, если для шага не задано управление потоком.
+func (f StepFlow) IsEmpty() bool {
+	return f == StepFlow{}
+}
+
+// ParseOnFailure возвращает действие при неудаче и ID шага для перехода (только для goto).
+func (f StepFlow) ParseOnFailure() (action string, target uint16, err error) {
+	if f.OnFailure == "" {
+		return FailureActionContinue, 0, nil
+	}
+
+	parts := strings.SplitN(strings.ToLower(strings.ReplaceAll(f.OnFailure, " ", "")), ":", 2)
+	switch parts[0] {
+	case FailureActionContinue, FailureActionAbort:
+		if len(parts) > 1 {
+			return "", 0, fmt.Errorf("некорректное действие on_failure: %s", f.OnFailure)
+		}
+		return parts[0], 0, nil
+	case FailureActionGoto:
+		if len(parts) < 2 {
+			return "", 0, fmt.Errorf("для goto необходимо указать ID шага: %s", f.OnFailure)
+		}
+		id, err := strconv.ParseUint(parts[1], 10, 16)
+		if err != nil || id == 0 {
+			return "", 0, fmt.Errorf("некорректный ID шага в on_failure: %s", f.OnFailure)
+		}
+		return FailureActionGoto, uint16(id), nil
+	}
+	return "", 0, fmt.Errorf("неподдерживаемое действие on_failure: %s", f.OnFailure)
+}
+
+// validateFlow проверяет выражения и ссылки управления потоком шага.
+func validateFlow(si *ScenarioStep, stepIds map[uint16]struct{}, definedEnvs map[string]struct{}) error {
+	f := si.Flow
+
+	for _, e := range []struct{ field, expr string }{{"condition", f.Condition}, {"until", f.Until}} {
+		if e.expr == "" {
+			continue
+		}
+		names, err := condition.Identifiers(e.expr)
+		if err != nil {
+			return stepError(si, e.field, err)
+		}
+		defined := definedEnvs
+		if e.field == "until" {
+			defined = untilEnvs(si, definedEnvs)
+		}
+		for _, name := range names {
+			if isFlowVar(name) {
+				continue
+			}
+			if _, ok := defined[name]; !ok {
+				return stepError(si, e.field, EnvironmentNotDefinedError{
+					msg: fmt.Sprintf("%s is not defined to use by global and captured environments", name),
+				})
+			}
+		}
+	}
+
+	if f.ForEach != "" {
+		if _, ok := definedEnvs[f.ForEach]; !ok {
+			return stepError(si, "for_each", EnvironmentNotDefinedError{
+				msg: fmt.Sprintf("%s is not defined to use by global and captured environments", f.ForEach),
+			})
+		}
+	}
+
+	if f.MaxRepeat < 0 {
+		return stepError(si, "max_repeat", fmt.Errorf("max_repeat не может быть отрицательным"))
+	}
+
+	action, target, err := f.ParseOnFailure()
+	if err != nil {
+		return stepError(si, "on_failure", err)
+	}
+	if action == FailureActionGoto {
+		if _, ok := stepIds[target]; !ok {
+			return stepError(si, "on_failure", fmt.Errorf("шаг для перехода не найден: %d", target))
+		}
+	}
+	return nil
+}
+
+// isFlowVar сообщает, является ли name переменной последнего запроса, доступной в выражениях условий.
+func isFlowVar(name string) bool {
+	return name == FlowVarStatus || name == FlowVarDuration || name == FlowVarError
+}
+
+// untilEnvs возвращает переменные, доступные в выражении until. Оно вычисляется после запроса,
+// поэтому кроме переменных, определённых до шага, в нём доступны элемент forEach и переменные, захваченные самим шагом.
+func untilEnvs(si *ScenarioStep, definedEnvs map[string]struct{}) map[string]struct{} {
+	envs := make(map[string]struct{}, len(definedEnvs)+len(si.EnvsToCapture)+1)
+	for name := range definedEnvs {
+		envs[name] = struct{}{}
+	}
+	if si.Flow.ForEach != "" {
+		envs[si.Flow.ForEachAs] = struct{}{}
+	}
+	for _, ce := range si.EnvsToCapture {
+		for _, name := range ce.CapturedNames() {
+			envs[name] = struct{}{}
+		}
+	}
+	return envs
+}
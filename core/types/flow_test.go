@@ -0,0 +1,68 @@
+package types
+
+import (
+	"errors"
+	"testing"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// Выражения condition и until могут использовать только определённые переменные: глобальные, захваченные
+// на предыдущих шагах и переменные последнего запроса. В until доступны также элемент forEach и захваты самого шага.
+func TestValidateFlowIdentifiers(t *testing.T) {
+	login := ScenarioStep{
+		ID: 1, Method: "POST", URL: "http://shop.example.test/login",
+		EnvsToCapture: []EnvCaptureConf{{Name: "TOKEN", From: Body, JsonPath: strPtr("token")}, {Name: "ORDERS", From: Body, JsonPath: strPtr("orders")}},
+	}
+	poll := func(flow StepFlow) ScenarioStep {
+		return ScenarioStep{
+			ID: 2, Method: "GET", URL: "http://shop.example.test/state", Flow: flow,
+			EnvsToCapture: []EnvCaptureConf{{Name: "STATE", From: Body, JsonPath: strPtr("state")}},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		flow  StepFlow
+		field string
+		err   string
+	}{
+		{name: "flow vars and envs", flow: StepFlow{Condition: `status == 200 && duration < 500 && error == "" && {{TOKEN}} != "" && BASE != ""`}},
+		{name: "captured by the step in until", flow: StepFlow{Until: `STATE == "ready" || status >= 500`}},
+		{name: "forEach item in until", flow: StepFlow{ForEach: "ORDERS", ForEachAs: "order", Until: `order contains STATE`}},
+		{
+			name: "undefined in condition", flow: StepFlow{Condition: `{{SESSION}} != ""`},
+			field: "condition", err: "SESSION is not defined to use by global and captured environments",
+		},
+		{
+			// condition вычисляется до запроса шага, поэтому его захваты ещё не доступны
+			name: "captured by the step in condition", flow: StepFlow{Condition: `STATE == "ready"`},
+			field: "condition", err: "STATE is not defined to use by global and captured environments",
+		},
+		{
+			name: "forEach item in condition", flow: StepFlow{ForEach: "ORDERS", ForEachAs: "order", Condition: `order != null`},
+			field: "condition", err: "order is not defined to use by global and captured environments",
+		},
+		{
+			name: "undefined in until", flow: StepFlow{Until: `state == "ready"`},
+			field: "until", err: "state is not defined to use by global and captured environments",
+		},
+		{name: "syntax", flow: StepFlow{Until: `STATE ==`}, field: "until", err: `invalid expression "STATE ==": unexpected end of expression`},
+	}
+	for _, test := range tests {
+		s := Scenario{Steps: []ScenarioStep{login, poll(test.flow)}, Envs: map[string]interface{}{"BASE": "http://shop.example.test"}}
+		err := s.validate()
+		if test.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", test.name, err)
+			}
+			continue
+		}
+		var stepErr StepValidationError
+		if !errors.As(err, &stepErr) || stepErr.StepID != 2 || stepErr.Field != test.field || errors.Unwrap(stepErr).Error() != test.err {
+			t.Errorf("%s: expected step 2 %s error %q, got %v", test.name, test.field, test.err, err)
+		}
+	}
+}
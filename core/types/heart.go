@@ -42,8 +42,15 @@ type Heart struct {
 	TestDuration      int                    // Общая продолжительность теста в секундах.
 	TimeRunCountMap   TimeRunCount           // Карта, отображающая количество запросов за определённые промежутки времени.
 	Scenario          Scenario               // Тестовый сценарий, содержащий шаги выполнения нагрузки.
+	Scenarios         []Scenario             // Смесь именованных сценариев с весами. Если задана, Scenario не используется.
 	Proxy             proxy.Proxy            // Прокси-серверы, которые будут использоваться для выполнения запросов.
+	ReportOutputs     []string               // Типы вывода отчёта, например "stdout" и "junit". Результаты передаются во все выводы.
 	ReportDestination string                 // Место назначения для записи данных о результатах теста.
+	Thresholds        []Threshold            // Пороговые значения метрик, определяющие успешность теста.
+	ResultExport      ResultExport           // Выгрузка результата каждого запроса в файл. Если задана, включается вывод export.
+	MetricsAddr       string                 // Адрес HTTP-сервера метрик Prometheus, например ":9090". Если задан, включается вывод prometheus.
+	Push              []PushTarget           // Внешние системы, в которые отправляются результаты запросов. Если заданы, включается вывод push.
+	RunID             string                 // Идентификатор запуска теста для тегов метрик.
 	Others            map[string]interface{} // Динамическое поле для дополнительных параметров, которые могут быть добавлены пользователем.
 	Debug             bool                   // Флаг для включения/выключения режима отладки.
 }
@@ -52,10 +59,14 @@ type Heart struct {
 // Метод выполняет базовую валидацию всех ключевых полей и вызывает проверки зависимых служб.
 func (h *Heart) Validate() error {
 	// Проверка, что сценарий содержит хотя бы один шаг.
-	if len(h.Scenario.Steps) == 0 {
-		return fmt.Errorf("scenario or target is empty") // Ошибка, если сценарий или цель отсутствуют.
-	} else if err := h.Scenario.validate(); err != nil {
-		return err // Возврат ошибки, если валидация сценария не удалась.
+	if len(h.Scenarios) == 0 {
+		if len(h.Scenario.Steps) == 0 {
+			return fmt.Errorf("scenario or target is empty") // Ошибка, если сценарий или цель отсутствуют.
+		} else if err := h.Scenario.validate(); err != nil {
+			return err // Возврат ошибки, если валидация сценария не удалась.
+		}
+	} else if err := h.validateScenarios(); err != nil {
+		return err
 	}
 
 	// Проверка, что указанный тип нагрузки поддерживается.
@@ -73,6 +84,83 @@ func (h *Heart) Validate() error {
 		}
 	}
 
+	// Проверка пороговых значений метрик.
+	stepIds := map[uint16]struct{}{}
+	for _, s := range h.AllScenarios() {
+		for _, st := range s.Steps {
+			stepIds[st.ID] = struct{}{}
+		}
+	}
+	for _, t := range h.Thresholds {
+		if err := t.validate(stepIds); err != nil {
+			return err
+		}
+	}
+
+	// Проверка отправки метрик во внешние системы.
+	for _, p := range h.Push {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Проверка формата выгрузки результатов запросов.
+	if err := h.ResultExport.validate(); err != nil {
+		return err
+	}
+
 	// Если все проверки пройдены успешно, возвращаем nil (ошибок нет).
 	return nil
 }
+
+// AllScenarios возвращает сценарии теста. Для конфигурации с одним сценарием возвращается Scenario с весом 1.
+func (h *Heart) AllScenarios() []Scenario {
+	if len(h.Scenarios) > 0 {
+		return h.Scenarios
+	}
+	s := h.Scenario
+	if s.Weight <= 0 {
+		s.Weight = 1
+	}
+	return []Scenario{s}
+}
+
+// validateScenarios проверяет смесь сценариев: имена, веса и уникальность ID шагов между сценариями.
+func (h *Heart) validateScenarios() error {
+	names := make(map[string]struct{}, len(h.Scenarios))
+	stepIds := map[uint16]string{}
+	totalWeight := 0
+	for _, s := range h.Scenarios {
+		if s.Name == "" {
+			return fmt.Errorf("scenario name is required when multiple scenarios are used")
+		}
+		if _, ok := names[s.Name]; ok {
+			return fmt.Errorf("duplicate scenario name: %s", s.Name)
+		}
+		names[s.Name] = struct{}{}
+
+		if s.Weight < 0 {
+			return fmt.Errorf("weight of scenario %s should not be negative", s.Name)
+		}
+		totalWeight += s.Weight
+
+		if len(s.Steps) == 0 {
+			return fmt.Errorf("scenario %s is empty", s.Name)
+		}
+		if err := s.validate(); err != nil {
+			return err
+		}
+
+		// Результаты шагов агрегируются по ID, поэтому ID должны быть уникальны во всей смеси
+		for _, st := range s.Steps {
+			if other, ok := stepIds[st.ID]; ok {
+				return fmt.Errorf("duplicate step id %d in scenarios %s and %s", st.ID, other, s.Name)
+			}
+			stepIds[st.ID] = s.Name
+		}
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("total weight of scenarios should be greater than 0")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package types
+
+import (
+	"fmt"
+	"net/url"
+
+	"httes/core/util"
+)
+
+// Протоколы отправки метрик во внешние системы.
+const (
+	PushInfluxDB  = "influxdb"  // Line protocol InfluxDB по HTTP или UDP
+	PushStatsD    = "statsd"    // StatsD по UDP, без тегов
+	PushDogStatsD = "dogstatsd" // DogStatsD по UDP, теги в формате #key:value
+	PushOTLP      = "otlp"      // OTLP/HTTP в кодировке JSON
+
+	DefaultPushBatchSize     = 500  // Количество результатов запросов в пакете по умолчанию
+	DefaultPushFlushInterval = 1000 // Интервал отправки неполного пакета в миллисекундах по умолчанию
+)
+
+// Список поддерживаемых протоколов отправки метрик.
+var pushTypes = [...]string{PushInfluxDB, PushStatsD, PushDogStatsD, PushOTLP}
+
+// PushTarget описывает внешнюю систему, в которую во время теста отправляются результаты запросов.
+type PushTarget struct {
+	// Протокол, например "influxdb" или "otlp".
+	Type string
+
+	// Адрес приёмника: http(s)://... для HTTP или udp://host:port для UDP.
+	URL string
+
+	// Дополнительные HTTP-заголовки, например токен авторизации.
+	Headers map[string]string
+
+	// Префикс имён метрик.
+	Prefix string
+
+	// Дополнительные теги всех метрик.
+	Tags map[string]string
+
+	// Количество результатов запросов, после которого пакет отправляется.
+	BatchSize int
+
+	// Интервал отправки неполного пакета в миллисекундах.
+	FlushInterval int
+}
+
+func (p PushTarget) validate() error {
+	if !util.StringInSlice(p.Type, pushTypes[:]) {
+		return fmt.Errorf("unsupported push type: %s", p.Type)
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid %s push url: %s", p.Type, p.URL)
+	}
+
+	var schemes []string
+	switch p.Type {
+	case PushInfluxDB:
+		schemes = []string{"http", "https", "udp"}
+	case PushStatsD, PushDogStatsD:
+		schemes = []string{"udp"}
+	case PushOTLP:
+		schemes = []string{"http", "https"}
+	}
+	if !util.StringInSlice(u.Scheme, schemes) {
+		return fmt.Errorf("%s push url should use one of the schemes %v: %s", p.Type, schemes, p.URL)
+	}
+	if p.BatchSize < 0 || p.FlushInterval < 0 {
+		return fmt.Errorf("batch size and flush interval of %s push should not be negative", p.Type)
+	}
+	return nil
+}
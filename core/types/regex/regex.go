@@ -5,3 +5,8 @@ const JsonDynamicVariableRegex = `\"{{(_)[^}]+\}}"`
 
 const EnvironmentVariableRegex = `\{{[^_]\w*\}}`
 const JsonEnvironmentVarRegex = `\"{{[^_]\w*\}}"`
+
+// Вызов без аргументов, например {{now}}, совпадает и с EnvironmentVariableRegex:
+// переменная с таким именем имеет приоритет над функцией
+const TemplateFunctionRegex = `\{{[a-zA-Z]\w*(\s+[^}]+)?\}}`
+const JsonTemplateFunctionRegex = `\"{{[a-zA-Z]\w*(\s+[^}]+)?\}}"`
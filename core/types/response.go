@@ -12,6 +12,12 @@ type ScenarioResult struct {
 	// Время начала первого запроса для сценария
 	StartTime time.Time
 
+	// Имя выполненного сценария, пустое для теста с одним безымянным сценарием
+	ScenarioName string
+
+	// Порядковый номер итерации в тесте, начиная с 1
+	IterationID int64
+
 	ProxyAddr   *url.URL
 	StepResults []*ScenarioStepResult
 
@@ -59,4 +65,15 @@ type ScenarioStepResult struct {
 
 	// Неудачные захваты и их причины
 	FailedCaptures map[string]string
+
+	// Шаг пропущен, так как его условие выполнения ложно
+	Skipped bool
+
+	// Порядковый номер выполнения шага в цикле forEach/until, начиная с 0
+	Iteration int
+}
+
+// IsFailed возвращает true, если запрос завершился ошибкой или статус-кодом 4xx/5xx.
+func (s *ScenarioStepResult) IsFailed() bool {
+	return !s.Skipped && (s.Err.Type != "" || s.StatusCode >= 400)
 }
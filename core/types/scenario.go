@@ -10,8 +10,12 @@ import (
 	"strconv"
 	"strings"
 
+	"httes/core/types/regex"
+	"httes/core/types/templatecall"
 	"httes/core/util"
 
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/xpath"
 	validator "github.com/asaskevich/govalidator"
 )
 
@@ -50,17 +54,48 @@ var supportedAuthentications = []string{
 // Регулярное выражение для проверки переменных окружения, компилируемое при инициализации
 var envVarRegexp *regexp.Regexp
 
+// Регулярное выражение для поиска вызовов шаблонных функций, например {{base64 token}}
+var templateFuncRegexp *regexp.Regexp
+
 // Инициализация регулярного выражения для переменных окружения
 func init() {
 	envVarRegexp = regexp.MustCompile(EnvironmentVariableRegexStr)
+	templateFuncRegexp = regexp.MustCompile(regex.TemplateFunctionRegex)
 }
 
 // Scenario описывает сценарий, состоящий из шагов и окружения
 type Scenario struct {
+	// Имя сценария. Обязательно, если в тесте используется несколько сценариев.
+	Name string
+	// Вес сценария в смеси. Определяет долю итераций, выполняющих этот сценарий.
+	Weight int
 	// Шаги сценария
 	Steps []ScenarioStep
 	// Глобальные переменные окружения, доступные для всех шагов
 	Envs map[string]interface{}
+	// Настройки cookie jar, общего для всех шагов одной итерации сценария
+	Cookies CookieConf
+}
+
+// CookieConf описывает cookie jar итерации сценария.
+type CookieConf struct {
+	// Включает cookie jar: cookie из ответов автоматически отправляются на последующих шагах итерации
+	Enabled bool
+	// Cookie, которые добавляются в jar в начале каждой итерации
+	Preset []*http.Cookie
+}
+
+// validate проверяет, что для предустановленных cookie указаны имя и домен
+func (c *CookieConf) validate() error {
+	for _, ck := range c.Preset {
+		if ck.Name == "" {
+			return fmt.Errorf("имя предустановленной cookie не указано")
+		}
+		if ck.Domain == "" {
+			return fmt.Errorf("домен предустановленной cookie %s не указан", ck.Name)
+		}
+	}
+	return nil
 }
 
 // validate проверяет уникальность ID шагов и валидность использования переменных окружения
@@ -75,8 +110,29 @@ func (s *Scenario) validate() error {
 		definedEnvs[key] = struct{}{}
 	}
 
+	// Проверка настроек cookie
+	if err := s.Cookies.validate(); err != nil {
+		return wrapAsScenarioValidationError(err)
+	}
+
+	// ID всех шагов нужны заранее для проверки переходов goto
+	allStepIds := make(map[uint16]struct{}, len(s.Steps))
+	for _, st := range s.Steps {
+		allStepIds[st.ID] = struct{}{}
+	}
+
 	// Проходим по всем шагам сценария
 	for _, st := range s.Steps {
+		// Проверка управления потоком шага
+		if err := validateFlow(&st, allStepIds, definedEnvs); err != nil {
+			return wrapAsScenarioValidationError(err)
+		}
+
+		// Текущий элемент forEach доступен в самом шаге и в последующих
+		if st.Flow.ForEach != "" {
+			definedEnvs[st.Flow.ForEachAs] = struct{}{}
+		}
+
 		// Валидация шага
 		if err := st.validate(definedEnvs); err != nil {
 			return err
@@ -84,11 +140,13 @@ func (s *Scenario) validate() error {
 
 		// Добавляем переменные, захваченные из текущего шага
 		for _, ce := range st.EnvsToCapture {
-			definedEnvs[ce.Name] = struct{}{}
+			for _, name := range ce.CapturedNames() {
+				definedEnvs[name] = struct{}{}
+			}
 		}
 		// Проверяем уникальность ID шага
 		if _, ok := stepIds[st.ID]; ok {
-			return fmt.Errorf("duplicate step id: %d", st.ID)
+			return stepError(&st, "id", fmt.Errorf("duplicate step id: %d", st.ID))
 		}
 		stepIds[st.ID] = struct{}{}
 	}
@@ -102,8 +160,10 @@ func checkEnvsValidInStep(st *ScenarioStep, definedEnvs map[string]struct{}) err
 	// Вспомогательная функция для проверки наличия переменных в окружении
 	matchInEnvs := func(matches []string) error {
 		for _, v := range matches {
-			// Проверяем, существует ли переменная в окружении
-			if _, ok := definedEnvs[v[2:len(v)-2]]; !ok { // {{...}}
+			// Проверяем, существует ли переменная в окружении. Вызов функции без аргументов, например {{now}},
+			// проверяется вместе с остальными вызовами
+			name := v[2 : len(v)-2] // {{...}}
+			if _, ok := definedEnvs[name]; !ok && !templatecall.IsFunc(name) {
 				return EnvironmentNotDefinedError{
 					msg: fmt.Sprintf("%s is not defined to use by global and captured environments", v),
 				}
@@ -112,34 +172,68 @@ func checkEnvsValidInStep(st *ScenarioStep, definedEnvs map[string]struct{}) err
 		return nil
 	}
 
+	// Вспомогательная функция для проверки вызовов шаблонных функций и их аргументов
+	matchTemplateCalls := func(matches []string) error {
+		for _, v := range matches {
+			call, err := templatecall.Parse(v)
+			if err != nil {
+				return err
+			}
+			if len(call.Args) == 0 && !templatecall.IsFunc(call.Name) {
+				continue // Переменная, проверена в matchInEnvs
+			}
+			if !templatecall.IsFunc(call.Name) {
+				return EnvironmentNotDefinedError{
+					msg: fmt.Sprintf("%s: unknown template function %s", v, call.Name),
+				}
+			}
+			for _, a := range call.Args {
+				if a.Literal || strings.HasPrefix(a.Value, "_") {
+					continue
+				}
+				if _, ok := definedEnvs[a.Value]; !ok {
+					return EnvironmentNotDefinedError{
+						msg: fmt.Sprintf("%s: %s is not defined to use by global and captured environments", v, a.Value),
+					}
+				}
+			}
+		}
+		return nil
+	}
+
 	// Функция для поиска и проверки переменных окружения в заданной строке
 	f := func(source string) error {
 		matches := envVarRegexp.FindAllString(source, -1)
-		return matchInEnvs(matches)
+		if err := matchInEnvs(matches); err != nil {
+			return err
+		}
+		return matchTemplateCalls(templateFuncRegexp.FindAllString(source, -1))
 	}
 
 	// Проверка переменных окружения в URL
 	err = f(st.URL)
 	if err != nil {
-		return err
+		return stepError(st, "url", err)
 	}
 
 	// Проверка переменных окружения в заголовках
 	for k, v := range st.Headers {
 		err = f(k)
 		if err != nil {
-			return err
+			return stepError(st, "headers", err)
 		}
 
 		err = f(v)
 		if err != nil {
-			return err
+			return stepError(st, "headers."+k, err)
 		}
 	}
 
 	// Проверка переменных окружения в полезной нагрузке
-	err = f(st.Payload)
-	return err
+	if err = f(st.Payload); err != nil {
+		return stepError(st, "payload", err)
+	}
+	return nil
 }
 
 // ScenarioStep представляет один шаг сценария.
@@ -183,6 +277,13 @@ type ScenarioStep struct {
 
 	// Переменные окружения, которые нужно извлечь из ответа на этот шаг.
 	EnvsToCapture []EnvCaptureConf
+
+	// Управление потоком: условие выполнения, циклы и действие при неудаче.
+	Flow StepFlow
+
+	// Ожидаемые статус-коды ответа: точные ("200") или классы ("2XX").
+	// Ответ с другим статусом считается неудачным. Пустой список не проверяется.
+	ExpectedStatus []string
 }
 
 type SourceType string
@@ -190,20 +291,69 @@ type SourceType string
 const (
 	Header SourceType = "header"
 	Body   SourceType = "body"
+	Cookie SourceType = "cookie"
+	// Статус-код ответа
+	Status SourceType = "status"
+	// Длительность запроса в миллисекундах
+	Duration SourceType = "duration"
+	// Итоговый URL после редиректов
+	URL SourceType = "url"
 )
 
 type RegexCaptureConf struct {
 	Exp *string `json:"exp"`
 	No  int     `json:"matchNo"`
+	// Захватить все совпадения в массив вместо одного по номеру
+	All bool `json:"all"`
+	// Захватить именованные группы (?P<name>...) в одноимённые переменные
+	NamedGroups bool `json:"namedGroups"`
+}
+
+// groupNames возвращает имена именованных групп регулярного выражения.
+func (r *RegexCaptureConf) groupNames() ([]string, error) {
+	re, err := regexp.Compile(*r.Exp)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, n := range re.SubexpNames() {
+		if n != "" {
+			names = append(names, n)
+		}
+	}
+	return names, nil
+}
+
+// CssSelectorConf описывает извлечение из HTML по CSS-селектору.
+type CssSelectorConf struct {
+	Selector *string `json:"selector"`
+	Attr     string  `json:"attr"` // Имя атрибута. Если не указано, извлекается текст элемента
+	No       int     `json:"matchNo"`
 }
 
 type EnvCaptureConf struct {
-	JsonPath *string           `json:"jsonPath"`
-	Xpath    *string           `json:"xpath"`
-	RegExp   *RegexCaptureConf `json:"regExp"`
-	Name     string            `json:"as"`
-	From     SourceType        `json:"from"`
-	Key      *string           `json:"headerKey"` // Ключ заголовка
+	JsonPath    *string           `json:"jsonPath"`
+	Xpath       *string           `json:"xpath"`
+	HtmlXpath   *string           `json:"htmlXpath"` // XPath по HTML, допускающий невалидную разметку
+	CssSelector *CssSelectorConf  `json:"cssSelector"`
+	RegExp      *RegexCaptureConf `json:"regExp"`
+	Name        string            `json:"as"`
+	From        SourceType        `json:"from"`
+	Key         *string           `json:"headerKey"`  // Ключ заголовка
+	Cookie      *string           `json:"cookieName"` // Имя cookie
+	// Значение переменной, если извлечение не удалось
+	Default interface{} `json:"default"`
+}
+
+// CapturedNames возвращает имена переменных, которые заполняет захват:
+// саму переменную и, для namedGroups, переменные именованных групп.
+func (c EnvCaptureConf) CapturedNames() []string {
+	names := []string{c.Name}
+	if c.RegExp != nil && c.RegExp.NamedGroups && c.RegExp.Exp != nil {
+		groups, _ := c.RegExp.groupNames()
+		names = append(names, groups...)
+	}
+	return names
 }
 
 // Auth должна включать все необходимые данные для аутентификации для поддерживаемых типов аутентификации.
@@ -215,42 +365,48 @@ type Auth struct {
 
 func (si *ScenarioStep) validate(definedEnvs map[string]struct{}) error {
 	if !util.StringInSlice(si.Method, supportedProtocolMethods) {
-		return fmt.Errorf("неподдерживаемый метод запроса: %s", si.Method)
+		return stepError(si, "method", fmt.Errorf("неподдерживаемый метод запроса: %s", si.Method))
 	}
 	if si.Auth != (Auth{}) && !util.StringInSlice(si.Auth.Type, supportedAuthentications) {
-		return fmt.Errorf("неподдерживаемый метод аутентификации (%s)", si.Auth.Type)
+		return stepError(si, "auth.type", fmt.Errorf("неподдерживаемый метод аутентификации (%s)", si.Auth.Type))
 	}
 	if si.ID == 0 {
-		return fmt.Errorf("ID шага должен быть больше нуля")
+		return stepError(si, "id", fmt.Errorf("ID шага должен быть больше нуля"))
 	}
-	if !envVarRegexp.MatchString(si.URL) && !validator.IsURL(strings.ReplaceAll(si.URL, " ", "_")) {
-		return fmt.Errorf("цель недействительна: %s", si.URL)
+	if !envVarRegexp.MatchString(si.URL) && !templateFuncRegexp.MatchString(si.URL) && !validator.IsURL(strings.ReplaceAll(si.URL, " ", "_")) {
+		return stepError(si, "url", fmt.Errorf("цель недействительна: %s", si.URL))
 	}
 	if si.Sleep != "" {
 		sleep := strings.Split(si.Sleep, "-")
 
 		// Избегайте некорректного синтаксиса, например, "-300-500"
 		if len(sleep) > 2 {
-			return fmt.Errorf("выражение ожидания недействительно: %s", si.Sleep)
+			return stepError(si, "sleep", fmt.Errorf("выражение ожидания недействительно: %s", si.Sleep))
 		}
 
 		// Проверка преобразования строки в число
 		for _, s := range sleep {
 			dur, err := strconv.Atoi(s)
 			if err != nil {
-				return fmt.Errorf("время ожидания недействительно: %s", si.Sleep)
+				return stepError(si, "sleep", fmt.Errorf("время ожидания недействительно: %s", si.Sleep))
 			}
 
 			if dur > maxSleep {
-				return fmt.Errorf("превышен максимальный предел ожидания. указано: %d мс, максимум: %d мс", dur, maxSleep)
+				return stepError(si, "sleep", fmt.Errorf("превышен максимальный предел ожидания. указано: %d мс, максимум: %d мс", dur, maxSleep))
 			}
 		}
 	}
 
+	for _, st := range si.ExpectedStatus {
+		if !expectedStatusRegexp.MatchString(st) {
+			return stepError(si, "expected_status", fmt.Errorf("ожидаемый статус-код недействителен: %s", st))
+		}
+	}
+
 	for _, conf := range si.EnvsToCapture {
 		err := validateCaptureConf(conf)
 		if err != nil {
-			return wrapAsScenarioValidationError(err)
+			return wrapAsScenarioValidationError(stepError(si, "captureEnv."+conf.Name, err))
 		}
 	}
 
@@ -262,6 +418,28 @@ func (si *ScenarioStep) validate(definedEnvs map[string]struct{}) error {
 	return nil
 }
 
+// Допустимые значения ожидаемого статуса: точный код или класс, например 404 или 4XX
+var expectedStatusRegexp = regexp.MustCompile(`^[1-5](\d\d|[xX]{2})$`)
+
+// StatusExpected сообщает, соответствует ли статус-код code ожидаемым статусам шага.
+func (si *ScenarioStep) StatusExpected(code int) bool {
+	if len(si.ExpectedStatus) == 0 {
+		return true
+	}
+	c := strconv.Itoa(code)
+	for _, st := range si.ExpectedStatus {
+		if strings.EqualFold(st, c) || (strings.EqualFold(st[1:], "xx") && st[0] == c[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// stepError добавляет к ошибке проверки ID шага и поле конфигурации.
+func stepError(si *ScenarioStep, field string, err error) error {
+	return StepValidationError{StepID: si.ID, Field: field, wrappedErr: err}
+}
+
 func wrapAsScenarioValidationError(err error) ScenarioValidationError {
 	return ScenarioValidationError{
 		msg:        fmt.Sprintf("Ошибка проверки сценария: %v", err),
@@ -270,7 +448,8 @@ func wrapAsScenarioValidationError(err error) ScenarioValidationError {
 }
 
 func validateCaptureConf(conf EnvCaptureConf) error {
-	if !(conf.From == Header || conf.From == Body) {
+	if !(conf.From == Header || conf.From == Body || conf.From == Cookie ||
+		conf.From == Status || conf.From == Duration || conf.From == URL) {
 		return CaptureConfigError{
 			msg: fmt.Sprintf("некорректный тип \"from\" в настройках извлечения: %s", conf.From),
 		}
@@ -282,9 +461,64 @@ func validateCaptureConf(conf EnvCaptureConf) error {
 		}
 	}
 
-	if conf.From == Body && conf.JsonPath == nil && conf.RegExp == nil && conf.Xpath == nil {
+	if conf.From == Cookie && conf.Cookie == nil {
+		return CaptureConfigError{
+			msg: fmt.Sprintf("%s, необходимо указать имя cookie", conf.Name),
+		}
+	}
+
+	if conf.From == Body && conf.JsonPath == nil && conf.RegExp == nil && conf.Xpath == nil &&
+		conf.HtmlXpath == nil && conf.CssSelector == nil {
 		return CaptureConfigError{
-			msg: fmt.Sprintf("%s, необходимо указать один из jsonPath, regExp или xPath для извлечения из тела", conf.Name),
+			msg: fmt.Sprintf("%s, необходимо указать один из jsonPath, regExp, xPath, htmlXPath или cssSelector для извлечения из тела", conf.Name),
+		}
+	}
+
+	if conf.RegExp != nil {
+		if conf.RegExp.Exp == nil {
+			return CaptureConfigError{
+				msg: fmt.Sprintf("%s, необходимо указать регулярное выражение", conf.Name),
+			}
+		}
+		groups, err := conf.RegExp.groupNames()
+		if err != nil {
+			return CaptureConfigError{
+				msg:        fmt.Sprintf("%s, некорректное регулярное выражение %s: %v", conf.Name, *conf.RegExp.Exp, err),
+				wrappedErr: err,
+			}
+		}
+		if conf.RegExp.NamedGroups && len(groups) == 0 {
+			return CaptureConfigError{
+				msg: fmt.Sprintf("%s, регулярное выражение не содержит именованных групп (?P<name>...)", conf.Name),
+			}
+		}
+	}
+
+	if conf.CssSelector != nil {
+		if conf.CssSelector.Selector == nil || *conf.CssSelector.Selector == "" {
+			return CaptureConfigError{
+				msg: fmt.Sprintf("%s, необходимо указать css-селектор", conf.Name),
+			}
+		}
+		if _, err := cascadia.Compile(*conf.CssSelector.Selector); err != nil {
+			return CaptureConfigError{
+				msg:        fmt.Sprintf("%s, некорректный css-селектор %s: %v", conf.Name, *conf.CssSelector.Selector, err),
+				wrappedErr: err,
+			}
+		}
+		if conf.CssSelector.No < 0 {
+			return CaptureConfigError{
+				msg: fmt.Sprintf("%s, номер совпадения css-селектора не может быть отрицательным", conf.Name),
+			}
+		}
+	}
+
+	if conf.HtmlXpath != nil {
+		if _, err := xpath.Compile(*conf.HtmlXpath); err != nil {
+			return CaptureConfigError{
+				msg:        fmt.Sprintf("%s, некорректный xPath %s: %v", conf.Name, *conf.HtmlXpath, err),
+				wrappedErr: err,
+			}
 		}
 	}
 
@@ -314,7 +548,7 @@ func ParseTLS(certFile, keyFile string) (tls.Certificate, *x509.CertPool, error)
 }
 
 func IsTargetValid(url string) error {
-	if !envVarRegexp.MatchString(url) && !validator.IsURL(strings.ReplaceAll(url, " ", "_")) {
+	if !envVarRegexp.MatchString(url) && !templateFuncRegexp.MatchString(url) && !validator.IsURL(strings.ReplaceAll(url, " ", "_")) {
 		return fmt.Errorf("цель недействительна: %s", url)
 	}
 	return nil
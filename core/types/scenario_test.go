@@ -0,0 +1,41 @@
+package types
+
+import (
+	"strings"
+	"testing"
+)
+
+// Некорректные CSS-селекторы и XPath по HTML отклоняются при проверке конфигурации, до отправки запросов.
+func TestValidateHtmlCaptureConf(t *testing.T) {
+	css := func(selector *string, no int) EnvCaptureConf {
+		return EnvCaptureConf{Name: "CSRF", From: Body, CssSelector: &CssSelectorConf{Selector: selector, Attr: "value", No: no}}
+	}
+	tests := []struct {
+		name string
+		conf EnvCaptureConf
+		err  string
+	}{
+		{name: "css selector", conf: css(strPtr("input[name=csrf]"), 1)},
+		{name: "html xpath", conf: EnvCaptureConf{Name: "TITLE", From: Body, HtmlXpath: strPtr("//title")}},
+		{name: "missing css selector", conf: css(nil, 0), err: "CSRF, необходимо указать css-селектор"},
+		{name: "empty css selector", conf: css(strPtr(""), 0), err: "CSRF, необходимо указать css-селектор"},
+		{name: "bad css selector", conf: css(strPtr("input[name="), 0), err: "CSRF, некорректный css-селектор input[name=: "},
+		{name: "negative match number", conf: css(strPtr("input"), -1), err: "CSRF, номер совпадения css-селектора не может быть отрицательным"},
+		{
+			name: "bad html xpath", conf: EnvCaptureConf{Name: "TITLE", From: Body, HtmlXpath: strPtr("//title[")},
+			err: "TITLE, некорректный xPath //title[: ",
+		},
+	}
+	for _, tt := range tests {
+		err := validateCaptureConf(tt.conf)
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("%s: expected error %q, got %v", tt.name, tt.err, err)
+		}
+	}
+}
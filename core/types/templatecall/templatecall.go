@@ -0,0 +1,122 @@
+// Package templatecall parses template function calls like {{name arg1 "arg2"}}. It has no dependencies
+// on the scripting engine, so both the config validation in core/types and the injector use it.
+package templatecall
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FuncNames are the names of the built-in template functions. The injector registers a function for each name.
+var FuncNames = []string{
+	// Encoding
+	"base64", "base64Url", "base64Decode", "urlEncode", "urlDecode", "json",
+	// Hashes
+	"md5", "sha1", "sha256", "sha512", "hmacSha256",
+	// Strings
+	"upper", "lower", "trim", "sprintf",
+	// Time and numbers
+	"now", "randomInt",
+}
+
+var funcNames = func() map[string]struct{} {
+	m := make(map[string]struct{}, len(FuncNames))
+	for _, n := range FuncNames {
+		m[n] = struct{}{}
+	}
+	return m
+}()
+
+// IsFunc reports whether name is a built-in template function.
+func IsFunc(name string) bool {
+	_, ok := funcNames[name]
+	return ok
+}
+
+// Arg is a single argument of a template function call.
+type Arg struct {
+	Value string
+	// Literal is true for quoted strings and numbers, otherwise Value is a variable name.
+	Literal bool
+	// Quoted is true for quoted strings. Unquoted literals are numbers.
+	Quoted bool
+}
+
+// LiteralValue returns the value of a literal argument: numbers are passed to functions as int or float64.
+func (a Arg) LiteralValue() interface{} {
+	if a.Quoted {
+		return a.Value
+	}
+	if i, err := strconv.Atoi(a.Value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(a.Value, 64); err == nil {
+		return f
+	}
+	return a.Value
+}
+
+// Call is a parsed template function call.
+type Call struct {
+	Name string
+	Args []Arg
+}
+
+// Parse parses a {{name arg1 "arg2"}} tag. Quotes escaped as \" (json payloads) are accepted.
+func Parse(tag string) (Call, error) {
+	var call Call
+
+	body := strings.TrimSpace(tag)
+	body = strings.TrimPrefix(body, `"`)
+	body = strings.TrimSuffix(body, `"`)
+	if !strings.HasPrefix(body, "{{") || !strings.HasSuffix(body, "}}") {
+		return call, fmt.Errorf("%s is not a template function call", tag)
+	}
+	body = strings.ReplaceAll(body[2:len(body)-2], `\"`, `"`)
+
+	tokens, err := tokenize(body)
+	if err != nil {
+		return call, fmt.Errorf("%s: %v", tag, err)
+	}
+	if len(tokens) == 0 || tokens[0].Literal {
+		return call, fmt.Errorf("%s: function name is missing", tag)
+	}
+
+	call.Name = tokens[0].Value
+	call.Args = tokens[1:]
+	return call, nil
+}
+
+func tokenize(s string) ([]Arg, error) {
+	var tokens []Arg
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated string literal")
+			}
+			tokens = append(tokens, Arg{Value: string(runes[i+1 : end]), Literal: true, Quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			word := string(runes[i:end])
+			_, numErr := strconv.ParseFloat(word, 64)
+			tokens = append(tokens, Arg{Value: word, Literal: numErr == nil})
+			i = end
+		}
+	}
+	return tokens, nil
+}
@@ -0,0 +1,62 @@
+package templatecall
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		call Call
+		err  string
+	}{
+		{tag: "{{now}}", call: Call{Name: "now", Args: []Arg{}}},
+		{tag: `{{now "+5m" 'RFC3339'}}`, call: Call{Name: "now", Args: []Arg{
+			{Value: "+5m", Literal: true, Quoted: true}, {Value: "RFC3339", Literal: true, Quoted: true},
+		}}},
+		// Вызов внутри строки JSON с экранированными кавычками
+		{tag: `"{{sprintf \"%s-%d\" NAME 7}}"`, call: Call{Name: "sprintf", Args: []Arg{
+			{Value: "%s-%d", Literal: true, Quoted: true}, {Value: "NAME"}, {Value: "7", Literal: true},
+		}}},
+		{tag: `{{upper "привет мир"}}`, call: Call{Name: "upper", Args: []Arg{{Value: "привет мир", Literal: true, Quoted: true}}}},
+		{tag: "now", err: "now is not a template function call"},
+		{tag: `{{upper "abc}}`, err: `{{upper "abc}}: unterminated string literal`},
+		{tag: "{{ 12 }}", err: "{{ 12 }}: function name is missing"},
+	}
+	for _, test := range tests {
+		call, err := Parse(test.tag)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: expected error %q, got %v", test.tag, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.tag, err)
+			continue
+		}
+		if len(call.Args) == 0 {
+			call.Args = []Arg{}
+		}
+		if !reflect.DeepEqual(call, test.call) {
+			t.Errorf("%s: expected %+v, got %+v", test.tag, test.call, call)
+		}
+	}
+}
+
+func TestLiteralValue(t *testing.T) {
+	call, err := Parse(`{{sprintf "%d %g %s" 12 -0.5 "3"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"%d %g %s", 12, -0.5, "3"}
+	if len(call.Args) != len(expected) {
+		t.Fatalf("expected %d args, got %d", len(expected), len(call.Args))
+	}
+	for i, a := range call.Args {
+		if got := a.LiteralValue(); got != expected[i] {
+			t.Errorf("arg %d: expected %v (%T), got %v (%T)", i, expected[i], expected[i], got, got)
+		}
+	}
+}
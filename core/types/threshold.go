@@ -0,0 +1,78 @@
+package types
+
+import (
+	"fmt"
+
+	"httes/core/util"
+)
+
+// Метрики, для которых можно задать пороговые значения.
+const (
+	ThresholdAvgDuration  = "avg_duration"  // Средняя длительность итерации (шага) в секундах
+	ThresholdP50          = "p50"           // 50-й процентиль длительности в секундах
+	ThresholdP90          = "p90"           // 90-й процентиль длительности в секундах
+	ThresholdP95          = "p95"           // 95-й процентиль длительности в секундах
+	ThresholdP99          = "p99"           // 99-й процентиль длительности в секундах
+	ThresholdFailRate     = "fail_rate"     // Доля неуспешных итераций (запросов) в процентах
+	ThresholdRps          = "rps"           // Среднее количество итераций в секунду
+	ThresholdSuccessCount = "success_count" // Количество успешных итераций (запросов)
+	ThresholdFailedCount  = "failed_count"  // Количество неуспешных итераций (запросов)
+)
+
+// Список поддерживаемых метрик порогов.
+var thresholdMetrics = [...]string{
+	ThresholdAvgDuration, ThresholdP50, ThresholdP90, ThresholdP95, ThresholdP99,
+	ThresholdFailRate, ThresholdRps, ThresholdSuccessCount, ThresholdFailedCount,
+}
+
+// Threshold описывает пороговое значение метрики теста.
+// Тест считается неуспешным, если значение метрики выходит за границы Min/Max.
+type Threshold struct {
+	// Имя метрики, например "p95" или "fail_rate".
+	Metric string
+
+	// ID шага, к которому относится порог. 0 — метрика всего теста.
+	StepID uint16
+
+	// Нижняя граница значения метрики. nil — не проверяется.
+	Min *float64
+
+	// Верхняя граница значения метрики. nil — не проверяется.
+	Max *float64
+}
+
+// String возвращает описание порога, например "p95 (step 2) <= 0.5".
+func (t Threshold) String() string {
+	s := t.Metric
+	if t.StepID != 0 {
+		s += fmt.Sprintf(" (step %d)", t.StepID)
+	}
+	if t.Min != nil {
+		s += fmt.Sprintf(" >= %g", *t.Min)
+	}
+	if t.Max != nil {
+		s += fmt.Sprintf(" <= %g", *t.Max)
+	}
+	return s
+}
+
+func (t Threshold) validate(stepIds map[uint16]struct{}) error {
+	if !util.StringInSlice(t.Metric, thresholdMetrics[:]) {
+		return fmt.Errorf("unsupported threshold metric: %s", t.Metric)
+	}
+	if t.Min == nil && t.Max == nil {
+		return fmt.Errorf("threshold %s should have min or max", t.Metric)
+	}
+	if t.Min != nil && t.Max != nil && *t.Min > *t.Max {
+		return fmt.Errorf("threshold %s: min should not be greater than max", t.Metric)
+	}
+	if t.StepID != 0 {
+		if t.Metric == ThresholdRps {
+			return fmt.Errorf("threshold %s is not supported for steps", t.Metric)
+		}
+		if _, ok := stepIds[t.StepID]; !ok {
+			return fmt.Errorf("threshold %s: step %d not found", t.Metric, t.StepID)
+		}
+	}
+	return nil
+}
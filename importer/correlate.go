@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Заголовки ответа, значения которых не переносятся в следующие запросы
+var harSkipResponseHeaders = map[string]bool{
+	"date": true, "expires": true, "last-modified": true, "age": true, "etag": true,
+	"server": true, "via": true, "vary": true, "set-cookie": true, "cache-control": true, "pragma": true,
+	"connection": true, "keep-alive": true, "transfer-encoding": true, "alt-svc": true,
+	"strict-transport-security": true, "content-security-policy": true, "report-to": true, "nel": true,
+}
+
+// harValue — значение из ответа шага, которое может передаваться в следующие запросы.
+type harValue struct {
+	step    int
+	value   string
+	name    string // Предлагаемое имя переменной
+	source  string // Место значения в ответе, например body order.id
+	capture Capture
+}
+
+// remember запоминает значения запроса: значения ответов, которые уже встречались в запросах,
+// введены пользователем, а не получены от сервера.
+func (c *harCaptures) remember(req harRequest) {
+	c.requests.WriteString(req.URL)
+	for _, h := range req.Headers {
+		c.requests.WriteString("\n" + h.Value)
+	}
+	if req.PostData != nil {
+		c.requests.WriteString("\n" + req.PostData.Text)
+		for _, p := range req.PostData.Params {
+			c.requests.WriteString("\n" + p.Value)
+		}
+	}
+}
+
+// observeValues запоминает значения JSON-тела и заголовков ответа шага step.
+func (c *harCaptures) observeValues(step int, resp harResponse, body interface{}) {
+	seen := c.requests.String()
+	add := func(v harValue) {
+		if correlatable(v.value) && !strings.Contains(seen, v.value) {
+			c.values = append(c.values, v)
+		}
+	}
+
+	for _, h := range resp.Headers {
+		k := strings.ToLower(h.Name)
+		if harSkipResponseHeaders[k] || strings.HasPrefix(k, "content-") || strings.HasPrefix(k, "access-control-") {
+			continue
+		}
+		add(harValue{step: step, value: h.Value, name: h.Name, source: "header " + h.Name,
+			capture: Capture{From: "header", Header: strPtr(h.Name)}})
+	}
+
+	if body != nil {
+		jsonValues(body, "", func(p, value string) {
+			add(harValue{step: step, value: value, name: jsonPathName(p), source: "body " + p,
+				capture: Capture{From: "body", JsonPath: strPtr(p)}})
+		})
+	}
+}
+
+// correlate заменяет в адресе, заголовках и теле шага значения из ответов предыдущих шагов
+// переменными и добавляет этим шагам извлечение переменных.
+func (c *harCaptures) correlate(st *Step) {
+	if len(c.values) == 0 {
+		return
+	}
+	st.URL = c.correlateURL(st)
+
+	keys := make([]string, 0, len(st.Headers))
+	for k := range st.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := st.Headers[k]
+		if strings.EqualFold(k, "Cookie") || strings.Contains(v, "{{") {
+			continue
+		}
+		prefix := ""
+		if token, ok := strings.CutPrefix(v, "Bearer "); ok {
+			prefix, v = "Bearer ", token
+		}
+		if hv, ok := c.match(v); ok {
+			st.Headers[k] = prefix + "{{" + c.use(hv, st, "header "+k) + "}}"
+		}
+	}
+
+	if st.Payload == "" {
+		return
+	}
+	var body interface{}
+	if json.Unmarshal([]byte(st.Payload), &body) == nil {
+		jsonValues(body, "", func(p, value string) {
+			if _, err := strconv.ParseFloat(value, 64); err == nil {
+				return // Числа в теле не заменяются: переменная подставляется строкой
+			}
+			if hv, ok := c.match(value); ok {
+				quoted, _ := json.Marshal(value)
+				st.Payload = strings.ReplaceAll(st.Payload, string(quoted), `"{{`+c.use(hv, st, "body "+p)+`}}"`)
+			}
+		})
+		return
+	}
+	if strings.Contains(st.Headers[headerName(st.Headers, "Content-Type")], "x-www-form-urlencoded") {
+		st.Payload = c.correlateQuery(st, st.Payload, "body")
+	}
+}
+
+// correlateURL заменяет переменными сегменты пути и значения параметров адреса шага.
+func (c *harCaptures) correlateURL(st *Step) string {
+	base, query, hasQuery := strings.Cut(st.URL, "?")
+	prefix, path := base, ""
+	if i := strings.Index(base, "://"); i >= 0 {
+		if j := strings.Index(base[i+3:], "/"); j >= 0 {
+			prefix, path = base[:i+3+j], base[i+3+j:]
+		}
+	}
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if v, err := url.PathUnescape(s); err == nil {
+			if hv, ok := c.match(v); ok {
+				segments[i] = "{{" + c.use(hv, st, "url") + "}}"
+			}
+		}
+	}
+	res := prefix + strings.Join(segments, "/")
+	if hasQuery {
+		res += "?" + c.correlateQuery(st, query, "url")
+	}
+	return res
+}
+
+// correlateQuery заменяет переменными значения параметров в строке вида a=1&b=2.
+func (c *harCaptures) correlateQuery(st *Step, query, where string) string {
+	params := strings.Split(query, "&")
+	for i, p := range params {
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			continue
+		}
+		if v, err := url.QueryUnescape(v); err == nil {
+			if hv, ok := c.match(v); ok {
+				params[i] = k + "={{" + c.use(hv, st, where+" "+k) + "}}"
+			}
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// match ищет значение в ответах предыдущих шагов, начиная с последнего.
+func (c *harCaptures) match(value string) (harValue, bool) {
+	for i := len(c.values) - 1; i >= 0; i-- {
+		if c.values[i].value == value {
+			return c.values[i], true
+		}
+	}
+	return harValue{}, false
+}
+
+// use добавляет извлечение значения hv и описывает его в Result.Captures. Возвращает имя переменной.
+func (c *harCaptures) use(hv harValue, st *Step, where string) string {
+	env := c.capture(hv.step, strings.ToUpper(hv.name), hv.capture)
+	c.res.capture("step %d %s: {{%s}} from step %d %s", st.ID, where, env, (*c.steps)[hv.step].ID, hv.source)
+	return env
+}
+
+// correlatable сообщает, похоже ли значение на идентификатор или токен, выданный сервером:
+// короткие значения без цифр часто совпадают случайно.
+func correlatable(v string) bool {
+	if len(v) < 3 || len(v) > 4096 || strings.ContainsAny(v, " \t\r\n\"") {
+		return false
+	}
+	return len(v) >= 16 || strings.ContainsAny(v, "0123456789")
+}
+
+// jsonValues вызывает fn для каждой строки и целого числа в JSON с путём в синтаксисе gjson.
+func jsonValues(v interface{}, prefix string, fn func(path, value string)) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := v.(type) {
+	case string:
+		if prefix != "" {
+			fn(prefix, t)
+		}
+	case float64:
+		if prefix != "" && t == float64(int64(t)) {
+			fn(prefix, strconv.FormatInt(int64(t), 10))
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			if !strings.ContainsAny(k, ".*?") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			jsonValues(t[k], join(k), fn)
+		}
+	case []interface{}:
+		for i, child := range t {
+			jsonValues(child, join(strconv.Itoa(i)), fn)
+		}
+	}
+}
+
+// jsonPathName возвращает имя переменной для пути JSON: последний ключ, а для коротких ключей вроде id —
+// вместе с предыдущим, например order_id для data.order.id.
+func jsonPathName(p string) string {
+	var keys []string
+	for _, k := range strings.Split(p, ".") {
+		if _, err := strconv.Atoi(k); err != nil {
+			keys = append(keys, k)
+		}
+	}
+	switch {
+	case len(keys) == 0:
+		return "VALUE"
+	case len(keys) > 1 && len(keys[len(keys)-1]) <= 3:
+		return keys[len(keys)-2] + "_" + keys[len(keys)-1]
+	}
+	return keys[len(keys)-1]
+}
+
+// capture добавляет описание найденного извлечения переменной, если такого ещё нет.
+func (r *Result) capture(format string, args ...interface{}) {
+	c := fmt.Sprintf(format, args...)
+	for _, e := range r.Captures {
+		if e == c {
+			return
+		}
+	}
+	r.Captures = append(r.Captures, c)
+}
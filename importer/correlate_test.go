@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testEntry — запрос и ответ записи HAR для тестов.
+type testEntry struct {
+	method      string
+	url         string
+	headers     [][2]string
+	body        string
+	bodyType    string
+	respHeaders [][2]string
+	respCookies [][2]string
+	respBody    string
+}
+
+// importEntries импортирует запись HAR из entries и возвращает результат с шагами единственного сценария.
+func importEntries(t *testing.T, entries ...testEntry) (*Result, []Step) {
+	t.Helper()
+	nameValues := func(pairs [][2]string) []map[string]string {
+		res := []map[string]string{}
+		for _, p := range pairs {
+			res = append(res, map[string]string{"name": p[0], "value": p[1]})
+		}
+		return res
+	}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	var har struct {
+		Log struct {
+			Entries []map[string]interface{} `json:"entries"`
+		} `json:"log"`
+	}
+	for i, e := range entries {
+		method := e.method
+		if method == "" {
+			method = "GET"
+		}
+		req := map[string]interface{}{"method": method, "url": e.url, "headers": nameValues(e.headers), "cookies": []string{}}
+		if e.body != "" {
+			req["postData"] = map[string]string{"mimeType": e.bodyType, "text": e.body}
+		}
+		har.Log.Entries = append(har.Log.Entries, map[string]interface{}{
+			"startedDateTime": start.Add(time.Duration(i) * time.Second),
+			"time":            10,
+			"request":         req,
+			"response": map[string]interface{}{
+				"status":  200,
+				"headers": nameValues(e.respHeaders),
+				"cookies": nameValues(e.respCookies),
+				"content": map[string]string{"mimeType": "application/json", "text": e.respBody},
+			},
+		})
+	}
+	data, err := json.Marshal(har)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Import(FormatHAR, "test.har", data, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, res.Scenarios[0].Steps
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Значения из ответов заменяются переменными в адресе, заголовках, cookie, JSON-теле и форме следующих шагов,
+// а шагам с этими ответами добавляется извлечение переменных.
+func TestCorrelateResponseValues(t *testing.T) {
+	const token = "eyJhbGciOiJIUzI1NiJ9.e30.sig"
+	res, steps := importEntries(t,
+		testEntry{
+			method: "POST", url: "https://api.example.com/login",
+			body: `{"user":"demo"}`, bodyType: "application/json",
+			headers:     [][2]string{{"Content-Type", "application/json"}},
+			respHeaders: [][2]string{{"X-Session", "sess-8f3a2c"}, {"Date", "Wed, 01 May 2024 10:00:00 GMT"}},
+			respCookies: [][2]string{{"sid", "s3cr3t42"}},
+			respBody:    `{"token":"` + token + `","user":{"id":1042,"name":"demo"}}`,
+		},
+		testEntry{
+			url:      "https://api.example.com/users/1042/orders?session=sess-8f3a2c",
+			headers:  [][2]string{{"Authorization", "Bearer " + token}, {"Cookie", "sid=s3cr3t42; theme=dark"}},
+			respBody: `{"orders":[{"id":"ord-77","total":1042}]}`,
+		},
+		testEntry{
+			method: "POST", url: "https://api.example.com/orders/ord-77/pay",
+			headers: [][2]string{{"Content-Type", "application/json"}},
+			body:    `{"order":"ord-77","amount":1042,"user":"demo"}`, bodyType: "application/json",
+			respBody: `{}`,
+		},
+		testEntry{
+			method: "POST", url: "https://api.example.com/confirm",
+			headers: [][2]string{{"Content-Type", "application/x-www-form-urlencoded"}},
+			body:    "order=ord-77&note=ok", bodyType: "application/x-www-form-urlencoded",
+			respBody: `{}`,
+		},
+	)
+	if len(steps) != 4 {
+		t.Fatalf("expected 4 steps, got %d", len(steps))
+	}
+
+	expectedCaptures := map[string]Capture{
+		"TOKEN":      {From: "body", JsonPath: strPtr("token")},
+		"USER_ID":    {From: "body", JsonPath: strPtr("user.id")},
+		"X_SESSION":  {From: "header", Header: strPtr("X-Session")},
+		"COOKIE_sid": {From: "cookie", Cookie: strPtr("sid")},
+	}
+	if !reflect.DeepEqual(steps[0].CaptureEnv, expectedCaptures) {
+		t.Errorf("step 1 captures:\n%v\nexpected:\n%v", steps[0].CaptureEnv, expectedCaptures)
+	}
+	if expected := map[string]Capture{"ORDERS_ID": {From: "body", JsonPath: strPtr("orders.0.id")}}; !reflect.DeepEqual(steps[1].CaptureEnv, expected) {
+		t.Errorf("step 2 captures: %v", steps[1].CaptureEnv)
+	}
+
+	if expected := "https://api.example.com/users/{{USER_ID}}/orders?session={{X_SESSION}}"; steps[1].URL != expected {
+		t.Errorf("step 2 url: expected %s, got %s", expected, steps[1].URL)
+	}
+	if h := steps[1].Headers; h["Authorization"] != "Bearer {{TOKEN}}" || h["Cookie"] != "sid={{COOKIE_sid}}; theme=dark" {
+		t.Errorf("step 2 headers: %v", h)
+	}
+	if expected := "https://api.example.com/orders/{{ORDERS_ID}}/pay"; steps[2].URL != expected {
+		t.Errorf("step 3 url: expected %s, got %s", expected, steps[2].URL)
+	}
+	// Числа в теле не заменяются, а значения, введённые пользователем, не считаются полученными от сервера
+	if expected := `{"order":"{{ORDERS_ID}}","amount":1042,"user":"demo"}`; steps[2].Payload != expected {
+		t.Errorf("step 3 payload: expected %s, got %s", expected, steps[2].Payload)
+	}
+	if expected := "order={{ORDERS_ID}}&note=ok"; steps[3].Payload != expected {
+		t.Errorf("step 4 payload: expected %s, got %s", expected, steps[3].Payload)
+	}
+
+	for _, c := range []string{
+		"step 2 url: {{USER_ID}} from step 1 body user.id",
+		"step 2 url session: {{X_SESSION}} from step 1 header X-Session",
+		"step 3 body order: {{ORDERS_ID}} from step 2 body orders.0.id",
+		"step 4 body order: {{ORDERS_ID}} from step 2 body orders.0.id",
+	} {
+		if !containsString(res.Captures, c) {
+			t.Errorf("captures do not contain %q: %q", c, res.Captures)
+		}
+	}
+}
+
+// Значения, которые уже были в предыдущих запросах, короткие значения без цифр и значения
+// служебных заголовков не заменяются переменными.
+func TestCorrelateSkipsUnrelatedValues(t *testing.T) {
+	_, steps := importEntries(t,
+		testEntry{
+			method: "POST", url: "https://api.example.com/search",
+			body: `{"q":"item-12345"}`, bodyType: "application/json",
+			respHeaders: [][2]string{{"Etag", "W/\"v-2024\""}, {"Server", "nginx-1.25"}},
+			respBody:    `{"query":"item-12345","status":"ok","next":"cursor-99"}`,
+		},
+		testEntry{
+			url:      "https://api.example.com/search/ok?q=item-12345&cursor=cursor-99&v=nginx-1.25",
+			respBody: `{}`,
+		},
+	)
+	if expected := "https://api.example.com/search/ok?q=item-12345&cursor={{NEXT}}&v=nginx-1.25"; steps[1].URL != expected {
+		t.Errorf("expected %s, got %s", expected, steps[1].URL)
+	}
+	if expected := map[string]Capture{"NEXT": {From: "body", JsonPath: strPtr("next")}}; !reflect.DeepEqual(steps[0].CaptureEnv, expected) {
+		t.Errorf("unexpected captures: %v", steps[0].CaptureEnv)
+	}
+}
+
+// Значения с одинаковыми именами из разных ответов получают разные переменные,
+// а повторное использование значения — ту же переменную.
+func TestCorrelateVariableNames(t *testing.T) {
+	_, steps := importEntries(t,
+		testEntry{url: "https://api.example.com/carts", respBody: `{"id":"cart-111"}`},
+		testEntry{url: "https://api.example.com/carts/cart-111/items", respBody: `{"id":"item-222"}`},
+		testEntry{url: "https://api.example.com/carts/cart-111/items/item-222", respBody: `{}`},
+	)
+	if expected := "https://api.example.com/carts/{{ID}}/items"; steps[1].URL != expected {
+		t.Errorf("step 2: expected %s, got %s", expected, steps[1].URL)
+	}
+	if expected := "https://api.example.com/carts/{{ID}}/items/{{ID_2}}"; steps[2].URL != expected {
+		t.Errorf("step 3: expected %s, got %s", expected, steps[2].URL)
+	}
+	if len(steps[0].CaptureEnv) != 1 || len(steps[1].CaptureEnv) != 1 || steps[1].CaptureEnv["ID_2"].JsonPath == nil {
+		t.Errorf("unexpected captures: %v, %v", steps[0].CaptureEnv, steps[1].CaptureEnv)
+	}
+}
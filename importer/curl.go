@@ -0,0 +1,503 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const FormatCurl = "curl"
+
+func init() {
+	AvailableImporters[FormatCurl] = curlImporter{}
+}
+
+// Параметры cURL со значением, которые не влияют на запрос и пропускаются
+var curlIgnoredArgs = map[string]bool{
+	"-o": true, "--output": true, "-w": true, "--write-out": true, "-c": true, "--cookie-jar": true,
+	"-D": true, "--dump-header": true, "--connect-timeout": true, "--retry": true, "--retry-delay": true,
+	"--retry-max-time": true, "--max-redirs": true, "--cacert": true, "--capath": true, "--trace": true,
+	"--trace-ascii": true, "--stderr": true, "--limit-rate": true, "--interface": true,
+}
+
+// Параметры cURL со значением, которые нельзя перенести в шаг: значение пропускается с предупреждением
+var curlUnsupportedArgs = map[string]bool{
+	"-T": true, "--upload-file": true, "-K": true, "--config": true, "--unix-socket": true,
+	"-U": true, "--proxy-user": true, "--aws-sigv4": true, "--resolve": true, "--connect-to": true,
+	"--pass": true, "--cert-type": true, "--key-type": true, "--proto": true, "--ciphers": true,
+	"--noproxy": true, "-z": true, "--time-cond": true,
+}
+
+// Параметры cURL без значения, которые не влияют на запрос или совпадают с поведением httes
+var curlIgnoredFlags = map[string]bool{
+	"-s": true, "--silent": true, "-S": true, "--show-error": true, "-v": true, "--verbose": true,
+	"-i": true, "--include": true, "-L": true, "--location": true, "-k": true, "--insecure": true,
+	"--compressed": true, "-f": true, "--fail": true, "-#": true, "--progress-bar": true, "-N": true,
+	"--no-buffer": true, "--http1.1": true, "-g": true, "--globoff": true,
+}
+
+// curlImporter импортирует команды cURL, например скопированные из документации API
+// или через «Copy as cURL» в DevTools браузера. Каждая команда становится шагом сценария.
+type curlImporter struct{}
+
+// Detect проверяет, что первая строка, не считая пустых строк и комментариев, начинается с команды curl.
+func (curlImporter) Detect(name string, data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		return bytes.HasPrefix(line, []byte("curl "))
+	}
+	return false
+}
+
+func (curlImporter) Import(data []byte, opts Options) (*Result, error) {
+	res, err := ParseCurl(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if opts.Name != "" {
+		res.Scenarios[0].Name = opts.Name
+	}
+	return res, nil
+}
+
+// ParseCurl преобразует одну или несколько команд cURL в сценарий. Команды разделяются
+// переводом строки, ";" или "&&". Прокси из параметра -x записывается в Result.Proxy.
+func ParseCurl(command string) (*Result, error) {
+	commands, err := curlSplit(command)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{}
+	var steps []Step
+	for _, args := range commands {
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] != "curl" {
+			return nil, fmt.Errorf("not a curl command: %s", args[0])
+		}
+		st, err := curlStep(args[1:], uint16(len(steps)+1), res)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, st)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("no curl commands found")
+	}
+	res.Scenarios = []Scenario{{Name: "cURL", Steps: steps}}
+	return res, nil
+}
+
+// curlStep преобразует аргументы одной команды cURL в шаг с идентификатором id.
+func curlStep(args []string, id uint16, res *Result) (Step, error) {
+	st := Step{ID: id, Headers: map[string]string{}}
+	var data []string
+	var method, rawURL string
+	var get, head bool
+	var cookies []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			rawURL = arg
+			continue
+		}
+
+		// Короткие параметры могут быть объединены (-sSL) или записаны слитно со значением (-XPOST)
+		name, value, hasValue := arg, "", false
+		if !strings.HasPrefix(arg, "--") && len(arg) > 2 {
+			name, value, hasValue = arg[:2], arg[2:], true
+			if curlIgnoredFlags[name] && !curlTakesValue(name) {
+				combined := true
+				for _, c := range arg[1:] {
+					if !curlIgnoredFlags["-"+string(c)] {
+						combined = false
+					}
+				}
+				if combined {
+					continue
+				}
+			}
+		}
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("curl option %s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		if curlIgnoredFlags[name] {
+			continue
+		}
+		if curlIgnoredArgs[name] || curlUnsupportedArgs[name] {
+			if _, err := next(); err != nil {
+				return st, err
+			}
+			if curlUnsupportedArgs[name] {
+				res.warn("step %d: curl option %s is not converted", id, name)
+			}
+			continue
+		}
+
+		v := ""
+		if curlTakesValue(name) {
+			var err error
+			if v, err = next(); err != nil {
+				return st, err
+			}
+		}
+		switch name {
+		case "-X", "--request":
+			method = strings.ToUpper(v)
+		case "--url":
+			rawURL = v
+		case "-H", "--header":
+			k, hv, ok := strings.Cut(v, ":")
+			if !ok {
+				res.warn("step %d: header %q is not converted", id, v)
+				continue
+			}
+			st.Headers[strings.TrimSpace(k)] = strings.TrimSpace(hv)
+		case "-A", "--user-agent":
+			st.Headers["User-Agent"] = v
+		case "-e", "--referer":
+			st.Headers["Referer"] = v
+		case "-r", "--range":
+			st.Headers["Range"] = "bytes=" + v
+		case "--oauth2-bearer":
+			st.Headers["Authorization"] = "Bearer " + v
+		case "-b", "--cookie":
+			if !strings.Contains(v, "=") {
+				res.warn("step %d: cookie file %s is not loaded", id, v)
+				continue
+			}
+			cookies = append(cookies, v)
+		case "-d", "--data", "--data-ascii", "--data-binary", "--data-raw":
+			if strings.HasPrefix(v, "@") && name != "--data-raw" {
+				if st.PayloadFile != "" || len(data) > 0 {
+					res.warn("step %d: file %s can not be combined with other data", id, v[1:])
+					continue
+				}
+				st.PayloadFile = v[1:]
+				continue
+			}
+			if name == "-d" || name == "--data" || name == "--data-ascii" {
+				v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+			}
+			data = append(data, v)
+		case "--data-urlencode":
+			data = append(data, curlURLEncode(v))
+		case "--json":
+			data = append(data, v)
+			st.Headers["Content-Type"] = "application/json"
+			st.Headers["Accept"] = "application/json"
+		case "-F", "--form", "--form-string":
+			field, err := curlFormField(v, name == "--form-string")
+			if err != nil {
+				return st, err
+			}
+			if strings.HasPrefix(field.Value, "<") {
+				res.warn("step %d: contents of file %s are not loaded for field %s", id, field.Value[1:], field.Name)
+			}
+			st.PayloadMultipart = append(st.PayloadMultipart, field)
+		case "-u", "--user":
+			user, pass, _ := strings.Cut(v, ":")
+			st.Auth = &Auth{Type: "basic", Username: user, Password: pass}
+		case "-E", "--cert":
+			st.CertPath = curlCertPath(v)
+		case "--key":
+			st.CertKeyPath = v
+		case "-x", "--proxy":
+			if !strings.Contains(v, "://") {
+				v = "http://" + v
+			}
+			if res.Proxy != "" && res.Proxy != v {
+				res.warn("step %d: only one proxy is used for all steps, %s is ignored", id, v)
+				continue
+			}
+			res.Proxy = v
+		case "-m", "--max-time":
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return st, fmt.Errorf("invalid curl max time %q", v)
+			}
+			st.Timeout = int(t + 0.999)
+		case "-G", "--get":
+			get = true
+		case "-I", "--head":
+			head = true
+		case "--http2", "--http2-prior-knowledge":
+			st.Others = map[string]interface{}{"h2": true}
+		default:
+			res.warn("step %d: curl option %s is not converted", id, name)
+		}
+	}
+
+	if rawURL == "" {
+		return st, fmt.Errorf("curl command without url")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	if _, err := url.Parse(rawURL); err != nil {
+		return st, fmt.Errorf("invalid url %s: %v", rawURL, err)
+	}
+
+	switch {
+	case get && len(data) > 0:
+		sep := "?"
+		if strings.Contains(rawURL, "?") {
+			sep = "&"
+		}
+		rawURL += sep + strings.Join(data, "&")
+		data = nil
+	case len(data) > 0:
+		st.Payload = strings.Join(data, "&")
+	}
+	st.URL = rawURL
+
+	hasBody := st.Payload != "" || st.PayloadFile != "" || len(st.PayloadMultipart) > 0
+	switch {
+	case method != "":
+		st.Method = method
+	case head:
+		st.Method = "HEAD"
+	case hasBody:
+		st.Method = "POST"
+	default:
+		st.Method = "GET"
+	}
+	contentType := headerName(st.Headers, "Content-Type")
+	if _, ok := st.Headers[contentType]; !ok && (st.Payload != "" || st.PayloadFile != "") {
+		st.Headers[contentType] = "application/x-www-form-urlencoded"
+	}
+	if len(st.PayloadMultipart) > 0 {
+		delete(st.Headers, contentType) // Заголовок с границей формируется заново
+	}
+	if len(cookies) > 0 {
+		st.Headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+	if st.CertPath != "" && st.CertKeyPath == "" || st.CertPath == "" && st.CertKeyPath != "" {
+		res.warn("step %d: both --cert and --key are required for a client certificate", id)
+	}
+	if len(st.Headers) == 0 {
+		st.Headers = nil
+	}
+
+	u, _ := url.Parse(rawURL)
+	st.Name = st.Method + " " + u.Host + u.Path
+	return st, nil
+}
+
+// curlTakesValue сообщает, принимает ли параметр cURL значение.
+func curlTakesValue(name string) bool {
+	switch name {
+	case "-X", "--request", "--url", "-H", "--header", "-A", "--user-agent", "-e", "--referer",
+		"-b", "--cookie", "-d", "--data", "--data-ascii", "--data-binary", "--data-raw", "--data-urlencode",
+		"--json", "-F", "--form", "--form-string", "-u", "--user", "-E", "--cert", "--key",
+		"-x", "--proxy", "-m", "--max-time", "-r", "--range", "--oauth2-bearer":
+		return true
+	}
+	return curlIgnoredArgs[name] || curlUnsupportedArgs[name]
+}
+
+// curlCertPath возвращает путь сертификата из значения --cert "path[:password]". Как и в cURL,
+// двоеточие в пути экранируется "\:", а двоеточие после буквы диска Windows (C:\certs) не отделяет пароль.
+func curlCertPath(v string) string {
+	var path strings.Builder
+	start := 0
+	if len(v) >= 3 && v[1] == ':' && (v[2] == '\\' || v[2] == '/') &&
+		('a' <= v[0] && v[0] <= 'z' || 'A' <= v[0] && v[0] <= 'Z') {
+		path.WriteString(v[:2])
+		start = 2
+	}
+	for i := start; i < len(v); i++ {
+		switch {
+		case v[i] == '\\' && i+1 < len(v) && v[i+1] == ':':
+			path.WriteByte(':')
+			i++
+		case v[i] == ':':
+			return path.String()
+		default:
+			path.WriteByte(v[i])
+		}
+	}
+	return path.String()
+}
+
+// curlURLEncode кодирует значение --data-urlencode: "name=value", "=value" или "value".
+func curlURLEncode(v string) string {
+	if name, value, ok := strings.Cut(v, "="); ok {
+		if name == "" {
+			return url.QueryEscape(value)
+		}
+		return name + "=" + url.QueryEscape(value)
+	}
+	return url.QueryEscape(v)
+}
+
+// curlFormField преобразует поле -F: "name=value", "name=@file" (файл) или "name=<file" (содержимое файла).
+// Параметры поля после ";" (type, filename) отбрасываются.
+func curlFormField(v string, literal bool) (MultipartField, error) {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok {
+		return MultipartField{}, fmt.Errorf("invalid curl form field %q", v)
+	}
+	if literal {
+		return MultipartField{Name: name, Value: value}, nil
+	}
+	if strings.HasPrefix(value, "@") {
+		path, _, _ := strings.Cut(value[1:], ";")
+		return MultipartField{Name: name, Value: path, Type: "file"}, nil
+	}
+	if !strings.HasPrefix(value, `"`) {
+		value, _, _ = strings.Cut(value, ";")
+	}
+	return MultipartField{Name: name, Value: strings.Trim(value, `"`)}, nil
+}
+
+// curlSplit разбивает текст на команды и аргументы по правилам командной оболочки:
+// одинарные и двойные кавычки, $'...', экранирование "\" и перенос строки через "\" или "^".
+func curlSplit(s string) ([][]string, error) {
+	var commands [][]string
+	var args []string
+	var cur strings.Builder
+	inArg := false
+
+	endArg := func() {
+		if inArg {
+			args = append(args, cur.String())
+			cur.Reset()
+			inArg = false
+		}
+	}
+	endCommand := func() {
+		endArg()
+		if len(args) > 0 {
+			commands = append(commands, args)
+			args = nil
+		}
+	}
+
+	r := []rune(s)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case (c == '\\' || c == '^') && i+1 < len(r) && (r[i+1] == '\n' || r[i+1] == '\r'):
+			// Перенос строки внутри команды
+			i++
+			if r[i] == '\r' && i+1 < len(r) && r[i+1] == '\n' {
+				i++
+			}
+		case c == '\\':
+			inArg = true
+			if i+1 < len(r) {
+				i++
+				cur.WriteRune(r[i])
+			}
+		case c == '\'':
+			inArg = true
+			end := indexRune(r, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in curl command")
+			}
+			cur.WriteString(string(r[i+1 : end]))
+			i = end
+		case c == '$' && i+1 < len(r) && r[i+1] == '\'':
+			inArg = true
+			n, err := ansiQuoted(r, i+2, &cur)
+			if err != nil {
+				return nil, err
+			}
+			i = n
+		case c == '"':
+			inArg = true
+			i++
+			for ; i < len(r) && r[i] != '"'; i++ {
+				if r[i] == '\\' && i+1 < len(r) && strings.ContainsRune("\"\\$`\n", r[i+1]) {
+					i++
+					if r[i] == '\n' {
+						continue
+					}
+				}
+				cur.WriteRune(r[i])
+			}
+			if i >= len(r) {
+				return nil, fmt.Errorf("unterminated quote in curl command")
+			}
+		case c == '#' && !inArg:
+			// Комментарий до конца строки
+			for i+1 < len(r) && r[i+1] != '\n' {
+				i++
+			}
+		case c == '\n' || c == ';':
+			endCommand()
+		case c == '&' && i+1 < len(r) && r[i+1] == '&':
+			i++
+			endCommand()
+		case unicode.IsSpace(c):
+			endArg()
+		default:
+			inArg = true
+			cur.WriteRune(c)
+		}
+	}
+	endCommand()
+	return commands, nil
+}
+
+func indexRune(r []rune, from int, c rune) int {
+	for i := from; i < len(r); i++ {
+		if r[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// ansiQuoted записывает строку $'...' начиная с позиции from и возвращает позицию закрывающей кавычки.
+func ansiQuoted(r []rune, from int, cur *strings.Builder) (int, error) {
+	escapes := map[rune]string{'n': "\n", 't': "\t", 'r': "\r", '\\': "\\", '\'': "'", '"': "\"", '0': "\x00"}
+	for i := from; i < len(r); i++ {
+		switch {
+		case r[i] == '\'':
+			return i, nil
+		case r[i] == '\\' && i+1 < len(r):
+			i++
+			if e, ok := escapes[r[i]]; ok {
+				cur.WriteString(e)
+			} else if r[i] == 'u' && i+4 < len(r) {
+				code, err := strconv.ParseUint(string(r[i+1:i+5]), 16, 32)
+				if err != nil {
+					return 0, fmt.Errorf("invalid escape in curl command: \\u%s", string(r[i+1:i+5]))
+				}
+				cur.WriteRune(rune(code))
+				i += 4
+			} else if r[i] == 'x' && i+2 < len(r) {
+				code, err := strconv.ParseUint(string(r[i+1:i+3]), 16, 8)
+				if err != nil {
+					return 0, fmt.Errorf("invalid escape in curl command: \\x%s", string(r[i+1:i+3]))
+				}
+				cur.WriteByte(byte(code))
+				i += 2
+			} else {
+				cur.WriteRune('\\')
+				cur.WriteRune(r[i])
+			}
+		default:
+			cur.WriteRune(r[i])
+		}
+	}
+	return 0, fmt.Errorf("unterminated quote in curl command")
+}
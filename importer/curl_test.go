@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"httes/core/types"
+)
+
+// Файл с несколькими командами, комментариями и переносами строк: каждая команда становится шагом.
+func TestImportCurl(t *testing.T) {
+	res := importFile(t, "requests.curl", Options{})
+	checkConfig(t, res, "requests.json")
+	checkWarnings(t, res, "step 4: curl option --resolve is not converted")
+}
+
+func TestParseCurl(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		step     Step
+		proxy    string
+		warnings []string
+	}{
+		{
+			name:    "quotes and escapes",
+			command: `curl "http://a.test/p?x=1" -H "X-Quote: say \"hi\"" -H $'X-Tab: a\tb' -d 'it'\''s' -d a\ b`,
+			step: Step{Method: "POST", URL: "http://a.test/p?x=1", Payload: "it's&a b", Headers: map[string]string{
+				"X-Quote": `say "hi"`, "X-Tab": "a\tb", "Content-Type": "application/x-www-form-urlencoded",
+			}},
+		},
+		{
+			name:    "method without space and combined flags",
+			command: `curl -sSLk -XPATCH a.test/items -H 'Content-Type: application/json' --data-raw '{"n":1}'`,
+			step: Step{Method: "PATCH", URL: "http://a.test/items", Payload: `{"n":1}`,
+				Headers: map[string]string{"Content-Type": "application/json"}},
+		},
+		{
+			name:    "data in query with -G",
+			command: `curl -G http://a.test/search?lang=en -d q=go --data-urlencode 'tag=a b'`,
+			step:    Step{Method: "GET", URL: "http://a.test/search?lang=en&q=go&tag=a+b"},
+		},
+		{
+			name:    "head",
+			command: `curl -I http://a.test/`,
+			step:    Step{Method: "HEAD", URL: "http://a.test/"},
+		},
+		{
+			name:    "form with files",
+			command: `curl http://a.test/upload -F 'doc=@scan.pdf;type=application/pdf' -F 'note=hello;type=text/plain' -F 'raw=<notes.txt' -H 'Content-Type: multipart/form-data'`,
+			step: Step{Method: "POST", URL: "http://a.test/upload", PayloadMultipart: []MultipartField{
+				{Name: "doc", Value: "scan.pdf", Type: "file"}, {Name: "note", Value: "hello"}, {Name: "raw", Value: "<notes.txt"},
+			}},
+			warnings: []string{"step 1: contents of file notes.txt are not loaded for field raw"},
+		},
+		{
+			name:    "basic auth",
+			command: `curl -u 'ann:p:ss' http://a.test/me`,
+			step:    Step{Method: "GET", URL: "http://a.test/me", Auth: &Auth{Type: "basic", Username: "ann", Password: "p:ss"}},
+		},
+		{
+			name:     "headers without value",
+			command:  `curl http://a.test/ -H 'X-Empty:' -H 'X-Removed;'`,
+			step:     Step{Method: "GET", URL: "http://a.test/", Headers: map[string]string{"X-Empty": ""}},
+			warnings: []string{`step 1: header "X-Removed;" is not converted`},
+		},
+		{
+			name:    "data from file",
+			command: `curl http://a.test/ --data-binary @order.json -H 'Content-Type: application/json'`,
+			step: Step{Method: "POST", URL: "http://a.test/", PayloadFile: "order.json",
+				Headers: map[string]string{"Content-Type": "application/json"}},
+		},
+		{
+			name:    "windows certificate path",
+			command: `curl https://a.test/ -E 'C:\certs\client.pem:secret' --key 'C:\certs\client.key'`,
+			step:    Step{Method: "GET", URL: "https://a.test/", CertPath: `C:\certs\client.pem`, CertKeyPath: `C:\certs\client.key`},
+		},
+		{
+			name:    "escaped colon in certificate path",
+			command: `curl https://a.test/ --cert 'certs\:v2/client.pem' --key client.key`,
+			step:    Step{Method: "GET", URL: "https://a.test/", CertPath: "certs:v2/client.pem", CertKeyPath: "client.key"},
+		},
+		{
+			name:    "proxy and timeout",
+			command: `curl --url http://a.test/ -x socks5://127.0.0.1:1080 --max-time 0.2 --http2`,
+			step:    Step{Method: "GET", URL: "http://a.test/", Timeout: 1, Others: map[string]interface{}{"h2": true}},
+			proxy:   "socks5://127.0.0.1:1080",
+		},
+		{
+			name:     "unsupported option",
+			command:  `curl -T file.txt http://a.test/`,
+			step:     Step{Method: "GET", URL: "http://a.test/"},
+			warnings: []string{"step 1: curl option -T is not converted"},
+		},
+	}
+	for _, tt := range tests {
+		res, err := ParseCurl(tt.command)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		st := res.Scenarios[0].Steps[0]
+		st.ID, st.Name = 0, ""
+		if !reflect.DeepEqual(st, tt.step) {
+			t.Errorf("%s: expected step\n%+v\ngot\n%+v", tt.name, tt.step, st)
+		}
+		if res.Proxy != tt.proxy {
+			t.Errorf("%s: expected proxy %q, got %q", tt.name, tt.proxy, res.Proxy)
+		}
+		if !reflect.DeepEqual(res.Warnings, tt.warnings) {
+			t.Errorf("%s: expected warnings %q, got %q", tt.name, tt.warnings, res.Warnings)
+		}
+	}
+}
+
+func TestParseCurlErrors(t *testing.T) {
+	tests := []struct {
+		command string
+		err     string
+	}{
+		{`curl http://a.test/ -H`, "curl option -H requires a value"},
+		{`curl -X POST`, "curl command without url"},
+		{`curl 'http://a.test/`, "unterminated quote in curl command"},
+		{`wget http://a.test/`, "not a curl command: wget"},
+		{`# только комментарий`, "no curl commands found"},
+		{`curl http://a.test/ -F file`, `invalid curl form field "file"`},
+		{`curl http://a.test/ -m soon`, `invalid curl max time "soon"`},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCurl(tt.command); err == nil || err.Error() != tt.err {
+			t.Errorf("%s: expected error %q, got %v", tt.command, tt.err, err)
+		}
+	}
+}
+
+// Команды разделяются переводом строки, ";" и "&&", а шаги нумеруются по порядку.
+func TestParseCurlCommands(t *testing.T) {
+	res, err := ParseCurl("curl a.test/1; curl a.test/2 && curl a.test/3\r\ncurl \\\r\n  a.test/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var urls []string
+	for i, st := range res.Scenarios[0].Steps {
+		if st.ID != uint16(i+1) {
+			t.Errorf("step %d has id %d", i+1, st.ID)
+		}
+		urls = append(urls, st.URL)
+	}
+	if strings.Join(urls, " ") != "http://a.test/1 http://a.test/2 http://a.test/3 http://a.test/4" {
+		t.Errorf("unexpected steps %v", urls)
+	}
+}
+
+// Команда, собранная из шага методом Curl, импортируется обратно в такой же запрос.
+func TestCurlRoundTrip(t *testing.T) {
+	steps := []types.ScenarioStep{
+		{Method: "GET", URL: "https://a.test/search?q=a+b&lang=en"},
+		{Method: "HEAD", URL: "http://a.test/"},
+		{
+			Method:  "POST",
+			URL:     "https://a.test/orders",
+			Headers: map[string]string{"Content-Type": "application/json", "X-Note": "it's {{TOKEN}}"},
+			Payload: `{"note":"it's ok","lines":"a\nb"}`,
+			Auth:    types.Auth{Type: "basic", Username: "ann", Password: "p:ss word"},
+			Timeout: 5,
+		},
+		{Method: "DELETE", URL: "http://a.test/orders/1", Custom: map[string]interface{}{"disable-redirect": true, "h2": true}},
+	}
+	for _, s := range steps {
+		cmd := s.Curl()
+		res, err := ParseCurl(cmd)
+		if err != nil {
+			t.Errorf("%s: %v", cmd, err)
+			continue
+		}
+		if len(res.Warnings) > 0 {
+			t.Errorf("%s: unexpected warnings %q", cmd, res.Warnings)
+		}
+		st := res.Scenarios[0].Steps[0]
+		if st.Method != s.Method || st.URL != s.URL || st.Payload != s.Payload || st.Timeout != s.Timeout {
+			t.Errorf("%s: imported as %+v", cmd, st)
+		}
+		if len(s.Headers) > 0 && !reflect.DeepEqual(st.Headers, s.Headers) {
+			t.Errorf("%s: expected headers %v, got %v", cmd, s.Headers, st.Headers)
+		}
+		if s.Auth != (types.Auth{}) && (st.Auth == nil || st.Auth.Username != s.Auth.Username || st.Auth.Password != s.Auth.Password) {
+			t.Errorf("%s: expected auth %+v, got %+v", cmd, s.Auth, st.Auth)
+		}
+		if h2, _ := s.Custom["h2"].(bool); h2 != (st.Others["h2"] == true) {
+			t.Errorf("%s: expected h2 %v, got %v", cmd, h2, st.Others)
+		}
+	}
+}
@@ -0,0 +1,464 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const FormatHAR = "har"
+
+func init() {
+	AvailableImporters[FormatHAR] = harImporter{}
+}
+
+// Максимальная пауза между шагами в миллисекундах, как в types.ScenarioStep
+const maxSleep = 90000
+
+// Заголовки, которые не переносятся в шаг: их формирует HTTP-клиент
+var harSkipHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"cookie":            true, // Cookie переносятся отдельно, см. harCaptures.cookieHeader
+	"transfer-encoding": true,
+	"upgrade":           true,
+	"keep-alive":        true,
+}
+
+// Расширения статических ресурсов
+var harStaticExt = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true, ".avif": true, ".bmp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true, ".eot": true,
+	".mp4": true, ".webm": true, ".mp3": true, ".wav": true,
+}
+
+// Типы ресурсов DevTools, которые считаются статическими
+var harStaticTypes = map[string]bool{
+	"stylesheet": true, "script": true, "image": true, "font": true, "media": true, "manifest": true,
+}
+
+type harFile struct {
+	Log struct {
+		Pages []struct {
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Миллисекунды
+	ResourceType    string      `json:"_resourceType"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method   string         `json:"method"`
+	URL      string         `json:"url"`
+	Headers  []harNameValue `json:"headers"`
+	Cookies  []harNameValue `json:"cookies"`
+	PostData *struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Params   []struct {
+			Name        string `json:"name"`
+			Value       string `json:"value"`
+			FileName    string `json:"fileName"`
+			ContentType string `json:"contentType"`
+		} `json:"params"`
+	} `json:"postData"`
+}
+
+type harResponse struct {
+	Status  int            `json:"status"`
+	Headers []harNameValue `json:"headers"`
+	Cookies []harNameValue `json:"cookies"`
+	Content struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding"`
+	} `json:"content"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// harImporter импортирует записи HAR (HTTP Archive), сохранённые в DevTools браузера.
+// Каждая запись становится шагом сценария, паузы между шагами берутся из времени записей.
+// Cookie, токены Bearer и похожие на идентификаторы значения, полученные в ответах предыдущих запросов,
+// заменяются извлечением переменных.
+type harImporter struct{}
+
+func (harImporter) Detect(name string, data []byte) bool {
+	if strings.EqualFold(path.Ext(name), ".har") {
+		return true
+	}
+	var probe struct {
+		Log *struct {
+			Entries json.RawMessage `json:"entries"`
+		} `json:"log"`
+	}
+	return json.Unmarshal(data, &probe) == nil && probe.Log != nil && probe.Log.Entries != nil
+}
+
+func (harImporter) Import(data []byte, opts Options) (*Result, error) {
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid har file: %v", err)
+	}
+
+	res := &Result{}
+	entries := harFilter(har.Log.Entries, opts, res)
+
+	name := opts.Name
+	if name == "" && len(har.Log.Pages) > 0 {
+		name = har.Log.Pages[0].Title
+	}
+	if name == "" {
+		name = "HAR import"
+	}
+	sc := Scenario{Name: name}
+
+	captures := &harCaptures{steps: &sc.Steps, names: map[string]bool{}, res: res}
+	for i, e := range entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil {
+			res.warn("entry %s: invalid url: %v", e.Request.URL, err)
+			continue
+		}
+		st := Step{
+			ID:     uint16(len(sc.Steps) + 1),
+			Name:   e.Request.Method + " " + u.EscapedPath(),
+			URL:    e.Request.URL,
+			Method: strings.ToUpper(e.Request.Method),
+		}
+
+		for _, h := range e.Request.Headers {
+			k := strings.ToLower(h.Name)
+			if strings.HasPrefix(k, ":") || harSkipHeaders[k] {
+				continue
+			}
+			if st.Headers == nil {
+				st.Headers = map[string]string{}
+			}
+			st.Headers[h.Name] = captures.replaceToken(h.Name, h.Value)
+		}
+		if cookie := captures.cookieHeader(e.Request); cookie != "" {
+			if st.Headers == nil {
+				st.Headers = map[string]string{}
+			}
+			st.Headers["Cookie"] = cookie
+		}
+
+		if pd := e.Request.PostData; pd != nil {
+			switch {
+			case strings.HasPrefix(pd.MimeType, "multipart/form-data") && len(pd.Params) > 0:
+				for _, p := range pd.Params {
+					if p.FileName != "" {
+						st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: p.Name, Value: p.FileName, Type: "file"})
+						res.warn("step %d: file %s of field %s should be placed next to the config", st.ID, p.FileName, p.Name)
+						continue
+					}
+					st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: p.Name, Value: p.Value})
+				}
+				// Граница multipart формируется заново при каждом запросе
+				delete(st.Headers, headerName(st.Headers, "Content-Type"))
+			case pd.Text != "":
+				st.Payload = pd.Text
+			case len(pd.Params) > 0:
+				form := url.Values{}
+				for _, p := range pd.Params {
+					form.Add(p.Name, p.Value)
+				}
+				st.Payload = form.Encode()
+			}
+		}
+
+		// Пауза после шага — время от окончания записи до начала следующей
+		if i+1 < len(entries) {
+			end := e.StartedDateTime.Add(time.Duration(e.Time * float64(time.Millisecond)))
+			if gap := entries[i+1].StartedDateTime.Sub(end).Milliseconds(); gap > 0 {
+				if gap > maxSleep {
+					gap = maxSleep
+				}
+				st.Sleep = strconv.FormatInt(gap, 10)
+			}
+		}
+
+		captures.correlate(&st)
+		sc.Steps = append(sc.Steps, st)
+		captures.remember(e.Request)
+		captures.observe(e.Response)
+	}
+
+	res.Scenarios = []Scenario{sc}
+	return res, nil
+}
+
+// harFilter оставляет записи с поддерживаемыми методами, кроме статических ресурсов и сторонних доменов.
+// Если шаблоны Include не заданы, импортируются запросы к домену первой записи и его поддоменам.
+func harFilter(entries []harEntry, opts Options, res *Result) []harEntry {
+	include := opts.Include
+	if len(include) == 0 && len(entries) > 0 {
+		if u, err := url.Parse(entries[0].Request.URL); err == nil {
+			site := siteDomain(u.Hostname())
+			include = []string{site, "*." + site}
+		}
+	}
+
+	var kept []harEntry
+	skipped := map[string]int{}
+	for _, e := range entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			skipped["non-http"]++
+			continue
+		}
+		switch {
+		case !supportedMethod(e.Request.Method):
+			res.warn("request %s %s skipped: unsupported method", e.Request.Method, e.Request.URL)
+		case !opts.KeepStatic && harStatic(e, u):
+			skipped["static"]++
+		case !matchAny(include, u) || matchAny(opts.Exclude, u):
+			skipped["filtered"]++
+		default:
+			kept = append(kept, e)
+		}
+	}
+	for _, reason := range []string{"non-http", "static", "filtered"} {
+		if n := skipped[reason]; n > 0 {
+			res.warn("%d %s request(s) skipped", n, reason)
+		}
+	}
+	return kept
+}
+
+// harStatic сообщает, является ли запись запросом статического ресурса.
+func harStatic(e harEntry, u *url.URL) bool {
+	if harStaticTypes[strings.ToLower(e.ResourceType)] || harStaticExt[strings.ToLower(path.Ext(u.Path))] {
+		return true
+	}
+	mime := strings.ToLower(e.Response.Content.MimeType)
+	for _, p := range []string{"image/", "font/", "audio/", "video/", "text/css", "javascript"} {
+		if strings.Contains(mime, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// siteDomain возвращает домен второго уровня хоста, например example.com для api.example.com.
+func siteDomain(host string) string {
+	if strings.Count(host, ".") < 2 || strings.Trim(host, "0123456789.") == "" {
+		return host
+	}
+	parts := strings.Split(host, ".")
+	return strings.Join(parts[len(parts)-2:], ".")
+}
+
+// matchAny сообщает, подходит ли адрес под один из шаблонов.
+// Шаблон без "/" сравнивается с хостом, с "/" — с хостом и путём, например "api.example.com/v1/*".
+func matchAny(patterns []string, u *url.URL) bool {
+	for _, p := range patterns {
+		target := u.Hostname()
+		if strings.Contains(p, "/") {
+			target += u.Path
+		}
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+		if strings.HasSuffix(p, "/*") && strings.HasPrefix(target, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func supportedMethod(m string) bool {
+	switch strings.ToUpper(m) {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete,
+		http.MethodPatch, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// headerName возвращает имя заголовка в headers без учёта регистра.
+func headerName(headers map[string]string, name string) string {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			return k
+		}
+	}
+	return name
+}
+
+// harCaptures отслеживает значения, полученные в ответах предыдущих шагов,
+// и заменяет их в запросах переменными окружения с извлечением из ответа.
+type harCaptures struct {
+	steps    *[]Step
+	names    map[string]bool
+	res      *Result
+	cookies  map[string]harOrigin // Cookie из Set-Cookie по имени
+	bodies   []harOrigin          // JSON-ответы предыдущих шагов
+	values   []harValue           // Значения ответов предыдущих шагов, см. correlate
+	requests strings.Builder      // Значения предыдущих запросов, см. remember
+}
+
+// harOrigin — значение или тело ответа шага с индексом step в списке шагов.
+type harOrigin struct {
+	step  int
+	value string
+	body  interface{}
+	env   string // Имя переменной, если извлечение уже добавлено
+}
+
+// observe запоминает cookie, заголовки и JSON-тело ответа последнего добавленного шага.
+func (c *harCaptures) observe(resp harResponse) {
+	step := len(*c.steps) - 1
+	if c.cookies == nil {
+		c.cookies = map[string]harOrigin{}
+	}
+	for _, ck := range resp.Cookies {
+		c.cookies[ck.Name] = harOrigin{step: step, value: ck.Value}
+	}
+	if len(resp.Cookies) == 0 {
+		for _, h := range resp.Headers {
+			if strings.EqualFold(h.Name, "Set-Cookie") {
+				if ck, err := http.ParseSetCookie(h.Value); err == nil {
+					c.cookies[ck.Name] = harOrigin{step: step, value: ck.Value}
+				}
+			}
+		}
+	}
+	var body interface{}
+	if strings.Contains(resp.Content.MimeType, "json") && resp.Content.Encoding == "" {
+		if json.Unmarshal([]byte(resp.Content.Text), &body) == nil {
+			c.bodies = append(c.bodies, harOrigin{step: step, body: body})
+		}
+	}
+	c.observeValues(step, resp, body)
+}
+
+// cookieHeader возвращает значение заголовка Cookie запроса. Cookie, установленные ответом
+// предыдущего шага, заменяются переменными, извлекаемыми из этого ответа.
+func (c *harCaptures) cookieHeader(req harRequest) string {
+	pairs := req.Cookies
+	if len(pairs) == 0 {
+		for _, h := range req.Headers {
+			if strings.EqualFold(h.Name, "Cookie") {
+				for _, ck := range strings.Split(h.Value, ";") {
+					if name, value, ok := strings.Cut(strings.TrimSpace(ck), "="); ok {
+						pairs = append(pairs, harNameValue{Name: name, Value: value})
+					}
+				}
+			}
+		}
+	}
+
+	parts := make([]string, 0, len(pairs))
+	for _, ck := range pairs {
+		value := ck.Value
+		if o, ok := c.cookies[ck.Name]; ok && o.value == ck.Value {
+			if o.env == "" {
+				o.env = c.capture(o.step, "COOKIE_"+ck.Name, Capture{From: "cookie", Cookie: strPtr(ck.Name)})
+				c.cookies[ck.Name] = o
+			}
+			value = "{{" + o.env + "}}"
+		}
+		parts = append(parts, ck.Name+"="+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// replaceToken заменяет токен Bearer в заголовке Authorization переменной,
+// если токен встречается в JSON-ответе предыдущего шага.
+func (c *harCaptures) replaceToken(header, value string) string {
+	token, ok := strings.CutPrefix(value, "Bearer ")
+	if !strings.EqualFold(header, "Authorization") || !ok || token == "" {
+		return value
+	}
+	for i := len(c.bodies) - 1; i >= 0; i-- {
+		if p, ok := jsonPathOf(c.bodies[i].body, token, ""); ok {
+			env := c.capture(c.bodies[i].step, "TOKEN", Capture{From: "body", JsonPath: &p})
+			return "Bearer {{" + env + "}}"
+		}
+	}
+	return value
+}
+
+// capture добавляет шагу step извлечение переменной и возвращает её имя.
+func (c *harCaptures) capture(step int, name string, capture Capture) string {
+	st := &(*c.steps)[step]
+	for env, cp := range st.CaptureEnv {
+		if cp.From == capture.From && equalPtr(cp.Cookie, capture.Cookie) && equalPtr(cp.JsonPath, capture.JsonPath) {
+			return env
+		}
+	}
+
+	env := envName(name)
+	for i := 2; c.names[env]; i++ {
+		env = envName(name) + "_" + strconv.Itoa(i)
+	}
+	c.names[env] = true
+	if st.CaptureEnv == nil {
+		st.CaptureEnv = map[string]Capture{}
+	}
+	st.CaptureEnv[env] = capture
+	return env
+}
+
+// jsonPathOf ищет строковое значение value в JSON и возвращает путь к нему в синтаксисе gjson.
+func jsonPathOf(v interface{}, value, prefix string) (string, bool) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + "." + k
+	}
+	switch t := v.(type) {
+	case string:
+		return prefix, t == value && prefix != ""
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			if !strings.ContainsAny(k, ".*?") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := jsonPathOf(t[k], value, join(k)); ok {
+				return p, true
+			}
+		}
+	case []interface{}:
+		for i, child := range t {
+			if p, ok := jsonPathOf(child, value, join(strconv.Itoa(i))); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func equalPtr(a, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
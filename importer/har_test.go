@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+// Запись HAR становится сценарием: статические ресурсы и запросы к другим сайтам пропускаются,
+// паузы берутся из времени записей, а токен и cookie из ответа входа извлекаются в переменные.
+func TestImportHAR(t *testing.T) {
+	res := importFile(t, "session.har", Options{})
+	if len(res.Scenarios) != 1 || res.Scenarios[0].Name != "Shop checkout" {
+		t.Fatalf("expected the scenario named by the page title, got %+v", res.Scenarios)
+	}
+	checkConfig(t, res, "session.json")
+	checkWarnings(t, res,
+		"1 static request(s) skipped",
+		"1 filtered request(s) skipped",
+		"step 3: file avatar.png of field file should be placed next to the config",
+	)
+}
+
+// Фильтры по хостам и адресам и импорт статических ресурсов.
+func TestImportHARFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		urls []string
+	}{
+		{
+			name: "keep static",
+			opts: Options{KeepStatic: true},
+			urls: []string{"/v1/login", "shop.example.com/static/app.js", "/v1/cart?currency=EUR", "/v1/avatar"},
+		},
+		{
+			name: "include path",
+			opts: Options{Include: []string{"api.shop.example.com/v1/ca*"}},
+			urls: []string{"/v1/cart?currency=EUR"},
+		},
+		{
+			name: "include other site",
+			opts: Options{Include: []string{"*.google-analytics.com"}},
+			urls: []string{"www.google-analytics.com/collect"},
+		},
+		{
+			name: "exclude",
+			opts: Options{Exclude: []string{"api.shop.example.com/v1/avatar"}},
+			urls: []string{"/v1/login", "/v1/cart?currency=EUR"},
+		},
+	}
+	for _, tt := range tests {
+		res := importFile(t, "session.har", tt.opts)
+		steps := res.Scenarios[0].Steps
+		if len(steps) != len(tt.urls) {
+			t.Errorf("%s: expected %d steps, got %d", tt.name, len(tt.urls), len(steps))
+			continue
+		}
+		for i, s := range steps {
+			if !strings.HasSuffix(s.URL, tt.urls[i]) {
+				t.Errorf("%s, step %d: expected url %s, got %s", tt.name, i+1, tt.urls[i], s.URL)
+			}
+		}
+	}
+
+	if _, err := Import(FormatHAR, "session.har", readTestdata(t, "session.har"), Options{Include: []string{"example.org"}}); err == nil ||
+		err.Error() != "no requests to import in Shop checkout" {
+		t.Errorf("expected an error without requests to import, got %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Пакет importer преобразует описания запросов из сторонних форматов (HAR, Postman, OpenAPI и другие)
+// в сценарии конфигурации httes.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"httes/core/types"
+	"httes/store"
+)
+
+// AvailableImporters хранит доступные импортёры по названию формата.
+var AvailableImporters = make(map[string]Importer)
+
+// Importer преобразует данные формата в сценарии.
+type Importer interface {
+	// Detect сообщает, похожи ли данные из файла name на этот формат.
+	Detect(name string, data []byte) bool
+
+	// Import преобразует данные в сценарии.
+	Import(data []byte, opts Options) (*Result, error)
+}
+
+// Options — параметры импорта. Каждый импортёр использует только нужные ему поля.
+type Options struct {
+	Name       string   // Имя сценария. По умолчанию берётся из исходных данных
+	Include    []string // Шаблоны хостов или адресов, запросы к которым импортируются
+	Exclude    []string // Шаблоны хостов или адресов, запросы к которым пропускаются
+	KeepStatic bool     // Импортировать запросы статических ресурсов (скрипты, стили, изображения, шрифты)
+
+	Environment []byte // Файл окружения Postman с переменными
+
+	Tags       []string // Теги операций OpenAPI, которые импортируются
+	Operations []string // Идентификаторы (operationId) операций OpenAPI, которые импортируются
+}
+
+// Step — шаг сценария в формате конфигурации config.JsonReader.
+type Step struct {
+	ID               uint16                 `json:"id"`
+	Name             string                 `json:"name"`
+	URL              string                 `json:"url"`
+	Method           string                 `json:"method"`
+	Auth             *Auth                  `json:"auth,omitempty"`
+	Headers          map[string]string      `json:"headers,omitempty"`
+	Payload          string                 `json:"payload,omitempty"`
+	PayloadFile      string                 `json:"payload_file,omitempty"`
+	PayloadMultipart []MultipartField       `json:"payload_multipart,omitempty"`
+	Timeout          int                    `json:"timeout,omitempty"`
+	Sleep            string                 `json:"sleep,omitempty"`
+	Others           map[string]interface{} `json:"others,omitempty"`
+	CertPath         string                 `json:"cert_path,omitempty"`
+	CertKeyPath      string                 `json:"cert_key_path,omitempty"`
+	CaptureEnv       map[string]Capture     `json:"captureEnv,omitempty"`
+	ExpectedStatus   []string               `json:"expected_status,omitempty"`
+}
+
+// Auth — аутентификация шага.
+type Auth struct {
+	Type     string `json:"type"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// MultipartField — поле multipart-запроса. Для файлов Value содержит путь к файлу.
+type MultipartField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  string `json:"type,omitempty"`
+	Src   string `json:"src,omitempty"`
+}
+
+// Capture — извлечение переменной окружения из ответа шага.
+type Capture struct {
+	From     string  `json:"from"`
+	JsonPath *string `json:"jsonPath,omitempty"`
+	Header   *string `json:"headerKey,omitempty"`
+	Cookie   *string `json:"cookieName,omitempty"`
+}
+
+// Scenario — импортированный сценарий.
+type Scenario struct {
+	Name  string
+	Steps []Step
+	Envs  map[string]interface{}
+}
+
+// Result — результат импорта: сценарии и предупреждения о том, что не удалось преобразовать.
+type Result struct {
+	Scenarios []Scenario
+	Proxy     string // Прокси для всех запросов теста, если он задан в исходных данных
+	Warnings  []string
+	// Извлечения переменных, найденные по совпадению значений ответов и следующих запросов. Их стоит проверить
+	Captures []string
+}
+
+// warn добавляет предупреждение, если такого ещё нет.
+func (r *Result) warn(format string, args ...interface{}) {
+	w := fmt.Sprintf(format, args...)
+	for _, e := range r.Warnings {
+		if e == w {
+			return
+		}
+	}
+	r.Warnings = append(r.Warnings, w)
+}
+
+// scenarioConfig — поля сценария в конфигурации и в store.Scenario.JSON.
+type scenarioConfig struct {
+	Name  string                 `json:"name,omitempty"`
+	Steps []Step                 `json:"steps"`
+	Envs  map[string]interface{} `json:"env,omitempty"`
+}
+
+// fileConfig — конфигурация теста с импортированными сценариями.
+type fileConfig struct {
+	IterationCount int                    `json:"iteration_count"`
+	LoadType       string                 `json:"load_type"`
+	Duration       int                    `json:"duration"`
+	Proxy          string                 `json:"proxy,omitempty"`
+	Steps          []Step                 `json:"steps,omitempty"`
+	Scenarios      []scenarioConfig       `json:"scenarios,omitempty"`
+	Envs           map[string]interface{} `json:"env,omitempty"`
+}
+
+// JSON возвращает сценарий в формате store.Scenario.JSON.
+func (s Scenario) JSON() (string, error) {
+	b, err := json.MarshalIndent(scenarioConfig{Steps: s.Steps, Envs: s.Envs}, "", "  ")
+	return string(b), err
+}
+
+// Config возвращает конфигурацию теста в формате config.JsonReader с параметрами нагрузки по умолчанию.
+// Один сценарий записывается шагами, несколько — смесью сценариев с равными весами.
+func (r *Result) Config() ([]byte, error) {
+	conf := fileConfig{
+		IterationCount: types.DefaultIterCount,
+		LoadType:       types.DefaultLoadType,
+		Duration:       types.DefaultDuration,
+		Proxy:          r.Proxy,
+	}
+	switch len(r.Scenarios) {
+	case 0:
+		return nil, fmt.Errorf("nothing to import")
+	case 1:
+		conf.Steps = r.Scenarios[0].Steps
+		conf.Envs = r.Scenarios[0].Envs
+	default:
+		for _, s := range r.Scenarios {
+			conf.Scenarios = append(conf.Scenarios, scenarioConfig{Name: s.Name, Steps: s.Steps, Envs: s.Envs})
+		}
+	}
+	return json.MarshalIndent(conf, "", "  ")
+}
+
+// Import преобразует данные формата format. Если format пуст, формат определяется по имени файла и содержимому.
+func Import(format, name string, data []byte, opts Options) (*Result, error) {
+	if format == "" {
+		format = DetectFormat(name, data)
+		if format == "" {
+			return nil, fmt.Errorf("unknown import format of %s", filepath.Base(name))
+		}
+	}
+	imp, ok := AvailableImporters[format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+	res, err := imp.Import(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range res.Scenarios {
+		if len(s.Steps) == 0 {
+			return nil, fmt.Errorf("no requests to import in %s", s.Name)
+		}
+	}
+	return res, nil
+}
+
+// DetectFormat возвращает формат данных или пустую строку, если формат не определён.
+func DetectFormat(name string, data []byte) string {
+	formats := make([]string, 0, len(AvailableImporters))
+	for f := range AvailableImporters {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	for _, f := range formats {
+		if AvailableImporters[f].Detect(name, data) {
+			return f
+		}
+	}
+	return ""
+}
+
+// Недопустимые символы имени переменной окружения
+var envNameRegexp = regexp.MustCompile(`\W+`)
+
+// envName приводит name к имени переменной окружения, подходящему для {{name}}.
+func envName(name string) string {
+	n := strings.Trim(envNameRegexp.ReplaceAllString(name, "_"), "_")
+	if len(n) < 2 {
+		n = "VAR_" + n
+	}
+	return n
+}
+
+// Save добавляет импортированные сценарии в хранилище. source — имя исходного файла для описания сценария.
+func (r *Result) Save(source string) ([]store.Scenario, error) {
+	saved := make([]store.Scenario, 0, len(r.Scenarios))
+	for _, s := range r.Scenarios {
+		js, err := s.JSON()
+		if err != nil {
+			return saved, err
+		}
+		sc, err := store.AddScenario(store.Scenario{
+			Name:        s.Name,
+			Description: "Импортирован из " + filepath.Base(source),
+			JSON:        js,
+		})
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, sc)
+	}
+	return saved, nil
+}
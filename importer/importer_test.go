@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readTestdata читает файл name из importer_testdata.
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("importer_testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// importFile импортирует файл name из importer_testdata с определением формата.
+func importFile(t *testing.T, name string, opts Options) *Result {
+	t.Helper()
+	res, err := Import("", name, readTestdata(t, name), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// checkConfig сравнивает конфигурацию теста из результата импорта с ожидаемой конфигурацией из файла expected.
+func checkConfig(t *testing.T, res *Result, expected string) {
+	t.Helper()
+	data, err := res.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(readTestdata(t, expected), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("config differs from %s:\n%s", expected, data)
+	}
+}
+
+// checkWarnings проверяет предупреждения импорта.
+func checkWarnings(t *testing.T, res *Result, expected ...string) {
+	t.Helper()
+	if !reflect.DeepEqual(res.Warnings, expected) {
+		t.Errorf("unexpected warnings:\n%q\nexpected:\n%q", res.Warnings, expected)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+	}{
+		{"session.har", FormatHAR},
+		{"shop.postman_collection.json", FormatPostman},
+		{"petstore.yaml", FormatOpenAPI},
+		{"requests.curl", FormatCurl},
+		{"shop.postman_environment.json", ""},
+		{"session.json", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.name, readTestdata(t, tt.name)); got != tt.format {
+			t.Errorf("%s: expected format %q, got %q", tt.name, tt.format, got)
+		}
+	}
+}
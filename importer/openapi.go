@@ -0,0 +1,641 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const FormatOpenAPI = "openapi"
+
+func init() {
+	AvailableImporters[FormatOpenAPI] = openapiImporter{}
+}
+
+// Переменная окружения с адресом сервера API
+const openapiBaseURL = "baseUrl"
+
+// Максимальная вложенность схем при генерации тела запроса, ограничивает рекурсивные схемы
+const openapiMaxDepth = 6
+
+// Методы операций в порядке импорта шагов одного пути
+var openapiMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// Переменные генератора тестовых данных для форматов строк
+var openapiStringFormats = map[string]string{
+	"uuid":      "randomUUID",
+	"email":     "randomEmail",
+	"date-time": "isoTimestamp",
+	"uri":       "randomUrl",
+	"url":       "randomUrl",
+	"hostname":  "randomDomainName",
+	"ipv4":      "randomIP",
+	"password":  "randomPassword",
+}
+
+// openapiFake — значение, которое генерируется при выполнении шага переменной {{_name}}.
+// Числа и логические значения записываются в JSON без кавычек, чтобы тело запроса сохранило типы схемы.
+type openapiFake struct {
+	name   string
+	quoted bool
+}
+
+func (f openapiFake) String() string {
+	return "{{_" + f.name + "}}"
+}
+
+// openapiImporter импортирует спецификации OpenAPI 3 в формате JSON или YAML.
+// Каждая операция становится шагом сценария. Параметры и тела запросов берутся из примеров,
+// а если их нет — генерируются по схеме переменными {{_name}}. Ожидаемые статусы шага берутся
+// из успешных ответов операции.
+type openapiImporter struct{}
+
+func (openapiImporter) Detect(name string, data []byte) bool {
+	if !bytes.Contains(data, []byte("openapi")) && !bytes.Contains(data, []byte("swagger")) {
+		return false
+	}
+	var probe struct {
+		OpenAPI string `yaml:"openapi"`
+		Swagger string `yaml:"swagger"`
+	}
+	return yaml.Unmarshal(data, &probe) == nil && (probe.OpenAPI != "" || probe.Swagger != "")
+}
+
+func (openapiImporter) Import(data []byte, opts Options) (*Result, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %v", err)
+	}
+	if len(root.Content) == 0 {
+		return nil, fmt.Errorf("openapi specification is empty")
+	}
+	var doc interface{}
+	if err := root.Content[0].Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %v", err)
+	}
+	spec, _ := normalizeYaml(doc).(map[string]interface{})
+	if v := str(spec["swagger"]); v != "" {
+		return nil, fmt.Errorf("unsupported swagger version %s, only OpenAPI 3 is supported", v)
+	}
+	if v := str(spec["openapi"]); !strings.HasPrefix(v, "3") {
+		return nil, fmt.Errorf("unsupported openapi version %q, only OpenAPI 3 is supported", v)
+	}
+
+	o := &openapi{spec: spec, opts: opts, res: &Result{}, envs: map[string]interface{}{}}
+	name := opts.Name
+	if name == "" {
+		name = str(o.object(spec["info"])["title"])
+	}
+	if name == "" {
+		name = "OpenAPI"
+	}
+	o.envs[openapiBaseURL] = o.baseURL()
+
+	paths := o.object(spec["paths"])
+	for _, p := range pathOrder(root.Content[0], paths) {
+		item := o.object(paths[p])
+		for _, m := range openapiMethods {
+			if op, ok := item[m]; ok {
+				o.operation(p, m, item, o.object(op))
+			}
+		}
+	}
+	if len(o.steps) == 0 && (len(opts.Tags) > 0 || len(opts.Operations) > 0) {
+		return nil, fmt.Errorf("no operations match the selected tags and operation ids")
+	}
+
+	o.res.Scenarios = []Scenario{{Name: name, Steps: o.steps, Envs: o.envs}}
+	return o.res, nil
+}
+
+// openapi — состояние импорта одной спецификации.
+type openapi struct {
+	spec  map[string]interface{}
+	opts  Options
+	res   *Result
+	steps []Step
+	envs  map[string]interface{}
+}
+
+// baseURL возвращает адрес первого сервера с подставленными значениями переменных сервера.
+func (o *openapi) baseURL() string {
+	servers, _ := o.spec["servers"].([]interface{})
+	if len(servers) == 0 {
+		o.res.warn("servers are not defined, set variable %s to the API address", openapiBaseURL)
+		return "http://localhost"
+	}
+	server := o.object(servers[0])
+	u := str(server["url"])
+	for name, v := range o.object(server["variables"]) {
+		u = strings.ReplaceAll(u, "{"+name+"}", str(o.object(v)["default"]))
+	}
+	u = strings.TrimSuffix(u, "/")
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		o.res.warn("server url %q is relative, set variable %s to the API address", u, openapiBaseURL)
+		u = "http://localhost" + u
+	}
+	return u
+}
+
+// operation добавляет шаг для операции method пути p, если она выбрана тегами или идентификаторами.
+func (o *openapi) operation(p, method string, item, op map[string]interface{}) {
+	id := str(op["operationId"])
+	if !o.selected(id, op) {
+		return
+	}
+
+	st := Step{
+		ID:      uint16(len(o.steps) + 1),
+		Name:    id,
+		Method:  strings.ToUpper(method),
+		Headers: map[string]string{},
+	}
+	if st.Name == "" {
+		st.Name = st.Method + " " + p
+	}
+
+	query := []string{}
+	cookies := []string{}
+	for _, param := range o.parameters(item, op) {
+		name := str(param["name"])
+		in := str(param["in"])
+		required, _ := param["required"].(bool)
+		value, fromExample := o.paramValue(param)
+		if in != "path" && !required && !fromExample {
+			continue
+		}
+		switch in {
+		case "path":
+			p = strings.ReplaceAll(p, "{"+name+"}", escapeStatic(value, url.PathEscape))
+		case "query":
+			query = append(query, url.QueryEscape(name)+"="+escapeStatic(value, url.QueryEscape))
+		case "header":
+			st.Headers[name] = value
+		case "cookie":
+			cookies = append(cookies, name+"="+value)
+		}
+	}
+	if len(cookies) > 0 {
+		st.Headers["Cookie"] = strings.Join(cookies, "; ")
+	}
+
+	st.URL = "{{" + openapiBaseURL + "}}" + p
+	if len(query) > 0 {
+		st.URL += "?" + strings.Join(query, "&")
+	}
+
+	if body, ok := op["requestBody"]; ok {
+		o.requestBody(&st, o.object(body))
+	}
+	o.security(&st, op)
+	st.ExpectedStatus = o.expectedStatus(&st, op)
+
+	if len(st.Headers) == 0 {
+		st.Headers = nil
+	}
+	o.steps = append(o.steps, st)
+}
+
+// selected сообщает, выбрана ли операция тегами или идентификаторами из Options.
+// Если ни то, ни другое не задано, импортируются все операции.
+func (o *openapi) selected(id string, op map[string]interface{}) bool {
+	if len(o.opts.Tags) == 0 && len(o.opts.Operations) == 0 {
+		return true
+	}
+	for _, sel := range o.opts.Operations {
+		if sel == id {
+			return true
+		}
+	}
+	tags, _ := op["tags"].([]interface{})
+	for _, t := range tags {
+		for _, sel := range o.opts.Tags {
+			if strings.EqualFold(sel, str(t)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parameters возвращает параметры пути и операции. Параметр операции заменяет параметр пути с тем же именем и местом.
+func (o *openapi) parameters(item, op map[string]interface{}) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := map[string]int{}
+	for _, src := range []interface{}{item["parameters"], op["parameters"]} {
+		list, _ := src.([]interface{})
+		for _, p := range list {
+			param := o.object(p)
+			key := str(param["in"]) + ":" + str(param["name"])
+			if i, ok := index[key]; ok {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// paramValue возвращает значение параметра из примера, значения по умолчанию или генератора тестовых данных.
+// fromExample сообщает, что значение задано в спецификации.
+func (o *openapi) paramValue(param map[string]interface{}) (value string, fromExample bool) {
+	if v, ok := o.example(param); ok {
+		return scalar(v), true
+	}
+	schema := o.object(param["schema"])
+	if v, ok := o.schemaExample(schema); ok {
+		return scalar(v), true
+	}
+	return scalar(o.generate(schema, str(param["name"]), 0)), false
+}
+
+// example возвращает пример объекта параметра или медиатипа: поле example или первое из examples.
+func (o *openapi) example(obj map[string]interface{}) (interface{}, bool) {
+	if v, ok := obj["example"]; ok {
+		return v, true
+	}
+	examples := o.object(obj["examples"])
+	keys := sortedKeys(examples)
+	if len(keys) > 0 {
+		if v, ok := o.object(examples[keys[0]])["value"]; ok {
+			return v, true
+		}
+		if ext := str(o.object(examples[keys[0]])["externalValue"]); ext != "" {
+			o.res.warn("external example %s is not loaded", ext)
+		}
+	}
+	return nil, false
+}
+
+// schemaExample возвращает пример, значение по умолчанию или первое допустимое значение схемы.
+func (o *openapi) schemaExample(schema map[string]interface{}) (interface{}, bool) {
+	for _, k := range []string{"example", "default"} {
+		if v, ok := schema[k]; ok {
+			return v, true
+		}
+	}
+	if enum, _ := schema["enum"].([]interface{}); len(enum) > 0 {
+		return enum[0], true
+	}
+	return nil, false
+}
+
+// generate строит значение по схеме. Примеры схемы используются как есть,
+// остальные значения заменяются переменными генератора тестовых данных.
+func (o *openapi) generate(schema map[string]interface{}, name string, depth int) interface{} {
+	if v, ok := o.schemaExample(schema); ok {
+		return v
+	}
+	if depth > openapiMaxDepth {
+		return nil
+	}
+	for _, k := range []string{"oneOf", "anyOf"} {
+		if list, _ := schema[k].([]interface{}); len(list) > 0 {
+			return o.generate(o.object(list[0]), name, depth+1)
+		}
+	}
+	if list, _ := schema["allOf"].([]interface{}); len(list) > 0 {
+		merged := map[string]interface{}{}
+		for _, s := range list {
+			if v, ok := o.generate(o.object(s), name, depth+1).(map[string]interface{}); ok {
+				for k, val := range v {
+					merged[k] = val
+				}
+			}
+		}
+		return merged
+	}
+
+	typ := str(schema["type"])
+	if typ == "" {
+		switch {
+		case schema["properties"] != nil:
+			typ = "object"
+		case schema["items"] != nil:
+			typ = "array"
+		}
+	}
+	switch typ {
+	case "object":
+		obj := map[string]interface{}{}
+		props := o.object(schema["properties"])
+		for k, v := range props {
+			prop := o.object(v)
+			if ro, _ := prop["readOnly"].(bool); ro {
+				continue
+			}
+			obj[k] = o.generate(prop, k, depth+1)
+		}
+		return obj
+	case "array":
+		return []interface{}{o.generate(o.object(schema["items"]), name, depth+1)}
+	case "integer":
+		return openapiFake{name: "randomInt"}
+	case "number":
+		return openapiFake{name: "randomFloat"}
+	case "boolean":
+		return openapiFake{name: "randomBoolean"}
+	case "string":
+		if f, ok := openapiStringFormats[str(schema["format"])]; ok {
+			return openapiFake{name: f, quoted: true}
+		}
+		if strings.Contains(strings.ToLower(name), "email") {
+			return openapiFake{name: "randomEmail", quoted: true}
+		}
+		return openapiFake{name: "randomLoremWord", quoted: true}
+	}
+	return nil
+}
+
+// requestBody задаёт тело шага по первому поддерживаемому медиатипу тела запроса.
+func (o *openapi) requestBody(st *Step, body map[string]interface{}) {
+	content := o.object(body["content"])
+	mediaType := ""
+	for _, mt := range sortedKeys(content) {
+		if mt == "application/json" || strings.HasSuffix(mt, "+json") {
+			mediaType = mt
+			break
+		}
+	}
+	if mediaType == "" {
+		for _, mt := range []string{"application/x-www-form-urlencoded", "multipart/form-data", "text/plain", "application/xml"} {
+			if _, ok := content[mt]; ok {
+				mediaType = mt
+				break
+			}
+		}
+	}
+	if mediaType == "" {
+		if len(content) > 0 {
+			o.res.warn("step %d (%s): request body types %s are not supported", st.ID, st.Name, strings.Join(sortedKeys(content), ", "))
+		}
+		return
+	}
+
+	media := o.object(content[mediaType])
+	value, ok := o.example(media)
+	if !ok {
+		value = o.generate(o.object(media["schema"]), "", 0)
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		fields := o.object(value)
+		pairs := make([]string, 0, len(fields))
+		for _, k := range sortedKeys(fields) {
+			pairs = append(pairs, url.QueryEscape(k)+"="+escapeStatic(scalar(fields[k]), url.QueryEscape))
+		}
+		st.Payload = strings.Join(pairs, "&")
+	case "multipart/form-data":
+		fields := o.object(value)
+		schema := o.object(o.object(media["schema"])["properties"])
+		for _, k := range sortedKeys(fields) {
+			if str(o.object(schema[k])["format"]) == "binary" {
+				o.res.warn("step %d (%s): file of field %s is not selected", st.ID, st.Name, k)
+				st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: k, Value: k, Type: "file"})
+				continue
+			}
+			st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: k, Value: scalar(fields[k])})
+		}
+		return
+	default:
+		if s, ok := value.(string); ok {
+			st.Payload = s
+		} else {
+			var buf bytes.Buffer
+			writeJSON(&buf, value)
+			st.Payload = buf.String()
+		}
+	}
+	st.Headers["Content-Type"] = mediaType
+}
+
+// security добавляет шагу аутентификацию первой схемы безопасности операции или спецификации.
+// Учётные данные записываются в переменные окружения, которые нужно заполнить перед запуском.
+func (o *openapi) security(st *Step, op map[string]interface{}) {
+	reqs, ok := op["security"].([]interface{})
+	if !ok {
+		reqs, _ = o.spec["security"].([]interface{})
+	}
+	if len(reqs) == 0 {
+		return
+	}
+	schemes := o.object(o.object(o.spec["components"])["securitySchemes"])
+	for _, name := range sortedKeys(o.object(reqs[0])) {
+		scheme := o.object(schemes[name])
+		v := envName(name)
+		switch typ := str(scheme["type"]); {
+		case typ == "http" && strings.EqualFold(str(scheme["scheme"]), "basic"):
+			o.credential(v + "_username")
+			o.credential(v + "_password")
+			st.Auth = &Auth{Type: "basic", Username: "{{" + v + "_username}}", Password: "{{" + v + "_password}}"}
+		case typ == "http" && strings.EqualFold(str(scheme["scheme"]), "bearer"), typ == "oauth2", typ == "openIdConnect":
+			o.credential(v)
+			st.Headers["Authorization"] = "Bearer {{" + v + "}}"
+		case typ == "apiKey":
+			o.credential(v)
+			key := str(scheme["name"])
+			switch str(scheme["in"]) {
+			case "header":
+				st.Headers[key] = "{{" + v + "}}"
+			case "query":
+				sep := "?"
+				if strings.Contains(st.URL, "?") {
+					sep = "&"
+				}
+				st.URL += sep + url.QueryEscape(key) + "={{" + v + "}}"
+			case "cookie":
+				cookie := key + "={{" + v + "}}"
+				if c := st.Headers["Cookie"]; c != "" {
+					cookie = c + "; " + cookie
+				}
+				st.Headers["Cookie"] = cookie
+			}
+		default:
+			o.res.warn("security scheme %s (%s) is not supported", name, typ)
+		}
+	}
+}
+
+// credential добавляет переменную окружения для учётных данных и предупреждает, что её нужно заполнить.
+func (o *openapi) credential(name string) {
+	if _, ok := o.envs[name]; ok {
+		return
+	}
+	o.envs[name] = ""
+	o.res.warn("set variable %s to the credentials of the API", name)
+}
+
+// expectedStatus возвращает документированные успешные статусы операции (1XX–3XX).
+func (o *openapi) expectedStatus(st *Step, op map[string]interface{}) []string {
+	var statuses []string
+	for _, code := range sortedKeys(o.object(op["responses"])) {
+		if len(code) == 3 && code[0] >= '1' && code[0] <= '3' {
+			statuses = append(statuses, strings.ToUpper(code))
+		}
+	}
+	if len(statuses) == 0 {
+		o.res.warn("step %d (%s): no success responses are documented, status is not checked", st.ID, st.Name)
+	}
+	return statuses
+}
+
+// object возвращает словарь значения v, разрешая локальные ссылки $ref.
+func (o *openapi) object(v interface{}) map[string]interface{} {
+	m, _ := v.(map[string]interface{})
+	for seen := 0; m != nil && seen < openapiMaxDepth; seen++ {
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		if !strings.HasPrefix(ref, "#/") {
+			o.res.warn("external reference %s is not resolved", ref)
+			return nil
+		}
+		var target interface{} = o.spec
+		for _, part := range strings.Split(ref[2:], "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			obj, _ := target.(map[string]interface{})
+			target = obj[part]
+		}
+		if m, _ = target.(map[string]interface{}); m == nil {
+			o.res.warn("reference %s is not found", ref)
+		}
+	}
+	return m
+}
+
+// pathOrder возвращает пути спецификации в порядке их описания в документе.
+func pathOrder(root *yaml.Node, paths map[string]interface{}) []string {
+	var order []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "paths" {
+			continue
+		}
+		node := root.Content[i+1]
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			if _, ok := paths[node.Content[j].Value]; ok {
+				order = append(order, node.Content[j].Value)
+			}
+		}
+	}
+	if len(order) != len(paths) {
+		return sortedKeys(paths)
+	}
+	return order
+}
+
+// normalizeYaml приводит ключи словарей YAML к строкам, например коды ответов 200.
+func normalizeYaml(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = normalizeYaml(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = normalizeYaml(val)
+		}
+		return m
+	case []interface{}:
+		for i := range t {
+			t[i] = normalizeYaml(t[i])
+		}
+	}
+	return v
+}
+
+// writeJSON записывает значение в JSON. Переменные генератора числовых и логических значений
+// записываются без кавычек и становятся значениями JSON после подстановки.
+func writeJSON(buf *bytes.Buffer, v interface{}) {
+	switch t := v.(type) {
+	case openapiFake:
+		if t.quoted {
+			buf.WriteString(strconv.Quote(t.String()))
+		} else {
+			buf.WriteString(t.String())
+		}
+	case map[string]interface{}:
+		buf.WriteByte('{')
+		for i, k := range sortedKeys(t) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			b, _ := json.Marshal(k)
+			buf.Write(b)
+			buf.WriteByte(':')
+			writeJSON(buf, t[k])
+		}
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSON(buf, item)
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			b = []byte("null")
+		}
+		buf.Write(b)
+	}
+}
+
+// scalar возвращает строковое представление значения параметра.
+func scalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case openapiFake:
+		return t.String()
+	case map[string]interface{}, []interface{}:
+		var buf bytes.Buffer
+		writeJSON(&buf, t)
+		return buf.String()
+	}
+	return fmt.Sprint(v)
+}
+
+// escapeStatic экранирует значение для URL, кроме переменных {{...}}, которые подставляются при выполнении.
+func escapeStatic(v string, escape func(string) string) string {
+	if strings.HasPrefix(v, "{{") && strings.HasSuffix(v, "}}") {
+		return v
+	}
+	return escape(v)
+}
+
+// str возвращает строку значения YAML или пустую строку.
+func str(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
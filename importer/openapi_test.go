@@ -0,0 +1,51 @@
+package importer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Спецификация OpenAPI становится сценарием из всех операций в порядке путей: адрес берётся из servers
+// с подстановкой переменных сервера, параметры пути и тела заполняются по примерам и схемам,
+// а ожидаемые статусы — по успешным ответам.
+func TestImportOpenAPI(t *testing.T) {
+	res := importFile(t, "petstore.yaml", Options{})
+	if len(res.Scenarios) != 1 || res.Scenarios[0].Name != "Petstore" {
+		t.Fatalf("expected the scenario named by the spec title, got %+v", res.Scenarios)
+	}
+	checkConfig(t, res, "petstore.json")
+	checkWarnings(t, res,
+		"set variable bearerAuth to the credentials of the API",
+		"set variable apiKey to the credentials of the API",
+		"step 5 (uploadPhoto): file of field file is not selected",
+	)
+}
+
+// Операции выбираются по тегам и идентификаторам, порядок шагов остаётся порядком спецификации.
+func TestImportOpenAPISelection(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		ops  []string
+	}{
+		{name: "tag", opts: Options{Tags: []string{"admin"}}, ops: []string{"deletePet"}},
+		{name: "tag in other case", opts: Options{Tags: []string{"Auth"}}, ops: []string{"login"}},
+		{name: "operations", opts: Options{Operations: []string{"login", "listPets"}}, ops: []string{"listPets", "login"}},
+		{name: "tag or operation", opts: Options{Tags: []string{"admin"}, Operations: []string{"login"}}, ops: []string{"deletePet", "login"}},
+	}
+	for _, tt := range tests {
+		res := importFile(t, "petstore.yaml", tt.opts)
+		var ops []string
+		for _, st := range res.Scenarios[0].Steps {
+			ops = append(ops, st.Name)
+		}
+		if !reflect.DeepEqual(ops, tt.ops) {
+			t.Errorf("%s: expected operations %v, got %v", tt.name, tt.ops, ops)
+		}
+	}
+
+	if _, err := Import(FormatOpenAPI, "petstore.yaml", readTestdata(t, "petstore.yaml"), Options{Tags: []string{"store"}}); err == nil ||
+		err.Error() != "no operations match the selected tags and operation ids" {
+		t.Errorf("expected an error without selected operations, got %v", err)
+	}
+}
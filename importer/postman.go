@@ -0,0 +1,596 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"httes/core/scenario/scripting/injection"
+	"httes/core/types"
+)
+
+const FormatPostman = "postman"
+
+func init() {
+	AvailableImporters[FormatPostman] = postmanImporter{}
+}
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Auth     *postmanAuth      `json:"auth"`
+	Variable []postmanVariable `json:"variable"`
+	Event    []postmanEvent    `json:"event"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"` // Элементы папки
+	Request *postmanRequest `json:"request"`
+	Auth    *postmanAuth    `json:"auth"` // Аутентификация папки
+	Event   []postmanEvent  `json:"event"`
+}
+
+type postmanRequest struct {
+	Method string       `json:"method"`
+	URL    postmanURL   `json:"url"`
+	Header []postmanKV  `json:"header"`
+	Body   *postmanBody `json:"body"`
+	Auth   *postmanAuth `json:"auth"`
+}
+
+// postmanURL — адрес запроса: строка или объект с полем raw.
+type postmanURL string
+
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*u = postmanURL(s)
+		return nil
+	}
+	var obj struct {
+		Raw string `json:"raw"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*u = postmanURL(obj.Raw)
+	return nil
+}
+
+type postmanKV struct {
+	Key      string      `json:"key"`
+	Value    string      `json:"value"`
+	Type     string      `json:"type"`
+	Src      interface{} `json:"src"` // Путь к файлу поля formdata: строка или список
+	Disabled bool        `json:"disabled"`
+}
+
+type postmanBody struct {
+	Mode       string      `json:"mode"`
+	Raw        string      `json:"raw"`
+	URLEncoded []postmanKV `json:"urlencoded"`
+	FormData   []postmanKV `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string      `json:"type"`
+	Basic  []postmanKV `json:"basic"`
+	Bearer []postmanKV `json:"bearer"`
+	APIKey []postmanKV `json:"apikey"`
+}
+
+type postmanVariable struct {
+	Key      string      `json:"key"`
+	Value    interface{} `json:"value"`
+	Disabled bool        `json:"disabled"`
+}
+
+type postmanEvent struct {
+	Listen string `json:"listen"`
+	Script struct {
+		Exec postmanExec `json:"exec"`
+	} `json:"script"`
+}
+
+// postmanExec — строки скрипта: список строк или одна строка.
+type postmanExec []string
+
+func (e *postmanExec) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*e = strings.Split(s, "\n")
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*e = lines
+	return nil
+}
+
+// postmanEnvironment — файл окружения Postman.
+type postmanEnvironment struct {
+	Values []struct {
+		Key     string      `json:"key"`
+		Value   interface{} `json:"value"`
+		Enabled *bool       `json:"enabled"`
+	} `json:"values"`
+}
+
+// postmanImporter импортирует коллекции Postman v2.1 и файлы окружения.
+// Папки верхнего уровня становятся сценариями, вложенные папки — группами шагов с общим префиксом имени.
+// Переменные коллекции и окружения становятся переменными окружения сценария, а простые вызовы
+// pm.environment.set в тестовых скриптах — извлечением переменных из ответа.
+type postmanImporter struct{}
+
+func (postmanImporter) Detect(name string, data []byte) bool {
+	var probe struct {
+		Info struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+	}
+	return json.Unmarshal(data, &probe) == nil && strings.Contains(probe.Info.Schema, "postman")
+}
+
+func (postmanImporter) Import(data []byte, opts Options) (*Result, error) {
+	var col postmanCollection
+	if err := json.Unmarshal(data, &col); err != nil {
+		return nil, fmt.Errorf("invalid postman collection: %v", err)
+	}
+	if !strings.Contains(col.Info.Schema, "v2.1") {
+		return nil, fmt.Errorf("unsupported postman collection schema %q, export the collection as v2.1", col.Info.Schema)
+	}
+
+	p := &postmanConverter{
+		res:      &Result{},
+		envs:     map[string]interface{}{},
+		renames:  map[string]string{},
+		captured: map[string]bool{},
+		used:     map[string]bool{},
+	}
+	for _, v := range col.Variable {
+		if !v.Disabled {
+			p.envs[p.varName(v.Key)] = v.Value
+		}
+	}
+	if len(opts.Environment) > 0 {
+		var env postmanEnvironment
+		if err := json.Unmarshal(opts.Environment, &env); err != nil {
+			return nil, fmt.Errorf("invalid postman environment: %v", err)
+		}
+		for _, v := range env.Values {
+			if v.Enabled == nil || *v.Enabled {
+				p.envs[p.varName(v.Key)] = v.Value
+			}
+		}
+	}
+	p.scriptWarnings("collection", col.Event)
+
+	// Запросы верхнего уровня составляют сценарий с именем коллекции, папки — отдельные сценарии
+	name := col.Info.Name
+	if opts.Name != "" {
+		name = opts.Name
+	}
+	root := Scenario{Name: name}
+	var folders []Scenario
+	for _, it := range col.Item {
+		if it.Request != nil {
+			p.addItem(&root, it, "", col.Auth)
+			continue
+		}
+		sc := Scenario{Name: it.Name}
+		p.scriptWarnings("folder "+it.Name, it.Event)
+		for _, child := range it.Item {
+			p.addItem(&sc, child, "", inheritAuth(it.Auth, col.Auth))
+		}
+		if len(sc.Steps) > 0 {
+			folders = append(folders, sc)
+		}
+	}
+	if dep := crossDependency(append([]Scenario{root}, folders...)); dep != "" {
+		// Сценарии смеси выполняются независимо, поэтому папки, связанные переменными, объединяются в один сценарий
+		p.res.warn("folders are imported as step groups of one scenario: %s", dep)
+		for _, f := range folders {
+			for _, st := range f.Steps {
+				st.Name = f.Name + " / " + st.Name
+				root.Steps = append(root.Steps, st)
+			}
+		}
+		folders = nil
+	}
+	if len(root.Steps) > 0 {
+		p.res.Scenarios = append(p.res.Scenarios, root)
+	}
+	p.res.Scenarios = append(p.res.Scenarios, folders...)
+	if len(p.res.Scenarios) == 0 {
+		return nil, fmt.Errorf("postman collection %s has no requests", col.Info.Name)
+	}
+	for i := range p.res.Scenarios {
+		p.res.Scenarios[i].Envs = p.envs
+	}
+
+	var undefined []string
+	for v := range p.used {
+		if _, ok := p.envs[v]; !ok && !p.captured[v] {
+			undefined = append(undefined, v)
+		}
+	}
+	sort.Strings(undefined)
+	for _, v := range undefined {
+		p.res.warn("variable %s is not defined in the collection or environment", v)
+	}
+	return p.res, nil
+}
+
+// crossDependency возвращает описание первой переменной, которая извлекается в одном сценарии,
+// а используется в другом, или пустую строку, если сценарии независимы.
+func crossDependency(scenarios []Scenario) string {
+	capturedIn := map[string]string{}
+	for _, sc := range scenarios {
+		for _, st := range sc.Steps {
+			for v := range st.CaptureEnv {
+				capturedIn[v] = sc.Name
+			}
+		}
+	}
+	for _, sc := range scenarios {
+		for _, st := range sc.Steps {
+			b, _ := json.Marshal(st)
+			for _, m := range postmanVarRegexp.FindAllStringSubmatch(string(b), -1) {
+				if owner, ok := capturedIn[m[1]]; ok && owner != sc.Name {
+					return fmt.Sprintf("%s uses %s captured in %s", sc.Name, m[1], owner)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// postmanConverter хранит состояние преобразования коллекции.
+type postmanConverter struct {
+	res      *Result
+	envs     map[string]interface{}
+	renames  map[string]string // Имена переменных Postman, недопустимые в httes, и их замены
+	captured map[string]bool   // Переменные, извлекаемые из ответов
+	used     map[string]bool   // Переменные, используемые в запросах
+	nextID   uint16
+}
+
+// addItem добавляет в сценарий шаг запроса или шаги папки. prefix — путь вложенных папок.
+func (p *postmanConverter) addItem(sc *Scenario, it postmanItem, prefix string, parentAuth *postmanAuth) {
+	if it.Request == nil {
+		p.scriptWarnings("folder "+prefix+it.Name, it.Event)
+		for _, child := range it.Item {
+			p.addItem(sc, child, prefix+it.Name+" / ", inheritAuth(it.Auth, parentAuth))
+		}
+		return
+	}
+
+	r := it.Request
+	p.nextID++
+	st := Step{
+		ID:     p.nextID,
+		Name:   prefix + it.Name,
+		URL:    p.vars(string(r.URL)),
+		Method: strings.ToUpper(r.Method),
+	}
+	if st.Method == "" {
+		st.Method = types.DefaultMethod
+	}
+	if !supportedMethod(st.Method) {
+		p.res.warn("request %s skipped: unsupported method %s", st.Name, st.Method)
+		p.nextID--
+		return
+	}
+
+	for _, h := range r.Header {
+		if h.Disabled {
+			continue
+		}
+		if st.Headers == nil {
+			st.Headers = map[string]string{}
+		}
+		st.Headers[h.Key] = p.vars(h.Value)
+	}
+	p.body(&st, r.Body)
+	p.auth(&st, inheritAuth(r.Auth, parentAuth))
+
+	for _, e := range it.Event {
+		switch e.Listen {
+		case "test":
+			p.testScript(&st, e.Script.Exec)
+		case "prerequest":
+			if scriptLines(e.Script.Exec) > 0 {
+				p.res.warn("step %d (%s): pre-request script is not converted", st.ID, st.Name)
+			}
+		}
+	}
+	sc.Steps = append(sc.Steps, st)
+}
+
+// body переносит тело запроса в шаг.
+func (p *postmanConverter) body(st *Step, b *postmanBody) {
+	if b == nil {
+		return
+	}
+	setType := func(ct string) {
+		if st.Headers == nil {
+			st.Headers = map[string]string{}
+		}
+		if _, ok := st.Headers[headerName(st.Headers, "Content-Type")]; !ok {
+			st.Headers["Content-Type"] = ct
+		}
+	}
+	switch b.Mode {
+	case "raw":
+		st.Payload = p.vars(b.Raw)
+		if b.Options.Raw.Language == "json" && b.Raw != "" {
+			setType("application/json")
+		}
+	case "urlencoded":
+		form := make([]string, 0, len(b.URLEncoded))
+		for _, kv := range b.URLEncoded {
+			if !kv.Disabled {
+				form = append(form, url.QueryEscape(kv.Key)+"="+url.QueryEscape(kv.Value))
+			}
+		}
+		// Переменные подставляются после кодирования, чтобы {{name}} не экранировался
+		st.Payload = p.vars(strings.NewReplacer("%7B%7B", "{{", "%7D%7D", "}}").Replace(strings.Join(form, "&")))
+		setType("application/x-www-form-urlencoded")
+	case "formdata":
+		for _, kv := range b.FormData {
+			if kv.Disabled {
+				continue
+			}
+			if kv.Type == "file" {
+				src, _ := kv.Src.(string)
+				if list, ok := kv.Src.([]interface{}); ok && len(list) > 0 {
+					src, _ = list[0].(string)
+				}
+				if src == "" {
+					p.res.warn("step %d (%s): file of field %s is not selected", st.ID, st.Name, kv.Key)
+				}
+				st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: kv.Key, Value: src, Type: "file"})
+				continue
+			}
+			st.PayloadMultipart = append(st.PayloadMultipart, MultipartField{Name: kv.Key, Value: p.vars(kv.Value)})
+		}
+	case "graphql":
+		if b.GraphQL != nil {
+			q := map[string]interface{}{"query": b.GraphQL.Query}
+			if strings.TrimSpace(b.GraphQL.Variables) != "" {
+				q["variables"] = json.RawMessage(b.GraphQL.Variables)
+			}
+			if js, err := json.Marshal(q); err == nil {
+				st.Payload = p.vars(string(js))
+				setType("application/json")
+			} else {
+				p.res.warn("step %d (%s): invalid graphql variables", st.ID, st.Name)
+			}
+		}
+	case "":
+	default:
+		p.res.warn("step %d (%s): body mode %s is not supported", st.ID, st.Name, b.Mode)
+	}
+}
+
+// auth переносит аутентификацию: basic — в Auth шага, bearer и apikey — в заголовки или параметры запроса.
+func (p *postmanConverter) auth(st *Step, a *postmanAuth) {
+	if a == nil {
+		return
+	}
+	get := func(kv []postmanKV, key string) string {
+		for _, e := range kv {
+			if e.Key == key {
+				return p.vars(e.Value)
+			}
+		}
+		return ""
+	}
+	setHeader := func(k, v string) {
+		if st.Headers == nil {
+			st.Headers = map[string]string{}
+		}
+		st.Headers[k] = v
+	}
+
+	switch a.Type {
+	case "noauth", "":
+	case "basic":
+		st.Auth = &Auth{Type: types.AuthHttpBasic, Username: get(a.Basic, "username"), Password: get(a.Basic, "password")}
+	case "bearer":
+		setHeader("Authorization", "Bearer "+get(a.Bearer, "token"))
+	case "apikey":
+		key, value := get(a.APIKey, "key"), get(a.APIKey, "value")
+		if get(a.APIKey, "in") == "query" {
+			sep := "?"
+			if strings.Contains(st.URL, "?") {
+				sep = "&"
+			}
+			st.URL += sep + url.QueryEscape(key) + "=" + value
+		} else {
+			setHeader(key, value)
+		}
+	default:
+		p.res.warn("step %d (%s): auth type %s is not supported", st.ID, st.Name, a.Type)
+	}
+}
+
+// inheritAuth возвращает аутентификацию элемента с учётом наследования от родителя.
+func inheritAuth(own, parent *postmanAuth) *postmanAuth {
+	if own == nil || own.Type == "inherit" {
+		return parent
+	}
+	return own
+}
+
+// Выражения тестовых скриптов, которые преобразуются в извлечение переменных
+var (
+	postmanSetRegexp   = regexp.MustCompile(`^(?:pm\.(?:environment|collectionVariables|globals|variables)\.set|postman\.set(?:Environment|Global)Variable)\(\s*["'` + "`" + `]([^"'` + "`" + `]+)["'` + "`" + `]\s*,\s*(.+?)\s*\)\s*;?$`)
+	postmanAliasRegexp = regexp.MustCompile(`^(?:var|let|const)\s+(\w+)\s*=\s*(pm\.response\.json\(\)|JSON\.parse\(\s*responseBody\s*\))\s*;?$`)
+	postmanHeader      = regexp.MustCompile(`^(?:pm\.response\.headers\.get|postman\.getResponseHeader)\(\s*["']([^"']+)["']\s*\)$`)
+	postmanCookie      = regexp.MustCompile(`^(?:pm\.cookies\.get|postman\.getResponseCookie)\(\s*["']([^"']+)["']\s*\)(?:\.value)?$`)
+	postmanAccessor    = regexp.MustCompile(`^(?:\.(\w+)|\[\s*["']([^"']+)["']\s*\]|\[(\d+)\])`)
+)
+
+// testScript преобразует установку переменных из ответа в извлечение переменных шага.
+// Остальные строки скрипта (проверки и прочая логика) попадают в предупреждение.
+func (p *postmanConverter) testScript(st *Step, exec []string) {
+	aliases := map[string]bool{}
+	skipped := 0
+	for _, line := range exec {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") || strings.Trim(line, "{}();") == "" {
+			continue
+		}
+		if m := postmanAliasRegexp.FindStringSubmatch(line); m != nil {
+			aliases[m[1]] = true
+			continue
+		}
+		m := postmanSetRegexp.FindStringSubmatch(line)
+		if m == nil {
+			skipped++
+			continue
+		}
+		capture, ok := postmanCapture(m[2], aliases)
+		if !ok {
+			skipped++
+			continue
+		}
+		name := p.varName(m[1])
+		if st.CaptureEnv == nil {
+			st.CaptureEnv = map[string]Capture{}
+		}
+		st.CaptureEnv[name] = capture
+		p.captured[name] = true
+	}
+	if skipped > 0 {
+		p.res.warn("step %d (%s): %d line(s) of the test script are not converted", st.ID, st.Name, skipped)
+	}
+}
+
+// postmanCapture преобразует выражение значения переменной в извлечение из тела, заголовка или cookie ответа.
+func postmanCapture(expr string, aliases map[string]bool) (Capture, bool) {
+	if m := postmanHeader.FindStringSubmatch(expr); m != nil {
+		return Capture{From: "header", Header: strPtr(m[1])}, true
+	}
+	if m := postmanCookie.FindStringSubmatch(expr); m != nil {
+		return Capture{From: "cookie", Cookie: strPtr(m[1])}, true
+	}
+
+	rest, ok := strings.CutPrefix(expr, "pm.response.json()")
+	if !ok {
+		name := expr
+		if i := strings.IndexAny(expr, ".["); i >= 0 {
+			name = expr[:i]
+		}
+		if !aliases[name] {
+			return Capture{}, false
+		}
+		rest = expr[len(name):]
+	}
+
+	var keys []string
+	for rest != "" {
+		m := postmanAccessor.FindStringSubmatch(rest)
+		if m == nil {
+			return Capture{}, false
+		}
+		keys = append(keys, m[1]+m[2]+m[3])
+		rest = rest[len(m[0]):]
+	}
+	if len(keys) == 0 {
+		return Capture{}, false
+	}
+	jp := strings.Join(keys, ".")
+	return Capture{From: "body", JsonPath: &jp}, true
+}
+
+// scriptWarnings предупреждает о скриптах коллекции или папки, которые не переносятся.
+func (p *postmanConverter) scriptWarnings(owner string, events []postmanEvent) {
+	for _, e := range events {
+		if scriptLines(e.Script.Exec) > 0 {
+			p.res.warn("%s: %s script is not converted", owner, e.Listen)
+		}
+	}
+}
+
+// scriptLines возвращает количество непустых строк скрипта без комментариев.
+func scriptLines(exec []string) int {
+	n := 0
+	for _, l := range exec {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, "//") {
+			n++
+		}
+	}
+	return n
+}
+
+// Ссылка на переменную Postman: {{name}} или динамическая {{$name}}
+var postmanVarRegexp = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// vars заменяет переменные Postman в s переменными httes: {{$guid}} становится {{_guid}},
+// имена с недопустимыми символами переименовываются.
+func (p *postmanConverter) vars(s string) string {
+	return postmanVarRegexp.ReplaceAllStringFunc(s, func(tag string) string {
+		name := postmanVarRegexp.FindStringSubmatch(tag)[1]
+		if dyn, ok := strings.CutPrefix(name, "$"); ok {
+			if injection.IsDynamicVariable(dyn) {
+				return "{{_" + dyn + "}}"
+			}
+			p.res.warn("dynamic variable %s is not supported", name)
+			return tag
+		}
+		n := p.varName(name)
+		p.used[n] = true
+		return "{{" + n + "}}"
+	})
+}
+
+// Допустимое имя переменной окружения
+var envVarNameRegexp = regexp.MustCompile(`^[A-Za-z0-9]\w+$`)
+
+// varName возвращает имя переменной httes для переменной Postman.
+func (p *postmanConverter) varName(name string) string {
+	if envVarNameRegexp.MatchString(name) {
+		return name
+	}
+	if n, ok := p.renames[name]; ok {
+		return n
+	}
+	n := envName(name)
+	for i := 2; p.isTaken(n); i++ {
+		n = envName(name) + "_" + strconv.Itoa(i)
+	}
+	p.renames[name] = n
+	p.res.warn("variable %s is renamed to %s", name, n)
+	return n
+}
+
+func (p *postmanConverter) isTaken(n string) bool {
+	for _, v := range p.renames {
+		if v == n {
+			return true
+		}
+	}
+	_, ok := p.envs[n]
+	return ok
+}
@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"testing"
+)
+
+// Коллекция Postman с окружением: папки становятся группами шагов одного сценария, авторизация
+// наследуется от папок, переменные окружения заменяют переменные коллекции, а тестовые скрипты
+// с pm.environment.set превращаются в извлечения переменных.
+func TestImportPostman(t *testing.T) {
+	res := importFile(t, "shop.postman_collection.json", Options{Environment: readTestdata(t, "shop.postman_environment.json")})
+	checkConfig(t, res, "shop.json")
+	checkWarnings(t, res,
+		"variable access-token is renamed to access_token",
+		"step 2 (Login): 2 line(s) of the test script are not converted",
+		"step 5 (Checkout): auth type oauth2 is not supported",
+		"step 5 (Checkout): pre-request script is not converted",
+		"folders are imported as step groups of one scenario: Cart uses userId captured in Auth",
+		"variable apiKey is not defined in the collection or environment",
+		"variable sku is not defined in the collection or environment",
+	)
+}
+
+// Без файла окружения используются переменные коллекции, а авторизация шагов не зависит от окружения.
+func TestImportPostmanWithoutEnvironment(t *testing.T) {
+	res := importFile(t, "shop.postman_collection.json", Options{})
+	if len(res.Scenarios) != 1 {
+		t.Fatalf("expected folders in one scenario, got %d scenarios", len(res.Scenarios))
+	}
+	sc := res.Scenarios[0]
+	if base := sc.Envs["baseUrl"]; base != "https://api.shop.example.com" || len(sc.Envs) != 1 {
+		t.Errorf("expected only baseUrl of the collection, got %v", sc.Envs)
+	}
+	if w := res.Warnings[len(res.Warnings)-1]; w != "variable user is not defined in the collection or environment" {
+		t.Errorf("expected a warning about the environment variable, got %q", w)
+	}
+
+	tests := []struct {
+		name   string
+		auth   string
+		header string
+		url    string
+	}{
+		// Шаг с noauth не наследует авторизацию коллекции
+		{name: "Health", url: "{{baseUrl}}/health"},
+		{name: "Auth / Login", auth: "basic", url: "{{baseUrl}}/v1/login"},
+		{name: "Cart / Items / Add item", header: "Bearer {{access_token}}", url: "{{baseUrl}}/v1/users/{{userId}}/cart"},
+		{name: "Cart / Items / Upload receipt", url: "{{baseUrl}}/v1/receipts?kind=pdf&api_key={{apiKey}}"},
+		{name: "Cart / Checkout", url: "{{baseUrl}}/v1/checkout"},
+	}
+	if len(sc.Steps) != len(tests) {
+		t.Fatalf("expected %d steps, got %d", len(tests), len(sc.Steps))
+	}
+	for i, tt := range tests {
+		st := sc.Steps[i]
+		if st.Name != tt.name || st.URL != tt.url {
+			t.Errorf("step %d: expected %s %s, got %s %s", i+1, tt.name, tt.url, st.Name, st.URL)
+		}
+		if (st.Auth == nil) != (tt.auth == "") || st.Auth != nil && st.Auth.Type != tt.auth {
+			t.Errorf("step %d: expected auth %q, got %+v", i+1, tt.auth, st.Auth)
+		}
+		if h := st.Headers["Authorization"]; h != tt.header {
+			t.Errorf("step %d: expected authorization header %q, got %q", i+1, tt.header, h)
+		}
+	}
+}
+
+// Имя сценария из параметров заменяет имя коллекции.
+func TestImportPostmanName(t *testing.T) {
+	res := importFile(t, "shop.postman_collection.json", Options{Name: "Smoke"})
+	if res.Scenarios[0].Name != "Smoke" {
+		t.Errorf("expected scenario Smoke, got %s", res.Scenarios[0].Name)
+	}
+}
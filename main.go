@@ -1,6 +1,7 @@
 package main
 
 import (
+	"httes/store"
 	"httes/ui"
 	"log"
 
@@ -23,6 +24,11 @@ func main() {
 		window.SetIcon(icon)
 	}
 
+	// Открываем хранилище сценариев и истории тестов. При ошибке данные хранятся только в памяти
+	if err := store.Init(); err != nil {
+		log.Println("Ошибка открытия хранилища:", err)
+	}
+
 	resultOutput := widget.NewTextGrid()
 	// Создаем MainPage с пустым username и role (будут заполнены после авторизации)
 	mp := ui.NewMainPage(myApp, resultOutput, window, "", "", icon)
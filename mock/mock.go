@@ -0,0 +1,304 @@
+// Пакет mock — локальный сервер с настраиваемыми маршрутами для пробных запусков сценариев,
+// интеграционных тестов и замеров производительности самого httes без обращения к реальным сервисам.
+package mock
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	mrand "math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server отвечает на запросы по маршрутам конфигурации. Он также работает как прокси:
+// запросы с полным адресом и туннели CONNECT обслуживаются теми же маршрутами, поэтому тест
+// можно направить на mock-сервер, указав его адрес как прокси, без изменения адресов шагов.
+type Server struct {
+	routes   []Route
+	counters []atomic.Int64
+	requests atomic.Int64
+
+	server   *http.Server
+	listener net.Listener
+
+	tlsOnce      sync.Once
+	tlsConfig    *tls.Config
+	tlsErr       error
+	tunnels      *tunnelListener
+	tunnelServer *http.Server
+}
+
+// New проверяет маршруты и создаёт сервер. Пустая конфигурация заменяется DefaultConfig.
+func New(cfg Config) (*Server, error) {
+	if len(cfg.Routes) == 0 {
+		cfg = DefaultConfig()
+	}
+	s := &Server{routes: make([]Route, len(cfg.Routes)), counters: make([]atomic.Int64, len(cfg.Routes))}
+	copy(s.routes, cfg.Routes)
+	for i := range s.routes {
+		if err := s.routes[i].compile(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Start начинает принимать соединения на адресе addr, например 127.0.0.1:0, и возвращает фактический адрес.
+func (s *Server) Start(addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s.listener = l
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 30 * time.Second}
+	go s.server.Serve(l)
+	return l.Addr(), nil
+}
+
+// URL возвращает адрес запущенного сервера, например http://127.0.0.1:8080.
+func (s *Server) URL() string {
+	if s.listener == nil {
+		return ""
+	}
+	return "http://" + s.listener.Addr().String()
+}
+
+// Close останавливает сервер и закрывает все соединения.
+func (s *Server) Close() error {
+	// Блокирует создание сервера туннелей после остановки и ждёт его, если он создаётся
+	s.tlsOnce.Do(func() { s.tlsErr = http.ErrServerClosed })
+	if s.tunnelServer != nil {
+		s.tunnelServer.Close()
+	}
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// Requests возвращает количество обработанных запросов.
+func (s *Server) Requests() int64 {
+	return s.requests.Load()
+}
+
+// ServeHTTP отвечает на запрос по первому подходящему маршруту.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		s.serveConnect(w, r)
+		return
+	}
+	s.requests.Add(1)
+
+	for i := range s.routes {
+		route := &s.routes[i]
+		params, ok := route.match(r.Method, r.URL.Path)
+		if !ok {
+			continue
+		}
+		counter := s.counters[i].Add(1)
+		time.Sleep(route.Latency.delay())
+
+		if route.Error.Rate > 0 && mrand.Float64() < route.Error.Rate {
+			if route.Error.Abort {
+				abort(w)
+				return
+			}
+			writeJSONError(w, route.Error.Status, "injected error")
+			return
+		}
+		s.respond(w, r, route, params, counter)
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+}
+
+// respond отправляет ответ маршрута с телом и заголовками по шаблонам.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, route *Route, params map[string]string, counter int64) {
+	reqBody, _ := io.ReadAll(r.Body)
+	data := templateData{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Params:  params,
+		Query:   map[string]string{},
+		Header:  r.Header,
+		Body:    string(reqBody),
+		Counter: counter,
+	}
+	for k, v := range r.URL.Query() {
+		data.Query[k] = v[0]
+	}
+	if len(reqBody) > 0 {
+		json.Unmarshal(reqBody, &data.JSON)
+	}
+
+	var body bytes.Buffer
+	if err := route.body.Execute(&body, data); err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for k, t := range route.headers {
+		var v strings.Builder
+		if err := t.Execute(&v, data); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if v.Len() > 0 {
+			w.Header().Set(k, v.String())
+		}
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType(body.Bytes()))
+	}
+
+	if route.Chunked.Count <= 1 {
+		w.Header().Set("Content-Length", fmt.Sprint(body.Len()))
+		w.WriteHeader(route.Status)
+		w.Write(body.Bytes())
+		return
+	}
+
+	// Тело без Content-Length отправляется с Transfer-Encoding: chunked, каждая часть — отдельно
+	w.WriteHeader(route.Status)
+	flusher, _ := w.(http.Flusher)
+	size := (body.Len() + route.Chunked.Count - 1) / route.Chunked.Count
+	for i := 0; i < route.Chunked.Count && body.Len() > 0; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(route.Chunked.Interval) * time.Millisecond)
+		}
+		if _, err := w.Write(body.Next(size)); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// serveConnect принимает туннель CONNECT и обслуживает запросы из него по TLS с самоподписанным сертификатом.
+func (s *Server) serveConnect(w http.ResponseWriter, r *http.Request) {
+	s.tlsOnce.Do(s.initTLS)
+	if s.tlsErr != nil {
+		http.Error(w, s.tlsErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunnels are not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		conn.Close()
+		return
+	}
+	if !s.tunnels.push(tls.Server(conn, s.tlsConfig)) {
+		conn.Close()
+	}
+}
+
+// initTLS создаёт самоподписанный сертификат и сервер для соединений из туннелей CONNECT.
+func (s *Server) initTLS() {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		s.tlsErr = err
+		return
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "httes mock"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		s.tlsErr = err
+		return
+	}
+	s.tlsConfig = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		NextProtos:   []string{"http/1.1"},
+	}
+	s.tunnels = &tunnelListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	s.tunnelServer = &http.Server{Handler: s, ReadHeaderTimeout: 30 * time.Second}
+	go s.tunnelServer.Serve(s.tunnels)
+}
+
+// tunnelListener передаёт серверу соединения из туннелей CONNECT.
+type tunnelListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *tunnelListener) push(c net.Conn) bool {
+	select {
+	case l.conns <- c:
+		return true
+	case <-l.done:
+		return false
+	}
+}
+
+func (l *tunnelListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *tunnelListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *tunnelListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+// abort разрывает соединение без ответа.
+func abort(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			if tcp, ok := conn.(*net.TCPConn); ok {
+				tcp.SetLinger(0) // Клиент получит RST, а не пустой ответ
+			}
+			conn.Close()
+			return
+		}
+	}
+	panic(http.ErrAbortHandler)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// contentType определяет Content-Type тела ответа: JSON или текст.
+func contentType(body []byte) string {
+	if json.Valid(body) && len(bytes.TrimSpace(body)) > 0 {
+		return "application/json"
+	}
+	return http.DetectContentType(body)
+}
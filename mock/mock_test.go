@@ -0,0 +1,281 @@
+package mock
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startServer(tb testing.TB, routes ...Route) *Server {
+	tb.Helper()
+	s, err := New(Config{Routes: routes})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := s.Start("127.0.0.1:0"); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { s.Close() })
+	return s
+}
+
+// get отправляет запрос и возвращает ответ с прочитанным телом.
+func get(t *testing.T, client *http.Client, method, url, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(data)
+}
+
+func TestServerTemplates(t *testing.T) {
+	s := startServer(t,
+		Route{
+			Method:  "POST",
+			Path:    "/users/{id}/orders/{order}",
+			Status:  201,
+			Headers: map[string]string{"X-Request": "{{.Method}} {{.Counter}}", "X-Empty": "{{if .Query.trace}}{{.Query.trace}}{{end}}"},
+			Body:    `{"user":"{{.Params.id}}","order":"{{.Params.order}}","tab":"{{.Query.tab}}","name":"{{.JSON.name}}","auth":"{{.Header.Get "Authorization"}}","path":"{{.Path}}"}`,
+		},
+		Route{Path: "/funcs", Body: `{"id":"{{uuid}}","n":{{randInt 5 7}},"at":"{{now}}","query":{{json .Query}}}`},
+		Route{Path: "/text", Body: "hello {{.Query.name}}"},
+		Route{Path: "/typed", Headers: map[string]string{"Content-Type": "application/xml"}, Body: "<ok/>"},
+		Route{Path: "/broken", Body: "{{.JSON.user.name}}"},
+	)
+
+	for i := 1; i <= 2; i++ {
+		resp, body := get(t, http.DefaultClient, "POST", s.URL()+"/users/42/orders/ord-7?tab=main", `{"name":"Ann"}`,
+			http.Header{"Authorization": {"Bearer t0k3n"}})
+		if resp.StatusCode != 201 {
+			t.Errorf("expected 201, got %s", resp.Status)
+		}
+		expected := `{"user":"42","order":"ord-7","tab":"main","name":"Ann","auth":"Bearer t0k3n","path":"/users/42/orders/ord-7"}`
+		if body != expected {
+			t.Errorf("expected body %s, got %s", expected, body)
+		}
+		if h := resp.Header.Get("X-Request"); h != "POST "+strconv.Itoa(i) {
+			t.Errorf("request %d: unexpected header %q", i, h)
+		}
+		// Пустое значение заголовка по шаблону не отправляется
+		if _, ok := resp.Header["X-Empty"]; ok {
+			t.Error("empty header is sent")
+		}
+		if ct, cl := resp.Header.Get("Content-Type"), resp.ContentLength; ct != "application/json" || cl != int64(len(expected)) {
+			t.Errorf("unexpected content type %q and length %d", ct, cl)
+		}
+	}
+
+	_, body := get(t, http.DefaultClient, "GET", s.URL()+"/funcs?a=1", "", nil)
+	var funcs struct {
+		ID    string
+		N     int
+		At    string
+		Query map[string]string
+	}
+	if err := json.Unmarshal([]byte(body), &funcs); err != nil {
+		t.Fatalf("%v: %s", err, body)
+	}
+	if _, err := time.Parse(time.RFC3339, funcs.At); err != nil || len(funcs.ID) != 36 || funcs.N < 5 || funcs.N > 7 || funcs.Query["a"] != "1" {
+		t.Errorf("unexpected template functions result %s", body)
+	}
+
+	resp, body := get(t, http.DefaultClient, "GET", s.URL()+"/text?name=Bob", "", nil)
+	if ct := resp.Header.Get("Content-Type"); body != "hello Bob" || ct != "text/plain; charset=utf-8" {
+		t.Errorf("unexpected text response %q with %q", body, ct)
+	}
+	if resp, _ := get(t, http.DefaultClient, "GET", s.URL()+"/typed", "", nil); resp.Header.Get("Content-Type") != "application/xml" {
+		t.Errorf("content type from the route headers is replaced: %q", resp.Header.Get("Content-Type"))
+	}
+	if resp, body := get(t, http.DefaultClient, "GET", s.URL()+"/broken", "", nil); resp.StatusCode != 500 || !strings.Contains(body, "error") {
+		t.Errorf("failed template: expected 500, got %s %s", resp.Status, body)
+	}
+	if resp, body := get(t, http.DefaultClient, "GET", s.URL()+"/users/42", "", nil); resp.StatusCode != 404 || body != `{"error":"no route for GET /users/42"}`+"\n" {
+		t.Errorf("unknown route: expected 404, got %s %s", resp.Status, body)
+	}
+	if n := s.Requests(); n != 7 {
+		t.Errorf("expected 7 requests, got %d", n)
+	}
+}
+
+func TestServerDefaultConfig(t *testing.T) {
+	s := startServer(t)
+	resp, body := get(t, http.DefaultClient, "POST", s.URL()+"/echo", "a=1", http.Header{"Content-Type": {"application/x-www-form-urlencoded"}})
+	if body != "a=1" || resp.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("echo: unexpected response %q with %q", body, resp.Header.Get("Content-Type"))
+	}
+	if _, body := get(t, http.DefaultClient, "DELETE", s.URL()+"/any/path", "", nil); body != `{"ok":true}` {
+		t.Errorf("default route: unexpected response %s", body)
+	}
+}
+
+// Доля внедрённых ошибок близка к Rate.
+func TestServerErrorRate(t *testing.T) {
+	s, err := New(Config{Routes: []Route{{Path: "/flaky", Body: "ok", Error: ErrorInjection{Rate: 0.3, Status: 503}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 2000
+	failed := 0
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest("GET", "/flaky", nil))
+		switch w.Code {
+		case 503:
+			failed++
+			if w.Body.String() != `{"error":"injected error"}`+"\n" {
+				t.Fatalf("unexpected error body %s", w.Body)
+			}
+		case 200:
+		default:
+			t.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+	// Стандартное отклонение количества ошибок — около 20, допуск — 5 отклонений
+	if failed < 500 || failed > 700 {
+		t.Errorf("expected about %d injected errors, got %d", n*3/10, failed)
+	}
+}
+
+func TestServerAbort(t *testing.T) {
+	s := startServer(t, Route{Path: "/reset", Error: ErrorInjection{Rate: 1, Abort: true}})
+	if resp, err := http.Get(s.URL() + "/reset"); err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected a connection error, got %s", resp.Status)
+	}
+}
+
+// Тело отправляется частями с паузами: заголовки приходят сразу, а тело — после всех пауз.
+func TestServerChunkedAndSlow(t *testing.T) {
+	s := startServer(t,
+		Route{Path: "/chunked", Body: "aaaabbbbcc", Chunked: Chunked{Count: 3, Interval: 100}},
+		Route{Path: "/slow", Body: "ok", Latency: Latency{Mean: 100}},
+	)
+
+	start := time.Now()
+	resp, err := http.Get(s.URL() + "/chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	headers := time.Since(start)
+	if len(resp.TransferEncoding) != 1 || resp.TransferEncoding[0] != "chunked" || resp.ContentLength != -1 {
+		t.Errorf("unexpected transfer encoding %v and length %d", resp.TransferEncoding, resp.ContentLength)
+	}
+	var parts []string
+	buf := make([]byte, 16)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			parts = append(parts, string(buf[:n]))
+		}
+		if err != nil {
+			break
+		}
+	}
+	total := time.Since(start)
+	if strings.Join(parts, "|") != "aaaa|bbbb|cc" {
+		t.Errorf("unexpected chunks %q", parts)
+	}
+	if headers >= 100*time.Millisecond || total < 200*time.Millisecond {
+		t.Errorf("headers are received in %v, body in %v", headers, total)
+	}
+
+	start = time.Now()
+	resp, body := get(t, http.DefaultClient, "GET", s.URL()+"/slow", "", nil)
+	if d := time.Since(start); d < 100*time.Millisecond || body != "ok" || resp.ContentLength != 2 {
+		t.Errorf("slow response: %q in %v", body, d)
+	}
+}
+
+// Сервер, указанный как прокси, отвечает на запросы с полным адресом и в туннелях CONNECT.
+func TestServerProxy(t *testing.T) {
+	s := startServer(t, Route{Path: "/users/{id}", Body: `{"id":"{{.Params.id}}","host":"{{.Header.Get "X-Host"}}"}`})
+	proxy, _ := url.Parse(s.URL())
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxy),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}
+
+	for _, base := range []string{"http://shop.example.test", "https://shop.example.test:8443"} {
+		resp, body := get(t, client, "GET", base+"/users/7", "", http.Header{"X-Host": {"shop"}})
+		if resp.StatusCode != 200 || body != `{"id":"7","host":"shop"}` {
+			t.Errorf("%s: unexpected response %s %s", base, resp.Status, body)
+		}
+		if strings.HasPrefix(base, "https") {
+			if resp.TLS == nil || resp.TLS.PeerCertificates[0].Subject.CommonName != "httes mock" {
+				t.Errorf("%s: response is not received in the TLS tunnel", base)
+			}
+		}
+	}
+	// Запросы в уже открытом туннеле идут по тому же соединению
+	if _, body := get(t, client, "GET", "https://shop.example.test:8443/users/8", "", nil); !strings.Contains(body, `"id":"8"`) {
+		t.Errorf("second tunnel request: unexpected response %s", body)
+	}
+	// CONNECT не считается запросом
+	if n := s.Requests(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	// После остановки сервера туннели не открываются
+	s.Close()
+	client.CloseIdleConnections()
+	if _, err := client.Get("https://other.example.test/users/1"); err == nil {
+		t.Error("tunnel is opened after the server is closed")
+	}
+}
+
+// BenchmarkServer измеряет пропускную способность mock-сервера на маршруте с параметрами пути и шаблонами.
+// Клиент работает в том же процессе, количество ядер задаётся флагом -cpu:
+//
+//	go test -run '^$' -bench Server -cpu 1,2,4 ./mock/
+func BenchmarkServer(b *testing.B) {
+	s := startServer(b, Route{
+		Path:    "/users/{id}",
+		Headers: map[string]string{"X-Request-Id": "req-{{.Counter}}"},
+		Body:    `{"id":"{{.Params.id}}","tab":"{{.Query.tab}}"}`,
+	})
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: 1024}}
+	defer client.CloseIdleConnections()
+	target := s.URL() + "/users/42?tab=main"
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resp, err := client.Get(target)
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				b.Errorf("unexpected status %s", resp.Status)
+				return
+			}
+		}
+	})
+	rps := float64(s.Requests()) / b.Elapsed().Seconds()
+	b.ReportMetric(rps, "rps")
+	b.ReportMetric(rps/float64(runtime.GOMAXPROCS(0)), "rps/core")
+}
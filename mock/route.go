@@ -0,0 +1,217 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Распределения задержки ответа
+const (
+	LatencyFixed       = "fixed"
+	LatencyUniform     = "uniform"
+	LatencyNormal      = "normal"
+	LatencyExponential = "exponential"
+)
+
+// Config — маршруты mock-сервера. Маршруты проверяются по порядку, ответ даёт первый подходящий.
+type Config struct {
+	Routes []Route `json:"routes" yaml:"routes"`
+}
+
+// Route описывает ответ на запросы с методом Method к пути Path.
+type Route struct {
+	// Метод запроса. Пустой подходит для любого метода
+	Method string `json:"method,omitempty" yaml:"method"`
+	// Путь. Сегмент {name} совпадает с любым значением и доступен в шаблоне тела как .Params.name,
+	// * в конце совпадает с любым остатком пути
+	Path string `json:"path" yaml:"path"`
+	// Статус-код ответа, по умолчанию 200
+	Status int `json:"status,omitempty" yaml:"status"`
+	// Заголовки ответа. Content-Type по умолчанию определяется по телу
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers"`
+	// Шаблон тела ответа в синтаксисе text/template, см. templateData
+	Body string `json:"body,omitempty" yaml:"body"`
+	// Задержка перед ответом
+	Latency Latency `json:"latency,omitempty" yaml:"latency"`
+	// Внедрение ошибок
+	Error ErrorInjection `json:"error,omitempty" yaml:"error"`
+	// Отправка тела частями
+	Chunked Chunked `json:"chunked,omitempty" yaml:"chunked"`
+
+	segments []string
+	body     *template.Template
+	headers  map[string]*template.Template
+}
+
+// Latency — задержка ответа в миллисекундах. Для fixed используется Mean, для uniform — диапазон Min-Max,
+// для normal — Mean и StdDev, для exponential — Mean. Min и Max ограничивают задержку всех распределений.
+type Latency struct {
+	Distribution string  `json:"distribution,omitempty" yaml:"distribution"`
+	Min          float64 `json:"min,omitempty" yaml:"min"`
+	Max          float64 `json:"max,omitempty" yaml:"max"`
+	Mean         float64 `json:"mean,omitempty" yaml:"mean"`
+	StdDev       float64 `json:"stddev,omitempty" yaml:"stddev"`
+}
+
+// ErrorInjection — доля запросов Rate (от 0 до 1), на которые вместо ответа маршрута возвращается ошибка:
+// статус Status (по умолчанию 500) или, если Abort, разрыв соединения без ответа.
+type ErrorInjection struct {
+	Rate   float64 `json:"rate,omitempty" yaml:"rate"`
+	Status int     `json:"status,omitempty" yaml:"status"`
+	Abort  bool    `json:"abort,omitempty" yaml:"abort"`
+}
+
+// Chunked — отправка тела ответа Count частями с паузой Interval мс между ними: так имитируются медленные ответы.
+type Chunked struct {
+	Count    int `json:"count,omitempty" yaml:"count"`
+	Interval int `json:"interval,omitempty" yaml:"interval"`
+}
+
+// templateData — данные шаблона тела ответа.
+type templateData struct {
+	Method  string
+	Path    string
+	Params  map[string]string // Значения сегментов {name} пути
+	Query   map[string]string // Первые значения параметров адреса
+	Header  http.Header
+	Body    string      // Тело запроса
+	JSON    interface{} // Тело запроса, разобранное как JSON, или nil
+	Counter int64       // Порядковый номер запроса к маршруту, начиная с 1
+}
+
+// Функции шаблона тела ответа
+var templateFuncs = template.FuncMap{
+	"uuid": func() string { return uuid.New().String() },
+	"randInt": func(min, max int) int {
+		if max <= min {
+			return min
+		}
+		return min + rand.Intn(max-min+1)
+	},
+	"now": func() string { return time.Now().UTC().Format(time.RFC3339) },
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// DefaultConfig возвращает маршруты по умолчанию: /echo возвращает тело запроса,
+// остальные пути отвечают {"ok":true}.
+func DefaultConfig() Config {
+	return Config{Routes: []Route{
+		{Path: "/echo", Headers: map[string]string{"Content-Type": `{{.Header.Get "Content-Type"}}`}, Body: "{{.Body}}"},
+		{Path: "/*", Body: `{"ok":true}`},
+	}}
+}
+
+// ParseConfig читает маршруты из JSON или YAML. Неизвестные поля считаются ошибкой.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid mock config: %v", err)
+	}
+	return cfg, nil
+}
+
+// compile проверяет маршрут и разбирает путь и шаблон тела.
+func (r *Route) compile() error {
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("route path %q should start with /", r.Path)
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+	if r.Status < 100 || r.Status > 599 {
+		return fmt.Errorf("route %s: invalid status %d", r.Path, r.Status)
+	}
+	if r.Error.Rate < 0 || r.Error.Rate > 1 {
+		return fmt.Errorf("route %s: error rate should be between 0 and 1", r.Path)
+	}
+	if r.Error.Status == 0 {
+		r.Error.Status = http.StatusInternalServerError
+	}
+	switch r.Latency.Distribution {
+	case "", LatencyFixed, LatencyUniform, LatencyNormal, LatencyExponential:
+	default:
+		return fmt.Errorf("route %s: unsupported latency distribution %s", r.Path, r.Latency.Distribution)
+	}
+	if r.Latency.Max > 0 && r.Latency.Max < r.Latency.Min {
+		return fmt.Errorf("route %s: latency max is less than min", r.Path)
+	}
+	for i, s := range strings.Split(strings.Trim(r.Path, "/"), "/") {
+		if s == "*" && i != strings.Count(strings.Trim(r.Path, "/"), "/") {
+			return fmt.Errorf("route %s: * is allowed only at the end of the path", r.Path)
+		}
+		r.segments = append(r.segments, s)
+	}
+
+	var err error
+	r.body, err = template.New(r.Path).Funcs(templateFuncs).Parse(r.Body)
+	if err != nil {
+		return fmt.Errorf("route %s: invalid body template: %v", r.Path, err)
+	}
+	r.headers = make(map[string]*template.Template, len(r.Headers))
+	for k, v := range r.Headers {
+		if r.headers[k], err = template.New(k).Funcs(templateFuncs).Parse(v); err != nil {
+			return fmt.Errorf("route %s: invalid header %s template: %v", r.Path, k, err)
+		}
+	}
+	return nil
+}
+
+// match сообщает, подходит ли маршрут к запросу, и возвращает значения сегментов {name} пути.
+func (r *Route) match(method, path string) (map[string]string, bool) {
+	if r.Method != "" && !strings.EqualFold(r.Method, method) {
+		return nil, false
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	params := map[string]string{}
+	for i, s := range r.segments {
+		if s == "*" {
+			return params, true
+		}
+		if i >= len(parts) {
+			return nil, false
+		}
+		if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+			params[s[1:len(s)-1]] = parts[i]
+			continue
+		}
+		if s != parts[i] {
+			return nil, false
+		}
+	}
+	return params, len(parts) == len(r.segments)
+}
+
+// delay возвращает задержку ответа по распределению маршрута.
+func (l Latency) delay() time.Duration {
+	var ms float64
+	switch l.Distribution {
+	case LatencyUniform:
+		ms = l.Min + rand.Float64()*(l.Max-l.Min)
+	case LatencyNormal:
+		ms = l.Mean + rand.NormFloat64()*l.StdDev
+	case LatencyExponential:
+		ms = rand.ExpFloat64() * l.Mean
+	default:
+		ms = l.Mean
+	}
+	ms = math.Max(ms, l.Min)
+	if l.Max > 0 {
+		ms = math.Min(ms, l.Max)
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
@@ -0,0 +1,161 @@
+package mock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func compiled(t *testing.T, r Route) *Route {
+	t.Helper()
+	if err := r.compile(); err != nil {
+		t.Fatal(err)
+	}
+	return &r
+}
+
+func TestRouteMatch(t *testing.T) {
+	tests := []struct {
+		route  Route
+		method string
+		path   string
+		params map[string]string
+		ok     bool
+	}{
+		{route: Route{Path: "/users"}, method: "GET", path: "/users", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/users"}, method: "DELETE", path: "/users/", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/users"}, method: "GET", path: "/users/1", ok: false},
+		{route: Route{Path: "/users/{id}"}, method: "GET", path: "/users", ok: false},
+		{route: Route{Path: "/"}, method: "GET", path: "/", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/"}, method: "GET", path: "/users", ok: false},
+		{route: Route{Method: "post", Path: "/users"}, method: "POST", path: "/users", params: map[string]string{}, ok: true},
+		{route: Route{Method: "POST", Path: "/users"}, method: "GET", path: "/users", ok: false},
+		{
+			route: Route{Path: "/users/{id}/orders/{order}"}, method: "GET", path: "/users/42/orders/ord-7",
+			params: map[string]string{"id": "42", "order": "ord-7"}, ok: true,
+		},
+		{route: Route{Path: "/users/{id}/orders/{order}"}, method: "GET", path: "/users/42/carts/ord-7", ok: false},
+		{route: Route{Path: "/static/*"}, method: "GET", path: "/static/css/app.css", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/static/*"}, method: "GET", path: "/static/", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/static/*"}, method: "GET", path: "/static", params: map[string]string{}, ok: true},
+		{route: Route{Path: "/{tenant}/*"}, method: "GET", path: "/acme/a/b", params: map[string]string{"tenant": "acme"}, ok: true},
+		{route: Route{Path: "/*"}, method: "GET", path: "/", params: map[string]string{}, ok: true},
+	}
+	for _, tt := range tests {
+		r := compiled(t, tt.route)
+		params, ok := r.match(tt.method, tt.path)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(params, tt.params)) {
+			t.Errorf("%s %s for route %s %s: expected %v %v, got %v %v",
+				tt.method, tt.path, tt.route.Method, tt.route.Path, tt.ok, tt.params, ok, params)
+		}
+	}
+}
+
+func TestRouteCompileErrors(t *testing.T) {
+	tests := []struct {
+		route Route
+		err   string
+	}{
+		{Route{Path: "users"}, `route path "users" should start with /`},
+		{Route{Path: "/a/*/b"}, "route /a/*/b: * is allowed only at the end of the path"},
+		{Route{Path: "/a", Status: 99}, "route /a: invalid status 99"},
+		{Route{Path: "/a", Error: ErrorInjection{Rate: 1.5}}, "route /a: error rate should be between 0 and 1"},
+		{Route{Path: "/a", Latency: Latency{Distribution: "pareto"}}, "route /a: unsupported latency distribution pareto"},
+		{Route{Path: "/a", Latency: Latency{Min: 10, Max: 5}}, "route /a: latency max is less than min"},
+		{Route{Path: "/a", Body: "{{.Params.id"}, "route /a: invalid body template"},
+		{Route{Path: "/a", Headers: map[string]string{"X-Id": "{{unknown}}"}}, "route /a: invalid header X-Id template"},
+	}
+	for _, tt := range tests {
+		err := tt.route.compile()
+		if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+			t.Errorf("route %+v: expected error %q, got %v", tt.route, tt.err, err)
+		}
+	}
+
+	// Значения по умолчанию
+	r := compiled(t, Route{Path: "/a", Error: ErrorInjection{Rate: 0.5}})
+	if r.Status != 200 || r.Error.Status != 500 {
+		t.Errorf("unexpected defaults: status %d, error status %d", r.Status, r.Error.Status)
+	}
+}
+
+// Задержки всех распределений остаются в пределах Min-Max, а средняя близка к заданной.
+func TestLatencyDelay(t *testing.T) {
+	ms := func(v float64) time.Duration { return time.Duration(v * float64(time.Millisecond)) }
+	tests := []struct {
+		name     string
+		latency  Latency
+		min, max time.Duration
+		mean     time.Duration
+	}{
+		{name: "none", latency: Latency{}, max: 0},
+		{name: "fixed", latency: Latency{Distribution: LatencyFixed, Mean: 20}, min: ms(20), max: ms(20), mean: ms(20)},
+		{name: "fixed by default", latency: Latency{Mean: 20}, min: ms(20), max: ms(20), mean: ms(20)},
+		{name: "uniform", latency: Latency{Distribution: LatencyUniform, Min: 10, Max: 30}, min: ms(10), max: ms(30), mean: ms(20)},
+		{name: "normal", latency: Latency{Distribution: LatencyNormal, Mean: 50, StdDev: 10}, max: time.Hour, mean: ms(50)},
+		{name: "normal bounded", latency: Latency{Distribution: LatencyNormal, Mean: 50, StdDev: 30, Min: 40, Max: 60}, min: ms(40), max: ms(60)},
+		{name: "exponential", latency: Latency{Distribution: LatencyExponential, Mean: 20}, max: time.Hour, mean: ms(20)},
+		{name: "exponential bounded", latency: Latency{Distribution: LatencyExponential, Mean: 20, Min: 5, Max: 25}, min: ms(5), max: ms(25)},
+	}
+	const n = 10000
+	for _, tt := range tests {
+		var sum time.Duration
+		for i := 0; i < n; i++ {
+			d := tt.latency.delay()
+			if d < tt.min || d > tt.max {
+				t.Fatalf("%s: delay %v is out of range %v-%v", tt.name, d, tt.min, tt.max)
+			}
+			sum += d
+		}
+		if tt.mean == 0 {
+			continue
+		}
+		// Допуск ±10% значительно шире статистического разброса среднего для n выборок
+		if mean := sum / n; mean < tt.mean*9/10 || mean > tt.mean*11/10 {
+			t.Errorf("%s: expected mean about %v, got %v", tt.name, tt.mean, mean)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	yamlConfig := `
+routes:
+  - method: POST
+    path: /login
+    status: 201
+    headers:
+      X-Session: "sess-{{.Counter}}"
+    body: '{"token":"{{uuid}}"}'
+    latency: {distribution: uniform, min: 1, max: 5}
+    error: {rate: 0.1, status: 503}
+    chunked: {count: 2, interval: 10}
+`
+	jsonConfig := `{"routes":[{"method":"POST","path":"/login","status":201,` +
+		`"headers":{"X-Session":"sess-{{.Counter}}"},"body":"{\"token\":\"{{uuid}}\"}",` +
+		`"latency":{"distribution":"uniform","min":1,"max":5},"error":{"rate":0.1,"status":503},` +
+		`"chunked":{"count":2,"interval":10}}]}`
+	expected := Config{Routes: []Route{{
+		Method:  "POST",
+		Path:    "/login",
+		Status:  201,
+		Headers: map[string]string{"X-Session": "sess-{{.Counter}}"},
+		Body:    `{"token":"{{uuid}}"}`,
+		Latency: Latency{Distribution: LatencyUniform, Min: 1, Max: 5},
+		Error:   ErrorInjection{Rate: 0.1, Status: 503},
+		Chunked: Chunked{Count: 2, Interval: 10},
+	}}}
+	for name, data := range map[string]string{"yaml": yamlConfig, "json": jsonConfig} {
+		cfg, err := ParseConfig([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(cfg, expected) {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, cfg)
+		}
+	}
+
+	if _, err := ParseConfig([]byte("routes:\n  - path: /a\n    delay: 10\n")); err == nil || !strings.HasPrefix(err.Error(), "invalid mock config") {
+		t.Errorf("unknown field: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,384 @@
+// Пакет recorder записывает запросы браузера или приложения через локальный прокси HTTP(S)
+// и преобразует запись в сценарий. HTTPS перехватывается сертификатами, подписанными корневым CA.
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"httes/importer"
+)
+
+// Максимальный размер тела запроса или ответа, который сохраняется в записи
+const maxBodySize = 10 << 20
+
+// Заголовки, которые относятся к соединению с прокси и не передаются серверу
+var hopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Connection", "Proxy-Authorization", "Proxy-Authenticate",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Recorder — прокси, который передаёт запросы серверам и записывает их вместе с ответами по порядку.
+type Recorder struct {
+	// Transport выполняет запросы к серверам. По умолчанию переменные окружения прокси не используются
+	Transport *http.Transport
+	// OnRecord вызывается после записи каждого запроса с количеством записанных запросов
+	OnRecord func(count int)
+
+	ca     *CA
+	server *http.Server
+
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// Записи в формате HAR 1.2, который читает importer
+type harEntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text"`
+	Params   []harParam `json:"params,omitempty"`
+}
+
+type harParam struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// New создаёт прокси, который перехватывает HTTPS сертификатами ca.
+func New(ca *CA) *Recorder {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return &Recorder{Transport: transport, ca: ca}
+}
+
+// Start начинает принимать соединения на адресе addr, например 127.0.0.1:8888, и возвращает фактический адрес.
+func (r *Recorder) Start(addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	r.server = &http.Server{Handler: r, ReadHeaderTimeout: 30 * time.Second}
+	go r.server.Serve(l)
+	return l.Addr(), nil
+}
+
+// Close останавливает прокси. Записанные запросы сохраняются.
+func (r *Recorder) Close() error {
+	if r.server == nil {
+		return nil
+	}
+	return r.server.Close()
+}
+
+// Count возвращает количество записанных запросов.
+func (r *Recorder) Count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// HAR возвращает запись в формате HAR.
+func (r *Recorder) HAR() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := r.entries
+	if entries == nil {
+		entries = []harEntry{}
+	}
+	var file struct {
+		Log struct {
+			Version string         `json:"version"`
+			Creator harNameVersion `json:"creator"`
+			Entries []harEntry     `json:"entries"`
+		} `json:"log"`
+	}
+	file.Log.Version = "1.2"
+	file.Log.Creator = harNameVersion{Name: "httes recorder", Version: "1.0"}
+	file.Log.Entries = entries
+	return json.MarshalIndent(file, "", "  ")
+}
+
+type harNameVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Result преобразует запись в сценарий так же, как импорт HAR: со фильтрами хостов, паузами
+// и извлечением переменных для значений, которые передаются из ответов в следующие запросы.
+func (r *Recorder) Result(opts importer.Options) (*importer.Result, error) {
+	har, err := r.HAR()
+	if err != nil {
+		return nil, err
+	}
+	if r.Count() == 0 {
+		return nil, fmt.Errorf("no requests were recorded")
+	}
+	return importer.Import(importer.FormatHAR, "recording.har", har, opts)
+}
+
+// ServeHTTP обрабатывает запросы к прокси: CONNECT для HTTPS и запросы с полным адресом для HTTP.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		r.serveConnect(w, req)
+		return
+	}
+	if !req.URL.IsAbs() {
+		http.Error(w, "httes recorder is an HTTP proxy: configure it as a proxy instead of opening it directly", http.StatusBadRequest)
+		return
+	}
+
+	resp, body, err := r.forward(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(body)
+}
+
+// serveConnect перехватывает туннель CONNECT: отвечает клиенту сертификатом хоста
+// и передаёт серверу расшифрованные запросы.
+func (r *Recorder) serveConnect(w http.ResponseWriter, req *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection can not be intercepted", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+
+	host := req.URL.Host
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+	}
+	tlsConn := tls.Server(conn, &tls.Config{
+		NextProtos: []string{"http/1.1"},
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				return r.ca.Certificate(hello.ServerName)
+			}
+			return r.ca.Certificate(hostname)
+		},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(tlsConn)
+	for {
+		inner, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		inner.URL.Scheme = "https"
+		inner.URL.Host = inner.Host
+		if inner.URL.Host == "" {
+			inner.URL.Host = host
+		}
+
+		resp, body, err := r.forward(inner)
+		if err != nil {
+			resp = &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}
+			body = []byte(err.Error())
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		resp.ContentLength = int64(len(body))
+		resp.TransferEncoding = nil
+		resp.Header.Del("Content-Length")
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = "HTTP/1.1", 1, 1
+		resp.Close = inner.Close
+		if err := resp.Write(tlsConn); err != nil || inner.Close {
+			return
+		}
+	}
+}
+
+// forward передаёт запрос серверу, читает ответ и записывает их.
+func (r *Recorder) forward(req *http.Request) (*http.Response, []byte, error) {
+	started := time.Now()
+	reqBody, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, nil, err
+	}
+	out.Header = req.Header.Clone()
+	for _, h := range hopHeaders {
+		out.Header.Del(h)
+	}
+	// Transport сам запросит сжатие и распакует ответ, чтобы в записи было читаемое тело
+	out.Header.Del("Accept-Encoding")
+	out.Host = req.Host
+
+	resp, err := r.Transport.RoundTrip(out)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, h := range hopHeaders {
+		resp.Header.Del(h)
+	}
+
+	r.record(started, out, reqBody, resp, body)
+	return resp, body, nil
+}
+
+// record добавляет запрос и ответ в запись.
+func (r *Recorder) record(started time.Time, req *http.Request, reqBody []byte, resp *http.Response, body []byte) {
+	e := harEntry{
+		StartedDateTime: started,
+		Time:            float64(time.Since(started).Microseconds()) / 1000,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(req.Header),
+			Cookies:     []harNameValue{},
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     harHeaders(resp.Header),
+			Cookies:     []harNameValue{},
+			Content:     harContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")},
+		},
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		e.Request.Headers = append([]harNameValue{{Name: "Host", Value: req.Host}}, e.Request.Headers...)
+	}
+	for _, c := range req.Cookies() {
+		e.Request.Cookies = append(e.Request.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	for _, c := range resp.Cookies() {
+		e.Response.Cookies = append(e.Response.Cookies, harNameValue{Name: c.Name, Value: c.Value})
+	}
+	if len(reqBody) > 0 {
+		e.Request.PostData = postData(req.Header.Get("Content-Type"), reqBody)
+	}
+	if utf8.Valid(body) {
+		e.Response.Content.Text = string(body)
+	} else {
+		e.Response.Content.Text = base64.StdEncoding.EncodeToString(body)
+		e.Response.Content.Encoding = "base64"
+	}
+
+	r.mu.Lock()
+	r.entries = append(r.entries, e)
+	count := len(r.entries)
+	r.mu.Unlock()
+	if r.OnRecord != nil {
+		r.OnRecord(count)
+	}
+}
+
+// postData описывает тело запроса. Поля multipart-формы разбираются, чтобы импорт собрал форму заново.
+func postData(contentType string, body []byte) *harPostData {
+	pd := &harPostData{MimeType: contentType}
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		pd.Text = string(body)
+		return pd
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		value, _ := io.ReadAll(part)
+		p := harParam{Name: part.FormName(), FileName: part.FileName(), ContentType: part.Header.Get("Content-Type")}
+		if p.FileName == "" {
+			p.Value = string(value)
+		}
+		pd.Params = append(pd.Params, p)
+	}
+	return pd
+}
+
+func harHeaders(h http.Header) []harNameValue {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := make([]harNameValue, 0, len(h))
+	for _, k := range keys {
+		for _, v := range h[k] {
+			res = append(res, harNameValue{Name: k, Value: v})
+		}
+	}
+	return res
+}
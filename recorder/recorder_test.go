@@ -0,0 +1,299 @@
+package recorder
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"httes/importer"
+)
+
+// upstream — сервер приложения: /login выдаёт токен и cookie, /profile требует токен, /upload читает форму.
+func upstream() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "s3cr3t42"})
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"token":"tok-9f8e7d6c5b4a","user":"demo"}`)
+	})
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok-9f8e7d6c5b4a" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Proto", r.Proto)
+		io.WriteString(w, `{"name":"Demo"}`)
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	return mux
+}
+
+func startRecorder(t *testing.T, configure func(*Recorder)) (*Recorder, *CA, *url.URL) {
+	t.Helper()
+	ca, err := LoadOrCreateCA(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New(ca)
+	if configure != nil {
+		configure(r)
+	}
+	addr, err := r.Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r, ca, &url.URL{Scheme: "http", Host: addr.String()}
+}
+
+// proxyClient возвращает клиент, который отправляет запросы через прокси и доверяет сертификатам ca.
+func proxyClient(proxy *url.URL, ca *CA) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxy),
+		TLSClientConfig: &tls.Config{RootCAs: ca.CertPool()},
+	}}
+}
+
+// login входит в приложение и запрашивает профиль с полученным токеном.
+func login(t *testing.T, client *http.Client, base string) *http.Response {
+	t.Helper()
+	resp, err := client.Post(base+"/login", "application/json", strings.NewReader(`{"user":"demo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var sid string
+	for _, c := range resp.Cookies() {
+		sid = c.Value
+	}
+	if sid != "s3cr3t42" {
+		t.Fatalf("cookie is not passed to the client: %v", resp.Header)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/profile?tab=main", nil)
+	req.Header.Set("Authorization", "Bearer tok-9f8e7d6c5b4a")
+	req.AddCookie(&http.Cookie{Name: "sid", Value: sid})
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"name":"Demo"}` {
+		t.Fatalf("unexpected response %s: %s", resp.Status, body)
+	}
+	return resp
+}
+
+type recordedHAR struct {
+	Log struct {
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+func recorded(t *testing.T, r *Recorder) []harEntry {
+	t.Helper()
+	data, err := r.HAR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var har recordedHAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatal(err)
+	}
+	return har.Log.Entries
+}
+
+// checkScenario проверяет сценарий из записи: токен и cookie входа извлекаются из ответа первого шага.
+func checkScenario(t *testing.T, r *Recorder, base string) {
+	t.Helper()
+	res, err := r.Result(importer.Options{Name: "recorded", Include: []string{"127.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := res.Scenarios[0].Steps
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+	if steps[0].Method != "POST" || steps[0].URL != base+"/login" || steps[0].Payload != `{"user":"demo"}` {
+		t.Errorf("unexpected login step: %+v", steps[0])
+	}
+	if steps[1].URL != base+"/profile?tab=main" {
+		t.Errorf("unexpected profile url %s", steps[1].URL)
+	}
+	if h := steps[1].Headers; h["Authorization"] != "Bearer {{TOKEN}}" || h["Cookie"] != "sid={{COOKIE_sid}}" {
+		t.Errorf("token and cookie are not correlated: %v", h)
+	}
+	if _, ok := steps[0].CaptureEnv["TOKEN"]; !ok {
+		t.Errorf("login step does not capture the token: %v", steps[0].CaptureEnv)
+	}
+}
+
+func TestRecorderHTTP(t *testing.T) {
+	app := httptest.NewServer(upstream())
+	defer app.Close()
+	counts := make(chan int, 10)
+	r, ca, proxy := startRecorder(t, func(r *Recorder) {
+		r.OnRecord = func(count int) { counts <- count }
+	})
+
+	login(t, proxyClient(proxy, ca), app.URL)
+	if r.Count() != 2 || <-counts != 1 || <-counts != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d", r.Count())
+	}
+
+	entries := recorded(t, r)
+	e := entries[0]
+	if e.Request.Method != "POST" || e.Request.URL != app.URL+"/login" || e.Request.PostData == nil || e.Request.PostData.Text != `{"user":"demo"}` {
+		t.Errorf("unexpected request: %+v", e.Request)
+	}
+	if e.Response.Status != 200 || e.Response.Content.Text != `{"token":"tok-9f8e7d6c5b4a","user":"demo"}` || e.Response.Content.MimeType != "application/json" {
+		t.Errorf("unexpected response: %+v", e.Response)
+	}
+	if len(e.Response.Cookies) != 1 || e.Response.Cookies[0] != (harNameValue{Name: "sid", Value: "s3cr3t42"}) {
+		t.Errorf("unexpected response cookies: %v", e.Response.Cookies)
+	}
+	for _, h := range entries[1].Request.Headers {
+		if h.Name == "Proxy-Connection" || h.Name == "Accept-Encoding" {
+			t.Errorf("hop header %s is recorded", h.Name)
+		}
+	}
+	if len(entries[1].Request.Cookies) != 1 || entries[1].Request.Cookies[0].Value != "s3cr3t42" {
+		t.Errorf("unexpected request cookies: %v", entries[1].Request.Cookies)
+	}
+	checkScenario(t, r, app.URL)
+}
+
+// Запросы HTTPS перехватываются в туннеле CONNECT сертификатом, подписанным CA записи.
+func TestRecorderConnect(t *testing.T) {
+	app := httptest.NewTLSServer(upstream())
+	defer app.Close()
+	r, ca, proxy := startRecorder(t, func(r *Recorder) {
+		r.Transport.TLSClientConfig = app.Client().Transport.(*http.Transport).TLSClientConfig
+	})
+
+	resp := login(t, proxyClient(proxy, ca), app.URL)
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		t.Fatal("response is not received over TLS")
+	}
+	leaf := resp.TLS.PeerCertificates[0]
+	if leaf.Issuer.CommonName != "httes recorder CA" || len(leaf.IPAddresses) != 1 || leaf.IPAddresses[0].String() != "127.0.0.1" {
+		t.Errorf("unexpected certificate: issuer %s, addresses %v", leaf.Issuer.CommonName, leaf.IPAddresses)
+	}
+	// Сервер приложения получает запросы от прокси по HTTP/1.1
+	if p := resp.Header.Get("X-Proto"); p != "HTTP/1.1" {
+		t.Errorf("unexpected upstream protocol %s", p)
+	}
+
+	entries := recorded(t, r)
+	if len(entries) != 2 || !strings.HasPrefix(entries[1].Request.URL, "https://") {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	checkScenario(t, r, app.URL)
+
+	// Клиент, не доверяющий CA записи, не принимает подменённый сертификат
+	untrusted := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxy)}}
+	if _, err := untrusted.Get(app.URL + "/profile"); err == nil {
+		t.Error("certificate of an untrusted CA is accepted")
+	}
+}
+
+func TestRecorderMultipartAndErrors(t *testing.T) {
+	app := httptest.NewServer(upstream())
+	r, ca, proxy := startRecorder(t, nil)
+	client := proxyClient(proxy, ca)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("title", "report")
+	fw, _ := mw.CreateFormFile("file", "data.csv")
+	io.WriteString(fw, "a,b\n1,2\n")
+	mw.Close()
+	resp, err := client.Post(app.URL+"/upload", mw.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected status %s", resp.Status)
+	}
+	pd := recorded(t, r)[0].Request.PostData
+	if pd == nil || len(pd.Params) != 2 || pd.Params[0] != (harParam{Name: "title", Value: "report"}) ||
+		pd.Params[1].Name != "file" || pd.Params[1].FileName != "data.csv" || pd.Params[1].Value != "" {
+		t.Errorf("unexpected multipart params: %+v", pd)
+	}
+
+	// Недоступный сервер: клиент получает 502, запрос не записывается
+	app.Close()
+	resp, err = client.Get(app.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || r.Count() != 1 {
+		t.Errorf("unavailable upstream: status %s, recorded %d", resp.Status, r.Count())
+	}
+
+	// Прокси, открытый напрямую, а не как прокси, отвечает ошибкой
+	resp, err = http.Get(proxy.String() + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("direct request: expected 400, got %s", resp.Status)
+	}
+}
+
+func TestRecorderEmptyResult(t *testing.T) {
+	r, _, _ := startRecorder(t, nil)
+	if _, err := r.Result(importer.Options{}); err == nil || err.Error() != "no requests were recorded" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Корневой сертификат создаётся один раз и загружается из того же каталога.
+func TestLoadOrCreateCA(t *testing.T) {
+	dir := t.TempDir()
+	ca, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOrCreateCA(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CertPath != ca.CertPath || !loaded.cert.Equal(ca.cert) {
+		t.Error("loaded CA differs from the created one")
+	}
+
+	leaf, err := loaded.Certificate("shop.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached, _ := loaded.Certificate("shop.example.com"); cached != leaf {
+		t.Error("host certificate is not cached")
+	}
+	cert, err := x509.ParseCertificate(leaf.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cert.Verify(x509.VerifyOptions{Roots: ca.CertPool(), DNSName: "shop.example.com"}); err != nil {
+		t.Errorf("host certificate is not signed by the CA: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Текущая версия схемы файла хранилища
+const schemaVersion = 1
+
+// Имя каталога приложения в каталоге пользовательских настроек и имя файла хранилища
+const (
+	appDirName = "httes"
+	dbFileName = "store.json"
+)
+
+// snapshot — содержимое файла хранилища.
+type snapshot struct {
+	Version      int            `json:"version"`
+	LastIDs      map[string]int `json:"last_ids"` // Последний выданный ID для каждой коллекции
+	Scenarios    []Scenario     `json:"scenarios"`
+	LoadProfiles []LoadProfile  `json:"load_profiles"`
+	TestRuns     []TestRun      `json:"test_runs"`
+}
+
+// Коллекции хранилища, используются как ключи LastIDs
+const (
+	collScenarios    = "scenarios"
+	collLoadProfiles = "load_profiles"
+	collTestRuns     = "test_runs"
+)
+
+// migration переводит хранилище с версии i на версию i+1, где i — индекс в migrations.
+type migration func(s *snapshot) error
+
+// Миграции схемы. Новые миграции добавляются в конец, существующие не изменяются.
+var migrations = []migration{
+	migrateV1,
+}
+
+// migrateV1 создаёт счётчики ID по уже существующим записям и профили нагрузки по умолчанию.
+func migrateV1(s *snapshot) error {
+	if s.LastIDs == nil {
+		s.LastIDs = map[string]int{}
+	}
+	for _, sc := range s.Scenarios {
+		s.LastIDs[collScenarios] = max(s.LastIDs[collScenarios], sc.ID)
+	}
+	for _, p := range s.LoadProfiles {
+		s.LastIDs[collLoadProfiles] = max(s.LastIDs[collLoadProfiles], p.ID)
+	}
+	for _, r := range s.TestRuns {
+		s.LastIDs[collTestRuns] = max(s.LastIDs[collTestRuns], r.ID)
+	}
+	if len(s.LoadProfiles) == 0 {
+		now := time.Now()
+		for _, p := range []LoadProfile{
+			{Name: "Constant", Type: "Linear"},
+			{Name: "Ramp-Up", Type: "Incremental"},
+			{Name: "Waves", Type: "Waved"},
+		} {
+			s.LastIDs[collLoadProfiles]++
+			p.ID = s.LastIDs[collLoadProfiles]
+			p.CreatedAt, p.UpdatedAt = now, now
+			s.LoadProfiles = append(s.LoadProfiles, p)
+		}
+	}
+	return nil
+}
+
+// migrate применяет миграции, которые ещё не были применены к хранилищу.
+// Возвращает true, если схема была обновлена.
+func (s *snapshot) migrate() (bool, error) {
+	if s.Version > schemaVersion {
+		return false, fmt.Errorf("store schema version %d is newer than supported %d", s.Version, schemaVersion)
+	}
+	migrated := false
+	for v := s.Version; v < schemaVersion; v++ {
+		if err := migrations[v](s); err != nil {
+			return migrated, fmt.Errorf("store migration to version %d failed: %v", v+1, err)
+		}
+		s.Version = v + 1
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// nextID выдаёт следующий ID для коллекции. ID удалённых записей повторно не используются.
+func (s *snapshot) nextID(coll string) int {
+	s.LastIDs[coll]++
+	return s.LastIDs[coll]
+}
+
+func newSnapshot() *snapshot {
+	s := &snapshot{}
+	s.migrate()
+	return s
+}
+
+// DefaultPath возвращает путь к файлу хранилища в каталоге пользовательских настроек.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName, dbFileName), nil
+}
+
+func readSnapshot(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return newSnapshot(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("store file %s is corrupted: %v", path, err)
+	}
+	if _, err := s.migrate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// writeSnapshot атомарно записывает хранилище: сначала во временный файл с уникальным именем, затем переименовывает его.
+// Вызывается под блокировкой файла хранилища (lockFile).
+func writeSnapshot(path string, s *snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), dbFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // После переименования файла уже нет, ошибка игнорируется
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockFile захватывает исключительную блокировку хранилища path, ожидая её освобождения другим процессом,
+// например GUI и CLI, работающими с одним файлом. Блокируется отдельный файл path.lock: сам файл хранилища
+// заменяется при каждой записи. Возвращает функцию снятия блокировки.
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lock(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock store %s: %v", path, err)
+	}
+	return func() {
+		unlock(f)
+		f.Close()
+	}, nil
+}
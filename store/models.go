@@ -1,35 +1,80 @@
 package store
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// Статусы запуска теста
+const (
+	RunStatusRunning = "running"
+	RunStatusSuccess = "success"
+	RunStatusFailed  = "failed"
+	RunStatusStopped = "stopped"
+)
+
 type Scenario struct {
-	ID          int
-	Name        string
-	Description string
-	Tags        []string
-	Endpoints   []Endpoint
-	Cert        *Cert
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	Profile     string     `json:"profile"` // Профиль нагрузки
+	JSON        string     `json:"json"`    // Конфигурация сценария в формате JSON
+	Endpoints   []Endpoint `json:"endpoints"`
+	Cert        *Cert      `json:"cert"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 type LoadProfile struct {
-	ID   int
-	Name string
-	Type string
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type TestRun struct {
-	ID        int
-	Name      string
-	StartTime string
-	Status    string
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	StartTime   time.Time   `json:"start_time"`
+	Status      string      `json:"status"`
+	Description string      `json:"description"`
+	Scenario    string      `json:"scenario"` // Имя сценария или смеси сценариев
+	Settings    RunSettings `json:"settings"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// Конфигурация теста в формате JSON, по которой запуск можно повторить
+	Config json.RawMessage `json:"config,omitempty"`
+	// Итоговый результат теста (report.RunSummary) в формате JSON
+	Result json.RawMessage `json:"result,omitempty"`
+	// Базовый запуск, с которым сравниваются новые запуски. Базовым может быть только один запуск
+	Baseline bool `json:"baseline,omitempty"`
+}
+
+// RunSettings — настройки теста на момент запуска.
+type RunSettings struct {
+	Method       string `json:"method"`
+	Protocol     string `json:"protocol"`
+	URL          string `json:"url"`
+	Proxy        string `json:"proxy"`
+	RequestCount int    `json:"request_count"`
+	Duration     int    `json:"duration"`
+	LoadType     string `json:"load_type"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	CertPath     string `json:"cert_path"`
+	CertKeyPath  string `json:"cert_key_path"`
 }
 
 type Endpoint struct {
-	URL     string
-	Method  string
-	Headers string
+	URL     string `json:"url"`
+	Method  string `json:"method"`
+	Headers string `json:"headers"`
 }
 
 type Cert struct {
-	Name string
-	Path string
-	Key  string
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Key  string `json:"key"`
 }
@@ -1,74 +1,328 @@
 package store
 
 import (
+	"fmt"
 	"sync"
+	"time"
 )
 
 var (
-	scenarios = []Scenario{
-		{ID: 1, Name: "API Stress Test", Description: "Тестирование API", Tags: []string{"API", "Stress"}},
-		{ID: 2, Name: "Login Test", Description: "Тест авторизации", Tags: []string{"Auth"}},
+	// Данные хранилища. До вызова Open хранилище работает только в памяти
+	db = newSnapshot()
+	// Путь к файлу хранилища, пустой для хранилища в памяти
+	dbPath string
+	mutex  sync.Mutex
+)
+
+// Init открывает хранилище по пути по умолчанию в каталоге пользовательских настроек.
+func Init() error {
+	path, err := DefaultPath()
+	if err != nil {
+		return err
+	}
+	return Open(path)
+}
+
+// Open загружает хранилище из файла, применяя миграции схемы. Если файл не существует, он будет создан.
+// Все последующие изменения сохраняются в этот файл.
+func Open(path string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
 	}
-	loadProfiles = []LoadProfile{
-		{ID: 1, Name: "High Load", Type: "Constant"},
-		{ID: 2, Name: "Ramp-Up", Type: "Incremental"},
+	defer unlock()
+
+	s, err := readSnapshot(path)
+	if err != nil {
+		return err
 	}
-	testRuns = []TestRun{
-		{ID: 1, Name: "API Stress Run 1", StartTime: "2025-05-18 10:00", Status: "Completed"},
+	if err := writeSnapshot(path, s); err != nil {
+		return err
 	}
-	mutex sync.Mutex
-)
+	db = s
+	dbPath = path
+	return nil
+}
+
+// Close отключает хранилище от файла и возвращает пустое хранилище в памяти.
+func Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	db = newSnapshot()
+	dbPath = ""
+}
+
+// update применяет изменение f к хранилищу и сохраняет его в файл. Файл блокируется и перечитывается
+// перед изменением, поэтому изменения других процессов, работающих с тем же файлом, не теряются.
+// Если f возвращает ошибку, файл не изменяется.
+func update(f func(s *snapshot) error) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if dbPath == "" {
+		return f(db)
+	}
+
+	unlock, err := lockFile(dbPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s, err := readSnapshot(dbPath)
+	if err != nil {
+		return err
+	}
+	db = s
+	if err := f(s); err != nil {
+		return err
+	}
+	return writeSnapshot(dbPath, s)
+}
 
 func ScenarioCount() int {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return len(scenarios)
+	return len(db.Scenarios)
 }
 
 func GetScenario(index int) Scenario {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return scenarios[index]
+	return db.Scenarios[index]
 }
 
-func AddScenario(scenario Scenario) {
+// ListScenarios возвращает копию списка сценариев.
+func ListScenarios() []Scenario {
 	mutex.Lock()
 	defer mutex.Unlock()
-	scenarios = append(scenarios, scenario)
+	return append([]Scenario(nil), db.Scenarios...)
+}
+
+// GetScenarioByID возвращает сценарий по ID.
+func GetScenarioByID(id int) (Scenario, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, s := range db.Scenarios {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return Scenario{}, false
+}
+
+// AddScenario добавляет сценарий, присваивая ему ID и время создания.
+func AddScenario(scenario Scenario) (Scenario, error) {
+	err := update(func(s *snapshot) error {
+		now := time.Now()
+		scenario.ID = s.nextID(collScenarios)
+		scenario.CreatedAt, scenario.UpdatedAt = now, now
+		s.Scenarios = append(s.Scenarios, scenario)
+		return nil
+	})
+	return scenario, err
+}
+
+// UpdateScenario обновляет сценарий с тем же ID, сохраняя время создания.
+func UpdateScenario(scenario Scenario) error {
+	return update(func(s *snapshot) error {
+		for i, sc := range s.Scenarios {
+			if sc.ID == scenario.ID {
+				scenario.CreatedAt = sc.CreatedAt
+				scenario.UpdatedAt = time.Now()
+				s.Scenarios[i] = scenario
+				return nil
+			}
+		}
+		return fmt.Errorf("scenario %d not found", scenario.ID)
+	})
+}
+
+// DeleteScenario удаляет сценарий по ID.
+func DeleteScenario(id int) error {
+	return update(func(s *snapshot) error {
+		for i, sc := range s.Scenarios {
+			if sc.ID == id {
+				s.Scenarios = append(s.Scenarios[:i], s.Scenarios[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("scenario %d not found", id)
+	})
 }
 
 func LoadProfileCount() int {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return len(loadProfiles)
+	return len(db.LoadProfiles)
 }
 
 func GetLoadProfile(index int) LoadProfile {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return loadProfiles[index]
+	return db.LoadProfiles[index]
 }
 
-func AddLoadProfile(profile LoadProfile) {
+// ListLoadProfiles возвращает копию списка профилей нагрузки.
+func ListLoadProfiles() []LoadProfile {
 	mutex.Lock()
 	defer mutex.Unlock()
-	loadProfiles = append(loadProfiles, profile)
+	return append([]LoadProfile(nil), db.LoadProfiles...)
+}
+
+// AddLoadProfile добавляет профиль нагрузки, присваивая ему ID и время создания.
+func AddLoadProfile(profile LoadProfile) (LoadProfile, error) {
+	err := update(func(s *snapshot) error {
+		now := time.Now()
+		profile.ID = s.nextID(collLoadProfiles)
+		profile.CreatedAt, profile.UpdatedAt = now, now
+		s.LoadProfiles = append(s.LoadProfiles, profile)
+		return nil
+	})
+	return profile, err
+}
+
+// UpdateLoadProfile обновляет профиль нагрузки с тем же ID.
+func UpdateLoadProfile(profile LoadProfile) error {
+	return update(func(s *snapshot) error {
+		for i, p := range s.LoadProfiles {
+			if p.ID == profile.ID {
+				profile.CreatedAt = p.CreatedAt
+				profile.UpdatedAt = time.Now()
+				s.LoadProfiles[i] = profile
+				return nil
+			}
+		}
+		return fmt.Errorf("load profile %d not found", profile.ID)
+	})
+}
+
+// DeleteLoadProfile удаляет профиль нагрузки по ID.
+func DeleteLoadProfile(id int) error {
+	return update(func(s *snapshot) error {
+		for i, p := range s.LoadProfiles {
+			if p.ID == id {
+				s.LoadProfiles = append(s.LoadProfiles[:i], s.LoadProfiles[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("load profile %d not found", id)
+	})
 }
 
 func TestRunCount() int {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return len(testRuns)
+	return len(db.TestRuns)
 }
 
 func GetTestRun(index int) TestRun {
 	mutex.Lock()
 	defer mutex.Unlock()
-	return testRuns[index]
+	return db.TestRuns[index]
+}
+
+// ListTestRuns возвращает копию списка запусков тестов.
+func ListTestRuns() []TestRun {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return append([]TestRun(nil), db.TestRuns...)
 }
 
-func AddTestRun(testRun TestRun) {
+// GetTestRunByID возвращает запуск теста по ID.
+func GetTestRunByID(id int) (TestRun, bool) {
 	mutex.Lock()
 	defer mutex.Unlock()
-	testRuns = append(testRuns, testRun)
+	for _, r := range db.TestRuns {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return TestRun{}, false
+}
+
+// AddTestRun добавляет запуск теста, присваивая ему ID. Если имя не задано, используется "Test Run <ID>".
+func AddTestRun(testRun TestRun) (TestRun, error) {
+	err := update(func(s *snapshot) error {
+		testRun.ID = s.nextID(collTestRuns)
+		if testRun.Name == "" {
+			testRun.Name = fmt.Sprintf("Test Run %d", testRun.ID)
+		}
+		if testRun.StartTime.IsZero() {
+			testRun.StartTime = time.Now()
+		}
+		testRun.UpdatedAt = time.Now()
+		s.TestRuns = append(s.TestRuns, testRun)
+		return nil
+	})
+	return testRun, err
+}
+
+// UpdateTestRun обновляет запуск теста с тем же ID, например его статус после завершения.
+func UpdateTestRun(testRun TestRun) error {
+	return update(func(s *snapshot) error {
+		for i, r := range s.TestRuns {
+			if r.ID == testRun.ID {
+				testRun.UpdatedAt = time.Now()
+				s.TestRuns[i] = testRun
+				return nil
+			}
+		}
+		return fmt.Errorf("test run %d not found", testRun.ID)
+	})
+}
+
+// DeleteTestRun удаляет запуск теста по ID.
+func DeleteTestRun(id int) error {
+	return update(func(s *snapshot) error {
+		for i, r := range s.TestRuns {
+			if r.ID == id {
+				s.TestRuns = append(s.TestRuns[:i], s.TestRuns[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("test run %d not found", id)
+	})
+}
+
+// ClearTestRuns удаляет всю историю запусков.
+func ClearTestRuns() error {
+	return update(func(s *snapshot) error {
+		s.TestRuns = nil
+		return nil
+	})
+}
+
+// SetBaseline отмечает запуск теста как базовый для сравнения. Отметка снимается с предыдущего базового запуска.
+func SetBaseline(id int) error {
+	return update(func(s *snapshot) error {
+		found := false
+		for _, r := range s.TestRuns {
+			if r.ID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("test run %d not found", id)
+		}
+		for i := range s.TestRuns {
+			s.TestRuns[i].Baseline = s.TestRuns[i].ID == id
+		}
+		return nil
+	})
+}
+
+// GetBaseline возвращает базовый запуск теста, если он отмечен.
+func GetBaseline() (TestRun, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, r := range db.TestRuns {
+		if r.Baseline {
+			return r, true
+		}
+	}
+	return TestRun{}, false
 }
@@ -0,0 +1,360 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTemp открывает хранилище во временном каталоге и возвращает путь к файлу.
+func openTemp(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), appDirName, dbFileName)
+	if err := Open(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(Close)
+	return path
+}
+
+func TestScenarioCRUD(t *testing.T) {
+	path := openTemp(t)
+
+	a, err := AddScenario(Scenario{Name: "a", JSON: `{"steps":[]}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := AddScenario(Scenario{Name: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ID != 1 || b.ID != 2 || a.CreatedAt.IsZero() {
+		t.Fatalf("unexpected ids or timestamps: %+v %+v", a, b)
+	}
+
+	a.Description = "updated"
+	if err := UpdateScenario(a); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := GetScenarioByID(a.ID); !ok || got.Description != "updated" || !got.CreatedAt.Equal(a.CreatedAt) {
+		t.Errorf("update: got %+v", got)
+	}
+
+	if err := DeleteScenario(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteScenario(a.ID); err == nil {
+		t.Error("expected an error for a deleted scenario")
+	}
+	if err := UpdateScenario(Scenario{ID: 42}); err == nil {
+		t.Error("expected an error for a missing scenario")
+	}
+
+	// ID удалённых записей повторно не выдаются
+	c, err := AddScenario(Scenario{Name: "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID != 3 {
+		t.Errorf("expected id 3, got %d", c.ID)
+	}
+	if ScenarioCount() != 2 || GetScenario(0).Name != "b" {
+		t.Errorf("unexpected scenarios: %+v", ListScenarios())
+	}
+
+	// Изменения сохраняются в файл
+	Close()
+	if ScenarioCount() != 0 {
+		t.Fatal("closed store should be empty")
+	}
+	if err := Open(path); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, s := range ListScenarios() {
+		names = append(names, s.Name)
+	}
+	if strings.Join(names, ",") != "b,c" {
+		t.Errorf("expected b,c after reopening, got %v", names)
+	}
+}
+
+func TestLoadProfileCRUD(t *testing.T) {
+	openTemp(t)
+
+	// Профили по умолчанию создаются миграцией нового хранилища
+	if LoadProfileCount() != 3 {
+		t.Fatalf("expected 3 default profiles, got %d", LoadProfileCount())
+	}
+	p, err := AddLoadProfile(LoadProfile{Name: "Spike", Type: "Incremental"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 4 {
+		t.Errorf("expected id 4, got %d", p.ID)
+	}
+	p.Type = "Waved"
+	if err := UpdateLoadProfile(p); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetLoadProfile(3); got.Type != "Waved" || !got.CreatedAt.Equal(p.CreatedAt) {
+		t.Errorf("update: got %+v", got)
+	}
+	if err := DeleteLoadProfile(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteLoadProfile(1); err == nil {
+		t.Error("expected an error for a deleted profile")
+	}
+	if n := len(ListLoadProfiles()); n != 3 {
+		t.Errorf("expected 3 profiles, got %d", n)
+	}
+}
+
+func TestTestRunCRUD(t *testing.T) {
+	openTemp(t)
+
+	first, err := AddTestRun(TestRun{Status: RunStatusRunning, Config: json.RawMessage(`{"iteration_count":1}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Name != "Test Run 1" || first.StartTime.IsZero() {
+		t.Errorf("unexpected defaults: %+v", first)
+	}
+	second, err := AddTestRun(TestRun{Name: "named"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first.Status = RunStatusSuccess
+	if err := UpdateTestRun(first); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetTestRunByID(first.ID); got.Status != RunStatusSuccess || string(got.Config) != `{"iteration_count":1}` {
+		t.Errorf("update: got %+v", got)
+	}
+
+	if err := SetBaseline(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetBaseline(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if b, ok := GetBaseline(); !ok || b.ID != second.ID {
+		t.Errorf("expected baseline %d, got %+v", second.ID, b)
+	}
+	if got, _ := GetTestRunByID(first.ID); got.Baseline {
+		t.Error("only one run can be the baseline")
+	}
+	if err := SetBaseline(42); err == nil {
+		t.Error("expected an error for a missing run")
+	}
+
+	if err := DeleteTestRun(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := GetBaseline(); ok {
+		t.Error("baseline should be deleted with its run")
+	}
+	if err := ClearTestRuns(); err != nil {
+		t.Fatal(err)
+	}
+	if TestRunCount() != 0 {
+		t.Errorf("expected no runs, got %d", TestRunCount())
+	}
+}
+
+func TestInMemoryStore(t *testing.T) {
+	Close()
+	if _, err := AddScenario(Scenario{Name: "memory"}); err != nil {
+		t.Fatal(err)
+	}
+	if ScenarioCount() != 1 {
+		t.Errorf("expected 1 scenario, got %d", ScenarioCount())
+	}
+	Close()
+	if ScenarioCount() != 0 {
+		t.Errorf("expected an empty store, got %d", ScenarioCount())
+	}
+}
+
+func TestMigrationFromUnversionedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), dbFileName)
+	legacy := `{
+  "scenarios": [{"id": 3, "name": "old"}, {"id": 7, "name": "older"}],
+  "test_runs": [{"id": 5, "name": "run"}]
+}`
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Open(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(Close)
+
+	// Счётчики ID продолжают существующие записи
+	s, err := AddScenario(Scenario{Name: "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != 8 {
+		t.Errorf("expected scenario id 8, got %d", s.ID)
+	}
+	r, err := AddTestRun(TestRun{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != 6 {
+		t.Errorf("expected run id 6, got %d", r.ID)
+	}
+	if LoadProfileCount() != 3 {
+		t.Errorf("expected default profiles, got %d", LoadProfileCount())
+	}
+
+	// Обновлённая схема записана в файл
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved snapshot
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Version != schemaVersion || saved.LastIDs[collScenarios] != 8 || saved.LastIDs[collLoadProfiles] != 3 {
+		t.Errorf("unexpected saved schema: version %d, ids %v", saved.Version, saved.LastIDs)
+	}
+}
+
+func TestOpenRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"newer.json":     `{"version": 99}`,
+		"corrupted.json": `{"scenarios": [`,
+	}
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := Open(path); err == nil {
+			Close()
+			t.Errorf("%s: expected an error", name)
+		}
+		// Файл не перезаписывается
+		if data, _ := os.ReadFile(path); string(data) != content {
+			t.Errorf("%s: file was modified: %s", name, data)
+		}
+	}
+}
+
+// Другой процесс (например, CLI при открытом GUI) изменил файл после Open: его изменения не теряются.
+func TestUpdateReloadsFile(t *testing.T) {
+	path := openTemp(t)
+	if _, err := AddScenario(Scenario{Name: "gui"}); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := readSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.TestRuns = append(other.TestRuns, TestRun{ID: other.nextID(collTestRuns), Name: "cli"})
+	if err := writeSnapshot(path, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := AddScenario(Scenario{Name: "gui2"}); err != nil {
+		t.Fatal(err)
+	}
+	run, err := AddTestRun(TestRun{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ScenarioCount() != 2 || TestRunCount() != 2 || run.ID != 2 {
+		t.Errorf("changes of another process are lost: %d scenarios, %d runs, run id %d", ScenarioCount(), TestRunCount(), run.ID)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s is left", e.Name())
+		}
+	}
+}
+
+func TestUpdateWaitsForLock(t *testing.T) {
+	path := openTemp(t)
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := AddScenario(Scenario{Name: "blocked"})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("update finished while the store was locked: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if ScenarioCount() != 1 {
+		t.Errorf("expected 1 scenario, got %d", ScenarioCount())
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	path := openTemp(t)
+
+	// Отдельные записи в файл в обход mutex, как это делал бы другой процесс
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := AddScenario(Scenario{Name: "in-process"}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer unlock()
+			s, err := readSnapshot(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.TestRuns = append(s.TestRuns, TestRun{ID: s.nextID(collTestRuns)})
+			if err := writeSnapshot(path, s); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s, err := readSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Scenarios) != 10 || len(s.TestRuns) != 10 {
+		t.Errorf("expected 10 scenarios and 10 runs, got %d and %d", len(s.Scenarios), len(s.TestRuns))
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"strings"
+
+	"httes/core/report"
+	"httes/store"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// regressionStyle выделяет строки сравнения с регрессией
+var regressionStyle = &widget.CustomTextGridStyle{FGColor: color.NRGBA{R: 180, G: 0, B: 0, A: 255}}
+
+// showRunComparison открывает окно сравнения запуска current с базовым запуском base.
+// Изменения метрик сверх допустимых отклонений выделяются цветом.
+func (mp *ControlPage) showRunComparison(base, current store.TestRun) error {
+	bs, err := storedSummary(base)
+	if err != nil {
+		return err
+	}
+	cs, err := storedSummary(current)
+	if err != nil {
+		return err
+	}
+	c := report.Compare(bs, cs, report.DefaultTolerances())
+
+	w := mp.app.NewWindow(fmt.Sprintf("Сравнение: %s → %s", base.Name, current.Name))
+	w.Resize(fyne.NewSize(800, 600))
+	if mp.icon != nil {
+		w.SetIcon(mp.icon)
+	}
+
+	lines, regressions := c.Lines()
+	grid := widget.NewTextGrid()
+	grid.SetText(strings.Join(lines, "\n"))
+	for i, r := range regressions {
+		if r {
+			grid.SetRowStyle(i, regressionStyle)
+		}
+	}
+
+	status := "Регрессий нет"
+	if c.Regressed {
+		status = fmt.Sprintf("Обнаружены регрессии: %d", len(c.Regressions()))
+	}
+	header := widget.NewLabelWithStyle(
+		fmt.Sprintf("Базовый: %s (%s)\nТекущий: %s (%s)\n%s", base.Name, base.StartTime.Format("02.01.2006 15:04:05"),
+			current.Name, current.StartTime.Format("02.01.2006 15:04:05"), status),
+		fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	tolerances := widget.NewLabel(fmt.Sprintf("Допустимые отклонения: длительность +%.0f%%, пропускная способность -%.0f%%, ошибки +%.0f п.п.",
+		c.Tolerances.Latency, c.Tolerances.Throughput, c.Tolerances.ErrorRate))
+
+	w.SetContent(container.NewBorder(
+		container.NewVBox(header, tolerances, widget.NewSeparator()),
+		nil, nil, nil,
+		container.NewScroll(grid),
+	))
+	w.Show()
+	return nil
+}
+
+// storedSummary возвращает сохранённый результат запуска.
+func storedSummary(r store.TestRun) (report.RunSummary, error) {
+	var s report.RunSummary
+	if len(r.Result) == 0 {
+		return s, fmt.Errorf("для запуска %s не сохранён результат", r.Name)
+	}
+	if err := json.Unmarshal(r.Result, &s); err != nil {
+		return s, fmt.Errorf("ошибка чтения результата %s: %v", r.Name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"httes/config"
+	"httes/core/report"
+	"httes/core/types"
+	"httes/store"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Расширения файлов конфигурации теста
+var configExtensions = []string{".json", ".yaml", ".yml"}
+
+// runConfigButtons возвращает кнопки экрана запуска для сохранения теста в файл конфигурации
+// и загрузки теста из него.
+func (mp *ControlPage) runConfigButtons(window fyne.Window) fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewButtonWithIcon("Открыть конфигурацию", theme.FolderOpenIcon(), func() {
+			mp.showConfigImportDialog(window)
+		}),
+		widget.NewButtonWithIcon("Сохранить конфигурацию", theme.DocumentSaveIcon(), func() {
+			debug := mp.loadTest != nil && mp.loadTest.debugCheck.Checked
+			cfg, err := mp.exportRunConfig(debug)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			showConfigSaveDialog(window, cfg, "httes.json")
+		}),
+	)
+}
+
+// showConfigImportDialog предлагает выбрать файл конфигурации и переносит тест на экран запуска:
+// параметры нагрузки заполняют поля ввода, сценарии сохраняются в хранилище и составляют смесь.
+func (mp *ControlPage) showConfigImportDialog(window fyne.Window) {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		source := reader.URI().Name()
+		if err := mp.importRunConfig(reader.URI().Path(), data); err != nil {
+			dialog.ShowError(fmt.Errorf("%s: %v", source, err), window)
+			return
+		}
+		dialog.ShowInformation("Конфигурация", fmt.Sprintf("Тест загружен из %s:\n%s", source, mp.scenarioMixName()), window)
+	}, window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter(configExtensions))
+	fileDialog.Show()
+}
+
+// importRunConfig проверяет конфигурацию из файла path и переносит тест на экран запуска.
+// Включаемые файлы и .env ищутся в каталоге файла.
+func (mp *ControlPage) importRunConfig(path string, data []byte) error {
+	opts := config.LoadOptions{BaseDir: filepath.Dir(path)}
+	configType := config.DetectConfigType(path, data)
+	reader, err := config.NewConfigReaderWithOptions(data, configType, opts)
+	if err != nil {
+		return err
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		return err
+	}
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	resolved, err := config.Resolve(data, configType, opts)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	imp, err := parseRunConfig(name, resolved)
+	if err != nil {
+		return err
+	}
+
+	mix := make([]scenarioMixItem, 0, len(imp.Scenarios))
+	for _, sc := range imp.Scenarios {
+		saved, err := store.AddScenario(store.Scenario{
+			Name:        sc.Name,
+			Description: "Импортирован из " + filepath.Base(path),
+			JSON:        sc.JSON,
+		})
+		if err != nil {
+			return err
+		}
+		mix = append(mix, scenarioMixItem{Scenario: saved, Weight: sc.Weight, TopLevel: imp.TopLevel})
+	}
+	mp.scenarioMix = mix
+	mp.configExtras = imp.Extras
+
+	s := imp.Settings
+	mp.reqCount.SetText(strconv.Itoa(s.RequestCount))
+	mp.duration.SetText(strconv.Itoa(s.Duration))
+	mp.loadType.SetSelected(strings.ToUpper(s.LoadType[:1]) + s.LoadType[1:])
+	mp.proxyEntry.SetText(s.Proxy)
+	if mp.loadTest != nil {
+		mp.loadTest.debugCheck.SetChecked(imp.Debug)
+	}
+	if mp.refreshMix != nil {
+		mp.refreshMix()
+	}
+	if mp.refreshScenarios != nil {
+		mp.refreshScenarios()
+	}
+	return nil
+}
+
+// showScenarioExportDialog сохраняет сценарий хранилища в файл конфигурации с параметрами нагрузки по умолчанию.
+func (mp *ControlPage) showScenarioExportDialog(window fyne.Window, sc store.Scenario) {
+	settings := store.RunSettings{
+		RequestCount: types.DefaultIterCount,
+		Duration:     types.DefaultDuration,
+		LoadType:     types.DefaultLoadType,
+	}
+	item := scenarioMixItem{Scenario: sc, Weight: 1, TopLevel: true}
+	cfg, err := runConfigFor(settings, []scenarioMixItem{item}, false, runExtras{}, report.OutputTypeStdout)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	showConfigSaveDialog(window, cfg, fmt.Sprintf("scenario-%d.json", sc.ID))
+}
+
+// showConfigSaveDialog предлагает выбрать файл и записывает в него конфигурацию.
+func showConfigSaveDialog(window fyne.Window, cfg []byte, fileName string) {
+	save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if w == nil {
+			return
+		}
+		defer w.Close()
+		if _, err := w.Write(append(cfg, '\n')); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+	save.SetFileName(fileName)
+	save.Show()
+}
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"httes/store"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
@@ -32,6 +34,25 @@ type ControlPage struct {
 	certKeyPathEntry *widget.Entry
 	selectCertButton *widget.Button
 	selectKeyButton  *widget.Button
+	// Смесь сценариев с весами для одного теста
+	scenarioMix []scenarioMixItem
+	// Параметры импортированной конфигурации, для которых нет полей ввода
+	configExtras runExtras
+	// Обновление описания смеси сценариев, устанавливается экраном запуска
+	refreshMix func()
+	// Обновление списков после изменений в хранилище, устанавливаются экранами
+	refreshScenarios func()
+	refreshHistory   func()
+	// Экран запуска теста, используется для повторного запуска из истории
+	loadTest *LoadTestUI
+}
+
+// scenarioMixItem — сценарий из хранилища и его вес в смеси.
+type scenarioMixItem struct {
+	Scenario store.Scenario
+	Weight   int
+	// Сценарий импортирован из конфигурации без смеси; пока он единственный, его шаги записываются так же
+	TopLevel bool
 }
 
 // NewMainPage создаёт новый экземпляр MainPage.
@@ -0,0 +1,129 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"httes/config"
+	"httes/importer"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// curlButtons возвращает кнопки редактора сценария для вставки шагов из команд cURL
+// и копирования шагов сценария в виде команд cURL.
+func (mp *ControlPage) curlButtons(window fyne.Window, jsonEditor *widget.Entry) fyne.CanvasObject {
+	return container.NewHBox(
+		widget.NewButtonWithIcon("Из cURL", theme.ContentPasteIcon(), func() {
+			mp.showCurlImportDialog(window, jsonEditor)
+		}),
+		widget.NewButtonWithIcon("В cURL", theme.ContentCopyIcon(), func() {
+			mp.showCurlExportDialog(window, jsonEditor.Text)
+		}),
+	)
+}
+
+// showCurlImportDialog предлагает вставить команды cURL и добавляет полученные шаги в конец JSON сценария.
+func (mp *ControlPage) showCurlImportDialog(window fyne.Window, jsonEditor *widget.Entry) {
+	input := widget.NewMultiLineEntry()
+	input.SetPlaceHolder("curl 'https://api.example.com/users' -H 'Accept: application/json'")
+	input.SetMinRowsVisible(10)
+	input.Wrapping = fyne.TextWrapWord
+
+	d := dialog.NewCustomConfirm("Шаги из cURL", "Добавить", "Отмена", input, func(ok bool) {
+		if !ok || strings.TrimSpace(input.Text) == "" {
+			return
+		}
+		res, err := importer.ParseCurl(input.Text)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		scenarioJSON, err := appendSteps(jsonEditor.Text, res.Scenarios[0].Steps)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		jsonEditor.SetText(scenarioJSON)
+
+		warnings := res.Warnings
+		if res.Proxy != "" {
+			warnings = append(warnings, fmt.Sprintf("прокси %s нужно указать в настройках теста", res.Proxy))
+		}
+		if len(warnings) > 0 {
+			dialog.ShowInformation("Шаги из cURL", "Не удалось преобразовать:\n"+strings.Join(warnings, "\n"), window)
+		}
+	}, window)
+	d.Resize(fyne.NewSize(700, 400))
+	d.Show()
+}
+
+// showCurlExportDialog показывает шаги сценария в виде команд cURL с кнопкой копирования.
+// Переменные окружения остаются в командах как {{name}}.
+func (mp *ControlPage) showCurlExportDialog(window fyne.Window, scenarioJSON string) {
+	if strings.TrimSpace(scenarioJSON) == "" {
+		dialog.ShowInformation("Шаги в cURL", "В сценарии нет шагов", window)
+		return
+	}
+	reader, err := config.NewConfigReader([]byte(scenarioJSON), config.ConfigTypeJson)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+
+	commands := make([]string, 0, len(h.Scenario.Steps))
+	for _, st := range h.Scenario.Steps {
+		commands = append(commands, fmt.Sprintf("# (%d) %s\n%s", st.ID, st.Name, st.Curl()))
+	}
+	text := strings.Join(commands, "\n\n")
+
+	output := widget.NewMultiLineEntry()
+	output.SetText(text)
+	output.SetMinRowsVisible(14)
+	copyBtn := widget.NewButtonWithIcon("Копировать", theme.ContentCopyIcon(), func() {
+		window.Clipboard().SetContent(text)
+	})
+
+	d := dialog.NewCustom("Шаги в cURL", "Закрыть", container.NewBorder(nil, copyBtn, nil, nil, output), window)
+	d.Resize(fyne.NewSize(800, 450))
+	d.Show()
+}
+
+// appendSteps добавляет шаги в конец JSON сценария и нумерует их после последнего шага.
+func appendSteps(scenarioJSON string, steps []importer.Step) (string, error) {
+	scenario := map[string]interface{}{}
+	if strings.TrimSpace(scenarioJSON) != "" {
+		if err := json.Unmarshal([]byte(scenarioJSON), &scenario); err != nil {
+			return "", fmt.Errorf("JSON сценария некорректен: %v", err)
+		}
+	}
+
+	existing, _ := scenario["steps"].([]interface{})
+	var lastID uint16
+	for _, s := range existing {
+		if step, ok := s.(map[string]interface{}); ok {
+			if id, ok := step["id"].(float64); ok && uint16(id) > lastID {
+				lastID = uint16(id)
+			}
+		}
+	}
+	for _, st := range steps {
+		lastID++
+		st.ID = lastID
+		existing = append(existing, st)
+	}
+	scenario["steps"] = existing
+
+	b, err := json.MarshalIndent(scenario, "", "  ")
+	return string(b), err
+}
@@ -1,9 +1,15 @@
 package ui
 
 import (
+	"encoding/json"
 	"fmt"
 	"image/color"
-	"time"
+	"net/http"
+	"sort"
+	"strings"
+
+	"httes/core/report"
+	"httes/store"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -15,103 +21,18 @@ import (
 )
 
 func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.AppTabs) fyne.CanvasObject {
-	// 1. Модель данных истории тестов
-	type TestHistory struct {
-		ID          string
-		Name        string
-		CreatedAt   time.Time
-		Status      string // "success", "failed", "running"
-		Description string
-		Scenario    string // Выбранный сценарий (например, "API")
-		APIDetails  string
-		Request     string
-		Response    string
-		// Настройки теста
-		Method       string // Например, "GET" или "POST"
-		Protocol     string // Например, "HTTP" или "HTTPS"
-		URL          string // URL из urlEntry
-		Proxy        string // Прокси, если указано
-		RequestCount string // Количество запросов
-		Duration     string // Длительность
-		LoadType     string // Тип нагрузки ("Linear", "Incremental", "Waved")
-		Username     string // Имя пользователя для Basic Auth
-		Password     string // Пароль для Basic Auth
-		CertPath     string // Путь к сертификату
-		CertKeyPath  string // Путь к ключу сертификата
-	}
-
-	// Инициализация истории тестов с примерами
-	history := []TestHistory{
-		{
-			ID:           "1",
-			Name:         "API User Flow Test",
-			CreatedAt:    time.Now().Add(-time.Hour * 2),
-			Status:       "success",
-			Description:  "Тестирование CRUD операций пользователей",
-			Scenario:     "API",
-			APIDetails:   "POST /users, GET /users/{id}, PUT /users/{id}, DELETE /users/{id}",
-			Request:      "POST /users {\"name\":\"test\",\"email\":\"test@example.com\"}",
-			Response:     "201 Created {\"id\":123,\"name\":\"test\",\"email\":\"test@example.com\"}",
-			Method:       "POST",
-			Protocol:     "HTTPS",
-			URL:          "example.com/users",
-			Proxy:        "", // Прокси не используется
-			RequestCount: "10",
-			Duration:     "1",
-			LoadType:     "Linear",
-			Username:     "admin",
-			Password:     "admin123",
-			CertPath:     "",
-			CertKeyPath:  "",
-		},
-		{
-			ID:           "2",
-			Name:         "Auth Stress Test",
-			CreatedAt:    time.Now().Add(-time.Hour * 5),
-			Status:       "failed",
-			Description:  "Нагрузочное тестирование аутентификации",
-			Scenario:     "API",
-			APIDetails:   "POST /auth/login 100 запросов/сек",
-			Request:      "POST /auth/login {\"username\":\"test\",\"password\":\"test123\"}",
-			Response:     "429 Too Many Requests",
-			Method:       "POST",
-			Protocol:     "HTTPS",
-			URL:          "example.com/auth/login",
-			Proxy:        "http://127.0.0.1:8080",
-			RequestCount: "100",
-			Duration:     "10",
-			LoadType:     "Incremental",
-			Username:     "test",
-			Password:     "test123",
-			CertPath:     "",
-			CertKeyPath:  "",
-		},
-		{
-			ID:           "3",
-			Name:         "Payment Validation",
-			CreatedAt:    time.Now().Add(-time.Hour * 24),
-			Status:       "success",
-			Description:  "Проверка валидации платежных данных",
-			Scenario:     "API",
-			APIDetails:   "POST /payments с различными входными данными",
-			Request:      "POST /payments {\"card\":\"4111111111111111\",\"expiry\":\"12/25\",\"cvv\":\"123\"}",
-			Response:     "200 OK {\"status\":\"processed\"}",
-			Method:       "POST",
-			Protocol:     "HTTPS",
-			URL:          "example.com/payments",
-			Proxy:        "",
-			RequestCount: "5",
-			Duration:     "2",
-			LoadType:     "Waved",
-			Username:     "",
-			Password:     "",
-			CertPath:     "/path/to/cert.crt",
-			CertKeyPath:  "/path/to/cert.key",
-		},
+	// 1. История тестов из хранилища, новые запуски первыми
+	var history []store.TestRun
+	loadHistory := func() {
+		history = store.ListTestRuns()
+		sort.Slice(history, func(i, j int) bool {
+			return history[i].StartTime.After(history[j].StartTime)
+		})
 	}
+	loadHistory()
 
 	// 2. Функция для отображения деталей теста
-	showTestDetails := func(test TestHistory) {
+	showTestDetails := func(test store.TestRun) {
 		detailWindow := mp.app.NewWindow("Детали теста: " + test.Name)
 		detailWindow.Resize(fyne.NewSize(800, 600))
 		// Устанавливаем иконку для нового окна
@@ -124,71 +45,178 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 
 		// Статус с цветом
 		statusText := canvas.NewText("Статус: "+test.Status, nil)
-		if test.Status == "success" {
+		if test.Status == store.RunStatusSuccess {
 			statusText.Color = color.NRGBA{R: 0, G: 180, B: 0, A: 255}
-		} else if test.Status == "failed" {
+		} else if test.Status == store.RunStatusFailed {
 			statusText.Color = color.NRGBA{R: 180, G: 0, B: 0, A: 255}
 		}
 		statusText.TextStyle.Bold = true
 
-		timeLabel := widget.NewLabel("Время выполнения: " + test.CreatedAt.Format("02.01.2006 15:04:05"))
+		timeLabel := widget.NewLabel("Время выполнения: " + test.StartTime.Format("02.01.2006 15:04:05"))
 		descLabel := widget.NewLabel(test.Description)
 		descLabel.Wrapping = fyne.TextWrapWord
 
 		// Секция: Детали сценария
 		scenarioLabel := widget.NewLabelWithStyle("Сценарий:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		scenarioText := widget.NewLabel(test.Scenario)
+		scenarioText := widget.NewLabel(func() string {
+			if test.Scenario == "" {
+				return "Не выбран"
+			}
+			return test.Scenario
+		}())
 		scenarioText.Wrapping = fyne.TextWrapWord
 
 		// Секция: Настройки теста
 		settingsLabel := widget.NewLabelWithStyle("Настройки теста:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		methodText := widget.NewLabel("Метод: " + test.Method)
-		protocolText := widget.NewLabel("Протокол: " + test.Protocol)
-		urlText := widget.NewLabel("URL: " + test.URL)
+		settings := test.Settings
+		methodText := widget.NewLabel("Метод: " + settings.Method)
+		protocolText := widget.NewLabel("Протокол: " + settings.Protocol)
+		urlText := widget.NewLabel("URL: " + settings.URL)
 
 		// Прокси (если указано)
 		proxyText := widget.NewLabel("Прокси: " + func() string {
-			if test.Proxy == "" {
+			if settings.Proxy == "" {
 				return "Не используется"
 			}
-			return test.Proxy
+			return settings.Proxy
 		}())
 
-		paramsText := widget.NewLabel(fmt.Sprintf("Параметры: %s запросов, %s сек, тип нагрузки: %s",
-			test.RequestCount, test.Duration, test.LoadType))
+		paramsText := widget.NewLabel(fmt.Sprintf("Параметры: %d запросов, %d сек, тип нагрузки: %s",
+			settings.RequestCount, settings.Duration, settings.LoadType))
 
 		// Basic Auth (если указано)
 		authText := widget.NewLabel("Basic Auth: " + func() string {
-			if test.Username == "" && test.Password == "" {
+			if settings.Username == "" && settings.Password == "" {
 				return "Не используется"
 			}
-			return fmt.Sprintf("Имя пользователя: %s, Пароль: %s", test.Username, test.Password)
+			return fmt.Sprintf("Имя пользователя: %s, Пароль: %s", settings.Username, settings.Password)
 		}())
 
 		// Сертификаты (если указаны)
 		certText := widget.NewLabel("Сертификаты: " + func() string {
-			if test.CertPath == "" && test.CertKeyPath == "" {
+			if settings.CertPath == "" && settings.CertKeyPath == "" {
 				return "Не используются"
 			}
-			return fmt.Sprintf("Путь к сертификату: %s, Путь к ключу: %s", test.CertPath, test.CertKeyPath)
+			return fmt.Sprintf("Путь к сертификату: %s, Путь к ключу: %s", settings.CertPath, settings.CertKeyPath)
 		}())
 
-		// Секция: API детали
-		apiLabel := widget.NewLabelWithStyle("API детали:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		apiDetails := widget.NewLabel(test.APIDetails)
-		apiDetails.Wrapping = fyne.TextWrapWord
+		// Секция: Результат теста
+		resultLabel := widget.NewLabelWithStyle("Результат:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+		resultText := widget.NewTextGrid()
+		resultText.SetText("Результат не сохранён")
+		if len(test.Result) > 0 {
+			var summary report.RunSummary
+			if err := json.Unmarshal(test.Result, &summary); err != nil {
+				resultText.SetText("Ошибка чтения результата: " + err.Error())
+			} else {
+				resultText.SetText(formatRunSummary(summary))
+			}
+		}
+
+		// Секция: Конфигурация теста и повторный запуск
+		configEntry := widget.NewMultiLineEntry()
+		configEntry.SetText(string(test.Config))
+		configEntry.Disable()
+		configEntry.SetMinRowsVisible(10)
+		configAccordion := widget.NewAccordion(widget.NewAccordionItem("Конфигурация", configEntry))
+
+		rerunBtn := widget.NewButtonWithIcon("Повторить запуск", theme.MediaReplayIcon(), func() {
+			if mp.loadTest == nil {
+				dialog.ShowError(fmt.Errorf("экран запуска теста недоступен"), detailWindow)
+				return
+			}
+			if err := mp.loadTest.Rerun(test); err != nil {
+				dialog.ShowError(err, detailWindow)
+				return
+			}
+			detailWindow.Close()
+			tabs.SelectIndex(1)
+		})
+		if len(test.Config) == 0 {
+			rerunBtn.Disable()
+		}
+
+		htmlBtn := widget.NewButtonWithIcon("HTML-отчёт", theme.DocumentSaveIcon(), func() {
+			summary, err := storedSummary(test)
+			if err != nil {
+				dialog.ShowError(err, detailWindow)
+				return
+			}
+			save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+				if err != nil {
+					dialog.ShowError(err, detailWindow)
+					return
+				}
+				if w == nil {
+					return
+				}
+				defer w.Close()
+				if err := report.WriteHTMLReport(w, test.Name, summary); err != nil {
+					dialog.ShowError(err, detailWindow)
+				}
+			}, detailWindow)
+			save.SetFileName(fmt.Sprintf("report-%d.html", test.ID))
+			save.Show()
+		})
+		if len(test.Result) == 0 {
+			htmlBtn.Disable()
+		}
 
-		// Секция: Запрос и ответ
-		requestLabel := widget.NewLabelWithStyle("Запрос:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		requestText := widget.NewLabel(test.Request)
-		requestText.Wrapping = fyne.TextWrapWord
+		// Секция: Сравнение с базовым или другим сохранённым запуском
+		baselineBtn := widget.NewButtonWithIcon("Сделать базовым", theme.ConfirmIcon(), nil)
+		baselineBtn.OnTapped = func() {
+			if err := store.SetBaseline(test.ID); err != nil {
+				dialog.ShowError(err, detailWindow)
+				return
+			}
+			test.Baseline = true
+			baselineBtn.Disable()
+			if mp.refreshHistory != nil {
+				mp.refreshHistory()
+			}
+		}
+		if test.Baseline || len(test.Result) == 0 {
+			baselineBtn.Disable()
+		}
 
-		responseLabel := widget.NewLabelWithStyle("Ответ:", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-		responseText := widget.NewLabel(test.Response)
-		responseText.Wrapping = fyne.TextWrapWord
+		compareOptions := map[string]store.TestRun{}
+		var compareNames []string
+		defaultCompare := ""
+		for _, r := range history {
+			if r.ID == test.ID || len(r.Result) == 0 {
+				continue
+			}
+			name := fmt.Sprintf("%s (%s)", r.Name, r.StartTime.Format("02.01.2006 15:04:05"))
+			if r.Baseline {
+				name += " — базовый"
+				defaultCompare = name
+			}
+			compareOptions[name] = r
+			compareNames = append(compareNames, name)
+		}
+		compareSelect := widget.NewSelect(compareNames, nil)
+		compareSelect.PlaceHolder = "Запуск для сравнения"
+		if defaultCompare != "" {
+			compareSelect.SetSelected(defaultCompare)
+		}
+		compareBtn := widget.NewButtonWithIcon("Сравнить", theme.SearchIcon(), func() {
+			base, ok := compareOptions[compareSelect.Selected]
+			if !ok {
+				dialog.ShowError(fmt.Errorf("выберите запуск для сравнения"), detailWindow)
+				return
+			}
+			if err := mp.showRunComparison(base, test); err != nil {
+				dialog.ShowError(err, detailWindow)
+			}
+		})
+		if len(test.Result) == 0 || len(compareNames) == 0 {
+			compareSelect.Disable()
+			compareBtn.Disable()
+		}
 
 		// Собираем содержимое
 		content := container.NewVScroll(container.NewVBox(
+			container.NewHBox(layout.NewSpacer(), htmlBtn, baselineBtn, rerunBtn),
 			nameLabel,
 			container.NewHBox(widget.NewLabel("Статус: "), statusText),
 			timeLabel,
@@ -207,14 +235,11 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 			authText,
 			certText,
 			widget.NewSeparator(),
-			apiLabel,
-			apiDetails,
+			resultLabel,
+			resultText,
 			widget.NewSeparator(),
-			requestLabel,
-			requestText,
-			widget.NewSeparator(),
-			responseLabel,
-			responseText,
+			container.NewBorder(nil, nil, widget.NewLabel("Сравнить с:"), compareBtn, compareSelect),
+			configAccordion,
 		))
 
 		detailWindow.SetContent(content)
@@ -242,14 +267,18 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 
 			// Основная информация
 			infoContainer := container.Objects[0].(*fyne.Container)
-			infoContainer.Objects[0].(*widget.Label).SetText(test.Name)
-			infoContainer.Objects[1].(*widget.Label).SetText(test.CreatedAt.Format("02.01.2006 15:04:05"))
+			name := test.Name
+			if test.Baseline {
+				name += " (базовый)"
+			}
+			infoContainer.Objects[0].(*widget.Label).SetText(name)
+			infoContainer.Objects[1].(*widget.Label).SetText(test.StartTime.Format("02.01.2006 15:04:05"))
 
 			// Создаем цветной текст для статуса
 			statusText := canvas.NewText(test.Status, nil)
-			if test.Status == "success" {
+			if test.Status == store.RunStatusSuccess {
 				statusText.Color = color.NRGBA{R: 0, G: 180, B: 0, A: 255}
-			} else if test.Status == "failed" {
+			} else if test.Status == store.RunStatusFailed {
 				statusText.Color = color.NRGBA{R: 180, G: 0, B: 0, A: 255}
 			}
 			statusText.TextStyle.Bold = true
@@ -266,16 +295,27 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 		list.Unselect(id) // Снимаем выделение после выбора
 	}
 
-	// 4. Кнопка очистки истории
+	totalLabel := widget.NewLabel(fmt.Sprintf("Всего: %d", len(history)))
+	refresh := func() {
+		loadHistory()
+		totalLabel.SetText(fmt.Sprintf("Всего: %d", len(history)))
+		list.Refresh()
+	}
+
+	// 4. Кнопки обновления и очистки истории
+	refreshBtn := widget.NewButtonWithIcon("Обновить", theme.ViewRefreshIcon(), refresh)
 	clearHistoryBtn := widget.NewButtonWithIcon("Очистить историю", theme.DeleteIcon(), func() {
 		dialog.ShowConfirm("Очистка истории", "Вы уверены, что хотите очистить всю историю тестов?", func(ok bool) {
 			if ok {
 				// Очищаем историю
-				history = []TestHistory{}
-				list.Refresh()
+				if err := store.ClearTestRuns(); err != nil {
+					dialog.ShowError(err, window)
+				}
+				refresh()
 			}
 		}, window)
 	})
+	mp.refreshHistory = refresh
 
 	// 5. Собираем интерфейс
 	header := container.NewHBox(
@@ -284,7 +324,7 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 		}),
 		widget.NewLabelWithStyle("История тестов", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		layout.NewSpacer(),
-		widget.NewLabel(fmt.Sprintf("Всего: %d", len(history))),
+		totalLabel,
 	)
 
 	content := container.NewBorder(
@@ -293,6 +333,7 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 			widget.NewSeparator(),
 			container.NewHBox(
 				layout.NewSpacer(),
+				refreshBtn,
 				clearHistoryBtn,
 			),
 		),
@@ -309,3 +350,60 @@ func (mp *ControlPage) createHistoryScreen(window fyne.Window, tabs *container.A
 		),
 	)
 }
+
+// formatRunSummary возвращает текстовое представление сохранённого результата теста.
+func formatRunSummary(s report.RunSummary) string {
+	b := strings.Builder{}
+	total := s.SuccessCount + s.FailedCount
+	b.WriteString(fmt.Sprintf("Итераций:         %d (успешно %d, с ошибками %d)\n", total, s.SuccessCount, s.FailedCount))
+	b.WriteString(fmt.Sprintf("Запросов:         %d\n", s.TotalRequests))
+	b.WriteString(fmt.Sprintf("Длительность:     %d сек, %.2f итераций/сек\n", s.Duration, s.Rps))
+	b.WriteString(fmt.Sprintf("Среднее время:    %.4fs\n", s.AvgDuration))
+	b.WriteString(fmt.Sprintf("Процентили:       p50 %.4fs, p90 %.4fs, p95 %.4fs, p99 %.4fs\n",
+		s.Percentiles["p50"], s.Percentiles["p90"], s.Percentiles["p95"], s.Percentiles["p99"]))
+
+	if len(s.StatusCodeDist) > 0 {
+		b.WriteString("\nСтатус-коды:\n")
+		codes := make([]int, 0, len(s.StatusCodeDist))
+		for c := range s.StatusCodeDist {
+			codes = append(codes, c)
+		}
+		sort.Ints(codes)
+		for _, c := range codes {
+			b.WriteString(fmt.Sprintf("  %-20s:%d\n", fmt.Sprintf("%d (%s)", c, http.StatusText(c)), s.StatusCodeDist[c]))
+		}
+	}
+
+	if len(s.Steps) > 0 {
+		b.WriteString("\nШаги:\n")
+		ids := make([]int, 0, len(s.Steps))
+		for id := range s.Steps {
+			ids = append(ids, int(id))
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			st := s.Steps[uint16(id)]
+			b.WriteString(fmt.Sprintf("  (%d) %-15s:ok %d, failed %d, skipped %d, avg %.4fs, p95 %.4fs\n",
+				id, st.Name, st.SuccessCount, st.FailedCount, st.SkippedCount, st.Durations["duration"], st.Percentiles["p95"]))
+		}
+	}
+
+	if len(s.Thresholds) > 0 {
+		b.WriteString("\nПороговые значения:\n")
+		for _, t := range s.Thresholds {
+			b.WriteString("  " + t.String() + "\n")
+		}
+	}
+
+	if len(s.TimeSeries) > 0 {
+		b.WriteString("\nПо секундам (сек: итераций, ошибок, среднее время):\n")
+		for _, tp := range s.TimeSeries {
+			b.WriteString(fmt.Sprintf("  %4d: %d, %d, %.4fs\n", tp.Second+1, tp.SuccessCount+tp.FailedCount, tp.FailedCount, tp.AvgDuration))
+		}
+	}
+
+	if len(s.Samples) > 0 {
+		b.WriteString(fmt.Sprintf("\nСохранено замеров запросов: %d из %d\n", len(s.Samples), s.SampleCount))
+	}
+	return b.String()
+}
@@ -0,0 +1,61 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"httes/importer"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+)
+
+// Расширения файлов, из которых можно импортировать сценарии
+var importExtensions = []string{".har", ".json", ".yaml", ".yml"}
+
+// showImportDialog предлагает выбрать файл, импортирует из него сценарии в хранилище
+// и показывает, что не удалось преобразовать. После импорта вызывается done.
+func (mp *ControlPage) showImportDialog(window fyne.Window, done func()) {
+	fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil || reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		res, err := importer.Import("", reader.URI().Name(), data, importer.Options{})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		saved, err := res.Save(reader.URI().Name())
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if done != nil {
+			done()
+		}
+
+		names := make([]string, 0, len(saved))
+		for _, s := range saved {
+			names = append(names, s.Name)
+		}
+		msg := fmt.Sprintf("Импортировано сценариев: %d\n%s", len(saved), strings.Join(names, "\n"))
+		if len(res.Warnings) > 0 {
+			msg += "\n\nНе удалось преобразовать:\n" + strings.Join(res.Warnings, "\n")
+		}
+		if len(res.Captures) > 0 {
+			msg += "\n\nИзвлечения переменных:\n" + strings.Join(res.Captures, "\n")
+		}
+		dialog.ShowInformation("Импорт", msg, window)
+	}, window)
+	fileDialog.SetFilter(storage.NewExtensionFileFilter(importExtensions))
+	fileDialog.Show()
+}
@@ -3,10 +3,14 @@ package ui
 import (
 	"fmt"
 	"strconv"
+	"strings"
+
+	"httes/store"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/storage"
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
@@ -27,6 +31,8 @@ func (mp *ControlPage) createTestRunScreen(window fyne.Window, tabs *container.A
 	}
 
 	ui := NewLoadTestUI(mp.app, window)
+	ui.mp = mp
+	mp.loadTest = ui
 	ui.resultOutput = mp.resultOutput
 	ui.progressBar = mp.progressBar
 	ui.progressText = mp.progressText
@@ -41,7 +47,7 @@ func (mp *ControlPage) createTestRunScreen(window fyne.Window, tabs *container.A
 			container.NewHBox(mp.createProxySection(), mp.createCertFields(window)),
 			mp.createScenariosSection(tabs),
 			widget.NewSeparator(),
-			ui.CreateButtons(),
+			container.NewHBox(ui.CreateButtons(), layout.NewSpacer(), mp.runConfigButtons(window)),
 			widget.NewSeparator(),
 			container.NewHBox(
 				container.NewGridWrap(fyne.NewSize(400, 30), ui.progressBar),
@@ -257,7 +263,92 @@ func (mp *ControlPage) createScenariosSection(tabs *container.AppTabs) fyne.Canv
 		closeBtn.Hide()
 	}
 
-	return scenarioContainer
+	return container.NewVBox(scenarioContainer, mp.createScenarioMixSection())
+}
+
+// createScenarioMixSection создаёт панель для составления смеси сценариев с весами,
+// например 70% browse, 25% search, 5% checkout.
+func (mp *ControlPage) createScenarioMixSection() fyne.CanvasObject {
+	mixLabel := widget.NewLabel("Смесь не задана")
+	mixLabel.Wrapping = fyne.TextWrapWord
+
+	updateMixLabel := func() {
+		if len(mp.scenarioMix) == 0 {
+			mixLabel.SetText("Смесь не задана")
+			return
+		}
+		total := 0
+		for _, item := range mp.scenarioMix {
+			total += item.Weight
+		}
+		parts := make([]string, 0, len(mp.scenarioMix))
+		for _, item := range mp.scenarioMix {
+			parts = append(parts, fmt.Sprintf("%s: %d%%", item.Scenario.Name, item.Weight*100/total))
+		}
+		mixLabel.SetText(strings.Join(parts, ", "))
+	}
+
+	scenarioNames := func() []string {
+		names := make([]string, 0, store.ScenarioCount())
+		for i := 0; i < store.ScenarioCount(); i++ {
+			names = append(names, store.GetScenario(i).Name)
+		}
+		return names
+	}
+
+	scenarioSelect := widget.NewSelect(scenarioNames(), nil)
+	scenarioSelect.PlaceHolder = "Сценарий"
+	mp.refreshMix = func() {
+		scenarioSelect.Options = scenarioNames()
+		scenarioSelect.Refresh()
+		updateMixLabel()
+	}
+
+	weightEntry := widget.NewEntry()
+	weightEntry.SetPlaceHolder("Вес")
+	weightEntry.SetText("1")
+
+	addBtn := widget.NewButtonWithIcon("В смесь", theme.ContentAddIcon(), func() {
+		weight, err := parseInt(weightEntry.Text)
+		if err != nil || weight <= 0 || scenarioSelect.SelectedIndex() < 0 {
+			return
+		}
+		selected := store.GetScenario(scenarioSelect.SelectedIndex())
+
+		// Повторное добавление сценария обновляет его вес
+		for i, item := range mp.scenarioMix {
+			if item.Scenario.ID == selected.ID {
+				mp.scenarioMix[i].Weight = weight
+				updateMixLabel()
+				return
+			}
+		}
+		mp.scenarioMix = append(mp.scenarioMix, scenarioMixItem{Scenario: selected, Weight: weight})
+		updateMixLabel()
+	})
+
+	clearBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		mp.scenarioMix = nil
+		mp.configExtras = runExtras{}
+		updateMixLabel()
+	})
+	clearBtn.Importance = widget.LowImportance
+
+	// Список сценариев мог измениться на вкладке сценариев
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		scenarioSelect.Options = scenarioNames()
+		scenarioSelect.Refresh()
+	})
+	refreshBtn.Importance = widget.LowImportance
+
+	return container.NewHBox(
+		container.NewGridWrap(fyne.NewSize(180, scenarioSelect.MinSize().Height), scenarioSelect),
+		refreshBtn,
+		container.NewGridWrap(fyne.NewSize(60, weightEntry.MinSize().Height), weightEntry),
+		addBtn,
+		clearBtn,
+		mixLabel,
+	)
 }
 
 func (mp *ControlPage) createURLSection() *fyne.Container {
@@ -396,6 +487,33 @@ func (mp *ControlPage) createCertFields(window fyne.Window) *fyne.Container {
 	return container.NewVBox(certAccordion)
 }
 
+// runSettings возвращает текущие настройки теста для сохранения в историю запусков.
+func (mp *ControlPage) runSettings() store.RunSettings {
+	settings := store.RunSettings{
+		Method:      mp.methodSelect.Selected,
+		Protocol:    mp.protocolSelect.Selected,
+		URL:         mp.urlEntry.Text,
+		Proxy:       mp.proxyEntry.Text,
+		LoadType:    mp.loadType.Selected,
+		Username:    mp.usernameEntry.Text,
+		Password:    mp.passwordEntry.Text,
+		CertPath:    mp.certPathEntry.Text,
+		CertKeyPath: mp.certKeyPathEntry.Text,
+	}
+	settings.RequestCount, _ = parseInt(mp.reqCount.Text)
+	settings.Duration, _ = parseInt(mp.duration.Text)
+	return settings
+}
+
+// scenarioMixName возвращает описание выбранной смеси сценариев, например "browse (70), search (30)".
+func (mp *ControlPage) scenarioMixName() string {
+	parts := make([]string, 0, len(mp.scenarioMix))
+	for _, item := range mp.scenarioMix {
+		parts = append(parts, fmt.Sprintf("%s (%d)", item.Scenario.Name, item.Weight))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func parseInt(s string) (int, error) {
 	i, err := strconv.Atoi(s)
 	if err != nil {
@@ -1,28 +1,24 @@
 package ui
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"httes/store"
-	"math"
-	"math/rand"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"httes/config"
+	"httes/core"
+	"httes/core/report"
+	"httes/store"
+
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 )
 
-// Metric представляет метрику теста с именем, длительностью и порядком.
-type Metric struct {
-	Name     string
-	Duration float64 // в миллисекундах
-	Order    int
-}
-
 type LoadTestUI struct {
 	isRunning       bool
 	uiUpdateChan    chan uiUpdate
@@ -36,6 +32,9 @@ type LoadTestUI struct {
 	chartsContainer *fyne.Container // Добавляем поле для контейнера с графиками
 	uiUpdaterOnce   sync.Once
 	mp              *ControlPage
+	debugCheck      *widget.Check
+	samplesCheck    *widget.Check      // Сохранять сырые замеры запросов в истории
+	cancel          context.CancelFunc // Остановка текущего теста
 }
 
 type uiUpdate struct {
@@ -68,6 +67,8 @@ func NewLoadTestUI(app fyne.App, window fyne.Window) *LoadTestUI {
 		startBtn:        widget.NewButton("Start Load Test", nil),
 		stopBtn:         widget.NewButton("Stop", nil),
 		chartsContainer: chartsContainer, // Сохраняем ссылку на контейнер
+		debugCheck:      widget.NewCheck("Debug Mode", nil),
+		samplesCheck:    widget.NewCheck("Сохранять замеры", nil),
 	}
 	ui.stopBtn.Disable()
 	ui.initUIUpdater()
@@ -181,219 +182,217 @@ func (ui *LoadTestUI) setupStartButton() {
 			ui.showErrorDialog("Тест уже запущен!")
 			return
 		}
-		ui.isRunning = true
-
-		// Очистка результатов и метрик перед началом нового теста
-		if ui.resultOutput != nil {
-			ui.resultOutput.SetText("")
-		}
-		if ui.progressBar != nil {
-			ui.safeUpdateUI(uiUpdate{progress: 0.0})
-		}
-		if ui.progressText != nil {
-			ui.safeUpdateUI(uiUpdate{progressText: "Request Avg Duration 0.000s"})
-		}
-
-		// Сбрасываем метрики с блокировкой
-		GlobalMetrics.mu.Lock()
-		GlobalMetrics.Times = []float64{0, 1}
-		GlobalMetrics.RPS = []float64{0, 0}
-		GlobalMetrics.RespTimes = []float64{0, 0}
-		GlobalMetrics.Errors = []float64{0, 0}
-		GlobalMetrics.mu.Unlock()
-
-		// Очищаем канал обновлений
-		select {
-		case <-GlobalMetrics.updateChan:
-		default:
-		}
 
-		reqCountVal, err := parseInt(ui.mp.reqCount.Text)
+		cfg, err := ui.mp.buildRunConfig(ui.debugCheck.Checked)
 		if err != nil {
-			ui.resetOnError(fmt.Errorf("invalid request count: %v", err))
-			return
-		}
-		if _, err := parseInt(ui.mp.duration.Text); err != nil {
-			ui.resetOnError(fmt.Errorf("invalid duration: %v", err))
+			ui.resetOnError(err)
 			return
 		}
+		ui.startRun(cfg, ui.mp.scenarioMixName(), ui.mp.runSettings())
+	}
+}
 
-		if ui.mp.urlEntry.Text == "" {
-			ui.resetOnError(fmt.Errorf("URL is required"))
-			return
+// Rerun повторяет сохранённый запуск с той же конфигурацией. Результат сохраняется как новый запуск.
+func (ui *LoadTestUI) Rerun(run store.TestRun) error {
+	if ui.isRunning {
+		return fmt.Errorf("тест уже запущен")
+	}
+	if len(run.Config) == 0 {
+		return fmt.Errorf("для запуска %s не сохранена конфигурация", run.Name)
+	}
+	ui.startRun(run.Config, run.Scenario, run.Settings)
+	return nil
+}
+
+// startRun запускает движок нагрузки по конфигурации cfg и сохраняет запуск с результатом в историю.
+func (ui *LoadTestUI) startRun(cfg []byte, scenario string, settings store.RunSettings) {
+	reader, err := config.NewConfigReader(cfg, config.DetectConfigType("", cfg))
+	if err != nil {
+		ui.resetOnError(err)
+		return
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		ui.resetOnError(err)
+		return
+	}
+
+	// Помимо интерфейса результаты передаются выводам из конфигурации, кроме консоли
+	outputs := []string{report.OutputTypeGui}
+	for _, o := range h.ReportOutputs {
+		if o != report.OutputTypeGui && o != report.OutputTypeStdout {
+			outputs = append(outputs, o)
 		}
+	}
+	rs, err := report.NewReportServices(outputs, report.Options{
+		ResultGrid:    ui.resultOutput,
+		ProgressBar:   ui.progressBar,
+		ProgressText:  ui.progressText,
+		TotalRequests: h.IterationCount,
+		Destination:   h.ReportDestination,
+		Thresholds:    h.Thresholds,
+		MetricsAddr:   h.MetricsAddr,
+		ResultExport:  h.ResultExport,
+		Push:          h.Push,
+		RunID:         h.RunID,
+	})
+	if err != nil {
+		ui.resetOnError(err)
+		return
+	}
+	result := rs.(report.ResultProvider).Result()
+	result.KeepSamples = ui.samplesCheck.Checked
 
-		// Показать значок загрузки и начать прогресс
-		ui.safeUpdateUI(uiUpdate{
-			outputText:   "",
-			startEnabled: false,
-			stopEnabled:  true,
-			status:       "loading",
-			progress:     0.0,
-			progressText: "Request Avg Duration 0.000s",
-		})
+	ctx, cancel := context.WithCancel(context.Background())
+	e, err := core.NewEngine(ctx, h, rs)
+	if err == nil {
+		err = e.Init()
+	}
+	if err != nil {
+		cancel()
+		ui.resetOnError(err)
+		return
+	}
 
-		go func() {
-			defer func() {
-				ui.isRunning = false
-				ui.safeUpdateUI(uiUpdate{
-					startEnabled: true,
-					stopEnabled:  false,
-					status:       "",
-					progress:     100.0,
-					progressText: "Request Avg Duration 0.000s",
-				})
-			}()
+	// Запуск сохраняется в историю сразу, статус и результат обновляются по завершении
+	run, err := store.AddTestRun(store.TestRun{
+		Status:   store.RunStatusRunning,
+		Scenario: scenario,
+		Settings: settings,
+		Config:   cfg,
+	})
+	if err != nil {
+		cancel()
+		ui.resetOnError(fmt.Errorf("failed to save test run: %v", err))
+		return
+	}
+	if ui.mp.refreshHistory != nil {
+		ui.mp.refreshHistory()
+	}
 
-			totalDuration := 0.0
-			var metrics []Metric
-			startTime := time.Now()
+	ui.isRunning = true
+	ui.cancel = cancel
+	if ui.resultOutput != nil {
+		ui.resultOutput.SetText("")
+	}
+	ui.mp.ResetCharts()
+
+	// Показать значок загрузки и начать прогресс
+	ui.safeUpdateUI(uiUpdate{
+		startEnabled: false,
+		stopEnabled:  true,
+		status:       "loading",
+		progress:     0.0,
+		progressText: "Request Avg Duration 0.000s",
+	})
 
-			// Переменные для подсчёта RPS и времени отклика
-			var requestCount int
-			var lastUpdateTime time.Time = startTime
-			var requestsInWindow int
-			var windowStartTime time.Time = startTime
+	go func() {
+		defer func() {
+			cancel()
+			ui.isRunning = false
+			ui.safeUpdateUI(uiUpdate{
+				startEnabled: true,
+				stopEnabled:  false,
+				status:       "",
+				progress:     -1,
+			})
+		}()
 
-			for i := 0; i < reqCountVal; i++ {
-				if !ui.isRunning {
-					currentOutput := ""
-					if ui.resultOutput != nil {
-						currentOutput = ui.resultOutput.Text()
-					}
-					ui.safeUpdateUI(uiUpdate{
-						outputText:   currentOutput + "\n🛑 Тест остановлен пользователем.",
-						startEnabled: true,
-						stopEnabled:  false,
-						status:       "",
-						progress:     0.0,
-						progressText: "Request Avg Duration 0.000s",
-					})
+		// Графики обновляются по завершённым секундам временного ряда
+		chartsDone := make(chan struct{})
+		charted := 0
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					charted = addChartPoints(result.Summary(nil).TimeSeries, charted, false)
+				case <-chartsDone:
 					return
 				}
+			}
+		}()
 
-				// Генерация метрик для каждого запроса
-				metrics = []Metric{
-					{Name: "DNS", Duration: 10.50 + rand.Float64()*5, Order: 1},
-					{Name: "Connection", Duration: 20.75 + rand.Float64()*10, Order: 2},
-					{Name: "TLS", Duration: 15.30 + rand.Float64()*5, Order: 3},
-					{Name: "Request Write", Duration: 5.25 + rand.Float64()*2, Order: 4},
-					{Name: "Server Processing", Duration: 50.80 + rand.Float64()*20, Order: 5},
-					{Name: "Response Read", Duration: 10.20 + rand.Float64()*5, Order: 6},
-				}
-				for _, m := range metrics {
-					totalDuration += m.Duration
-				}
-				metrics = append(metrics, Metric{Name: "Total", Duration: totalDuration, Order: 7})
-
-				// Сортировка по порядку
-				sort.Slice(metrics, func(i, j int) bool {
-					return metrics[i].Order < metrics[j].Order
-				})
-
-				// Обновление прогресса
-				progress := float64(i+1) * 100.0 / float64(reqCountVal)
-				avgDuration := totalDuration / 1000.0 / float64(i+1) // Среднее в секундах
-				progressText := fmt.Sprintf("Request Avg Duration %.3fs", avgDuration)
-
-				// Сбор метрик для графиков
-				currentTime := time.Now()
-				elapsedSeconds := currentTime.Sub(startTime).Seconds()
-				requestCount++
-				requestsInWindow++
-
-				// Обновляем метрики каждую секунду
-				if currentTime.Sub(lastUpdateTime).Seconds() >= 1.0 {
-					rps := float64(requestsInWindow) / currentTime.Sub(windowStartTime).Seconds()
-
-					// Проверяем, что avgDuration корректно вычислено
-					if math.IsNaN(avgDuration) || math.IsInf(avgDuration, 0) {
-						avgDuration = 0
-					}
-
-					GlobalMetrics.AddData(
-						[]float64{elapsedSeconds},     // Новые значения времени
-						[]float64{rps},                // Новые значения RPS
-						[]float64{avgDuration * 1000}, // Новые значения времени отклика
-						[]float64{0},                  // Новые значения ошибок
-					)
-
-					// Сбрасываем счётчик и время окна
-					requestsInWindow = 0
-					windowStartTime = currentTime
-					lastUpdateTime = currentTime
-				}
+		go rs.Start(e.GetResultChan())
+		e.Start()
+		close(chartsDone)
 
-				ui.safeUpdateUI(uiUpdate{
-					progress:     progress,
-					progressText: progressText,
-					startEnabled: false,
-					stopEnabled:  true,
-				})
+		summary := result.Summary(h.Thresholds)
+		addChartPoints(summary.TimeSeries, charted, true)
 
-				// Увеличенная задержка для обеспечения обновления каждую секунду
-				time.Sleep(time.Duration(200+rand.Intn(100)) * time.Millisecond)
-			}
+		run.Status = store.RunStatusSuccess
+		if ctx.Err() != nil {
+			run.Status = store.RunStatusStopped
+		} else if !summary.Passed {
+			run.Status = store.RunStatusFailed
+		}
+		if run.Result, err = json.Marshal(summary); err != nil {
+			ui.safeUpdateUI(uiUpdate{errMsg: fmt.Sprintf("failed to save test result: %v", err)})
+		}
+		if err := store.UpdateTestRun(run); err != nil {
+			ui.safeUpdateUI(uiUpdate{errMsg: fmt.Sprintf("failed to save test run: %v", err)})
+		}
+		if ui.mp.refreshHistory != nil {
+			ui.mp.refreshHistory()
+		}
 
-			// Формирование итогового вывода
-			var resultOutput bytes.Buffer
-			for _, metric := range metrics {
-				resultOutput.WriteString(fmt.Sprintf("%s: %.2fms\n", metric.Name, metric.Duration))
+		if len(summary.Thresholds) > 0 {
+			var b strings.Builder
+			b.WriteString("\nThresholds:\n")
+			for _, t := range summary.Thresholds {
+				b.WriteString("  " + t.String() + "\n")
 			}
-			resultOutput.WriteString(fmt.Sprintf("Общее время: %.2fсек\n", time.Since(startTime).Seconds()))
-			resultOutput.WriteString(fmt.Sprintf("Среднее время запроса: %.2fms\n", totalDuration/float64(reqCountVal)))
-
-			avgDuration := totalDuration / 1000.0 / float64(reqCountVal)
-			finalProgressText := fmt.Sprintf("Request Avg Duration %.3fs", avgDuration)
+			ui.appendOutput(b.String())
+		}
+		if run.Status == store.RunStatusStopped {
+			ui.appendOutput("\n🛑 Тест остановлен пользователем.")
+		}
+		ui.safeUpdateUI(uiUpdate{status: "completed", progress: -1, refreshCharts: true})
+	}()
+}
 
-			// Добавление TestRun
-			store.AddTestRun(store.TestRun{
-				ID:        store.TestRunCount() + 1,
-				Name:      fmt.Sprintf("Test Run %d", store.TestRunCount()+1),
-				StartTime: time.Now().Format("2006-01-02 15:04"),
-				Status:    "Completed",
-			})
+// appendOutput дописывает текст к выводу результатов. Вывод отчёта обновляется асинхронно,
+// поэтому текст добавляется после короткой паузы.
+func (ui *LoadTestUI) appendOutput(text string) {
+	if ui.resultOutput == nil {
+		return
+	}
+	time.Sleep(100 * time.Millisecond)
+	ui.safeUpdateUI(uiUpdate{outputText: ui.resultOutput.Text() + text, progress: -1})
+}
 
-			// Показать результаты и обновить графики
-			ui.safeUpdateUI(uiUpdate{
-				outputText:    resultOutput.String(),
-				progress:      100.0,
-				progressText:  finalProgressText,
-				status:        "completed",
-				startEnabled:  true,
-				stopEnabled:   false,
-				refreshCharts: true, // Добавляем флаг для обновления графиков
-			})
-		}()
+// addChartPoints добавляет на графики точки временного ряда, начиная с секунды from.
+// Последняя секунда добавляется только при final, так как во время теста она ещё не завершена.
+// Возвращает номер следующей недобавленной секунды.
+func addChartPoints(series []report.TimePoint, from int, final bool) int {
+	for i, tp := range series {
+		if tp.Second < from || (!final && i == len(series)-1) {
+			continue
+		}
+		GlobalMetrics.AddData(
+			[]float64{float64(tp.Second + 1)},
+			[]float64{float64(tp.SuccessCount + tp.FailedCount)},
+			[]float64{float64(tp.AvgDuration) * 1000},
+			[]float64{float64(tp.FailedCount)},
+		)
+		from = tp.Second + 1
 	}
+	return from
 }
 
 func (ui *LoadTestUI) setupStopButton() {
 	ui.stopBtn.OnTapped = func() {
-		if !ui.isRunning {
+		if !ui.isRunning || ui.cancel == nil {
 			return
 		}
-
-		ui.isRunning = false
-
-		currentOutput := ""
-		if ui.resultOutput != nil {
-			currentOutput = ui.resultOutput.Text()
-		}
-		ui.safeUpdateUI(uiUpdate{
-			outputText:   currentOutput + "\n🛑 Тест остановлен пользователем.",
-			startEnabled: true,
-			stopEnabled:  false,
-			status:       "",
-			progress:     0.0,
-			progressText: "Request Avg Duration 0.000s",
-		})
+		ui.cancel()
+		ui.safeUpdateUI(uiUpdate{startEnabled: false, stopEnabled: false, progress: -1})
 	}
 }
 
 func (ui *LoadTestUI) CreateButtons() *fyne.Container {
-	debugCheck := widget.NewCheck("Debug Mode", nil)
 	ui.setupStartButton()
 	ui.setupStopButton()
-	return container.NewHBox(ui.startBtn, ui.stopBtn, debugCheck)
+	return container.NewHBox(ui.startBtn, ui.stopBtn, ui.debugCheck, ui.samplesCheck)
 }
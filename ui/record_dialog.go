@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"httes/importer"
+	"httes/recorder"
+	"httes/store"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showRecordDialog запускает прокси записи: запросы браузера или приложения, настроенного на этот прокси,
+// после остановки сохраняются сценарием в хранилище. После сохранения вызывается done.
+func (mp *ControlPage) showRecordDialog(window fyne.Window, done func()) {
+	addrEntry := widget.NewEntry()
+	addrEntry.SetText("127.0.0.1:8888")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText("Запись")
+	includeEntry := widget.NewEntry()
+	includeEntry.SetPlaceHolder("*.example.com, api.example.com/v1/*")
+	statusLabel := widget.NewLabel("Укажите адрес прокси в настройках браузера или приложения и нажмите «Начать»")
+	statusLabel.Wrapping = fyne.TextWrapWord
+
+	var rec *recorder.Recorder
+	toggleBtn := widget.NewButtonWithIcon("Начать", theme.MediaRecordIcon(), nil)
+	toggleBtn.OnTapped = func() {
+		if rec == nil {
+			path, err := store.DefaultPath()
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			ca, err := recorder.LoadOrCreateCA(filepath.Dir(path))
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			r := recorder.New(ca)
+			r.OnRecord = func(count int) {
+				statusLabel.SetText(fmt.Sprintf("Записано запросов: %d", count))
+			}
+			addr, err := r.Start(addrEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			rec = r
+			addrEntry.Disable()
+			toggleBtn.SetText("Остановить и сохранить")
+			toggleBtn.SetIcon(theme.MediaStopIcon())
+			statusLabel.SetText(fmt.Sprintf("Прокси слушает %s. Для записи HTTPS добавьте в доверенные сертификат %s", addr, ca.CertPath))
+			return
+		}
+
+		rec.Close()
+		r := rec
+		rec = nil
+		addrEntry.Enable()
+		toggleBtn.SetText("Начать")
+		toggleBtn.SetIcon(theme.MediaRecordIcon())
+
+		var opts importer.Options
+		opts.Name = nameEntry.Text
+		for _, s := range strings.Split(includeEntry.Text, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				opts.Include = append(opts.Include, s)
+			}
+		}
+		res, err := r.Result(opts)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		saved, err := res.Save("записи через прокси")
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if done != nil {
+			done()
+		}
+
+		msg := fmt.Sprintf("Сохранён сценарий %s, шагов: %d", saved[0].Name, len(res.Scenarios[0].Steps))
+		if len(res.Warnings) > 0 {
+			msg += "\n\nНе удалось преобразовать:\n" + strings.Join(res.Warnings, "\n")
+		}
+		if len(res.Captures) > 0 {
+			msg += "\n\nИзвлечения переменных:\n" + strings.Join(res.Captures, "\n")
+		}
+		statusLabel.SetText(msg)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Адрес прокси", addrEntry),
+		widget.NewFormItem("Имя сценария", nameEntry),
+		widget.NewFormItem("Только хосты", includeEntry),
+	)
+	d := dialog.NewCustom("Запись сценария", "Закрыть", container.NewVBox(form, toggleBtn, statusLabel), window)
+	d.SetOnClosed(func() {
+		if rec != nil {
+			rec.Close()
+		}
+	})
+	d.Resize(fyne.NewSize(700, 400))
+	d.Show()
+}
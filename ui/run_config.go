@@ -0,0 +1,353 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"httes/core/report"
+	"httes/core/types"
+	"httes/store"
+)
+
+// runConfig — конфигурация теста в формате config.JsonReader, формируемая из настроек экрана запуска.
+// Сохраняется вместе с запуском, чтобы тест можно было повторить с той же конфигурацией.
+type runConfig struct {
+	IterationCount int                    `json:"iteration_count,omitempty"`
+	RequestCount   int                    `json:"request_count,omitempty"`
+	LoadType       string                 `json:"load_type"`
+	Duration       int                    `json:"duration"`
+	Proxy          string                 `json:"proxy,omitempty"`
+	Steps          []json.RawMessage      `json:"steps,omitempty"`
+	Scenarios      []runScenario          `json:"scenarios,omitempty"`
+	Envs           map[string]interface{} `json:"env,omitempty"`
+	Output         interface{}            `json:"output,omitempty"`
+	Debug          bool                   `json:"debug,omitempty"`
+	runExtras
+}
+
+// runExtras — параметры конфигурации, для которых на экране запуска нет полей ввода.
+// Сохраняются при импорте конфигурации и без изменений записываются в конфигурацию запуска и экспорта.
+type runExtras struct {
+	ManualLoad   json.RawMessage `json:"manual_load,omitempty"`
+	Cookies      json.RawMessage `json:"cookies,omitempty"`
+	Thresholds   json.RawMessage `json:"thresholds,omitempty"`
+	ResultExport json.RawMessage `json:"result_export,omitempty"`
+	MetricsAddr  string          `json:"metrics_addr,omitempty"`
+	Push         json.RawMessage `json:"push,omitempty"`
+	OutputPath   string          `json:"output_path,omitempty"`
+
+	// Вывод отчёта экспортируемой конфигурации. При запуске из интерфейса используется gui
+	ExportOutput interface{} `json:"-"`
+	// Глобальные переменные окружения смеси сценариев. При импорте они добавляются в сценарии смеси,
+	// а при экспорте записываются один раз: из сценариев удаляются переменные с теми же значениями
+	GlobalEnvs map[string]interface{} `json:"-"`
+	// Количество итераций задано устаревшим полем request_count, оно же записывается при экспорте
+	LegacyRequestCount bool `json:"-"`
+}
+
+// runConfigFile — поля конфигурации, которые читаются при импорте теста на экран запуска.
+type runConfigFile struct {
+	ReqCount  *int              `json:"request_count"`
+	IterCount *int              `json:"iteration_count"`
+	LoadType  string            `json:"load_type"`
+	Duration  *int              `json:"duration"`
+	Proxy     string            `json:"proxy"`
+	Steps     []json.RawMessage `json:"steps"`
+	Scenarios []struct {
+		Name   string                 `json:"name"`
+		Weight *int                   `json:"weight"`
+		Steps  []json.RawMessage      `json:"steps"`
+		Envs   map[string]interface{} `json:"env"`
+	} `json:"scenarios"`
+	Envs   map[string]interface{} `json:"env"`
+	Output interface{}            `json:"output"`
+	Debug  bool                   `json:"debug"`
+	runExtras
+}
+
+// importedRun — тест, импортированный из конфигурации: параметры нагрузки и сценарии для хранилища.
+type importedRun struct {
+	Settings  store.RunSettings
+	Debug     bool
+	Scenarios []importedScenario
+	// Шаги записаны в конфигурации без смеси сценариев, единственный сценарий экспортируется так же
+	TopLevel bool
+	Extras   runExtras
+}
+
+// importedScenario — сценарий импортированной конфигурации в формате store.Scenario.JSON с весом в смеси.
+type importedScenario struct {
+	Name   string
+	Weight int
+	JSON   string
+}
+
+// runScenario — сценарий смеси в конфигурации теста.
+type runScenario struct {
+	Name   string                 `json:"name"`
+	Weight int                    `json:"weight"`
+	Steps  []json.RawMessage      `json:"steps"`
+	Envs   map[string]interface{} `json:"env,omitempty"`
+}
+
+// runStep — шаг, создаваемый из адреса на экране запуска или из эндпоинта сценария.
+type runStep struct {
+	Id          uint16            `json:"id"`
+	Name        string            `json:"name"`
+	Url         string            `json:"url"`
+	Method      string            `json:"method"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Auth        *runAuth          `json:"auth,omitempty"`
+	CertPath    string            `json:"cert_path,omitempty"`
+	CertKeyPath string            `json:"cert_key_path,omitempty"`
+}
+
+type runAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// scenarioJSON — поля конфигурации сценария из хранилища, используемые в смеси.
+type scenarioJSON struct {
+	Steps []json.RawMessage      `json:"steps"`
+	Envs  map[string]interface{} `json:"env,omitempty"`
+}
+
+// buildRunConfig формирует конфигурацию теста из текущих настроек экрана запуска.
+// Если выбрана смесь сценариев, шаги берутся из сценариев смеси, иначе — из адреса.
+func (mp *ControlPage) buildRunConfig(debug bool) ([]byte, error) {
+	return runConfigFor(mp.runSettings(), mp.scenarioMix, debug, mp.configExtras, report.OutputTypeGui)
+}
+
+// exportRunConfig формирует конфигурацию теста для запуска без графического интерфейса.
+// Отчёт выводится так же, как в импортированной конфигурации; если вывод не задан, поле не записывается
+// и используется вывод по умолчанию stdout.
+func (mp *ControlPage) exportRunConfig(debug bool) ([]byte, error) {
+	return runConfigFor(mp.runSettings(), mp.scenarioMix, debug, mp.configExtras, mp.configExtras.ExportOutput)
+}
+
+// runConfigFor формирует конфигурацию теста из настроек, смеси сценариев и дополнительных параметров.
+func runConfigFor(settings store.RunSettings, mix []scenarioMixItem, debug bool, extras runExtras, output interface{}) ([]byte, error) {
+	if settings.RequestCount <= 0 {
+		return nil, fmt.Errorf("request count should be greater than 0")
+	}
+	if settings.Duration <= 0 {
+		return nil, fmt.Errorf("duration should be greater than 0")
+	}
+
+	conf := runConfig{
+		LoadType:  strings.ToLower(settings.LoadType),
+		Duration:  settings.Duration,
+		Proxy:     settings.Proxy,
+		Output:    output,
+		Debug:     debug,
+		runExtras: extras,
+	}
+	if extras.LegacyRequestCount {
+		conf.RequestCount = settings.RequestCount
+	} else {
+		conf.IterationCount = settings.RequestCount
+	}
+
+	var auth *runAuth
+	if settings.Username != "" {
+		auth = &runAuth{Username: settings.Username, Password: settings.Password}
+	}
+
+	if len(mix) == 0 {
+		if settings.URL == "" {
+			return nil, fmt.Errorf("URL is required")
+		}
+		step, err := json.Marshal(runStep{
+			Id:          1,
+			Name:        settings.URL,
+			Url:         targetURL(settings.Protocol, settings.URL),
+			Method:      settings.Method,
+			Auth:        auth,
+			CertPath:    settings.CertPath,
+			CertKeyPath: settings.CertKeyPath,
+		})
+		if err != nil {
+			return nil, err
+		}
+		conf.Steps = []json.RawMessage{step}
+		return json.MarshalIndent(conf, "", "  ")
+	}
+
+	// Шаги эндпоинтов нумеруются после максимального ID во всей смеси, чтобы ID не пересекались
+	nextID := uint16(0)
+	parsed := make([]scenarioJSON, len(mix))
+	for i, item := range mix {
+		if strings.TrimSpace(item.Scenario.JSON) == "" {
+			continue
+		}
+		if err := json.Unmarshal([]byte(item.Scenario.JSON), &parsed[i]); err != nil {
+			return nil, fmt.Errorf("scenario %s: invalid json: %v", item.Scenario.Name, err)
+		}
+		for _, st := range parsed[i].Steps {
+			var s struct {
+				Id uint16 `json:"id"`
+			}
+			if err := json.Unmarshal(st, &s); err == nil && s.Id > nextID {
+				nextID = s.Id
+			}
+		}
+	}
+
+	for i, item := range mix {
+		sc := runScenario{
+			Name:   item.Scenario.Name,
+			Weight: item.Weight,
+			Steps:  parsed[i].Steps,
+			Envs:   parsed[i].Envs,
+		}
+		if len(sc.Steps) == 0 {
+			steps, err := endpointSteps(item.Scenario, auth, &nextID)
+			if err != nil {
+				return nil, err
+			}
+			sc.Steps = steps
+		}
+		if len(sc.Steps) == 0 {
+			return nil, fmt.Errorf("scenario %s has no steps or endpoints", item.Scenario.Name)
+		}
+		if len(mix) == 1 && item.TopLevel {
+			conf.Steps, conf.Envs = sc.Steps, sc.Envs
+			break
+		}
+		sc.Envs = withoutGlobalEnvs(sc.Envs, extras.GlobalEnvs)
+		conf.Scenarios = append(conf.Scenarios, sc)
+		conf.Envs = extras.GlobalEnvs
+	}
+	return json.MarshalIndent(conf, "", "  ")
+}
+
+// withoutGlobalEnvs возвращает переменные сценария без глобальных переменных с теми же значениями.
+// Переменные, значения которых изменены в сценарии, остаются и переопределяют глобальные.
+func withoutGlobalEnvs(envs, global map[string]interface{}) map[string]interface{} {
+	if len(global) == 0 {
+		return envs
+	}
+	res := make(map[string]interface{}, len(envs))
+	for k, v := range envs {
+		if g, ok := global[k]; !ok || !reflect.DeepEqual(g, v) {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// parseRunConfig разбирает собранную конфигурацию теста (config.Resolve) для импорта на экран запуска.
+// Сценарии смеси становятся сценариями хранилища с глобальными переменными окружения,
+// шаги без смеси — одним сценарием с именем name.
+func parseRunConfig(name string, data []byte) (*importedRun, error) {
+	var f runConfigFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	imp := &importedRun{
+		Settings: store.RunSettings{
+			RequestCount: types.DefaultIterCount,
+			Duration:     types.DefaultDuration,
+			LoadType:     types.DefaultLoadType,
+			Proxy:        f.Proxy,
+		},
+		Debug:  f.Debug,
+		Extras: f.runExtras,
+	}
+	if f.IterCount != nil {
+		imp.Settings.RequestCount = *f.IterCount
+	} else if f.ReqCount != nil {
+		imp.Settings.RequestCount = *f.ReqCount
+		imp.Extras.LegacyRequestCount = true
+	}
+	if f.Duration != nil {
+		imp.Settings.Duration = *f.Duration
+	}
+	if f.LoadType != "" {
+		imp.Settings.LoadType = strings.ToLower(f.LoadType)
+	}
+	if f.Output != report.OutputTypeGui {
+		imp.Extras.ExportOutput = f.Output
+	}
+
+	if len(f.Scenarios) == 0 {
+		if len(f.Steps) == 0 {
+			return nil, fmt.Errorf("config has no steps")
+		}
+		js, err := json.MarshalIndent(scenarioJSON{Steps: f.Steps, Envs: f.Envs}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		imp.Scenarios = []importedScenario{{Name: name, Weight: 1, JSON: string(js)}}
+		imp.TopLevel = true
+		return imp, nil
+	}
+
+	imp.Extras.GlobalEnvs = f.Envs
+	for _, sc := range f.Scenarios {
+		envs := make(map[string]interface{}, len(f.Envs)+len(sc.Envs))
+		for k, v := range f.Envs {
+			envs[k] = v
+		}
+		for k, v := range sc.Envs {
+			envs[k] = v
+		}
+		js, err := json.MarshalIndent(scenarioJSON{Steps: sc.Steps, Envs: envs}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		weight := 1
+		if sc.Weight != nil {
+			weight = *sc.Weight
+		}
+		imp.Scenarios = append(imp.Scenarios, importedScenario{Name: sc.Name, Weight: weight, JSON: string(js)})
+	}
+	return imp, nil
+}
+
+// endpointSteps создаёт шаги из эндпоинтов сценария хранилища.
+func endpointSteps(s store.Scenario, auth *runAuth, nextID *uint16) ([]json.RawMessage, error) {
+	steps := make([]json.RawMessage, 0, len(s.Endpoints))
+	for _, ep := range s.Endpoints {
+		if ep.URL == "" {
+			continue
+		}
+		var headers map[string]string
+		if strings.TrimSpace(ep.Headers) != "" {
+			if err := json.Unmarshal([]byte(ep.Headers), &headers); err != nil {
+				return nil, fmt.Errorf("scenario %s: invalid headers of %s: %v", s.Name, ep.URL, err)
+			}
+		}
+
+		*nextID++
+		st := runStep{
+			Id:      *nextID,
+			Name:    ep.URL,
+			Url:     ep.URL,
+			Method:  ep.Method,
+			Headers: headers,
+			Auth:    auth,
+		}
+		if s.Cert != nil {
+			st.CertPath, st.CertKeyPath = s.Cert.Path, s.Cert.Key
+		}
+		b, err := json.Marshal(st)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, b)
+	}
+	return steps, nil
+}
+
+// targetURL добавляет схему выбранного протокола к адресу без схемы.
+func targetURL(protocol, addr string) string {
+	if strings.Contains(addr, "://") {
+		return addr
+	}
+	return strings.ToLower(protocol) + "://" + addr
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"httes/config"
+	"httes/core/types"
+	"httes/store"
+)
+
+// resolveFixture собирает конфигурацию из config/config_testdata так же, как импорт на экран запуска.
+func resolveFixture(t *testing.T, name string) ([]byte, config.LoadOptions) {
+	t.Helper()
+	path := filepath.Join("..", "config", "config_testdata", name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := config.LoadOptions{BaseDir: filepath.Dir(path)}
+	resolved, err := config.Resolve(data, config.DetectConfigType(path, data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved, opts
+}
+
+func createHeart(t *testing.T, data []byte) types.Heart {
+	t.Helper()
+	reader, err := config.NewConfigReader(data, config.ConfigTypeJson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := reader.CreateHammer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// exportImported переносит собранную конфигурацию на экран запуска и экспортирует её обратно.
+func exportImported(t *testing.T, name string, resolved []byte) []byte {
+	t.Helper()
+	imp, err := parseRunConfig(name, resolved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mix := make([]scenarioMixItem, 0, len(imp.Scenarios))
+	for _, sc := range imp.Scenarios {
+		mix = append(mix, scenarioMixItem{Scenario: store.Scenario{Name: sc.Name, JSON: sc.JSON}, Weight: sc.Weight, TopLevel: imp.TopLevel})
+	}
+	exported, err := runConfigFor(imp.Settings, mix, imp.Debug, imp.Extras, imp.Extras.ExportOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exported
+}
+
+// Импорт конфигурации и её экспорт с экрана запуска дают тот же тест.
+func TestRunConfigRoundTrip(t *testing.T) {
+	fixtures := []string{
+		"config.json",
+		"config_scenario_mix.json",
+		"config_global_envs.json",
+		"config_thresholds.json",
+		"config_push.json",
+		"config_result_export.json",
+		"config_manual_load.json",
+		"config_multi_output.json",
+	}
+	for _, name := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			resolved, opts := resolveFixture(t, name)
+			exported := exportImported(t, name, resolved)
+			again, err := config.Resolve(exported, config.ConfigTypeJson, opts)
+			if err != nil {
+				t.Fatalf("%v\n%s", err, exported)
+			}
+			if expected, got := createHeart(t, resolved), createHeart(t, again); !reflect.DeepEqual(expected, got) {
+				t.Errorf("exported config differs:\n%+v\nexpected:\n%+v\nexported config:\n%s", got, expected, exported)
+			}
+		})
+	}
+}
+
+// Экспорт сохраняет форму импортированной конфигурации: устаревшее поле request_count
+// и глобальные переменные окружения, которые не повторяются в сценариях смеси.
+func TestRunConfigExportShape(t *testing.T) {
+	resolved, _ := resolveFixture(t, "config.json")
+	var f map[string]interface{}
+	if err := json.Unmarshal(exportImported(t, "config", resolved), &f); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := f["iteration_count"]; ok || f["request_count"] == nil {
+		t.Errorf("expected request_count only, got request_count=%v iteration_count=%v", f["request_count"], f["iteration_count"])
+	}
+
+	resolved, _ = resolveFixture(t, "config_scenario_mix.json")
+	var mix struct {
+		Scenarios []runScenario          `json:"scenarios"`
+		Envs      map[string]interface{} `json:"env"`
+	}
+	if err := json.Unmarshal(exportImported(t, "mix", resolved), &mix); err != nil {
+		t.Fatal(err)
+	}
+	if mix.Envs["HOST"] != "http://localhost:8084" {
+		t.Errorf("global env is not exported: %v", mix.Envs)
+	}
+	for _, sc := range mix.Scenarios {
+		if _, ok := sc.Envs["HOST"]; ok {
+			t.Errorf("scenario %s repeats global env: %v", sc.Name, sc.Envs)
+		}
+	}
+	if mix.Scenarios[1].Envs["QUERY"] != "phone" {
+		t.Errorf("scenario env is lost: %v", mix.Scenarios[1].Envs)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"net/url"
 	"sort"
 	"strings"
-	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -21,29 +20,8 @@ import (
 )
 
 func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container.AppTabs) fyne.CanvasObject {
-	// 1. Модель данных сценариев
-	scenarios := []struct {
-		Name        string
-		CreatedAt   time.Time
-		Description string
-	}{
-		{
-			Name:        "API User Flow",
-			CreatedAt:   time.Date(2025, 5, 20, 14, 30, 0, 0, time.UTC),
-			Description: "Полный цикл работы с пользователями (CRUD)",
-		},
-		{
-			Name:        "Auth Test",
-			CreatedAt:   time.Date(2025, 5, 10, 9, 15, 0, 0, time.UTC),
-			Description: "Тестирование нагрузки на эндпоинты аутентификации",
-		},
-		{
-			Name:        "Payment Validation",
-			CreatedAt:   time.Date(2025, 4, 10, 16, 45, 0, 0, time.UTC),
-			Description: "Проверка валидации платежных данных",
-		},
-	}
-
+	// 1. Сценарии из хранилища с учётом поиска
+	var scenarios []store.Scenario
 	// 2. Состояние сортировки
 	sortNewestFirst := true
 	refreshList := func() {}
@@ -52,6 +30,26 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 	searchEntry := widget.NewEntry()
 	searchEntry.PlaceHolder = "Поиск по названию..."
 
+	loadScenarios := func() {
+		query := strings.ToLower(strings.TrimSpace(searchEntry.Text))
+		scenarios = scenarios[:0]
+		for _, sc := range store.ListScenarios() {
+			if query == "" || strings.Contains(strings.ToLower(sc.Name), query) {
+				scenarios = append(scenarios, sc)
+			}
+		}
+		sort.Slice(scenarios, func(i, j int) bool {
+			if sortNewestFirst {
+				return scenarios[i].CreatedAt.After(scenarios[j].CreatedAt)
+			}
+			return scenarios[i].CreatedAt.Before(scenarios[j].CreatedAt)
+		})
+	}
+	loadScenarios()
+	searchEntry.OnChanged = func(string) {
+		refreshList()
+	}
+
 	// Кнопка сортировки
 	sortBtn := widget.NewButtonWithIcon("Новее", theme.MenuDropDownIcon(), nil)
 	sortMenu := fyne.NewMenu("",
@@ -84,6 +82,16 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 		scenarioWindow.Show()
 	})
 
+	// Кнопка импорта сценариев из файлов HAR и других форматов
+	importBtn := widget.NewButtonWithIcon("Импорт", theme.FolderOpenIcon(), func() {
+		mp.showImportDialog(window, refreshList)
+	})
+
+	// Кнопка записи сценария через локальный прокси
+	recordBtn := widget.NewButtonWithIcon("Запись", theme.MediaRecordIcon(), func() {
+		mp.showRecordDialog(window, refreshList)
+	})
+
 	// 4. Создаем список сценариев
 	list := widget.NewList(
 		func() int { return len(scenarios) },
@@ -95,6 +103,7 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 				container.NewHBox(
 					widget.NewButtonWithIcon("", theme.MediaPlayIcon(), nil),
 					widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil), // OnTapped set in update function
+					widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), nil),
 					widget.NewButtonWithIcon("", theme.DeleteIcon(), nil),
 				),
 				container.NewVBox(
@@ -104,17 +113,6 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 			)
 		},
 		func(id widget.ListItemID, item fyne.CanvasObject) {
-			// Сортируем перед отображением
-			if sortNewestFirst {
-				sort.Slice(scenarios, func(i, j int) bool {
-					return scenarios[i].CreatedAt.After(scenarios[j].CreatedAt)
-				})
-			} else {
-				sort.Slice(scenarios, func(i, j int) bool {
-					return scenarios[i].CreatedAt.Before(scenarios[j].CreatedAt)
-				})
-			}
-
 			scenario := scenarios[id]
 			container := item.(*fyne.Container)
 
@@ -141,27 +139,41 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 				if mp.icon != nil {
 					editWindow.SetIcon(mp.icon) // Устанавливаем иконку
 				}
-				editWindow.SetContent(mp.editScenarioEditorContent(id, window))
+				editWindow.SetContent(mp.editScenarioEditorContent(scenario.ID, editWindow))
 				editWindow.Resize(fyne.NewSize(970, 600))
 				editWindow.Show()
 			}
 
-			// Кнопка удаления
+			// Кнопка сохранения в файл конфигурации
 			buttons.Objects[2].(*widget.Button).OnTapped = func() {
+				mp.showScenarioExportDialog(window, scenario)
+			}
+
+			// Кнопка удаления
+			buttons.Objects[3].(*widget.Button).OnTapped = func() {
 				dialog.ShowConfirm("Удаление", fmt.Sprintf("Удалить '%s'?", scenario.Name),
 					func(ok bool) {
 						if ok {
-							// Логика удаления
+							if err := store.DeleteScenario(scenario.ID); err != nil {
+								dialog.ShowError(err, window)
+							}
+							refreshList()
 						}
 					}, window)
 			}
 		},
 	)
 
+	totalLabel := widget.NewLabel("")
+
 	// Функция обновления списка
 	refreshList = func() {
+		loadScenarios()
+		totalLabel.SetText(fmt.Sprintf("Всего: %d", len(scenarios)))
 		list.Refresh()
 	}
+	refreshList()
+	mp.refreshScenarios = refreshList
 
 	// 5. Собираем интерфейс
 	header := container.NewHBox(
@@ -170,7 +182,7 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 		}),
 		widget.NewLabelWithStyle("Выберите сценарий", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
 		layout.NewSpacer(),
-		widget.NewLabel(fmt.Sprintf("Всего: %d", len(scenarios))),
+		totalLabel,
 	)
 
 	searchPanel := container.NewBorder(
@@ -179,6 +191,8 @@ func (mp *ControlPage) createScenariosScreen(window fyne.Window, tabs *container
 		nil,
 		container.NewHBox(
 			sortBtn,
+			importBtn,
+			recordBtn,
 			newScenarioBtn,
 		),
 		searchEntry,
@@ -373,14 +387,25 @@ func (mp *ControlPage) createScenarioEditorContent(window fyne.Window) fyne.Canv
 			}
 		}
 
-		store.AddScenario(store.Scenario{
-			ID:          store.ScenarioCount() + 1,
+		_, err := store.AddScenario(store.Scenario{
 			Name:        nameEntry.Text,
 			Description: descEntry.Text,
+			Profile:     profileSelect.Selected,
+			JSON:        jsonEditor.Text,
+			Endpoints:   endpoints,
 			Cert:        selectedCert,
 		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if mp.refreshScenarios != nil {
+			mp.refreshScenarios()
+		}
 
 		dialog.NewInformation("Сценарий", "Сценарий сохранён", window).Show()
+		endpoints = nil
+		selectedCert = nil
 
 		// Очистка формы
 		nameEntry.SetText("")
@@ -410,6 +435,7 @@ func (mp *ControlPage) createScenarioEditorContent(window fyne.Window) fyne.Canv
 			container.NewHBox(jsonError, jsonErrorLabel),
 		),
 		jsonEditor,
+		mp.curlButtons(window, jsonEditor),
 	)
 
 	// Правая колонка
@@ -440,100 +466,12 @@ func (mp *ControlPage) createScenarioEditorContent(window fyne.Window) fyne.Canv
 	return container.NewCenter(container.NewGridWrap(fyne.NewSize(970, 550), mainContent))
 }
 
-func (mp *ControlPage) editScenarioEditorContent(scenarioIndex int, parentWindow fyne.Window) fyne.CanvasObject {
-	// 1. Модель данных сценариев
-	scenarios := []struct {
-		Name        string
-		CreatedAt   time.Time
-		Description string
-		Profile     string
-		JSON        string
-		Endpoints   []store.Endpoint
-		Cert        *store.Cert
-	}{
-		{
-			Name:        "API User Flow",
-			CreatedAt:   time.Date(2025, 5, 15, 14, 30, 0, 0, time.UTC),
-			Description: "Полный цикл работы с пользователями (CRUD)",
-			Profile:     "Linear",
-			JSON: `{
-"steps": [
-{
-  "name": "Create user",
-  "request": {
-    "method": "POST",
-    "url": "/users"
-  }
-}
-]
-}`,
-			Endpoints: []store.Endpoint{
-				{
-					URL:     "https://api.example.com/users",
-					Headers: `{"Content-Type": "application/json"}`,
-				},
-			},
-			Cert: nil,
-		},
-		{
-			Name:        "Auth Stress Test",
-			CreatedAt:   time.Date(2025, 5, 20, 9, 15, 0, 0, time.UTC),
-			Description: "Тестирование нагрузки на эндпоинты аутентификации",
-			Profile:     "Waved",
-			JSON: `{
-"steps": [
-{
-  "name": "Login",
-  "request": {
-    "method": "POST",
-    "url": "/auth/login"
-  }
-}
-]
-}`,
-			Endpoints: []store.Endpoint{
-				{
-					URL:     "https://api.example.com/auth",
-					Headers: `{"Authorization": "Bearer token"}`,
-				},
-			},
-			Cert: &store.Cert{
-				Name: "auth_cert.pem",
-				Path: "/certs/auth_cert.pem",
-				Key:  "/certs/auth_key.pem",
-			},
-		},
-		{
-			Name:        "Payment Validation",
-			CreatedAt:   time.Date(2025, 6, 1, 16, 45, 0, 0, time.UTC),
-			Description: "Проверка валидации платежных данных",
-			Profile:     "Incremental",
-			JSON: `{
-"steps": [
-{
-  "name": "Validate payment",
-  "request": {
-    "method": "POST",
-    "url": "/payments/validate"
-  }
-}
-]
-}`,
-			Endpoints: []store.Endpoint{
-				{
-					URL:     "https://api.example.com/payments",
-					Headers: `{"Content-Type": "application/json"}`,
-				},
-			},
-			Cert: nil,
-		},
-	}
-
-	// 2. Проверка валидности индекса сценария
-	if scenarioIndex < 0 || scenarioIndex >= len(scenarios) {
-		return widget.NewLabel("Ошибка: Неверный индекс сценария")
+func (mp *ControlPage) editScenarioEditorContent(scenarioID int, parentWindow fyne.Window) fyne.CanvasObject {
+	// 1. Загрузка сценария из хранилища
+	scenario, ok := store.GetScenarioByID(scenarioID)
+	if !ok {
+		return widget.NewLabel("Ошибка: сценарий не найден")
 	}
-	scenario := scenarios[scenarioIndex]
 
 	// 3. Контейнеры для сообщений об ошибках
 	nameError := widget.NewLabel("")
@@ -715,25 +653,20 @@ func (mp *ControlPage) editScenarioEditorContent(scenarioIndex int, parentWindow
 		}
 
 		// Обновляем сценарий
-		scenarios[scenarioIndex] = struct {
-			Name        string
-			CreatedAt   time.Time
-			Description string
-			Profile     string
-			JSON        string
-			Endpoints   []store.Endpoint
-			Cert        *store.Cert
-		}{
-			Name:        nameEntry.Text,
-			Description: descEntry.Text,
-			CreatedAt:   time.Now(),
-			Profile:     profileSelect.Selected,
-			JSON:        jsonEditor.Text,
-			Endpoints:   endpoints,
-			Cert:        selectedCert,
+		scenario.Name = nameEntry.Text
+		scenario.Description = descEntry.Text
+		scenario.Profile = profileSelect.Selected
+		scenario.JSON = jsonEditor.Text
+		scenario.Endpoints = endpoints
+		scenario.Cert = selectedCert
+		if err := store.UpdateScenario(scenario); err != nil {
+			dialog.ShowError(err, parentWindow)
+			return
+		}
+		if mp.refreshScenarios != nil {
+			mp.refreshScenarios()
 		}
 
-		dialog.NewInformation("Сценарий", "Сценарий обновлён", parentWindow).Show()
 		parentWindow.Close()
 	})
 
@@ -759,6 +692,7 @@ func (mp *ControlPage) editScenarioEditorContent(scenarioIndex int, parentWindow
 			container.NewHBox(jsonError, jsonErrorLabel),
 		),
 		jsonEditor,
+		mp.curlButtons(parentWindow, jsonEditor),
 	)
 
 	// 10. Правая колонка